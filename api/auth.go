@@ -0,0 +1,268 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scope is the level of access a credential grants
+type Scope string
+
+const (
+	ScopeRead  Scope = "read"  // query endpoints and the event stream
+	ScopeAdmin Scope = "admin" // everything, including kill switch and control plane
+)
+
+// HMAC request signing headers. The signature is the hex encoded
+// HMAC-SHA256 of "timestamp\nMETHOD\n/path?query\nbody" with the key secret.
+const (
+	headerKeyID     = "X-HFT-Key"
+	headerTimestamp = "X-HFT-Timestamp"
+	headerSignature = "X-HFT-Signature"
+
+	maxSignatureSkew = 30 * time.Second
+	maxSignedBody    = 1 << 20
+)
+
+// Credential is a bearer token or HMAC key with its scope
+type Credential struct {
+	ID     string
+	Scope  Scope
+	Secret string
+}
+
+func newCredential(id, scope, secret string) (Credential, error) {
+	switch Scope(scope) {
+	case ScopeRead, ScopeAdmin:
+	default:
+		return Credential{}, fmt.Errorf("unknown scope %q (want read or admin)", scope)
+	}
+	return Credential{ID: id, Scope: Scope(scope), Secret: secret}, nil
+}
+
+// allows reports whether the credential grants a scope; admin implies read
+func (c Credential) allows(scope Scope) bool {
+	return c.Scope == ScopeAdmin || c.Scope == scope
+}
+
+var (
+	errUnauthenticated = errors.New("missing credentials")
+	errBodyTooLarge    = fmt.Errorf("signed body larger than %d bytes", maxSignedBody)
+)
+
+type credentialKey struct{}
+
+// authenticate identifies the caller from a bearer token or an HMAC
+// signature. Query tokens (?access_token=) are accepted only when
+// allowQueryToken is set, for clients such as browsers that cannot set
+// headers on WebSocket and EventSource requests.
+func (api *PnLAPI) authenticate(r *http.Request, allowQueryToken bool) (Credential, error) {
+	if keyID := r.Header.Get(headerKeyID); keyID != "" {
+		return api.verifySignature(r, keyID)
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok && allowQueryToken {
+		token = r.URL.Query().Get("access_token")
+	}
+	if token == "" {
+		return Credential{}, errUnauthenticated
+	}
+	for _, cred := range api.config.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(cred.Secret)) == 1 {
+			return cred, nil
+		}
+	}
+	return Credential{}, errors.New("invalid token")
+}
+
+// verifySignature checks an HMAC signed request. The body is restored so
+// handlers can still read it.
+func (api *PnLAPI) verifySignature(r *http.Request, keyID string) (Credential, error) {
+	var cred Credential
+	found := false
+	for _, key := range api.config.HMACKeys {
+		if key.ID == keyID {
+			cred, found = key, true
+			break
+		}
+	}
+	if !found {
+		return Credential{}, fmt.Errorf("unknown key %q", keyID)
+	}
+
+	ts, err := strconv.ParseInt(r.Header.Get(headerTimestamp), 10, 64)
+	if err != nil {
+		return Credential{}, errors.New("missing or invalid " + headerTimestamp)
+	}
+	if skew := time.Since(time.Unix(ts, 0)); skew > maxSignatureSkew || skew < -maxSignatureSkew {
+		return Credential{}, fmt.Errorf("timestamp outside the allowed %s skew", maxSignatureSkew)
+	}
+
+	var body []byte
+	if r.Body != nil {
+		if r.ContentLength > maxSignedBody {
+			return Credential{}, errBodyTooLarge
+		}
+		// Read one byte past the limit so that a longer body is rejected
+		// rather than verified truncated
+		body, err = io.ReadAll(io.LimitReader(r.Body, maxSignedBody+1))
+		if err != nil {
+			return Credential{}, fmt.Errorf("reading body: %w", err)
+		}
+		if len(body) > maxSignedBody {
+			return Credential{}, errBodyTooLarge
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	signature := strings.ToLower(r.Header.Get(headerSignature))
+	expected := Sign(cred.Secret, ts, r.Method, r.URL.RequestURI(), body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return Credential{}, errors.New("invalid signature")
+	}
+	if !api.signatures.first(cred.ID+":"+signature, time.Now()) {
+		return Credential{}, errors.New("signature already used")
+	}
+	return cred, nil
+}
+
+// signatureCache remembers the signatures accepted within the skew window,
+// so that a captured request cannot be replayed while its timestamp is still
+// accepted
+type signatureCache struct {
+	mu        sync.Mutex
+	seen      map[string]time.Time // key id and signature -> when it expires
+	lastSweep time.Time
+}
+
+func newSignatureCache() *signatureCache {
+	return &signatureCache{seen: make(map[string]time.Time), lastSweep: time.Now()}
+}
+
+// first records a signature and reports whether it had not been seen. A
+// signature is kept for twice the skew, the longest its timestamp is accepted.
+func (c *signatureCache) first(signature string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.lastSweep) > maxSignatureSkew {
+		for key, expires := range c.seen {
+			if now.After(expires) {
+				delete(c.seen, key)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	if expires, ok := c.seen[signature]; ok && !now.After(expires) {
+		return false
+	}
+	c.seen[signature] = now.Add(2 * maxSignatureSkew)
+	return true
+}
+
+// Sign returns the HMAC signature a client sends for a request
+func Sign(secret string, timestamp int64, method, requestURI string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d\n%s\n%s\n", timestamp, method, requestURI)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// require wraps a handler with authentication for scope and the per-client
+// rate limit. Without any configured credentials read endpoints are open
+// and admin endpoints are disabled.
+func (api *PnLAPI) require(scope Scope, next http.HandlerFunc) http.HandlerFunc {
+	return api.authorize(scope, false, next)
+}
+
+// requireStream is require for streaming endpoints, which also accept
+// ?access_token=
+func (api *PnLAPI) requireStream(scope Scope, next http.HandlerFunc) http.HandlerFunc {
+	return api.authorize(scope, true, next)
+}
+
+func (api *PnLAPI) authorize(scope Scope, allowQueryToken bool, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		client := "ip:" + remoteIP(r)
+
+		if api.config.AuthRequired() {
+			// Failed attempts are charged to the caller's address, and an
+			// address out of tokens is refused before its credentials are
+			// checked, so credentials cannot be guessed faster than the limit
+			if ok, retryAfter := api.limiter.peek(client); !ok {
+				api.writeRateLimited(w, r, retryAfter)
+				return
+			}
+			cred, err := api.authenticate(r, allowQueryToken)
+			if err != nil {
+				api.limiter.allow(client)
+				authFailures.WithLabelValues("unauthenticated").Inc()
+				logger.Warn("unauthenticated request", "remote", r.RemoteAddr, "path", r.URL.Path, "err", err)
+				if errors.Is(err, errBodyTooLarge) {
+					writeError(w, r, http.StatusRequestEntityTooLarge, err.Error())
+					return
+				}
+				w.Header().Set("WWW-Authenticate", `Bearer realm="hft-arbitrage-bot"`)
+				writeError(w, r, http.StatusUnauthorized, "authentication required: "+err.Error())
+				return
+			}
+			if !cred.allows(scope) {
+				authFailures.WithLabelValues("forbidden").Inc()
+				logger.Warn("insufficient scope", "credential", cred.ID, "path", r.URL.Path, "need", scope)
+				writeError(w, r, http.StatusForbidden, fmt.Sprintf("credential %s lacks %s scope", cred.ID, scope))
+				return
+			}
+			client = "key:" + cred.ID
+			r = r.WithContext(context.WithValue(r.Context(), credentialKey{}, cred))
+		} else if scope == ScopeAdmin {
+			writeError(w, r, http.StatusForbidden, "admin API disabled: configure HFT_ADMIN_TOKEN, HFT_API_TOKENS or HFT_API_HMAC_KEYS")
+			return
+		}
+
+		if ok, retryAfter := api.limiter.allow(client); !ok {
+			api.writeRateLimited(w, r, retryAfter)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// writeRateLimited answers 429 with the time until the client's next token
+func (api *PnLAPI) writeRateLimited(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	rateLimited.Inc()
+	w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds()+0.999)))
+	writeError(w, r, http.StatusTooManyRequests, "rate limit exceeded")
+}
+
+// clientID describes the caller for audit records
+func clientID(r *http.Request) string {
+	if cred, ok := r.Context().Value(credentialKey{}).(Credential); ok {
+		return cred.ID + "@" + remoteIP(r)
+	}
+	return remoteIP(r)
+}
+
+// remoteIP returns the host part of the request's remote address
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
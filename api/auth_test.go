@@ -0,0 +1,134 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   func() ServerConfig
+		method   string
+		path     string
+		token    string
+		wantCode int
+	}{
+		{name: "open read without credentials configured", config: DefaultServerConfig, method: http.MethodGet, path: "/pnl", wantCode: http.StatusOK},
+		{name: "admin disabled without credentials configured", config: DefaultServerConfig, method: http.MethodPost, path: "/control/pause", wantCode: http.StatusForbidden},
+		{name: "missing token", config: testConfig, method: http.MethodGet, path: "/pnl", wantCode: http.StatusUnauthorized},
+		{name: "wrong token", config: testConfig, method: http.MethodGet, path: "/pnl", token: "guess", wantCode: http.StatusUnauthorized},
+		{name: "read token reads", config: testConfig, method: http.MethodGet, path: "/pnl", token: readToken, wantCode: http.StatusOK},
+		{name: "read token cannot administer", config: testConfig, method: http.MethodPost, path: "/risk/kill", token: readToken, wantCode: http.StatusForbidden},
+		{name: "admin token reads", config: testConfig, method: http.MethodGet, path: "/pnl", token: adminToken, wantCode: http.StatusOK},
+		{name: "health is public", config: testConfig, method: http.MethodGet, path: "/health", wantCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(tt.config())
+			w := serve(api, newRequest(tt.method, tt.path, tt.token, ""))
+			if w.Code != tt.wantCode {
+				t.Errorf("status code = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+		})
+	}
+}
+
+func TestFailedAuthenticationIsRateLimited(t *testing.T) {
+	config := testConfig()
+	config.RateLimit = 0.001
+	config.RateBurst = 2
+	api := newTestAPI(config)
+
+	tests := []struct {
+		name     string
+		token    string
+		wantCode int
+	}{
+		{name: "first guess", token: "guess-1", wantCode: http.StatusUnauthorized},
+		{name: "second guess", token: "guess-2", wantCode: http.StatusUnauthorized},
+		{name: "guessing stops at the limit", token: "guess-3", wantCode: http.StatusTooManyRequests},
+		{name: "the address stays limited", token: readToken, wantCode: http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(api, newRequest(http.MethodGet, "/pnl", tt.token, ""))
+			if w.Code != tt.wantCode {
+				t.Errorf("status code = %d, want %d", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestSignedRequests(t *testing.T) {
+	const secret = "hmac-secret"
+	config := testConfig()
+	config.HMACKeys = []Credential{{ID: "ops", Scope: ScopeAdmin, Secret: secret}}
+	api := newTestAPI(config)
+
+	// signed builds a POST /control/params signed at ts; tamper changes the
+	// body after signing
+	signed := func(ts int64, body, tamper string) *http.Request {
+		r := newRequest(http.MethodPost, "/control/params", "", body+tamper)
+		r.Header.Set(headerKeyID, "ops")
+		r.Header.Set(headerTimestamp, strconv.FormatInt(ts, 10))
+		r.Header.Set(headerSignature, Sign(secret, ts, http.MethodPost, "/control/params", []byte(body)))
+		return r
+	}
+	now := time.Now().Unix()
+	replayed := signed(now, `{"trade_size":60}`, "")
+	oversized := `{"reason":"` + strings.Repeat("x", maxSignedBody) + `"}`
+
+	tests := []struct {
+		name     string
+		request  *http.Request
+		wantCode int
+	}{
+		{name: "valid signature", request: signed(now, `{"trade_size":50}`, ""), wantCode: http.StatusOK},
+		{name: "body is restored for the handler", request: replayed, wantCode: http.StatusOK},
+		{name: "replayed signature", request: signed(now, `{"trade_size":60}`, ""), wantCode: http.StatusUnauthorized},
+		{name: "tampered body", request: signed(now, `{"trade_size":50}`, " "), wantCode: http.StatusUnauthorized},
+		{name: "stale timestamp", request: signed(now-int64(2*maxSignatureSkew/time.Second), `{"trade_size":70}`, ""), wantCode: http.StatusUnauthorized},
+		{name: "body over the limit", request: signed(now, oversized, ""), wantCode: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(api, tt.request)
+			if w.Code != tt.wantCode {
+				t.Errorf("status code = %d, want %d: %.200s", w.Code, tt.wantCode, w.Body)
+			}
+		})
+	}
+	if got := api.strategy.Params().TradeSize; got != 60 {
+		t.Errorf("trade size = %.2f, want 60 from the last accepted request", got)
+	}
+}
+
+func TestSignatureCache(t *testing.T) {
+	start := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		signature string
+		at        time.Duration
+		want      bool
+	}{
+		{name: "first use", signature: "a", want: true},
+		{name: "other signature", signature: "b", want: true},
+		{name: "reuse within the window", signature: "a", at: maxSignatureSkew, want: false},
+		{name: "reuse after the window", signature: "a", at: 3 * maxSignatureSkew, want: true},
+	}
+
+	c := newSignatureCache()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.first(tt.signature, start.Add(tt.at)); got != tt.want {
+				t.Errorf("first(%q) = %v, want %v", tt.signature, got, tt.want)
+			}
+		})
+	}
+}
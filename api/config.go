@@ -0,0 +1,144 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ServerConfig configures how the API server listens and who may call it
+type ServerConfig struct {
+	BindAddr    string // interface to listen on; "0.0.0.0" exposes the API to the network
+	Port        int
+	TLSCertFile string // TLS is enabled when both files are set
+	TLSKeyFile  string
+
+	Tokens   []Credential // bearer tokens
+	HMACKeys []Credential // HMAC request signing keys
+
+	CORSOrigins []string // allowed browser origins; "*" allows any
+
+	RateLimit float64 // requests per second per client; 0 disables
+	RateBurst int
+}
+
+// DefaultServerConfig returns a config that only listens on localhost, with
+// no credentials and a per-client limit of 20 requests per second
+func DefaultServerConfig() ServerConfig {
+	return ServerConfig{
+		BindAddr:  "127.0.0.1",
+		Port:      8080,
+		RateLimit: 20,
+		RateBurst: 40,
+	}
+}
+
+// ServerConfigFromEnv builds the server config from HFT_API_* variables on
+// top of DefaultServerConfig:
+//
+//	HFT_API_BIND, HFT_API_PORT
+//	HFT_API_TLS_CERT, HFT_API_TLS_KEY
+//	HFT_API_TOKENS       comma separated scope:token pairs, e.g. read:abc,admin:xyz
+//	HFT_ADMIN_TOKEN      a single admin token
+//	HFT_API_HMAC_KEYS    comma separated id:scope:secret triples
+//	HFT_API_CORS_ORIGINS comma separated origins, or *
+//	HFT_API_RATE_LIMIT, HFT_API_RATE_BURST
+func ServerConfigFromEnv() (ServerConfig, error) {
+	config := DefaultServerConfig()
+
+	if bind := os.Getenv("HFT_API_BIND"); bind != "" {
+		config.BindAddr = bind
+	}
+	if port := os.Getenv("HFT_API_PORT"); port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil || p <= 0 || p > 65535 {
+			return config, fmt.Errorf("invalid HFT_API_PORT %q", port)
+		}
+		config.Port = p
+	}
+
+	config.TLSCertFile = os.Getenv("HFT_API_TLS_CERT")
+	config.TLSKeyFile = os.Getenv("HFT_API_TLS_KEY")
+	if (config.TLSCertFile == "") != (config.TLSKeyFile == "") {
+		return config, fmt.Errorf("HFT_API_TLS_CERT and HFT_API_TLS_KEY must be set together")
+	}
+
+	for i, entry := range splitList(os.Getenv("HFT_API_TOKENS")) {
+		scope, token, ok := strings.Cut(entry, ":")
+		if !ok || token == "" {
+			return config, fmt.Errorf("invalid HFT_API_TOKENS entry %d: want scope:token", i+1)
+		}
+		cred, err := newCredential(fmt.Sprintf("token-%d", i+1), scope, token)
+		if err != nil {
+			return config, fmt.Errorf("HFT_API_TOKENS entry %d: %w", i+1, err)
+		}
+		config.Tokens = append(config.Tokens, cred)
+	}
+	if token := os.Getenv("HFT_ADMIN_TOKEN"); token != "" {
+		cred, err := newCredential("admin-token", string(ScopeAdmin), token)
+		if err != nil {
+			return config, fmt.Errorf("HFT_ADMIN_TOKEN: %w", err)
+		}
+		config.Tokens = append(config.Tokens, cred)
+	}
+
+	for i, entry := range splitList(os.Getenv("HFT_API_HMAC_KEYS")) {
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return config, fmt.Errorf("invalid HFT_API_HMAC_KEYS entry %d: want id:scope:secret", i+1)
+		}
+		cred, err := newCredential(parts[0], parts[1], parts[2])
+		if err != nil {
+			return config, fmt.Errorf("HFT_API_HMAC_KEYS entry %d: %w", i+1, err)
+		}
+		config.HMACKeys = append(config.HMACKeys, cred)
+	}
+
+	config.CORSOrigins = splitList(os.Getenv("HFT_API_CORS_ORIGINS"))
+
+	if limit := os.Getenv("HFT_API_RATE_LIMIT"); limit != "" {
+		l, err := strconv.ParseFloat(limit, 64)
+		if err != nil || l < 0 {
+			return config, fmt.Errorf("invalid HFT_API_RATE_LIMIT %q", limit)
+		}
+		config.RateLimit = l
+	}
+	if burst := os.Getenv("HFT_API_RATE_BURST"); burst != "" {
+		b, err := strconv.Atoi(burst)
+		if err != nil || b <= 0 {
+			return config, fmt.Errorf("invalid HFT_API_RATE_BURST %q", burst)
+		}
+		config.RateBurst = b
+	}
+
+	return config, nil
+}
+
+// Addr returns the host:port the server listens on
+func (c ServerConfig) Addr() string {
+	return net.JoinHostPort(c.BindAddr, strconv.Itoa(c.Port))
+}
+
+// TLS reports whether the server serves HTTPS
+func (c ServerConfig) TLS() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// AuthRequired reports whether any credentials are configured. Without
+// credentials read endpoints are open and admin endpoints are disabled.
+func (c ServerConfig) AuthRequired() bool {
+	return len(c.Tokens) > 0 || len(c.HMACKeys) > 0
+}
+
+// splitList splits a comma separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,43 @@
+package api
+
+import "testing"
+
+func TestServerConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        map[string]string
+		wantAddr   string
+		wantTokens int
+		wantErr    bool
+	}{
+		{name: "defaults", wantAddr: "127.0.0.1:8080"},
+		{name: "IPv6 bind address", env: map[string]string{"HFT_API_BIND": "::1", "HFT_API_PORT": "9090"}, wantAddr: "[::1]:9090"},
+		{name: "tokens and admin token", env: map[string]string{"HFT_API_TOKENS": "read:abc, admin:xyz", "HFT_ADMIN_TOKEN": "root"}, wantAddr: "127.0.0.1:8080", wantTokens: 3},
+		{name: "invalid port", env: map[string]string{"HFT_API_PORT": "70000"}, wantErr: true},
+		{name: "unknown token scope", env: map[string]string{"HFT_API_TOKENS": "write:abc"}, wantErr: true},
+		{name: "token without scope", env: map[string]string{"HFT_API_TOKENS": "abc"}, wantErr: true},
+		{name: "certificate without key", env: map[string]string{"HFT_API_TLS_CERT": "cert.pem"}, wantErr: true},
+		{name: "short HMAC key", env: map[string]string{"HFT_API_HMAC_KEYS": "ops:admin"}, wantErr: true},
+		{name: "negative rate limit", env: map[string]string{"HFT_API_RATE_LIMIT": "-1"}, wantErr: true},
+	}
+
+	vars := []string{"HFT_API_BIND", "HFT_API_PORT", "HFT_API_TLS_CERT", "HFT_API_TLS_KEY", "HFT_API_TOKENS", "HFT_ADMIN_TOKEN",
+		"HFT_API_HMAC_KEYS", "HFT_API_CORS_ORIGINS", "HFT_API_RATE_LIMIT", "HFT_API_RATE_BURST"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range vars {
+				t.Setenv(name, tt.env[name])
+			}
+			config, err := ServerConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ServerConfigFromEnv() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if config.Addr() != tt.wantAddr || len(config.Tokens) != tt.wantTokens {
+				t.Errorf("got addr %s with %d tokens, want %s with %d", config.Addr(), len(config.Tokens), tt.wantAddr, tt.wantTokens)
+			}
+		})
+	}
+}
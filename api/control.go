@@ -0,0 +1,247 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hft-arbitrage-bot/logging"
+	"hft-arbitrage-bot/strategy"
+)
+
+var auditLogger = logging.Component("audit")
+
+// maxAuditEntries is the number of control actions kept in memory
+const maxAuditEntries = 500
+
+// AuditEntry records one control-plane action
+type AuditEntry struct {
+	Time   time.Time       `json:"time"`
+	Actor  string          `json:"actor"`
+	Action string          `json:"action"`
+	Venue  string          `json:"venue,omitempty"`
+	Reason string          `json:"reason,omitempty"`
+	Result string          `json:"result"` // "applied" or the rejection error
+	Before strategy.Params `json:"before"`
+	After  strategy.Params `json:"after"`
+}
+
+// auditLog keeps the most recent control actions
+type auditLog struct {
+	mu      sync.Mutex
+	entries []AuditEntry
+}
+
+func (a *auditLog) record(entry AuditEntry) {
+	a.mu.Lock()
+	a.entries = append(a.entries, entry)
+	if len(a.entries) > maxAuditEntries {
+		a.entries = a.entries[len(a.entries)-maxAuditEntries:]
+	}
+	a.mu.Unlock()
+
+	auditLogger.Warn("control action",
+		"actor", entry.Actor,
+		"action", entry.Action,
+		"venue", entry.Venue,
+		"reason", entry.Reason,
+		"result", entry.Result,
+		"before", entry.Before,
+		"after", entry.After)
+}
+
+// recent returns up to limit entries, newest first
+func (a *auditLog) recent(limit int) []AuditEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entries := make([]AuditEntry, 0, min(limit, len(a.entries)))
+	for i := len(a.entries) - 1; i >= 0 && len(entries) < limit; i-- {
+		entries = append(entries, a.entries[i])
+	}
+	return entries
+}
+
+// ParamsRequest is the body of POST /control/params. Omitted fields are left
+// unchanged.
+type ParamsRequest struct {
+	MinSpreadPercent  *float64           `json:"min_spread_percent"`
+	TradeSize         *float64           `json:"trade_size"`
+	FeeOverrides      map[string]float64 `json:"fee_overrides"`
+	ClearFeeOverrides []string           `json:"clear_fee_overrides"`
+	Reason            string             `json:"reason"`
+}
+
+// updateParams runs a parameter change and audit-logs it, whether or not it
+// was accepted. Pauses and venue changes are applied to the other strategies
+// too; parameter changes only to the strategy the API serves.
+func (api *PnLAPI) updateParams(r *http.Request, action, venue, reason string, change func(p *strategy.Params) error) (strategy.Params, error) {
+	before, after, err := api.strategy.UpdateParams(change)
+	if err == nil && action != "params" {
+		for _, s := range api.strategies[1:] {
+			if _, _, err := s.UpdateParams(change); err != nil {
+				logger.Error("control action failed", "strategy", s.Name(), "action", action, "err", err)
+			}
+		}
+	}
+
+	entry := AuditEntry{
+		Time:   time.Now(),
+		Actor:  clientID(r),
+		Action: action,
+		Venue:  venue,
+		Reason: reason,
+		Result: "applied",
+		Before: before,
+		After:  after,
+	}
+	if err != nil {
+		entry.Result = err.Error()
+	}
+	api.audit.record(entry)
+
+	if err != nil {
+		return before, newAPIError(http.StatusBadRequest, err.Error())
+	}
+	return after, nil
+}
+
+// applyControl runs a parameter change and writes the result
+func (api *PnLAPI) applyControl(w http.ResponseWriter, r *http.Request, action, venue, reason string, change func(p *strategy.Params) error) {
+	after, err := api.updateParams(r, action, venue, reason, change)
+	if err != nil {
+		writeAPIError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"data":      after,
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// handleControl returns the parameters currently in effect
+func (api *PnLAPI) handleControl(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"data":      api.strategy.Params(),
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// pauseChange pauses or resumes execution globally, or for one venue
+func pauseChange(venue string, paused bool) func(p *strategy.Params) error {
+	return func(p *strategy.Params) error {
+		switch {
+		case venue == "":
+			p.TradingPaused = paused
+		case paused:
+			p.PausedVenues[venue] = true
+		default:
+			delete(p.PausedVenues, venue)
+		}
+		return nil
+	}
+}
+
+// paramsChange applies the fields set in a params request
+func paramsChange(req ParamsRequest) func(p *strategy.Params) error {
+	return func(p *strategy.Params) error {
+		if req.MinSpreadPercent != nil {
+			p.MinSpreadPercent = *req.MinSpreadPercent
+		}
+		if req.TradeSize != nil {
+			p.TradeSize = *req.TradeSize
+		}
+		for _, venue := range req.ClearFeeOverrides {
+			delete(p.FeeOverrides, strings.ToLower(venue))
+		}
+		for venue, fee := range req.FeeOverrides {
+			p.FeeOverrides[strings.ToLower(venue)] = fee
+		}
+		return nil
+	}
+}
+
+// venueChange enables or disables a venue
+func venueChange(venue, action string) (func(p *strategy.Params) error, error) {
+	if action != "enable" && action != "disable" {
+		return nil, newAPIError(http.StatusNotFound, fmt.Sprintf("unknown venue action %q (want enable or disable)", action))
+	}
+	return func(p *strategy.Params) error {
+		if action == "disable" {
+			p.DisabledVenues[venue] = true
+		} else {
+			delete(p.DisabledVenues, venue)
+		}
+		return nil
+	}, nil
+}
+
+// handlePause stops execution globally, or for ?venue= only
+func (api *PnLAPI) handlePause(w http.ResponseWriter, r *http.Request) {
+	venue := strings.ToLower(r.URL.Query().Get("venue"))
+	api.applyControl(w, r, "pause", venue, r.URL.Query().Get("reason"), pauseChange(venue, true))
+}
+
+// handleResumeTrading resumes execution globally, or for ?venue= only
+func (api *PnLAPI) handleResumeTrading(w http.ResponseWriter, r *http.Request) {
+	venue := strings.ToLower(r.URL.Query().Get("venue"))
+	api.applyControl(w, r, "resume", venue, r.URL.Query().Get("reason"), pauseChange(venue, false))
+}
+
+// handleParams changes the spread threshold, trade size and fee overrides
+// in one atomic update
+func (api *PnLAPI) handleParams(w http.ResponseWriter, r *http.Request) {
+	var req ParamsRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid params body: "+err.Error())
+		return
+	}
+
+	api.applyControl(w, r, "params", "", req.Reason, paramsChange(req))
+}
+
+// handleVenue enables or disables a venue. Disabled venues' quotes are
+// ignored until the venue is enabled again.
+func (api *PnLAPI) handleVenue(w http.ResponseWriter, r *http.Request) {
+	venue := strings.ToLower(r.PathValue("venue"))
+	action := r.PathValue("action")
+	change, err := venueChange(venue, action)
+	if err != nil {
+		writeAPIError(w, r, err)
+		return
+	}
+
+	api.applyControl(w, r, action, venue, r.URL.Query().Get("reason"), change)
+}
+
+// handleAudit returns the most recent control actions, newest first
+func (api *PnLAPI) handleAudit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	entries := api.audit.recent(limit)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"data":      entries,
+		"count":     len(entries),
+		"timestamp": time.Now().Unix(),
+	})
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"hft-arbitrage-bot/strategy"
+)
+
+func TestControlActions(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		token    string
+		body     string
+		wantCode int
+		check    func(t *testing.T, p strategy.Params)
+	}{
+		{name: "read token cannot pause", method: http.MethodPost, path: "/control/pause", token: readToken, wantCode: http.StatusForbidden},
+		{name: "no token", method: http.MethodPost, path: "/control/pause", wantCode: http.StatusUnauthorized},
+		{
+			name: "pause trading", method: http.MethodPost, path: "/control/pause?reason=maintenance", token: adminToken, wantCode: http.StatusOK,
+			check: func(t *testing.T, p strategy.Params) {
+				if !p.TradingPaused {
+					t.Error("trading not paused")
+				}
+			},
+		},
+		{
+			name: "pause one venue", method: http.MethodPost, path: "/control/pause?venue=OKX", token: adminToken, wantCode: http.StatusOK,
+			check: func(t *testing.T, p strategy.Params) {
+				if p.TradingPaused || !p.PausedVenues["okx"] {
+					t.Errorf("paused %v, venues %v; want okx paused only", p.TradingPaused, p.PausedVenues)
+				}
+			},
+		},
+		{
+			name: "change params", method: http.MethodPost, path: "/control/params", token: adminToken, wantCode: http.StatusOK,
+			body: `{"min_spread_percent":0.1,"trade_size":50,"fee_overrides":{"OKX":0.0008},"reason":"promo"}`,
+			check: func(t *testing.T, p strategy.Params) {
+				if p.MinSpreadPercent != 0.1 || p.TradeSize != 50 || p.Fee("okx") != 0.0008 {
+					t.Errorf("params = %+v", p)
+				}
+			},
+		},
+		{
+			name: "invalid params are rejected whole", method: http.MethodPost, path: "/control/params", token: adminToken, wantCode: http.StatusBadRequest,
+			body: `{"min_spread_percent":0.1,"trade_size":-5}`,
+			check: func(t *testing.T, p strategy.Params) {
+				if p.MinSpreadPercent != 0 || p.TradeSize != 100 {
+					t.Errorf("rejected change applied: %+v", p)
+				}
+			},
+		},
+		{name: "unknown params field", method: http.MethodPost, path: "/control/params", token: adminToken, body: `{"size":5}`, wantCode: http.StatusBadRequest},
+		{
+			name: "disable a venue", method: http.MethodPost, path: "/control/venues/kraken/disable", token: adminToken, wantCode: http.StatusOK,
+			check: func(t *testing.T, p strategy.Params) {
+				if !p.DisabledVenues["kraken"] || p.Tradable("kraken") {
+					t.Errorf("kraken not disabled: %v", p.DisabledVenues)
+				}
+			},
+		},
+		{name: "unknown venue action", method: http.MethodPost, path: "/control/venues/kraken/restart", token: adminToken, wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(testConfig())
+			w := serve(api, newRequest(tt.method, tt.path, tt.token, tt.body))
+			if w.Code != tt.wantCode {
+				t.Fatalf("status code = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if tt.check != nil {
+				tt.check(t, api.strategy.Params())
+			}
+		})
+	}
+}
+
+func TestControlAppliesToEveryStrategy(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantShared bool // whether the other strategy sees the change
+	}{
+		{name: "pause", path: "/control/pause", wantShared: true},
+		{name: "venue", path: "/control/venues/okx/disable", wantShared: true},
+		{name: "params", path: "/control/params", body: `{"trade_size":50}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(testConfig())
+			other := strategy.NewArbitrageStrategy(0, 1000, 100)
+			api.SetStrategies(other)
+			before := other.Params()
+
+			if w := serve(api, newRequest(http.MethodPost, tt.path, adminToken, tt.body)); w.Code != http.StatusOK {
+				t.Fatalf("status code = %d: %s", w.Code, w.Body)
+			}
+			after := other.Params()
+			changed := after.TradingPaused != before.TradingPaused || len(after.DisabledVenues) != len(before.DisabledVenues) || after.TradeSize != before.TradeSize
+			if changed != tt.wantShared {
+				t.Errorf("other strategy changed = %v, want %v: %+v", changed, tt.wantShared, after)
+			}
+		})
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	api := newTestAPI(testConfig())
+	for _, path := range []string{"/control/pause", "/control/resume", "/control/venues/okx/disable"} {
+		serve(api, newRequest(http.MethodPost, path, adminToken, ""))
+	}
+	serve(api, newRequest(http.MethodPost, "/control/params", adminToken, `{"trade_size":-1}`))
+
+	tests := []struct {
+		name        string
+		path        string
+		wantActions []string
+	}{
+		{name: "newest first", path: "/control/audit", wantActions: []string{"params", "disable", "resume", "pause"}},
+		{name: "limited", path: "/control/audit?limit=2", wantActions: []string{"params", "disable"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(api, newRequest(http.MethodGet, tt.path, adminToken, ""))
+			var body struct {
+				Data []AuditEntry `json:"data"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("decoding body: %v", err)
+			}
+			if len(body.Data) != len(tt.wantActions) {
+				t.Fatalf("got %d entries, want %d", len(body.Data), len(tt.wantActions))
+			}
+			for i, entry := range body.Data {
+				if entry.Action != tt.wantActions[i] || entry.Actor != "operator@192.0.2.1" {
+					t.Errorf("entry %d = %s by %s, want %s by operator@192.0.2.1", i, entry.Action, entry.Actor, tt.wantActions[i])
+				}
+			}
+			if rejected := body.Data[0]; rejected.Result == "applied" {
+				t.Error("rejected params change recorded as applied")
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"hft-arbitrage-bot/exchange"
+)
+
+// HealthConfig sets the thresholds used by the liveness and readiness checks
+type HealthConfig struct {
+	MinReadyVenues     int           // venues that must be connected with fresh quotes
+	MaxQuoteAge        time.Duration // a venue's last quote must be younger than this
+	MaxHeartbeatAge    time.Duration // readiness: the strategy loop must have ticked within this
+	LivenessHeartbeat  time.Duration // liveness: a loop silent for this long is considered dead
+	LedgerWriteTimeout time.Duration // how long to wait for the ledger write lock
+}
+
+// DefaultHealthConfig returns thresholds suited to the 100ms strategy loop
+func DefaultHealthConfig() HealthConfig {
+	return HealthConfig{
+		MinReadyVenues:     2,
+		MaxQuoteAge:        10 * time.Second,
+		MaxHeartbeatAge:    2 * time.Second,
+		LivenessHeartbeat:  30 * time.Second,
+		LedgerWriteTimeout: 250 * time.Millisecond,
+	}
+}
+
+// ComponentHealth is the result of checking one component
+type ComponentHealth struct {
+	Healthy bool          `json:"healthy"`
+	Detail  string        `json:"detail"`
+	Venues  []VenueHealth `json:"venues,omitempty"`
+}
+
+// VenueHealth is the feed state of one venue as seen by the readiness check
+type VenueHealth struct {
+	Venue      string `json:"venue"`
+	Connected  bool   `json:"connected"`
+	Fresh      bool   `json:"fresh"`
+	QuoteAge   string `json:"quote_age"`
+	Reconnects int    `json:"reconnects"`
+	LastError  string `json:"last_error,omitempty"`
+}
+
+// HealthReport is the body of the liveness and readiness probes
+type HealthReport struct {
+	Status     string                     `json:"status"` // alive/dead or ready/not_ready
+	Components map[string]ComponentHealth `json:"components"`
+	Uptime     string                     `json:"uptime"`
+	Timestamp  int64                      `json:"timestamp"`
+}
+
+// HealthSummary is the overall state reported by /health
+type HealthSummary struct {
+	Status string `json:"status"` // "healthy" or "degraded"
+	Uptime string `json:"uptime"`
+}
+
+// healthSummary runs the readiness checks and folds them into one state
+func (api *PnLAPI) healthSummary(now time.Time) HealthSummary {
+	status := "healthy"
+	for _, c := range []ComponentHealth{api.checkFeeds(now), api.checkStrategy(now, api.health.MaxHeartbeatAge), api.checkLedger()} {
+		if !c.Healthy {
+			status = "degraded"
+		}
+	}
+	return HealthSummary{Status: status, Uptime: now.Sub(api.startedAt).Round(time.Second).String()}
+}
+
+// checkFeeds reports the venues that are connected with fresh quotes
+func (api *PnLAPI) checkFeeds(now time.Time) ComponentHealth {
+	return feedHealth(exchange.FeedStates(), now, api.health)
+}
+
+// feedHealth checks feed states against the thresholds. A venue with several
+// feeds, such as bybit's spot and perpetual ones, counts once toward
+// MinReadyVenues, when any of its feeds is ready.
+func feedHealth(states []exchange.FeedState, now time.Time, config HealthConfig) ComponentHealth {
+	venues := make([]VenueHealth, 0, len(states))
+	all := make(map[string]bool)
+	ready := make(map[string]bool)
+
+	for _, state := range states {
+		venue := VenueHealth{
+			Venue:      state.Venue,
+			Connected:  state.Connected,
+			QuoteAge:   "never",
+			Reconnects: state.Reconnects,
+			LastError:  state.LastError,
+		}
+		if !state.LastMessage.IsZero() {
+			age := now.Sub(state.LastMessage)
+			venue.QuoteAge = age.Round(time.Millisecond).String()
+			venue.Fresh = age <= config.MaxQuoteAge
+		}
+		all[exchange.FeedVenue(state.Venue)] = true
+		if venue.Connected && venue.Fresh {
+			ready[exchange.FeedVenue(state.Venue)] = true
+		}
+		venues = append(venues, venue)
+	}
+
+	return ComponentHealth{
+		Healthy: len(ready) >= config.MinReadyVenues,
+		Detail:  fmt.Sprintf("%d of %d venues connected with quotes younger than %s (need %d)", len(ready), len(all), config.MaxQuoteAge, config.MinReadyVenues),
+		Venues:  venues,
+	}
+}
+
+// checkStrategy reports whether the strategy loop heartbeat is recent enough
+func (api *PnLAPI) checkStrategy(now time.Time, maxAge time.Duration) ComponentHealth {
+	last := api.strategy.LastHeartbeat()
+	if last.IsZero() {
+		return ComponentHealth{Healthy: false, Detail: "strategy loop has not started"}
+	}
+
+	age := now.Sub(last)
+	return ComponentHealth{
+		Healthy: age <= maxAge,
+		Detail:  fmt.Sprintf("last heartbeat %s ago (max %s)", age.Round(time.Millisecond), maxAge),
+	}
+}
+
+// checkLedger reports whether trades can currently be booked
+func (api *PnLAPI) checkLedger() ComponentHealth {
+	if err := api.pnlManager.CheckWritable(api.health.LedgerWriteTimeout); err != nil {
+		return ComponentHealth{Healthy: false, Detail: err.Error()}
+	}
+	return ComponentHealth{Healthy: true, Detail: "writable"}
+}
+
+// handleLive reports whether the process should be restarted: it fails only
+// when the strategy loop has stopped ticking altogether
+func (api *PnLAPI) handleLive(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	components := map[string]ComponentHealth{
+		"strategy": api.checkStrategy(now, api.health.LivenessHeartbeat),
+	}
+	api.writeHealth(w, now, "alive", "dead", components)
+}
+
+// handleReady reports whether the bot can trade: enough fresh venues, a live
+// strategy loop and a writable ledger
+func (api *PnLAPI) handleReady(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	components := map[string]ComponentHealth{
+		"feeds":    api.checkFeeds(now),
+		"strategy": api.checkStrategy(now, api.health.MaxHeartbeatAge),
+		"ledger":   api.checkLedger(),
+	}
+	api.writeHealth(w, now, "ready", "not_ready", components)
+}
+
+// writeHealth answers 200 when every component is healthy and 503 otherwise
+func (api *PnLAPI) writeHealth(w http.ResponseWriter, now time.Time, okStatus, failStatus string, components map[string]ComponentHealth) {
+	healthy := true
+	for _, c := range components {
+		healthy = healthy && c.Healthy
+	}
+
+	status := okStatus
+	code := http.StatusOK
+	if !healthy {
+		status = failStatus
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(HealthReport{
+		Status:     status,
+		Components: components,
+		Uptime:     now.Sub(api.startedAt).Round(time.Second).String(),
+		Timestamp:  now.Unix(),
+	})
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hft-arbitrage-bot/clock"
+	"hft-arbitrage-bot/exchange"
+)
+
+func TestHealthProbes(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		heartbeat time.Duration // age of the last strategy loop iteration; 0 if it never ran
+		wantCode  int
+		wantState string
+	}{
+		{name: "live before the loop starts", path: "/health/live", wantCode: http.StatusServiceUnavailable, wantState: "dead"},
+		{name: "live with a recent heartbeat", path: "/health/live", heartbeat: time.Second, wantCode: http.StatusOK, wantState: "alive"},
+		{name: "live with a slow loop", path: "/health/live", heartbeat: 10 * time.Second, wantCode: http.StatusOK, wantState: "alive"},
+		{name: "dead after the liveness heartbeat", path: "/health/live", heartbeat: time.Minute, wantCode: http.StatusServiceUnavailable, wantState: "dead"},
+		{name: "not ready without fresh feeds", path: "/health/ready", heartbeat: time.Second, wantCode: http.StatusServiceUnavailable, wantState: "not_ready"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(DefaultServerConfig())
+			if tt.heartbeat > 0 {
+				api.strategy.SetClock(clock.NewSim(time.Now().Add(-tt.heartbeat)))
+				api.strategy.Evaluate()
+			}
+
+			w := serve(api, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.wantCode {
+				t.Errorf("status code = %d, want %d", w.Code, tt.wantCode)
+			}
+			var report HealthReport
+			if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+				t.Fatalf("decoding report: %v", err)
+			}
+			if report.Status != tt.wantState {
+				t.Errorf("status = %q, want %q", report.Status, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestCheckStrategy(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		heartbeat   time.Duration
+		maxAge      time.Duration
+		wantHealthy bool
+	}{
+		{name: "never ran", maxAge: 2 * time.Second},
+		{name: "within max age", heartbeat: time.Second, maxAge: 2 * time.Second, wantHealthy: true},
+		{name: "at max age", heartbeat: 2 * time.Second, maxAge: 2 * time.Second, wantHealthy: true},
+		{name: "older than max age", heartbeat: 3 * time.Second, maxAge: 2 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(DefaultServerConfig())
+			if tt.heartbeat > 0 {
+				api.strategy.SetClock(clock.NewSim(now.Add(-tt.heartbeat)))
+				api.strategy.Evaluate()
+			}
+			if got := api.checkStrategy(now, tt.maxAge); got.Healthy != tt.wantHealthy {
+				t.Errorf("healthy = %v (%s), want %v", got.Healthy, got.Detail, tt.wantHealthy)
+			}
+		})
+	}
+}
+
+func TestFeedHealth(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	fresh := func(feed string) exchange.FeedState {
+		return exchange.FeedState{Venue: feed, Connected: true, LastMessage: now.Add(-time.Second)}
+	}
+	stale := func(feed string) exchange.FeedState {
+		return exchange.FeedState{Venue: feed, Connected: true, LastMessage: now.Add(-time.Minute)}
+	}
+	tests := []struct {
+		name        string
+		states      []exchange.FeedState
+		wantHealthy bool
+		wantDetail  string
+	}{
+		{
+			name:        "two venues",
+			states:      []exchange.FeedState{fresh("binance"), fresh("okx")},
+			wantHealthy: true,
+			wantDetail:  "2 of 2 venues connected with quotes younger than 10s (need 2)",
+		},
+		{
+			name:       "spot and perpetual feeds of one venue",
+			states:     []exchange.FeedState{stale("binance"), fresh("bybit"), fresh("bybit-perp")},
+			wantDetail: "1 of 2 venues connected with quotes younger than 10s (need 2)",
+		},
+		{
+			name:        "a venue ready on its perpetual feed only",
+			states:      []exchange.FeedState{fresh("binance"), stale("bybit"), fresh("bybit-perp")},
+			wantHealthy: true,
+			wantDetail:  "2 of 2 venues connected with quotes younger than 10s (need 2)",
+		},
+		{
+			name:       "disconnected",
+			states:     []exchange.FeedState{fresh("binance"), {Venue: "okx", LastMessage: now}},
+			wantDetail: "1 of 2 venues connected with quotes younger than 10s (need 2)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := feedHealth(tt.states, now, DefaultHealthConfig())
+			if got.Healthy != tt.wantHealthy || got.Detail != tt.wantDetail {
+				t.Errorf("feedHealth() = %v (%s), want %v (%s)", got.Healthy, got.Detail, tt.wantHealthy, tt.wantDetail)
+			}
+			if len(got.Venues) != len(tt.states) {
+				t.Errorf("reported %d feeds, want %d", len(got.Venues), len(tt.states))
+			}
+		})
+	}
+}
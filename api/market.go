@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hft-arbitrage-bot/strategy"
+)
+
+const (
+	defaultBookDepth = 5
+	maxBookDepth     = 50
+)
+
+// handleQuotes returns the latest quote of every venue with its age
+func (api *PnLAPI) handleQuotes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	quotes := api.strategy.GetQuotes()
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"data":      quotes,
+		"count":     len(quotes),
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// handleBook returns the top ?depth= levels (default 5) of a venue's book
+func (api *PnLAPI) handleBook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	depth := defaultBookDepth
+	if depthStr := r.URL.Query().Get("depth"); depthStr != "" {
+		if d, err := strconv.Atoi(depthStr); err == nil && d > 0 {
+			depth = min(d, maxBookDepth)
+		}
+	}
+
+	venue, symbol := r.PathValue("venue"), r.PathValue("symbol")
+	book, updated, err := api.strategy.GetOrderBook(venue, symbol, depth)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, strategy.ErrNoQuote) {
+			code = http.StatusNotFound
+		}
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "error",
+			"data":      err.Error(),
+			"timestamp": time.Now().Unix(),
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "success",
+		"data": map[string]interface{}{
+			"venue":   venue,
+			"symbol":  symbol,
+			"bids":    book.Bids,
+			"asks":    book.Asks,
+			"updated": updated,
+			"age_ms":  float64(time.Since(updated)) / float64(time.Millisecond),
+		},
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// handleSpreads returns the gross and net edge of every directed venue pair
+func (api *PnLAPI) handleSpreads(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	spreads := api.strategy.GetSpreads()
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"data":      spreads,
+		"count":     len(spreads),
+		"timestamp": time.Now().Unix(),
+	})
+}
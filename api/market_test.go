@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hft-arbitrage-bot/strategy"
+)
+
+func TestHandleBook(t *testing.T) {
+	api := newTestAPI(DefaultServerConfig())
+	levels := make([]strategy.PriceLevel, 60)
+	for i := range levels {
+		levels[i] = strategy.PriceLevel{Price: 0.1 - float64(i)*0.0001, Size: 10}
+	}
+	asks := make([]strategy.PriceLevel, 60)
+	for i := range asks {
+		asks[i] = strategy.PriceLevel{Price: 0.1001 + float64(i)*0.0001, Size: 10}
+	}
+	api.strategy.UpdateQuote(strategy.Quote{
+		Exchange: "okx", Symbol: "DOGEUSDT", Bid: levels[0].Price, Ask: asks[0].Price, Timestamp: time.Now(),
+		Book: &strategy.OrderBook{Bids: levels, Asks: asks},
+	})
+
+	tests := []struct {
+		name      string
+		path      string
+		wantCode  int
+		wantDepth int
+	}{
+		{name: "default depth", path: "/book/okx/DOGEUSDT", wantCode: http.StatusOK, wantDepth: defaultBookDepth},
+		{name: "requested depth", path: "/book/okx/DOGEUSDT?depth=12", wantCode: http.StatusOK, wantDepth: 12},
+		{name: "depth capped", path: "/book/okx/DOGEUSDT?depth=500", wantCode: http.StatusOK, wantDepth: maxBookDepth},
+		{name: "invalid depth uses the default", path: "/book/okx/DOGEUSDT?depth=-3", wantCode: http.StatusOK, wantDepth: defaultBookDepth},
+		{name: "unknown venue", path: "/book/kraken/DOGEUSD", wantCode: http.StatusNotFound},
+		{name: "unknown symbol", path: "/book/okx/BTCUSDT", wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(api, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("status code = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			var body struct {
+				Data strategy.OrderBook `json:"data"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("decoding body: %v", err)
+			}
+			if len(body.Data.Bids) != tt.wantDepth || len(body.Data.Asks) != tt.wantDepth {
+				t.Errorf("got %d bids and %d asks, want %d", len(body.Data.Bids), len(body.Data.Asks), tt.wantDepth)
+			}
+		})
+	}
+}
+
+func TestHandleQuotesAndSpreads(t *testing.T) {
+	api := newTestAPI(DefaultServerConfig())
+	now := time.Now()
+	api.strategy.UpdateQuote(strategy.Quote{Exchange: "binance", Symbol: "DOGEUSDT", Bid: 0.1000, Ask: 0.1001, Timestamp: now})
+	api.strategy.UpdateQuote(strategy.Quote{Exchange: "okx", Symbol: "DOGEUSDT", Bid: 0.1002, Ask: 0.1003, Timestamp: now})
+
+	tests := []struct {
+		path      string
+		wantCount int
+	}{
+		{path: "/quotes", wantCount: 2},
+		{path: "/spreads", wantCount: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := serve(api, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			var body struct {
+				Status string `json:"status"`
+				Count  int    `json:"count"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("decoding body: %v", err)
+			}
+			if w.Code != http.StatusOK || body.Status != "success" || body.Count != tt.wantCount {
+				t.Errorf("got %d %s count %d, want 200 success count %d", w.Code, body.Status, body.Count, tt.wantCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package api
+
+import "hft-arbitrage-bot/metrics"
+
+var (
+	authFailures = metrics.NewCounterVec("hft_api_auth_failures_total",
+		"API requests rejected for missing, invalid or insufficient credentials.", "reason")
+	rateLimited = metrics.NewCounter("hft_api_rate_limited_total",
+		"API requests rejected by the per-client rate limit.")
+)
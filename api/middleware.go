@@ -0,0 +1,114 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket per client
+type rateLimiter struct {
+	rate  float64 // tokens per second; 0 disables the limiter
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// idleBucketTTL is how long an unused client bucket is kept
+const idleBucketTTL = 5 * time.Minute
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:      rate,
+		burst:     float64(burst),
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// allow takes one token from the client's bucket. When the bucket is empty
+// it returns false and how long until the next token.
+func (l *rateLimiter) allow(client string) (bool, time.Duration) {
+	return l.take(client, 1)
+}
+
+// peek reports whether the client's bucket has a token without taking it
+func (l *rateLimiter) peek(client string) (bool, time.Duration) {
+	return l.take(client, 0)
+}
+
+// take refills the client's bucket and takes n tokens if at least one is
+// left
+func (l *rateLimiter) take(client string, n float64) (bool, time.Duration) {
+	if l.rate <= 0 {
+		return true, 0
+	}
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > idleBucketTTL {
+		for key, b := range l.buckets {
+			if now.Sub(b.last) > idleBucketTTL {
+				delete(l.buckets, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens -= n
+	return true, 0
+}
+
+// originAllowed reports whether a browser origin may call the API
+func (api *PnLAPI) originAllowed(origin string) bool {
+	for _, allowed := range api.config.CORSOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// cors adds CORS headers for allowed origins and answers preflight requests
+func (api *PnLAPI) cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin != "" && api.originAllowed(origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+			w.Header().Set("Access-Control-Allow-Headers",
+				"Authorization, Content-Type, "+headerKeyID+", "+headerTimestamp+", "+headerSignature)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Max-Age", "600")
+		}
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,251 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// openAPIVersion is the version of the /api/v1 contract reported in the document
+const openAPIVersion = "1.0.0"
+
+var pathParamPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+// schemas builds OpenAPI schemas from Go types, collecting named structs as
+// components so each is described once
+type schemas struct {
+	components map[string]interface{}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// of returns the schema of a value's type
+func (s *schemas) of(v interface{}) map[string]interface{} {
+	return s.schema(reflect.TypeOf(v))
+}
+
+func (s *schemas) schema(t reflect.Type) map[string]interface{} {
+	if t == nil {
+		return map[string]interface{}{}
+	}
+	if t.Kind() == reflect.Pointer {
+		return s.schema(t.Elem())
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		if _, ok := s.components[t.Name()]; !ok {
+			s.components[t.Name()] = map[string]interface{}{} // placeholder for recursive types
+			s.components[t.Name()] = s.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	default: // interface{} data may be anything
+		return map[string]interface{}{}
+	}
+}
+
+// object describes a struct by its JSON field names; fields without
+// omitempty or omitzero are required
+func (s *schemas) object(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = s.schema(field.Type)
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			required = append(required, name)
+		}
+	}
+
+	object := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		object["required"] = required
+	}
+	return object
+}
+
+// envelope describes a Response whose data has the given schema
+func envelope(data map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type":     "object",
+		"required": []string{"status", "data", "timestamp"},
+		"properties": map[string]interface{}{
+			"status":    map[string]interface{}{"type": "string", "enum": []string{"success"}},
+			"data":      data,
+			"count":     map[string]interface{}{"type": "integer", "description": "number of items when data is a list"},
+			"timestamp": map[string]interface{}{"type": "integer", "description": "Unix seconds"},
+		},
+	}
+}
+
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{"schema": schema},
+	}
+}
+
+// operation describes one route
+func (s *schemas) operation(rt route, errorRef map[string]interface{}) map[string]interface{} {
+	op := map[string]interface{}{
+		"summary":     rt.summary,
+		"operationId": strings.ToLower(rt.method) + operationName(rt.path),
+	}
+
+	var params []interface{}
+	for _, match := range pathParamPattern.FindAllStringSubmatch(rt.path, -1) {
+		params = append(params, map[string]interface{}{
+			"name": match[1], "in": "path", "required": true,
+			"schema": map[string]interface{}{"type": "string"},
+		})
+	}
+	for _, q := range rt.query {
+		params = append(params, map[string]interface{}{
+			"name": q.name, "in": "query", "description": q.description,
+			"schema": map[string]interface{}{"type": q.kind},
+		})
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	if rt.body != nil {
+		op["requestBody"] = map[string]interface{}{
+			"required": false,
+			"content":  jsonContent(s.of(rt.body)),
+		}
+	}
+
+	responses := map[string]interface{}{}
+	switch {
+	case rt.stream:
+		responses["200"] = map[string]interface{}{
+			"description": "WebSocket upgrade or text/event-stream; each message is one event",
+			"content": map[string]interface{}{
+				"text/event-stream": map[string]interface{}{"schema": s.of(rt.data)},
+			},
+		}
+	case rt.raw != nil && rt.data != nil:
+		// Probes answer with the report itself so orchestrators can read it
+		responses["200"] = map[string]interface{}{"description": "healthy", "content": jsonContent(s.of(rt.data))}
+		responses["503"] = map[string]interface{}{"description": "unhealthy", "content": jsonContent(s.of(rt.data))}
+	case rt.raw != nil:
+		responses["200"] = map[string]interface{}{"description": "OK", "content": jsonContent(map[string]interface{}{"type": "object"})}
+	default:
+		content := jsonContent(envelope(s.of(rt.data)))
+		if rt.csv != nil {
+			content["text/csv"] = map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}
+		}
+		responses["200"] = map[string]interface{}{"description": "OK", "content": content}
+	}
+
+	errorCodes := []int{http.StatusBadRequest, http.StatusMethodNotAllowed, http.StatusInternalServerError}
+	if rt.scope != "" {
+		errorCodes = append(errorCodes, http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests)
+		op["security"] = []interface{}{
+			map[string]interface{}{"bearerAuth": []string{}},
+			map[string]interface{}{"hmacAuth": []string{}},
+		}
+		op["x-required-scope"] = rt.scope
+	}
+	if len(pathParamPattern.FindAllString(rt.path, -1)) > 0 {
+		errorCodes = append(errorCodes, http.StatusNotFound)
+	}
+	for _, code := range errorCodes {
+		responses[strconv.Itoa(code)] = map[string]interface{}{
+			"description": http.StatusText(code),
+			"content":     jsonContent(errorRef),
+		}
+	}
+	op["responses"] = responses
+
+	return op
+}
+
+// operationName turns /control/venues/{venue}/{action} into
+// ControlVenuesVenueAction
+func operationName(path string) string {
+	var name strings.Builder
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '.' || r == '_'
+	}) {
+		name.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return name.String()
+}
+
+// buildOpenAPI generates the OpenAPI 3 document of the /api/v1 routes
+func buildOpenAPI(routes []route) map[string]interface{} {
+	s := &schemas{components: make(map[string]interface{})}
+	errorRef := s.of(ErrorResponse{})
+
+	paths := make(map[string]interface{})
+	for _, rt := range routes {
+		item, ok := paths[rt.path].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			paths[rt.path] = item
+		}
+		item[strings.ToLower(rt.method)] = s.operation(rt, errorRef)
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "HFT Arbitrage Bot API",
+			"version":     openAPIVersion,
+			"description": "Monitoring and control API. Read endpoints need the read scope, state changes the admin scope (x-required-scope).",
+		},
+		"servers": []interface{}{map[string]interface{}{"url": v1Prefix}},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": s.components,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer"},
+				"hmacAuth": map[string]interface{}{
+					"type": "apiKey", "in": "header", "name": headerKeyID,
+					"description": "HMAC-SHA256 signed request: also send " + headerTimestamp + " and " + headerSignature,
+				},
+			},
+		},
+	}
+}
+
+// handleOpenAPI serves the OpenAPI document of /api/v1
+func (api *PnLAPI) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(buildOpenAPI(api.v1Routes()))
+}
@@ -1,116 +1,362 @@
 package api
 
 import (
+	"crypto/tls"
 	"encoding/json"
-	"log"
 	"net/http"
-	"strconv"
+	"strings"
 	"time"
 
+	"hft-arbitrage-bot/logging"
+	"hft-arbitrage-bot/metrics"
+	"hft-arbitrage-bot/risk"
 	"hft-arbitrage-bot/strategy"
 )
 
+var logger = logging.Component("api")
+
 // PnLAPI provides HTTP endpoints for P&L monitoring
 type PnLAPI struct {
+	strategy   *strategy.ArbitrageStrategy
+	strategies []*strategy.ArbitrageStrategy // every strategy sharing the feeds, the one served first
 	pnlManager *strategy.PnLManager
+	riskEngine *risk.Engine
+	breakers   *risk.Breakers
+	health     HealthConfig
+	config     ServerConfig
+	limiter    *rateLimiter
+	signatures *signatureCache
+	audit      *auditLog
+	startedAt  time.Time
 	server     *http.Server
 }
 
-// NewPnLAPI creates a new P&L API server
-func NewPnLAPI(pnlManager *strategy.PnLManager, port int) *PnLAPI {
+// NewPnLAPI creates a new P&L API server for a running strategy
+func NewPnLAPI(arbitrageStrategy *strategy.ArbitrageStrategy, config ServerConfig) *PnLAPI {
 	mux := http.NewServeMux()
-	api := &PnLAPI{pnlManager: pnlManager}
-	
-	// Register routes
-	mux.HandleFunc("/pnl", api.handlePnL)
-	mux.HandleFunc("/trades", api.handleTrades)
-	mux.HandleFunc("/summary", api.handleSummary)
+	api := &PnLAPI{
+		strategy:   arbitrageStrategy,
+		strategies: []*strategy.ArbitrageStrategy{arbitrageStrategy},
+		pnlManager: arbitrageStrategy.GetPnLManager(),
+		riskEngine: arbitrageStrategy.GetRiskEngine(),
+		breakers:   arbitrageStrategy.GetCircuitBreakers(),
+		health:     DefaultHealthConfig(),
+		config:     config,
+		limiter:    newRateLimiter(config.RateLimit, config.RateBurst),
+		signatures: newSignatureCache(),
+		audit:      &auditLog{},
+		startedAt:  time.Now(),
+	}
+
+	// Register routes. Health probes are public; everything else needs the
+	// read scope, and anything that changes state needs the admin scope.
+	mux.HandleFunc("/pnl", api.require(ScopeRead, api.handlePnL))
+	mux.HandleFunc("/trades", api.require(ScopeRead, api.handleTrades))
+	mux.HandleFunc("/summary", api.require(ScopeRead, api.handleSummary))
+	mux.HandleFunc("/attribution", api.require(ScopeRead, api.handleAttribution))
 	mux.HandleFunc("/health", api.handleHealth)
-	
+	mux.HandleFunc("/health/live", api.handleLive)
+	mux.HandleFunc("/health/ready", api.handleReady)
+	mux.HandleFunc("/risk", api.require(ScopeRead, api.handleRisk))
+	mux.HandleFunc("/risk/kill", api.require(ScopeAdmin, api.handleKill))
+	mux.HandleFunc("/risk/resume", api.require(ScopeAdmin, api.handleResume))
+	mux.HandleFunc("/breakers", api.require(ScopeRead, api.handleBreakers))
+	mux.HandleFunc("/breakers/reset", api.require(ScopeAdmin, api.handleBreakerReset))
+	mux.HandleFunc("/metrics", api.require(ScopeRead, api.handleMetrics))
+	mux.HandleFunc("/stream", api.requireStream(ScopeRead, api.handleStream))
+	mux.HandleFunc("/quotes", api.require(ScopeRead, api.handleQuotes))
+	mux.HandleFunc("GET /book/{venue}/{symbol}", api.require(ScopeRead, api.handleBook))
+	mux.HandleFunc("/spreads", api.require(ScopeRead, api.handleSpreads))
+	mux.HandleFunc("GET /control", api.require(ScopeRead, api.handleControl))
+	mux.HandleFunc("GET /control/audit", api.require(ScopeAdmin, api.handleAudit))
+	mux.HandleFunc("POST /control/pause", api.require(ScopeAdmin, api.handlePause))
+	mux.HandleFunc("POST /control/resume", api.require(ScopeAdmin, api.handleResumeTrading))
+	mux.HandleFunc("POST /control/params", api.require(ScopeAdmin, api.handleParams))
+	mux.HandleFunc("POST /control/venues/{venue}/{action}", api.require(ScopeAdmin, api.handleVenue))
+
+	// The versioned API; the routes above are kept for existing clients
+	api.registerV1(mux)
+
 	api.server = &http.Server{
-		Addr:    ":" + strconv.Itoa(port),
-		Handler: mux,
+		Addr:              config.Addr(),
+		Handler:           api.cors(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig:         &tls.Config{MinVersion: tls.VersionTLS12},
 	}
-	
+
 	return api
 }
 
+// SetStrategies tells the API about the other strategies sharing the
+// exchange feeds with the one it serves. They are listed by GET
+// /api/v1/strategies, and the kill switch, breaker resets, pauses and venue
+// changes apply to all of them. It must be called before Start.
+func (api *PnLAPI) SetStrategies(others ...*strategy.ArbitrageStrategy) {
+	api.strategies = append([]*strategy.ArbitrageStrategy{api.strategy}, others...)
+}
+
+// halt engages the kill switch of every strategy
+func (api *PnLAPI) halt(reason string) {
+	for _, s := range api.strategies {
+		s.GetRiskEngine().Halt(reason)
+	}
+}
+
+// resume releases the kill switch of every strategy
+func (api *PnLAPI) resume() {
+	for _, s := range api.strategies {
+		s.GetRiskEngine().Resume()
+	}
+}
+
+// resetBreakers clears a venue's circuit breaker in every strategy
+func (api *PnLAPI) resetBreakers(venue string) {
+	for _, s := range api.strategies {
+		s.GetCircuitBreakers().Reset(venue)
+	}
+}
+
 // Start starts the HTTP server
 func (api *PnLAPI) Start() {
-	log.Printf("🌐 Starting P&L API server on port %s", api.server.Addr)
+	logger.Info("starting API server",
+		"addr", api.server.Addr,
+		"tls", api.config.TLS(),
+		"auth", api.config.AuthRequired(),
+		"cors_origins", api.config.CORSOrigins,
+		"rate_limit", api.config.RateLimit)
+	if !api.config.AuthRequired() && api.config.BindAddr != "127.0.0.1" && api.config.BindAddr != "localhost" {
+		logger.Warn("API is reachable from the network without authentication", "addr", api.server.Addr)
+	}
 	go func() {
-		if err := api.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Printf("❌ API server error: %v", err)
+		var err error
+		if api.config.TLS() {
+			err = api.server.ListenAndServeTLS(api.config.TLSCertFile, api.config.TLSKeyFile)
+		} else {
+			err = api.server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			logger.Error("API server error", "err", err)
 		}
 	}()
 }
 
 // Stop stops the HTTP server
 func (api *PnLAPI) Stop() {
-	log.Println("🛑 Stopping P&L API server...")
+	logger.Info("stopping API server")
 	api.server.Close()
 }
 
 // handlePnL handles P&L status requests
 func (api *PnLAPI) handlePnL(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	
+
 	status := api.pnlManager.GetCurrentPnL()
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status": "success",
-		"data":   status,
+		"status":    "success",
+		"data":      status,
 		"timestamp": time.Now().Unix(),
 	})
 }
 
-// handleTrades handles trade history requests
+// handleTrades handles trade history requests. It takes the filters, sort
+// and cursor of /api/v1/trades but keeps the legacy envelope and returns the
+// last 10 trades by default.
 func (api *PnLAPI) handleTrades(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	
-	limit := 10 // default limit
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
+	page, err := api.queryTrades(r, 10)
+	if err != nil {
+		writeAPIError(w, r, err)
+		return
+	}
+	if wantsCSV(r) {
+		writeTradesCSV(w, page)
+		return
 	}
-	
-	trades := api.pnlManager.GetTradeHistory(limit)
+
+	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status": "success",
-		"data":   trades,
-		"count":  len(trades),
-		"timestamp": time.Now().Unix(),
+		"status":      "success",
+		"data":        page.Trades,
+		"count":       len(page.Trades),
+		"total":       page.Total,
+		"next_cursor": page.NextCursor,
+		"timestamp":   time.Now().Unix(),
 	})
 }
 
 // handleSummary handles P&L summary requests
 func (api *PnLAPI) handleSummary(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	
+
 	summary := api.pnlManager.GetPnLSummary()
 	status := api.pnlManager.GetCurrentPnL()
-	
+
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"status": "success",
 		"data": map[string]interface{}{
-			"summary": summary,
-			"current_balance": status.CurrentBalance,
-			"total_pnl": status.TotalPnL,
+			"summary":           summary,
+			"current_balance":   status.CurrentBalance,
+			"total_pnl":         status.TotalPnL,
 			"total_pnl_percent": status.TotalPnLPercent,
-			"total_trades": status.TotalTrades,
-			"win_rate": status.WinRate,
+			"total_trades":      status.TotalTrades,
+			"win_rate":          status.WinRate,
 		},
 		"timestamp": time.Now().Unix(),
 	})
 }
 
-// handleHealth handles health check requests
-func (api *PnLAPI) handleHealth(w http.ResponseWriter, r *http.Request) {
+// handleAttribution handles P&L attribution requests, optionally restricted
+// to one dimension with ?by=pair|symbol|hour
+func (api *PnLAPI) handleAttribution(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	by := r.URL.Query().Get("by")
+	if by == "" {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "success",
+			"data":      api.pnlManager.GetAttribution(),
+			"timestamp": time.Now().Unix(),
+		})
+		return
+	}
+
+	buckets, err := api.pnlManager.GetAttributionBy(by)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "error",
+			"data":      err.Error(),
+			"timestamp": time.Now().Unix(),
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"data":      buckets,
+		"count":     len(buckets),
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// handleRisk handles risk engine status requests
+func (api *PnLAPI) handleRisk(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	
+
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status": "healthy",
+		"status":    "success",
+		"data":      api.riskEngine.Status(),
 		"timestamp": time.Now().Unix(),
-		"uptime": time.Since(time.Now()).String(), // This will be 0, but you could track actual uptime
 	})
-} 
\ No newline at end of file
+}
+
+// handleKill engages the kill switch; an optional ?reason= is recorded
+func (api *PnLAPI) handleKill(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "error",
+			"data":      "kill switch requires POST",
+			"timestamp": time.Now().Unix(),
+		})
+		return
+	}
+
+	reason := r.URL.Query().Get("reason")
+	if reason == "" {
+		reason = "manual halt via API"
+	}
+	api.halt(reason)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"data":      api.riskEngine.Status(),
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// handleResume releases the kill switch
+func (api *PnLAPI) handleResume(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "error",
+			"data":      "resume requires POST",
+			"timestamp": time.Now().Unix(),
+		})
+		return
+	}
+
+	api.resume()
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"data":      api.riskEngine.Status(),
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// handleBreakers handles circuit breaker status requests
+func (api *PnLAPI) handleBreakers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	statuses := api.breakers.Status()
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"data":      statuses,
+		"count":     len(statuses),
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// handleBreakerReset clears the breaker of the venue given by ?venue= in
+// every strategy
+func (api *PnLAPI) handleBreakerReset(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	venue := r.URL.Query().Get("venue")
+	if r.Method != http.MethodPost || venue == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "error",
+			"data":      "breaker reset requires POST with ?venue=",
+			"timestamp": time.Now().Unix(),
+		})
+		return
+	}
+
+	api.resetBreakers(strings.ToLower(venue))
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"data":      api.breakers.Status(),
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// handleMetrics serves every registered metric in the Prometheus text format
+func (api *PnLAPI) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", metrics.ContentType)
+	metrics.Default.WritePrometheus(w)
+}
+
+// handleHealth handles health check requests. It reports the readiness
+// checks without failing the request; use /health/ready for status codes.
+func (api *PnLAPI) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	now := time.Now()
+	summary := api.healthSummary(now)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "success",
+		"data": map[string]interface{}{
+			"status": summary.Status,
+		},
+		"timestamp": now.Unix(),
+		"uptime":    summary.Uptime,
+	})
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"hft-arbitrage-bot/strategy"
+)
+
+// Bearer tokens of testConfig
+const (
+	readToken  = "read-secret"
+	adminToken = "admin-secret"
+)
+
+// testConfig is the default config with a read and an admin token and no
+// rate limit
+func testConfig() ServerConfig {
+	config := DefaultServerConfig()
+	config.Tokens = []Credential{
+		{ID: "reader", Scope: ScopeRead, Secret: readToken},
+		{ID: "operator", Scope: ScopeAdmin, Secret: adminToken},
+	}
+	config.RateLimit = 0
+	return config
+}
+
+// newTestAPI returns an API serving a fresh strategy with a $1000 balance
+// and $100 trades
+func newTestAPI(config ServerConfig) *PnLAPI {
+	return NewPnLAPI(strategy.NewArbitrageStrategy(0, 1000, 100), config)
+}
+
+// newRequest builds a request with a bearer token, if any, and a JSON body
+func newRequest(method, path, token, body string) *http.Request {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	r := httptest.NewRequest(method, path, reader)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+// serve sends one request through the API's handler chain
+func serve(api *PnLAPI, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	api.server.Handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// v1Prefix is the path prefix of the versioned API
+const v1Prefix = "/api/v1"
+
+// Response is the envelope of every successful /api/v1 response
+type Response struct {
+	Status    string      `json:"status"` // always "success"
+	Data      interface{} `json:"data"`
+	Count     *int        `json:"count,omitempty"` // number of items when data is a list
+	Timestamp int64       `json:"timestamp"`
+}
+
+// ErrorResponse is the envelope of every failed /api/v1 response
+type ErrorResponse struct {
+	Status    string   `json:"status"` // always "error"
+	Error     APIError `json:"error"`
+	Timestamp int64    `json:"timestamp"`
+}
+
+// APIError describes why a request failed
+type APIError struct {
+	Code    int    `json:"code"`    // HTTP status code
+	Type    string `json:"type"`    // machine readable, e.g. "not_found"
+	Message string `json:"message"` // human readable
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// newAPIError returns an error that is written with the given status code
+func newAPIError(code int, message string) *APIError {
+	return &APIError{Code: code, Type: errorType(code), Message: message}
+}
+
+// errorType maps a status code to the error type reported to clients
+func errorType(code int) string {
+	switch code {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusUnauthorized:
+		return "unauthenticated"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusMethodNotAllowed:
+		return "method_not_allowed"
+	case http.StatusRequestEntityTooLarge:
+		return "too_large"
+	case http.StatusTooManyRequests:
+		return "rate_limited"
+	case http.StatusServiceUnavailable:
+		return "unavailable"
+	default:
+		return "internal"
+	}
+}
+
+// writeError writes an error in the envelope of the API the request is for:
+// the typed error object under /api/v1, the message as data on legacy routes
+func writeError(w http.ResponseWriter, r *http.Request, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if strings.HasPrefix(r.URL.Path, v1Prefix+"/") {
+		json.NewEncoder(w).Encode(ErrorResponse{
+			Status:    "error",
+			Error:     *newAPIError(code, message),
+			Timestamp: time.Now().Unix(),
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "error",
+		"data":      message,
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// writeAPIError writes a handler error; errors that are not an *APIError
+// are reported as internal errors
+func writeAPIError(w http.ResponseWriter, r *http.Request, err error) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		logger.Error("request failed", "path", r.URL.Path, "err", err)
+		apiErr = newAPIError(http.StatusInternalServerError, err.Error())
+	}
+	writeError(w, r, apiErr.Code, apiErr.Message)
+}
+
+// writeData writes a successful /api/v1 response; lists also report a count
+func writeData(w http.ResponseWriter, data interface{}) {
+	response := Response{Status: "success", Data: data, Timestamp: time.Now().Unix()}
+	if v := reflect.ValueOf(data); v.Kind() == reflect.Slice {
+		count := v.Len()
+		response.Count = &count
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// decodeBody decodes an optional JSON request body, rejecting unknown fields
+func decodeBody(r *http.Request, v interface{}) error {
+	if r.Body == nil {
+		return nil
+	}
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return newAPIError(http.StatusBadRequest, "invalid request body: "+err.Error())
+	}
+	return nil
+}
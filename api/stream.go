@@ -0,0 +1,246 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"hft-arbitrage-bot/strategy"
+)
+
+const (
+	streamBufferSize   = 256              // events queued per client before new ones are dropped
+	streamWriteTimeout = 5 * time.Second  // a client that cannot take a write in this time is dropped
+	streamPingInterval = 30 * time.Second // keepalive for WebSocket pings and SSE comments
+)
+
+// upgrader accepts WebSocket connections from non-browser clients, from the
+// API's own origin and from the configured CORS origins
+func (api *PnLAPI) upgrader() *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 4096,
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			if origin == "" || api.originAllowed(origin) {
+				return true
+			}
+			u, err := url.Parse(origin)
+			return err == nil && strings.EqualFold(u.Host, r.Host)
+		},
+	}
+}
+
+// streamRequest is a subscription change sent by a WebSocket client
+type streamRequest struct {
+	Action string   `json:"action"` // "subscribe" or "unsubscribe"
+	Topics []string `json:"topics"`
+}
+
+// parseTopics validates a list of topic names; "all" or an empty list selects
+// every topic
+func parseTopics(names []string) ([]string, error) {
+	var topics []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if name == "all" {
+			return strategy.Topics, nil
+		}
+		valid := false
+		for _, topic := range strategy.Topics {
+			if name == topic {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown topic %q (valid: %s)", name, strings.Join(strategy.Topics, ", "))
+		}
+		topics = append(topics, name)
+	}
+	if len(topics) == 0 {
+		return strategy.Topics, nil
+	}
+	return topics, nil
+}
+
+// handleStream pushes strategy events to the client as they happen. It
+// speaks WebSocket when the request is an upgrade and Server-Sent Events
+// otherwise. ?topics=quotes,opportunities,trades,pnl selects the topics.
+func (api *PnLAPI) handleStream(w http.ResponseWriter, r *http.Request) {
+	var names []string
+	if topics := r.URL.Query().Get("topics"); topics != "" {
+		names = strings.Split(topics, ",")
+	}
+	topics, err := parseTopics(names)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if websocket.IsWebSocketUpgrade(r) {
+		api.streamWebSocket(w, r, topics)
+		return
+	}
+	api.streamSSE(w, r, topics)
+}
+
+// streamWebSocket serves one WebSocket client. Clients may change their
+// topics at any time with {"action":"subscribe","topics":[...]}.
+func (api *PnLAPI) streamWebSocket(w http.ResponseWriter, r *http.Request, topics []string) {
+	conn, err := api.upgrader().Upgrade(w, r, nil)
+	if err != nil {
+		logger.Warn("stream upgrade failed", "remote", r.RemoteAddr, "err", err)
+		return
+	}
+	defer conn.Close()
+
+	sub := api.strategy.GetEventBus().Subscribe(streamBufferSize, topics...)
+	defer sub.Close()
+	logger.Info("stream client connected", "remote", r.RemoteAddr, "transport", "websocket", "topics", topics)
+
+	// Control messages are written by the writer loop below so that only one
+	// goroutine ever writes to the connection
+	control := make(chan strategy.Event, 8)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			var req streamRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			event := strategy.Event{Topic: "control", Timestamp: time.Now()}
+			if next, err := applyStreamRequest(sub, req); err != nil {
+				event.Type = "error"
+				event.Data = err.Error()
+			} else {
+				event.Type = "subscribed"
+				event.Data = next
+			}
+			select {
+			case control <- event:
+			default:
+			}
+		}
+	}()
+
+	ping := time.NewTicker(streamPingInterval)
+	defer ping.Stop()
+
+	control <- strategy.Event{Topic: "control", Type: "subscribed", Data: topics, Timestamp: time.Now()}
+	for {
+		var event strategy.Event
+		select {
+		case <-done:
+			logger.Info("stream client disconnected", "remote", r.RemoteAddr)
+			return
+		case <-ping.C:
+			conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+			continue
+		case event = <-control:
+		case e, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			event = e
+		}
+
+		conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if err := conn.WriteJSON(event); err != nil {
+			logger.Info("stream client dropped", "remote", r.RemoteAddr, "err", err)
+			return
+		}
+	}
+}
+
+// applyStreamRequest updates a subscription from a client request and
+// returns the resulting topics
+func applyStreamRequest(sub *strategy.Subscription, req streamRequest) ([]string, error) {
+	topics, err := parseTopics(req.Topics)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]bool)
+	for _, topic := range strategy.Topics {
+		if sub.Subscribed(topic) {
+			current[topic] = true
+		}
+	}
+
+	switch req.Action {
+	case "subscribe":
+		for _, topic := range topics {
+			current[topic] = true
+		}
+	case "unsubscribe":
+		for _, topic := range topics {
+			delete(current, topic)
+		}
+	default:
+		return nil, fmt.Errorf("unknown action %q (want subscribe or unsubscribe)", req.Action)
+	}
+
+	next := make([]string, 0, len(current))
+	for _, topic := range strategy.Topics {
+		if current[topic] {
+			next = append(next, topic)
+		}
+	}
+	sub.SetTopics(next...)
+	return next, nil
+}
+
+// streamSSE serves one Server-Sent Events client
+func (api *PnLAPI) streamSSE(w http.ResponseWriter, r *http.Request, topics []string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	sub := api.strategy.GetEventBus().Subscribe(streamBufferSize, topics...)
+	defer sub.Close()
+	logger.Info("stream client connected", "remote", r.RemoteAddr, "transport", "sse", "topics", topics)
+
+	ping := time.NewTicker(streamPingInterval)
+	defer ping.Stop()
+
+	fmt.Fprintf(w, ": subscribed to %s\n\n", strings.Join(topics, ","))
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			logger.Info("stream client disconnected", "remote", r.RemoteAddr)
+			return
+		case <-ping.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Topic, data)
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"slices"
+	"testing"
+
+	"hft-arbitrage-bot/strategy"
+)
+
+func TestParseTopics(t *testing.T) {
+	tests := []struct {
+		name    string
+		names   []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty selects every topic", want: strategy.Topics},
+		{name: "all", names: []string{"quotes", "all"}, want: strategy.Topics},
+		{name: "listed topics", names: []string{" trades", "pnl", ""}, want: []string{"trades", "pnl"}},
+		{name: "unknown topic", names: []string{"trades", "orders"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTopics(tt.names)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTopics() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseTopics() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyStreamRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial []string
+		req     streamRequest
+		want    []string
+		wantErr bool
+	}{
+		{name: "subscribe adds", initial: []string{"trades"}, req: streamRequest{Action: "subscribe", Topics: []string{"quotes"}}, want: []string{"quotes", "trades"}},
+		{name: "unsubscribe removes", initial: []string{"quotes", "trades"}, req: streamRequest{Action: "unsubscribe", Topics: []string{"quotes"}}, want: []string{"trades"}},
+		{name: "unsubscribe all", initial: []string{"quotes", "trades"}, req: streamRequest{Action: "unsubscribe", Topics: []string{"all"}}, want: []string{}},
+		{name: "unknown action", initial: []string{"trades"}, req: streamRequest{Action: "replace", Topics: []string{"quotes"}}, wantErr: true},
+		{name: "unknown topic", initial: []string{"trades"}, req: streamRequest{Action: "subscribe", Topics: []string{"orders"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := strategy.NewEventBus().Subscribe(1, tt.initial...)
+			defer sub.Close()
+
+			got, err := applyStreamRequest(sub, tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyStreamRequest() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				// A rejected request leaves the subscription as it was
+				for _, topic := range tt.initial {
+					if !sub.Subscribed(topic) {
+						t.Errorf("lost topic %s", topic)
+					}
+				}
+				return
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("applyStreamRequest() = %v, want %v", got, tt.want)
+			}
+			for _, topic := range strategy.Topics {
+				if sub.Subscribed(topic) != slices.Contains(tt.want, topic) {
+					t.Errorf("Subscribed(%s) = %v", topic, sub.Subscribed(topic))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hft-arbitrage-bot/strategy"
+)
+
+const (
+	defaultTradePageSize = 100
+	maxTradePageSize     = 5000
+)
+
+// tradeQueryParams documents the query string of the trades endpoints
+var tradeQueryParams = []queryParam{
+	{"limit", "integer", fmt.Sprintf("trades per page (default %d, max %d)", defaultTradePageSize, maxTradePageSize)},
+	{"cursor", "string", "next_cursor of the previous page"},
+	{"venue", "string", "exchange the leg traded on"},
+	{"symbol", "string", "symbol in any format, e.g. DOGE-USDT or DOGEUSDT"},
+	{"side", "string", "BUY or SELL"},
+	{"status", "string", "FILLED, PENDING, CANCELLED or FAILED"},
+	{"round_trip_id", "string", "both legs of one arbitrage"},
+	{"from", "string", "RFC 3339 time or Unix seconds, inclusive"},
+	{"to", "string", "RFC 3339 time or Unix seconds, exclusive"},
+	{"sort", "string", "timestamp, price or quantity; prefix with - for descending (default -timestamp)"},
+	{"format", "string", "csv for a CSV export (or send Accept: text/csv)"},
+}
+
+// parseTime accepts RFC 3339 or Unix seconds
+func parseTime(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, newAPIError(http.StatusBadRequest, fmt.Sprintf("%s must be RFC 3339 or Unix seconds, got %q", name, value))
+	}
+	return t, nil
+}
+
+// parseTradeQuery reads the filters, sort and page of a trades request
+func parseTradeQuery(r *http.Request, defaultLimit int) (strategy.TradeQuery, error) {
+	query := r.URL.Query()
+	limit, err := queryInt(r, "limit", defaultLimit, maxTradePageSize)
+	if err != nil {
+		return strategy.TradeQuery{}, err
+	}
+
+	q := strategy.TradeQuery{
+		TradeFilter: strategy.TradeFilter{
+			Venue:       query.Get("venue"),
+			Symbol:      query.Get("symbol"),
+			Side:        query.Get("side"),
+			Status:      query.Get("status"),
+			RoundTripID: query.Get("round_trip_id"),
+		},
+		Sort:   query.Get("sort"),
+		Limit:  limit,
+		Cursor: query.Get("cursor"),
+	}
+	if q.From, err = parseTime("from", query.Get("from")); err != nil {
+		return strategy.TradeQuery{}, err
+	}
+	if q.To, err = parseTime("to", query.Get("to")); err != nil {
+		return strategy.TradeQuery{}, err
+	}
+	return q, nil
+}
+
+// queryTrades runs a trades request against the ledger
+func (api *PnLAPI) queryTrades(r *http.Request, defaultLimit int) (strategy.TradePage, error) {
+	q, err := parseTradeQuery(r, defaultLimit)
+	if err != nil {
+		return strategy.TradePage{}, err
+	}
+	page, err := api.pnlManager.QueryTrades(q)
+	if err != nil {
+		return strategy.TradePage{}, newAPIError(http.StatusBadRequest, err.Error())
+	}
+	return page, nil
+}
+
+// wantsCSV reports whether the client asked for CSV instead of JSON
+func wantsCSV(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return strings.EqualFold(format, "csv")
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/csv")
+}
+
+// writeTradesCSV writes a trade page as CSV; the cursor of the next page is
+// sent in the X-Next-Cursor header
+func writeTradesCSV(w http.ResponseWriter, data interface{}) error {
+	page, ok := data.(strategy.TradePage)
+	if !ok {
+		return fmt.Errorf("cannot write %T as CSV", data)
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="trades.csv"`)
+	if page.NextCursor != "" {
+		w.Header().Set("X-Next-Cursor", page.NextCursor)
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+
+	out := csv.NewWriter(w)
+	out.Write([]string{"id", "type", "exchange", "symbol", "price", "quantity", "timestamp", "order_id", "status", "round_trip_id", "fee", "liquidity"})
+	for _, t := range page.Trades {
+		out.Write([]string{
+			t.ID,
+			t.Type,
+			t.Exchange,
+			t.Symbol,
+			strconv.FormatFloat(t.Price, 'f', -1, 64),
+			strconv.FormatFloat(t.Quantity, 'f', -1, 64),
+			t.Timestamp.Format(time.RFC3339Nano),
+			t.OrderID,
+			t.Status,
+			t.RoundTripID,
+			strconv.FormatFloat(t.Fee, 'f', -1, 64),
+			t.Liquidity,
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"hft-arbitrage-bot/strategy"
+)
+
+// newTradingAPI returns a test API whose ledger holds n executed arbitrages
+func newTradingAPI(t *testing.T, n int) *PnLAPI {
+	api := newTestAPI(testConfig())
+	for i := 0; i < n; i++ {
+		opp := strategy.ArbitrageOpportunity{
+			ID: "opp", BuyExchange: "okx", SellExchange: "binance", Symbol: "DOGEUSDT",
+			BuyPrice: 0.1000, SellPrice: 0.1010, EffBuyPrice: 0.1001, EffSellPrice: 0.1009, Timestamp: time.Now(),
+		}
+		if _, err := api.pnlManager.ExecuteArbitrage(opp); err != nil {
+			t.Fatalf("executing arbitrage %d: %v", i, err)
+		}
+	}
+	return api
+}
+
+func TestTradesEndpoint(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		wantCode   int
+		wantTrades int
+		wantTotal  int
+	}{
+		{name: "v1 first page", path: "/api/v1/trades?limit=4", wantCode: http.StatusOK, wantTrades: 4, wantTotal: 6},
+		{name: "filtered by side", path: "/api/v1/trades?side=buy", wantCode: http.StatusOK, wantTrades: 3, wantTotal: 3},
+		{name: "time window in unix seconds", path: "/api/v1/trades?to=1", wantCode: http.StatusOK, wantTrades: 0, wantTotal: 0},
+		{name: "invalid time", path: "/api/v1/trades?from=yesterday", wantCode: http.StatusBadRequest},
+		{name: "invalid limit", path: "/api/v1/trades?limit=-1", wantCode: http.StatusBadRequest},
+		{name: "unknown sort", path: "/api/v1/trades?sort=fee", wantCode: http.StatusBadRequest},
+		{name: "invalid cursor", path: "/api/v1/trades?cursor=abc", wantCode: http.StatusBadRequest},
+	}
+
+	api := newTradingAPI(t, 3)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(api, newRequest(http.MethodGet, tt.path, readToken, ""))
+			if w.Code != tt.wantCode {
+				t.Fatalf("status code = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var body struct {
+				Data strategy.TradePage `json:"data"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("decoding body: %v", err)
+			}
+			if len(body.Data.Trades) != tt.wantTrades || body.Data.Total != tt.wantTotal {
+				t.Errorf("got %d of %d trades, want %d of %d", len(body.Data.Trades), body.Data.Total, tt.wantTrades, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestTradesCSV(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		accept     string
+		wantRows   int
+		wantCursor bool
+	}{
+		{name: "format parameter", path: "/api/v1/trades?format=csv", wantRows: 6},
+		{name: "accept header", path: "/api/v1/trades?limit=2", accept: "text/csv", wantRows: 2, wantCursor: true},
+	}
+
+	api := newTradingAPI(t, 3)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRequest(http.MethodGet, tt.path, readToken, "")
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := serve(api, r)
+			if ct := w.Header().Get("Content-Type"); ct != "text/csv" {
+				t.Fatalf("content type = %q, want text/csv", ct)
+			}
+			records, err := csv.NewReader(w.Body).ReadAll()
+			if err != nil {
+				t.Fatalf("reading CSV: %v", err)
+			}
+			if len(records) != tt.wantRows+1 || records[0][0] != "id" {
+				t.Errorf("got %d records with header %v, want %d rows and a header", len(records), records[0], tt.wantRows)
+			}
+			if (w.Header().Get("X-Next-Cursor") != "") != tt.wantCursor || w.Header().Get("X-Total-Count") != "6" {
+				t.Errorf("cursor %q, total %q", w.Header().Get("X-Next-Cursor"), w.Header().Get("X-Total-Count"))
+			}
+		})
+	}
+}
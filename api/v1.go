@@ -0,0 +1,469 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hft-arbitrage-bot/risk"
+	"hft-arbitrage-bot/strategy"
+)
+
+// maxOpportunities caps GET /api/v1/opportunities; the strategy keeps about
+// as many closed lifecycles
+const maxOpportunities = 1000
+
+// route is one /api/v1 endpoint. The same table registers the handlers and
+// generates the OpenAPI document, so the two cannot drift apart.
+type route struct {
+	method  string
+	path    string // relative to v1Prefix, in ServeMux pattern syntax
+	scope   Scope  // required scope; empty for public endpoints
+	summary string
+	query   []queryParam
+	body    interface{} // zero value of the request body, nil when there is none
+	data    interface{} // zero value of the response data
+
+	// handle returns the response data, which is written in the Response
+	// envelope. Endpoints that write their own response set raw instead.
+	handle func(r *http.Request) (interface{}, error)
+	raw    http.HandlerFunc
+	stream bool // accept ?access_token= as well as headers
+
+	// csv, when set, writes the data as CSV for ?format=csv or Accept: text/csv
+	csv func(w http.ResponseWriter, data interface{}) error
+}
+
+// queryParam documents a query string parameter
+type queryParam struct {
+	name        string
+	kind        string // OpenAPI type: "integer" or "string"
+	description string
+}
+
+// SummaryResponse is the data of GET /api/v1/summary
+type SummaryResponse struct {
+	Summary         string  `json:"summary"`
+	CurrentBalance  float64 `json:"current_balance"`
+	TotalPnL        float64 `json:"total_pnl"`
+	TotalPnLPercent float64 `json:"total_pnl_percent"`
+	TotalTrades     int     `json:"total_trades"`
+	WinRate         float64 `json:"win_rate"`
+}
+
+// BookResponse is the data of GET /api/v1/book/{venue}/{symbol}
+type BookResponse struct {
+	Venue   string                `json:"venue"`
+	Symbol  string                `json:"symbol"`
+	Bids    []strategy.PriceLevel `json:"bids"`
+	Asks    []strategy.PriceLevel `json:"asks"`
+	Updated time.Time             `json:"updated"`
+	AgeMs   float64               `json:"age_ms"`
+}
+
+// StrategyResponse is one strategy in GET /api/v1/strategies. Each
+// strategy keeps its own P&L book and risk engine.
+type StrategyResponse struct {
+	Name        string      `json:"name"`
+	Types       []string    `json:"types"` // opportunity types it trades
+	Balance     float64     `json:"balance"`
+	TotalPnL    float64     `json:"total_pnl"`
+	TotalTrades int         `json:"total_trades"`
+	WinRate     float64     `json:"win_rate"`
+	Halted      bool        `json:"halted"`
+	HaltReason  string      `json:"halt_reason,omitempty"`
+	Limits      risk.Limits `json:"limits"`
+}
+
+// ReasonRequest is the optional body of actions that record a reason
+type ReasonRequest struct {
+	Reason string `json:"reason"`
+}
+
+// PauseRequest is the optional body of POST /api/v1/control/pause and
+// /control/resume; without a venue trading is paused or resumed globally
+type PauseRequest struct {
+	Venue  string `json:"venue"`
+	Reason string `json:"reason"`
+}
+
+// v1Routes returns every /api/v1 endpoint
+func (api *PnLAPI) v1Routes() []route {
+	limit := queryParam{"limit", "integer", "maximum number of items to return"}
+
+	return []route{
+		{method: http.MethodGet, path: "/pnl", scope: ScopeRead, summary: "Current P&L",
+			data: strategy.PnLStatus{}, handle: api.v1PnL},
+		{method: http.MethodGet, path: "/summary", scope: ScopeRead, summary: "One line P&L summary with headline figures",
+			data: SummaryResponse{}, handle: api.v1Summary},
+		{method: http.MethodGet, path: "/trades", scope: ScopeRead, summary: "Trade history with filters, sorting and cursor pagination",
+			query: tradeQueryParams, data: strategy.TradePage{}, handle: api.v1Trades, csv: writeTradesCSV},
+		{method: http.MethodGet, path: "/attribution", scope: ScopeRead, summary: "P&L by venue pair, symbol and hour",
+			data: strategy.PnLAttribution{}, handle: api.v1Attribution},
+		{method: http.MethodGet, path: "/attribution/{by}", scope: ScopeRead, summary: "P&L along one dimension: pair, symbol or hour",
+			data: []strategy.AttributionBucket{}, handle: api.v1AttributionBy},
+		{method: http.MethodGet, path: "/basis", scope: ScopeRead, summary: "Open spot-perpetual basis positions with funding and carry",
+			data: []strategy.BasisPosition{}, handle: api.v1Basis},
+		{method: http.MethodGet, path: "/opportunities", scope: ScopeRead, summary: "Opportunity lifecycles: open ones, then closed ones newest first",
+			query: []queryParam{limit, {"status", "string", "open or closed; both when omitted"}},
+			data:  []strategy.OpportunityLifecycle{}, handle: api.v1Opportunities},
+		{method: http.MethodGet, path: "/opportunities/stats", scope: ScopeRead, summary: "Opportunity frequency, duration and outcomes per type and venue pair",
+			data: []strategy.OpportunityStats{}, handle: api.v1OpportunityStats},
+		{method: http.MethodGet, path: "/orders", scope: ScopeRead, summary: "Resting maker orders and fills waiting for their hedge",
+			data: []strategy.RestingOrder{}, handle: api.v1Orders},
+		{method: http.MethodGet, path: "/strategies", scope: ScopeRead, summary: "Every strategy sharing the feeds with its P&L and kill switch state",
+			data: []StrategyResponse{}, handle: api.v1Strategies},
+
+		{method: http.MethodGet, path: "/health", summary: "Overall health without failing the request",
+			data: HealthSummary{}, handle: api.v1Health},
+		{method: http.MethodGet, path: "/health/live", summary: "Liveness probe; 503 when the strategy loop has stopped",
+			data: HealthReport{}, raw: api.handleLive},
+		{method: http.MethodGet, path: "/health/ready", summary: "Readiness probe; 503 when the bot cannot trade",
+			data: HealthReport{}, raw: api.handleReady},
+
+		{method: http.MethodGet, path: "/risk", scope: ScopeRead, summary: "Risk engine status",
+			data: risk.Status{}, handle: api.v1Risk},
+		{method: http.MethodPost, path: "/risk/kill", scope: ScopeAdmin, summary: "Engage the kill switch",
+			body: ReasonRequest{}, data: risk.Status{}, handle: api.v1Kill},
+		{method: http.MethodPost, path: "/risk/resume", scope: ScopeAdmin, summary: "Release the kill switch",
+			data: risk.Status{}, handle: api.v1Resume},
+		{method: http.MethodGet, path: "/breakers", scope: ScopeRead, summary: "Circuit breaker state of every venue",
+			data: []risk.BreakerStatus{}, handle: api.v1Breakers},
+		{method: http.MethodPost, path: "/breakers/{venue}/reset", scope: ScopeAdmin, summary: "Clear a venue's circuit breaker in every strategy",
+			data: []risk.BreakerStatus{}, handle: api.v1BreakerReset},
+
+		{method: http.MethodGet, path: "/quotes", scope: ScopeRead, summary: "Latest quote of every venue",
+			data: []strategy.VenueQuote{}, handle: api.v1Quotes},
+		{method: http.MethodGet, path: "/book/{venue}/{symbol}", scope: ScopeRead, summary: "Top levels of a venue's order book",
+			query: []queryParam{{"depth", "integer", fmt.Sprintf("levels per side (default %d, max %d)", defaultBookDepth, maxBookDepth)}},
+			data:  BookResponse{}, handle: api.v1Book},
+		{method: http.MethodGet, path: "/spreads", scope: ScopeRead, summary: "Gross and net edge of every directed venue pair",
+			data: []strategy.PairSpread{}, handle: api.v1Spreads},
+		{method: http.MethodGet, path: "/signals", scope: ScopeRead, summary: "Imbalance, microprice, mid drift and trade flow of every book",
+			data: []strategy.MicrostructureSignals{}, handle: api.v1Signals},
+
+		{method: http.MethodGet, path: "/control", scope: ScopeRead, summary: "Runtime parameters in effect",
+			data: strategy.Params{}, handle: api.v1Control},
+		{method: http.MethodGet, path: "/control/audit", scope: ScopeAdmin, summary: "Recent control actions, newest first",
+			query: []queryParam{limit}, data: []AuditEntry{}, handle: api.v1Audit},
+		{method: http.MethodPost, path: "/control/pause", scope: ScopeAdmin, summary: "Stop execution globally or for one venue",
+			body: PauseRequest{}, data: strategy.Params{}, handle: api.v1Pause(true)},
+		{method: http.MethodPost, path: "/control/resume", scope: ScopeAdmin, summary: "Resume execution globally or for one venue",
+			body: PauseRequest{}, data: strategy.Params{}, handle: api.v1Pause(false)},
+		{method: http.MethodPost, path: "/control/params", scope: ScopeAdmin, summary: "Change spread threshold, trade size and fee overrides atomically",
+			body: ParamsRequest{}, data: strategy.Params{}, handle: api.v1Params},
+		{method: http.MethodPost, path: "/control/venues/{venue}/{action}", scope: ScopeAdmin, summary: "Enable or disable a venue",
+			body: ReasonRequest{}, data: strategy.Params{}, handle: api.v1Venue},
+
+		{method: http.MethodGet, path: "/stream", scope: ScopeRead, summary: "WebSocket or Server-Sent Events stream of strategy events",
+			query: []queryParam{{"topics", "string", "comma separated topics: " + strings.Join(strategy.Topics, ", ")}},
+			data:  strategy.Event{}, raw: api.handleStream, stream: true},
+		{method: http.MethodGet, path: "/openapi.json", summary: "This OpenAPI document",
+			raw: api.handleOpenAPI},
+	}
+}
+
+// registerV1 registers the /api/v1 routes. Routes are grouped by path so
+// that a wrong method gets a 405 error envelope listing the allowed ones.
+func (api *PnLAPI) registerV1(mux *http.ServeMux) {
+	routes := api.v1Routes()
+
+	var paths []string
+	byPath := make(map[string]map[string]http.HandlerFunc)
+	for _, rt := range routes {
+		if byPath[rt.path] == nil {
+			byPath[rt.path] = make(map[string]http.HandlerFunc)
+			paths = append(paths, rt.path)
+		}
+		byPath[rt.path][rt.method] = api.v1Handler(rt)
+	}
+
+	for _, path := range paths {
+		mux.HandleFunc(v1Prefix+path, methodDispatch(byPath[path]))
+	}
+	mux.HandleFunc(v1Prefix+"/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, http.StatusNotFound, "no such endpoint: "+r.URL.Path)
+	})
+}
+
+// v1Handler wraps a route with its scope check and the response envelope
+func (api *PnLAPI) v1Handler(rt route) http.HandlerFunc {
+	handler := rt.raw
+	if handler == nil {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			data, err := rt.handle(r)
+			if err != nil {
+				writeAPIError(w, r, err)
+				return
+			}
+			if rt.csv != nil && wantsCSV(r) {
+				if err := rt.csv(w, data); err != nil {
+					logger.Error("writing CSV failed", "path", r.URL.Path, "err", err)
+				}
+				return
+			}
+			writeData(w, data)
+		}
+	}
+
+	switch {
+	case rt.scope == "":
+		return handler
+	case rt.stream:
+		return api.requireStream(rt.scope, handler)
+	default:
+		return api.require(rt.scope, handler)
+	}
+}
+
+// methodDispatch picks the handler for the request method; GET handlers
+// also serve HEAD
+func methodDispatch(handlers map[string]http.HandlerFunc) http.HandlerFunc {
+	var allowed []string
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete} {
+		if handlers[method] != nil {
+			allowed = append(allowed, method)
+		}
+	}
+	allow := strings.Join(allowed, ", ")
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		method := r.Method
+		if method == http.MethodHead {
+			method = http.MethodGet
+		}
+		if handler := handlers[method]; handler != nil {
+			handler(w, r)
+			return
+		}
+		w.Header().Set("Allow", allow)
+		writeError(w, r, http.StatusMethodNotAllowed, fmt.Sprintf("%s not allowed on %s (allowed: %s)", r.Method, r.URL.Path, allow))
+	}
+}
+
+// queryInt parses an optional positive integer query parameter, capped at max
+func queryInt(r *http.Request, name string, def, max int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, newAPIError(http.StatusBadRequest, fmt.Sprintf("%s must be a positive integer, got %q", name, value))
+	}
+	return min(n, max), nil
+}
+
+func (api *PnLAPI) v1PnL(r *http.Request) (interface{}, error) {
+	return api.pnlManager.GetCurrentPnL(), nil
+}
+
+func (api *PnLAPI) v1Summary(r *http.Request) (interface{}, error) {
+	status := api.pnlManager.GetCurrentPnL()
+	return SummaryResponse{
+		Summary:         api.pnlManager.GetPnLSummary(),
+		CurrentBalance:  status.CurrentBalance,
+		TotalPnL:        status.TotalPnL,
+		TotalPnLPercent: status.TotalPnLPercent,
+		TotalTrades:     status.TotalTrades,
+		WinRate:         status.WinRate,
+	}, nil
+}
+
+func (api *PnLAPI) v1Trades(r *http.Request) (interface{}, error) {
+	return api.queryTrades(r, defaultTradePageSize)
+}
+
+func (api *PnLAPI) v1Attribution(r *http.Request) (interface{}, error) {
+	return api.pnlManager.GetAttribution(), nil
+}
+
+func (api *PnLAPI) v1Basis(r *http.Request) (interface{}, error) {
+	return api.pnlManager.GetBasisPositions(), nil
+}
+
+func (api *PnLAPI) v1Opportunities(r *http.Request) (interface{}, error) {
+	limit, err := queryInt(r, "limit", 100, maxOpportunities)
+	if err != nil {
+		return nil, err
+	}
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", strategy.LifecycleOpen, strategy.LifecycleClosed:
+	default:
+		return nil, newAPIError(http.StatusBadRequest, fmt.Sprintf("invalid status %q (want %s or %s)", status, strategy.LifecycleOpen, strategy.LifecycleClosed))
+	}
+	return api.strategy.Opportunities(status, limit), nil
+}
+
+func (api *PnLAPI) v1OpportunityStats(r *http.Request) (interface{}, error) {
+	return api.strategy.OpportunityStats(), nil
+}
+
+func (api *PnLAPI) v1Orders(r *http.Request) (interface{}, error) {
+	return api.strategy.RestingOrders(), nil
+}
+
+func (api *PnLAPI) v1Strategies(r *http.Request) (interface{}, error) {
+	strategies := make([]StrategyResponse, 0, len(api.strategies))
+	for _, s := range api.strategies {
+		status := s.GetPnLManager().GetCurrentPnL()
+		halted, reason := s.GetRiskEngine().Halted()
+		strategies = append(strategies, StrategyResponse{
+			Name:        s.Name(),
+			Types:       s.Types(),
+			Balance:     status.CurrentBalance,
+			TotalPnL:    status.TotalPnL,
+			TotalTrades: status.TotalTrades,
+			WinRate:     status.WinRate,
+			Halted:      halted,
+			HaltReason:  reason,
+			Limits:      s.GetRiskEngine().Status().Limits,
+		})
+	}
+	return strategies, nil
+}
+
+func (api *PnLAPI) v1AttributionBy(r *http.Request) (interface{}, error) {
+	buckets, err := api.pnlManager.GetAttributionBy(r.PathValue("by"))
+	if err != nil {
+		return nil, newAPIError(http.StatusNotFound, err.Error())
+	}
+	return buckets, nil
+}
+
+func (api *PnLAPI) v1Health(r *http.Request) (interface{}, error) {
+	return api.healthSummary(time.Now()), nil
+}
+
+func (api *PnLAPI) v1Risk(r *http.Request) (interface{}, error) {
+	return api.riskEngine.Status(), nil
+}
+
+func (api *PnLAPI) v1Kill(r *http.Request) (interface{}, error) {
+	var req ReasonRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if req.Reason == "" {
+		req.Reason = "manual halt via API"
+	}
+	logger.Warn("kill switch engaged via API", "actor", clientID(r), "reason", req.Reason)
+	api.halt(req.Reason)
+	return api.riskEngine.Status(), nil
+}
+
+func (api *PnLAPI) v1Resume(r *http.Request) (interface{}, error) {
+	logger.Warn("kill switch released via API", "actor", clientID(r))
+	api.resume()
+	return api.riskEngine.Status(), nil
+}
+
+func (api *PnLAPI) v1Breakers(r *http.Request) (interface{}, error) {
+	return api.breakers.Status(), nil
+}
+
+func (api *PnLAPI) v1BreakerReset(r *http.Request) (interface{}, error) {
+	api.resetBreakers(strings.ToLower(r.PathValue("venue")))
+	return api.breakers.Status(), nil
+}
+
+func (api *PnLAPI) v1Quotes(r *http.Request) (interface{}, error) {
+	return api.strategy.GetQuotes(), nil
+}
+
+func (api *PnLAPI) v1Book(r *http.Request) (interface{}, error) {
+	depth, err := queryInt(r, "depth", defaultBookDepth, maxBookDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	venue, symbol := r.PathValue("venue"), r.PathValue("symbol")
+	book, updated, err := api.strategy.GetOrderBook(venue, symbol, depth)
+	if errors.Is(err, strategy.ErrNoQuote) {
+		return nil, newAPIError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return BookResponse{
+		Venue:   venue,
+		Symbol:  symbol,
+		Bids:    book.Bids,
+		Asks:    book.Asks,
+		Updated: updated,
+		AgeMs:   float64(time.Since(updated)) / float64(time.Millisecond),
+	}, nil
+}
+
+func (api *PnLAPI) v1Spreads(r *http.Request) (interface{}, error) {
+	return api.strategy.GetSpreads(), nil
+}
+
+func (api *PnLAPI) v1Signals(r *http.Request) (interface{}, error) {
+	return api.strategy.Signals(), nil
+}
+
+func (api *PnLAPI) v1Control(r *http.Request) (interface{}, error) {
+	return api.strategy.Params(), nil
+}
+
+func (api *PnLAPI) v1Audit(r *http.Request) (interface{}, error) {
+	limit, err := queryInt(r, "limit", 50, maxAuditEntries)
+	if err != nil {
+		return nil, err
+	}
+	return api.audit.recent(limit), nil
+}
+
+func (api *PnLAPI) v1Pause(paused bool) func(r *http.Request) (interface{}, error) {
+	action := "resume"
+	if paused {
+		action = "pause"
+	}
+	return func(r *http.Request) (interface{}, error) {
+		var req PauseRequest
+		if err := decodeBody(r, &req); err != nil {
+			return nil, err
+		}
+		venue := strings.ToLower(req.Venue)
+		params, err := api.updateParams(r, action, venue, req.Reason, pauseChange(venue, paused))
+		if err != nil {
+			return nil, err
+		}
+		return params, nil
+	}
+}
+
+func (api *PnLAPI) v1Params(r *http.Request) (interface{}, error) {
+	var req ParamsRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	params, err := api.updateParams(r, "params", "", req.Reason, paramsChange(req))
+	if err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+func (api *PnLAPI) v1Venue(r *http.Request) (interface{}, error) {
+	var req ReasonRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	venue, action := strings.ToLower(r.PathValue("venue")), r.PathValue("action")
+	change, err := venueChange(venue, action)
+	if err != nil {
+		return nil, err
+	}
+	params, err := api.updateParams(r, action, venue, req.Reason, change)
+	if err != nil {
+		return nil, err
+	}
+	return params, nil
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"hft-arbitrage-bot/strategy"
+)
+
+func TestV1Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		token     string
+		wantCode  int
+		wantType  string
+		wantAllow string
+	}{
+		{name: "unknown endpoint", method: http.MethodGet, path: "/api/v1/orderbook", token: readToken, wantCode: http.StatusNotFound, wantType: "not_found"},
+		{name: "wrong method", method: http.MethodPost, path: "/api/v1/pnl", token: adminToken, wantCode: http.StatusMethodNotAllowed, wantType: "method_not_allowed", wantAllow: "GET"},
+		{name: "invalid query", method: http.MethodGet, path: "/api/v1/opportunities?limit=zero", token: readToken, wantCode: http.StatusBadRequest, wantType: "bad_request"},
+		{name: "missing credentials", method: http.MethodGet, path: "/api/v1/pnl", wantCode: http.StatusUnauthorized, wantType: "unauthenticated"},
+		{name: "missing scope", method: http.MethodPost, path: "/api/v1/risk/kill", token: readToken, wantCode: http.StatusForbidden, wantType: "forbidden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(testConfig())
+			w := serve(api, newRequest(tt.method, tt.path, tt.token, ""))
+			var body ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("decoding body: %v", err)
+			}
+			if w.Code != tt.wantCode || body.Status != "error" || body.Error.Code != tt.wantCode || body.Error.Type != tt.wantType {
+				t.Errorf("got %d %+v, want %d %s", w.Code, body, tt.wantCode, tt.wantType)
+			}
+			if allow := w.Header().Get("Allow"); allow != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", allow, tt.wantAllow)
+			}
+		})
+	}
+}
+
+func TestV1Envelope(t *testing.T) {
+	api := newTestAPI(testConfig())
+	api.SetStrategies(strategy.NewArbitrageStrategy(0, 500, 50))
+
+	tests := []struct {
+		name      string
+		path      string
+		wantCount int // -1 when the response has no count
+	}{
+		{name: "list carries a count", path: "/api/v1/strategies", wantCount: 2},
+		{name: "object has no count", path: "/api/v1/pnl", wantCount: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(api, newRequest(http.MethodGet, tt.path, readToken, ""))
+			var body Response
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("decoding body: %v", err)
+			}
+			if w.Code != http.StatusOK || body.Status != "success" || body.Data == nil {
+				t.Fatalf("got %d %+v", w.Code, body)
+			}
+			count := -1
+			if body.Count != nil {
+				count = *body.Count
+			}
+			if count != tt.wantCount {
+				t.Errorf("count = %d, want %d", count, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestBreakerResetAppliesToEveryStrategy(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "v1", path: "/api/v1/breakers/OKX/reset"},
+		{name: "legacy", path: "/breakers/reset?venue=okx"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(testConfig())
+			api.SetStrategies(strategy.NewArbitrageStrategy(0, 1000, 100))
+
+			// A crossed book trips the okx breaker in both strategies
+			crossed := strategy.Quote{Exchange: "okx", Symbol: "DOGEUSDT", Bid: 0.1002, Ask: 0.1001, Timestamp: time.Now()}
+			for _, s := range api.strategies {
+				s.UpdateQuote(crossed)
+				if paused, _ := s.GetCircuitBreakers().Paused("okx", time.Now()); !paused {
+					t.Fatalf("strategy %s: crossed book did not trip the breaker", s.Name())
+				}
+			}
+
+			if w := serve(api, newRequest(http.MethodPost, tt.path, adminToken, "")); w.Code != http.StatusOK {
+				t.Fatalf("status code = %d: %s", w.Code, w.Body)
+			}
+			for _, s := range api.strategies {
+				if paused, reason := s.GetCircuitBreakers().Paused("okx", time.Now()); paused {
+					t.Errorf("strategy %s still paused: %s", s.Name(), reason)
+				}
+			}
+		})
+	}
+}
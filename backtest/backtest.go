@@ -0,0 +1,245 @@
+// Package backtest replays recorded quotes through an unchanged
+// ArbitrageStrategy and PnLManager on a simulated clock, with fills from a
+// simulated execution model, and reports the resulting P&L.
+package backtest
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"hft-arbitrage-bot/clock"
+	"hft-arbitrage-bot/execution"
+	"hft-arbitrage-bot/risk"
+	"hft-arbitrage-bot/strategy"
+)
+
+// Config is the strategy and execution setup of a backtest
+type Config struct {
+	MinSpreadPercent float64
+	InitialBalance   float64
+	TradeSize        float64
+	FeeOverrides     map[string]float64 // venue -> taker fee rate
+	Limits           risk.Limits
+	Interval         time.Duration       // strategy evaluation interval
+	Execution        execution.Config    // the paper backend simulates fills against the data; instant fills at detected prices
+	Triangles        []strategy.Triangle // single venue cycles, evaluated where the data has all three books
+	Graph            strategy.GraphConfig
+	Basis            strategy.BasisConfig  // spot against perpetuals, where the data has both books
+	Maker            strategy.MakerConfig  // resting orders hedged on fill; maker fees come from Execution
+	EV               strategy.EVConfig     // opportunity selection; latencies and queue position come from Execution
+	Signals          strategy.SignalConfig // microstructure filters; trade flow needs trade messages in the data
+	Cooldown         strategy.CooldownConfig
+
+	// OnEvent, when set, sees every opportunity and trade event with the
+	// simulated time it happened at
+	OnEvent func(now time.Time, event strategy.Event)
+}
+
+// DefaultConfig matches the live bot's settings
+func DefaultConfig() Config {
+	return Config{
+		MinSpreadPercent: strategy.DefaultMinSpreadPercent,
+		InitialBalance:   1000,
+		TradeSize:        100,
+		Limits:           risk.DefaultLimits(),
+		Interval:         strategy.EvaluationInterval,
+		Execution:        execution.DefaultConfig(),
+		Triangles:        append([]strategy.Triangle(nil), strategy.DefaultTriangles...),
+		Graph:            strategy.DefaultGraphConfig(),
+		Basis:            strategy.DefaultBasisConfig(),
+		Maker:            strategy.DefaultMakerConfig(),
+		EV:               strategy.DefaultEVConfig(),
+		Signals:          strategy.DefaultSignalConfig(),
+		Cooldown:         strategy.DefaultCooldownConfig(),
+	}
+}
+
+// Report is the outcome of a backtest
+type Report struct {
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Quotes      int       `json:"quotes"`
+	Evaluations int       `json:"evaluations"`
+
+	Detected int            `json:"detected"`
+	Executed int            `json:"executed"`
+	Rejected map[string]int `json:"rejected"` // reason -> opportunities
+
+	Opportunities []strategy.OpportunityStats `json:"opportunities"` // lifecycles closed by the end, per type and venue pair
+
+	PnL         strategy.PnLStatus      `json:"pnl"`
+	Attribution strategy.PnLAttribution `json:"attribution"`
+	Execution   execution.Stats         `json:"execution"`
+
+	WinRate        float64 `json:"win_rate"`         // percent of round trips with positive P&L
+	GrossProfit    float64 `json:"gross_profit"`     // sum of winning round trips
+	GrossLoss      float64 `json:"gross_loss"`       // sum of losing round trips, negative
+	ProfitFactor   float64 `json:"profit_factor"`    // gross profit over gross loss, 0 without losses
+	MaxDrawdown    float64 `json:"max_drawdown"`     // largest peak-to-trough fall of cumulative P&L
+	SharpePerTrade float64 `json:"sharpe_per_trade"` // mean over standard deviation of round trip P&L
+
+	Halted     bool   `json:"halted"`
+	HaltReason string `json:"halt_reason,omitempty"`
+}
+
+// Run replays quotes, which must be in time order, through a fresh strategy.
+// The strategy is evaluated every cfg.Interval of simulated time, exactly as
+// the live loop would, and quotes are applied at their receive timestamps.
+func Run(quotes []strategy.Quote, cfg Config) (*Report, error) {
+	if len(quotes) == 0 {
+		return nil, errors.New("no quotes to replay")
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = strategy.EvaluationInterval
+	}
+
+	if err := cfg.Execution.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Execution.Backend == execution.BackendLive {
+		return nil, execution.ErrLiveUnavailable
+	}
+
+	start := quotes[0].Timestamp
+	sim := clock.NewSim(start)
+	paper := execution.NewPaper(NewMarket(quotes), cfg.Execution)
+
+	as := strategy.NewArbitrageStrategy(cfg.MinSpreadPercent, cfg.InitialBalance, cfg.TradeSize)
+	as.SetClock(sim)
+	as.SetTriangles(cfg.Triangles...)
+	if err := cfg.Graph.Validate(); err != nil {
+		return nil, err
+	}
+	as.SetGraph(cfg.Graph)
+	if err := cfg.Basis.Validate(); err != nil {
+		return nil, err
+	}
+	as.SetBasis(cfg.Basis)
+	if cfg.Execution.MakerFees != nil {
+		cfg.Maker.Fees = cfg.Execution.MakerFees
+	}
+	if err := cfg.Maker.Validate(); err != nil {
+		return nil, err
+	}
+	as.SetMaker(cfg.Maker)
+	cfg.EV.Latency, cfg.EV.VenueLatency, cfg.EV.QueueAhead = cfg.Execution.Latency, cfg.Execution.VenueLatency, cfg.Execution.QueueAhead
+	if err := cfg.EV.Validate(); err != nil {
+		return nil, err
+	}
+	as.SetEV(cfg.EV)
+	if err := cfg.Signals.Validate(); err != nil {
+		return nil, err
+	}
+	as.SetSignals(cfg.Signals)
+	if err := cfg.Cooldown.Validate(); err != nil {
+		return nil, err
+	}
+	as.SetCooldown(cfg.Cooldown)
+	if cfg.Execution.Backend == execution.BackendPaper {
+		as.GetPnLManager().SetExecutionModel(paper)
+	}
+	as.GetRiskEngine().SetLimits(cfg.Limits)
+	_, _, err := as.UpdateParams(func(p *strategy.Params) error {
+		p.MinSpreadPercent = cfg.MinSpreadPercent
+		for venue, fee := range cfg.FeeOverrides {
+			p.FeeOverrides[venue] = fee
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Outcomes are collected from the event bus, which the strategy
+	// publishes to synchronously; the buffer is drained after every step
+	sub := as.GetEventBus().Subscribe(4096, strategy.TopicOpportunities, strategy.TopicTrades)
+	defer sub.Close()
+
+	report := &Report{Start: start, Rejected: make(map[string]int)}
+	var roundTrips []float64
+	drain := func() {
+		for {
+			select {
+			case event := <-sub.Events():
+				if cfg.OnEvent != nil {
+					cfg.OnEvent(sim.Now(), event)
+				}
+				switch data := event.Data.(type) {
+				case strategy.ArbitrageOpportunity:
+					report.Detected++
+				case strategy.RejectedOpportunity:
+					report.Rejected[data.Reason]++
+				case strategy.RoundTrip:
+					report.Executed++
+					roundTrips = append(roundTrips, data.PnL)
+				}
+			default:
+				return
+			}
+		}
+	}
+
+	next := start.Add(cfg.Interval)
+	evaluate := func() {
+		sim.Set(next)
+		as.Evaluate()
+		report.Evaluations++
+		drain()
+		next = next.Add(cfg.Interval)
+	}
+
+	for _, q := range quotes {
+		for !next.After(q.Timestamp) {
+			evaluate()
+		}
+		sim.Set(q.Timestamp)
+		as.UpdateQuote(q)
+		report.Quotes++
+	}
+	evaluate()
+
+	report.End = sim.Now()
+	report.PnL = as.GetPnLManager().GetCurrentPnL()
+	report.Attribution = as.GetPnLManager().GetAttribution()
+	report.Opportunities = as.OpportunityStats()
+	report.Execution = paper.Stats()
+	report.Halted, report.HaltReason = as.GetRiskEngine().Halted()
+	report.addTradeStats(roundTrips)
+	return report, nil
+}
+
+// addTradeStats derives the performance figures from round trip P&L in
+// execution order
+func (r *Report) addTradeStats(pnls []float64) {
+	cumulative, peak, wins := 0.0, 0.0, 0
+	for _, pnl := range pnls {
+		if pnl > 0 {
+			r.GrossProfit += pnl
+			wins++
+		} else {
+			r.GrossLoss += pnl
+		}
+		cumulative += pnl
+		peak = math.Max(peak, cumulative)
+		r.MaxDrawdown = math.Max(r.MaxDrawdown, peak-cumulative)
+	}
+	if len(pnls) > 0 {
+		r.WinRate = float64(wins) / float64(len(pnls)) * 100
+	}
+	if r.GrossLoss < 0 {
+		r.ProfitFactor = r.GrossProfit / -r.GrossLoss
+	}
+
+	if len(pnls) < 2 {
+		return
+	}
+	mean := cumulative / float64(len(pnls))
+	variance := 0.0
+	for _, pnl := range pnls {
+		variance += (pnl - mean) * (pnl - mean)
+	}
+	if std := math.Sqrt(variance / float64(len(pnls)-1)); std > 0 {
+		r.SharpePerTrade = mean / std
+	}
+}
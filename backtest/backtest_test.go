@@ -0,0 +1,156 @@
+package backtest_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"hft-arbitrage-bot/backtest"
+	"hft-arbitrage-bot/execution"
+	"hft-arbitrage-bot/strategy"
+)
+
+// spreadQuotes returns two seconds of binance and okx DOGE quotes 50ms
+// apart, with okx bid spread above the binance ask and a little jitter so
+// the breakers see a normal market
+func spreadQuotes(start time.Time, spread float64) []strategy.Quote {
+	var quotes []strategy.Quote
+	for i := 0; i < 40; i++ {
+		at := start.Add(time.Duration(i) * 50 * time.Millisecond)
+		jitter := float64(i%3) * 0.00001
+		okxBid := 0.1001 + spread + jitter
+		quotes = append(quotes,
+			strategy.Quote{Exchange: "binance", Symbol: "DOGEUSDT", Bid: 0.1000 + jitter, Ask: 0.1001 + jitter, BidSize: 5000, AskSize: 5000, Timestamp: at},
+			strategy.Quote{Exchange: "okx", Symbol: "DOGE-USDT", Bid: okxBid, Ask: okxBid + 0.0001, BidSize: 5000, AskSize: 5000, Timestamp: at.Add(time.Millisecond)},
+		)
+	}
+	return quotes
+}
+
+func TestDefaultConfigMatchesLive(t *testing.T) {
+	live := strategy.NewArbitrageStrategy(0, 1000, 100).Params()
+	if got := backtest.DefaultConfig().MinSpreadPercent; got != live.MinSpreadPercent {
+		t.Errorf("DefaultConfig().MinSpreadPercent = %v, live strategy uses %v", got, live.MinSpreadPercent)
+	}
+}
+
+func TestRun(t *testing.T) {
+	start := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		quotes       []strategy.Quote
+		configure    func(cfg *backtest.Config)
+		wantErr      bool
+		wantErrIs    error
+		wantExecuted int
+		wantProfit   bool
+	}{
+		// The jitter refreshes both books, so the spread is entered again
+		// once the pair's one second cooldown is over
+		{name: "paper fills a persistent spread", quotes: spreadQuotes(start, 0.0009), wantExecuted: 2, wantProfit: true},
+		{
+			name:         "instant fills a persistent spread",
+			quotes:       spreadQuotes(start, 0.0009),
+			configure:    func(cfg *backtest.Config) { cfg.Execution.Backend = execution.BackendInstant },
+			wantExecuted: 2,
+			wantProfit:   true,
+		},
+		{name: "no spread, no trades", quotes: spreadQuotes(start, 0)},
+		{
+			name:      "minimum spread above the edge",
+			quotes:    spreadQuotes(start, 0.0009),
+			configure: func(cfg *backtest.Config) { cfg.MinSpreadPercent = 1 },
+		},
+		{
+			name:      "live backend",
+			quotes:    spreadQuotes(start, 0.0009),
+			configure: func(cfg *backtest.Config) { cfg.Execution.Backend = execution.BackendLive },
+			wantErr:   true,
+			wantErrIs: execution.ErrLiveUnavailable,
+		},
+		{name: "no quotes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := backtest.DefaultConfig()
+			if tt.configure != nil {
+				tt.configure(&cfg)
+			}
+			report, err := backtest.Run(tt.quotes, cfg)
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Fatalf("Run() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if report.Quotes != len(tt.quotes) {
+				t.Errorf("Quotes = %d, want %d", report.Quotes, len(tt.quotes))
+			}
+			if report.Executed != tt.wantExecuted {
+				t.Errorf("Executed = %d, want %d", report.Executed, tt.wantExecuted)
+			}
+			if (report.PnL.TotalPnL > 0) != tt.wantProfit {
+				t.Errorf("TotalPnL = %v, want profit %v", report.PnL.TotalPnL, tt.wantProfit)
+			}
+			if report.Halted {
+				t.Errorf("halted: %s", report.HaltReason)
+			}
+		})
+	}
+}
+
+func TestLoadQuotes(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		content    string
+		wantVenues []string // in load order
+		wantErr    bool
+	}{
+		{
+			name: "csv sorted by time",
+			file: "quotes.csv",
+			content: "timestamp,exchange,symbol,bid,ask,bid_size,ask_size\n" +
+				"2026-01-05T12:00:01Z,okx,DOGE-USDT,0.1000,0.1001,10,20\n" +
+				"1767614400000000000,binance,DOGEUSDT,0.1000,0.1001,,\n",
+			wantVenues: []string{"binance", "okx"},
+		},
+		{
+			name: "json lines",
+			file: "quotes.jsonl",
+			content: `{"exchange":"kraken","symbol":"DOGE/USD","bid":0.1,"ask":0.1001,"timestamp":"2026-01-05T12:00:00Z"}` + "\n\n" +
+				`{"exchange":"okx","symbol":"DOGE-USDT","bid":0.1,"ask":0.1001,"timestamp":"2026-01-05T12:00:00Z"}` + "\n",
+			wantVenues: []string{"kraken", "okx"},
+		},
+		{name: "csv missing a column", file: "quotes.csv", content: "timestamp,exchange,symbol,bid,ask\n", wantErr: true},
+		{name: "csv bad price", file: "quotes.csv", content: "timestamp,exchange,symbol,bid,ask,bid_size,ask_size\n1,okx,DOGE-USDT,x,0.1,,\n", wantErr: true},
+		{name: "json without exchange", file: "quotes.jsonl", content: `{"symbol":"DOGEUSDT","bid":0.1,"ask":0.1001,"timestamp":"2026-01-05T12:00:00Z"}` + "\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			quotes, err := backtest.LoadQuotes(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadQuotes() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(quotes) != len(tt.wantVenues) {
+				t.Fatalf("loaded %d quotes, want %d", len(quotes), len(tt.wantVenues))
+			}
+			for i, venue := range tt.wantVenues {
+				if quotes[i].Exchange != venue {
+					t.Errorf("quote %d from %s, want %s", i, quotes[i].Exchange, venue)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package backtest
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"hft-arbitrage-bot/capture"
+	"hft-arbitrage-bot/strategy"
+)
+
+// quoteColumns is the header of a CSV quote file
+var quoteColumns = []string{"timestamp", "exchange", "symbol", "bid", "ask", "bid_size", "ask_size"}
+
+// LoadQuotes reads historical quotes and returns them in time order. A
+// directory is read as a recording of capture files, as is a single .hftcap
+// file. Files ending in .csv hold one quote per row under a header of
+// timestamp,exchange,symbol,bid,ask,bid_size,ask_size with RFC 3339 or unix
+// nanosecond timestamps; any other file holds one JSON strategy.Quote per
+// line, which may include the order book. A .gz suffix is decompressed.
+func LoadQuotes(path string) ([]strategy.Quote, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() || strings.HasSuffix(path, capture.FileExtension) {
+		return loadCapture(path, info.IsDir())
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	name := path
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+		name = strings.TrimSuffix(name, ".gz")
+	}
+
+	var quotes []strategy.Quote
+	if strings.HasSuffix(name, ".csv") {
+		quotes, err = readCSVQuotes(r)
+	} else {
+		quotes, err = readJSONQuotes(r)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// Stable, so quotes with equal timestamps keep their file order
+	sort.SliceStable(quotes, func(i, j int) bool { return quotes[i].Timestamp.Before(quotes[j].Timestamp) })
+	return quotes, nil
+}
+
+func loadCapture(path string, dir bool) ([]strategy.Quote, error) {
+	files := []string{path}
+	if dir {
+		var err error
+		if files, err = capture.Files(path); err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("%s: no %s files", path, capture.FileExtension)
+		}
+	}
+	quotes, err := capture.ReadQuotes(files...)
+	if err != nil {
+		return nil, err
+	}
+	// Feeds record concurrently, so receive times interleave across files
+	sort.SliceStable(quotes, func(i, j int) bool { return quotes[i].Timestamp.Before(quotes[j].Timestamp) })
+	return quotes, nil
+}
+
+func readJSONQuotes(r io.Reader) ([]strategy.Quote, error) {
+	var quotes []strategy.Quote
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var q strategy.Quote
+		if err := json.Unmarshal([]byte(text), &q); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := validQuote(q); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		quotes = append(quotes, q)
+	}
+	return quotes, scanner.Err()
+}
+
+func readCSVQuotes(r io.Reader) ([]strategy.Quote, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	index := make(map[string]int, len(header))
+	for i, column := range header {
+		index[strings.TrimSpace(strings.ToLower(column))] = i
+	}
+	for _, column := range quoteColumns {
+		if _, ok := index[column]; !ok {
+			return nil, fmt.Errorf("missing column %q (want %s)", column, strings.Join(quoteColumns, ","))
+		}
+	}
+
+	var quotes []strategy.Quote
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		q := strategy.Quote{
+			Exchange: record[index["exchange"]],
+			Symbol:   record[index["symbol"]],
+		}
+		if q.Timestamp, err = parseTimestamp(record[index["timestamp"]]); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		for column, field := range map[string]*float64{"bid": &q.Bid, "ask": &q.Ask, "bid_size": &q.BidSize, "ask_size": &q.AskSize} {
+			value := record[index[column]]
+			if value == "" {
+				continue
+			}
+			if *field, err = strconv.ParseFloat(value, 64); err != nil {
+				return nil, fmt.Errorf("line %d: %s: %w", line, column, err)
+			}
+		}
+		if err := validQuote(q); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		quotes = append(quotes, q)
+	}
+	return quotes, nil
+}
+
+// parseTimestamp accepts RFC 3339 or unix nanoseconds
+func parseTimestamp(value string) (time.Time, error) {
+	if nanos, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(0, nanos), nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+func validQuote(q strategy.Quote) error {
+	switch {
+	case q.Exchange == "":
+		return fmt.Errorf("quote without exchange")
+	case q.Timestamp.IsZero():
+		return fmt.Errorf("quote without timestamp")
+	}
+	return nil
+}
+
+// Market answers what the book of a venue looked like at any time of the
+// recorded data
+type Market struct {
+	quotes map[string][]strategy.Quote // per venue and symbol, in time order
+}
+
+// NewMarket indexes quotes, which must be in time order. Trade stream
+// messages carry no book and are left out.
+func NewMarket(quotes []strategy.Quote) *Market {
+	m := &Market{quotes: make(map[string][]strategy.Quote)}
+	for _, q := range quotes {
+		if q.TradesOnly() {
+			continue
+		}
+		key := marketKey(q.Exchange, q.Symbol)
+		m.quotes[key] = append(m.quotes[key], q)
+	}
+	return m
+}
+
+// At returns the latest quote of a venue's symbol received at or before t
+func (m *Market) At(venue, symbol string, t time.Time) (strategy.Quote, bool) {
+	quotes := m.quotes[marketKey(venue, symbol)]
+	i := sort.Search(len(quotes), func(i int) bool { return quotes[i].Timestamp.After(t) })
+	if i == 0 {
+		return strategy.Quote{}, false
+	}
+	return quotes[i-1], true
+}
+
+func marketKey(venue, symbol string) string {
+	return venue + "/" + strategy.NormalizeSymbol(symbol)
+}
@@ -0,0 +1,89 @@
+package backtest
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"hft-arbitrage-bot/strategy"
+)
+
+// Print writes the report in the console style of the live bot
+func (r *Report) Print(w io.Writer) {
+	fmt.Fprintln(w, "=== BACKTEST REPORT ===")
+	fmt.Fprintf(w, "Period: %s -> %s (%s)\n", r.Start.Format(time.RFC3339), r.End.Format(time.RFC3339), r.End.Sub(r.Start).Round(time.Second))
+	fmt.Fprintf(w, "Quotes: %d | Evaluations: %d\n", r.Quotes, r.Evaluations)
+	fmt.Fprintln(w, "")
+
+	fmt.Fprintln(w, "🔎 Opportunities")
+	fmt.Fprintf(w, "   Detected: %d | Executed: %d\n", r.Detected, r.Executed)
+	reasons := make([]string, 0, len(r.Rejected))
+	for reason := range r.Rejected {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		fmt.Fprintf(w, "   Rejected (%s): %d\n", reason, r.Rejected[reason])
+	}
+	fmt.Fprintln(w, "")
+
+	fmt.Fprintln(w, "⚙️  Execution")
+	fmt.Fprintf(w, "   Attempts: %d | Filled: %d (partial %d) | Price moved: %d | Thin liquidity: %d\n",
+		r.Execution.Attempts, r.Execution.Filled, r.Execution.PartialFills, r.Execution.PriceMoved, r.Execution.ThinLiquidity)
+	fmt.Fprintf(w, "   Fill ratio: %.1f%% of requested quantity\n", r.Execution.FillRatio()*100)
+	fmt.Fprintln(w, "")
+
+	mark := "📈"
+	if r.PnL.TotalPnL < 0 {
+		mark = "📉"
+	}
+	fmt.Fprintf(w, "%s P&L\n", mark)
+	fmt.Fprintf(w, "   Balance: $%.2f -> $%.2f\n", r.PnL.InitialBalance, r.PnL.CurrentBalance)
+	fmt.Fprintf(w, "   Total P&L: $%.4f (%.3f%%)\n", r.PnL.TotalPnL, r.PnL.TotalPnLPercent)
+	fmt.Fprintf(w, "   Round trips: %d | Win rate: %.1f%%\n", r.Attribution.RoundTrips, r.WinRate)
+	fmt.Fprintf(w, "   Largest win: $%.4f | Largest loss: $%.4f\n", r.PnL.LargestWin, r.PnL.LargestLoss)
+	profitFactor := "n/a"
+	if r.GrossLoss < 0 {
+		profitFactor = fmt.Sprintf("%.2f", r.ProfitFactor)
+	}
+	fmt.Fprintf(w, "   Gross profit: $%.4f | Gross loss: $%.4f | Profit factor: %s\n", r.GrossProfit, r.GrossLoss, profitFactor)
+	fmt.Fprintf(w, "   Max drawdown: $%.4f | Sharpe per trade: %.3f\n", r.MaxDrawdown, r.SharpePerTrade)
+	if r.PnL.FundingPnL != 0 || r.PnL.BasisPositions > 0 {
+		fmt.Fprintf(w, "   Funding: $%.4f | Open basis positions: %d (carry $%.4f)\n", r.PnL.FundingPnL, r.PnL.BasisPositions, r.PnL.CarryPnL)
+	}
+	if r.Halted {
+		fmt.Fprintf(w, "   🛑 Kill switch engaged: %s\n", r.HaltReason)
+	}
+
+	printBuckets(w, "venue pair", r.Attribution.ByVenuePair)
+	printBuckets(w, "symbol", r.Attribution.BySymbol)
+	printBuckets(w, "hour", r.Attribution.ByHour)
+	printOpportunityStats(w, r.Opportunities)
+	fmt.Fprintln(w, strings.Repeat("=", 23))
+}
+
+func printOpportunityStats(w io.Writer, stats []strategy.OpportunityStats) {
+	if len(stats) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n--- opportunities by type and venue pair ---\n")
+	fmt.Fprintf(w, "%-12s %-22s %6s %8s %7s %10s %10s %10s %8s\n", "TYPE", "PAIR", "COUNT", "EXECUTED", "MISSED", "AVG MS", "MAX MS", "PEAK %", "PER HR")
+	for _, s := range stats {
+		fmt.Fprintf(w, "%-12s %-22s %6d %8d %7d %10.1f %10.1f %10.4f %8.1f\n",
+			s.Type, s.VenuePair, s.Opportunities, s.Executed, s.Missed, s.AverageDurationMs, s.MaxDurationMs, s.AveragePeakEdge, s.PerHour)
+	}
+}
+
+func printBuckets(w io.Writer, title string, buckets []strategy.AttributionBucket) {
+	if len(buckets) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n--- by %s ---\n", title)
+	fmt.Fprintf(w, "%-22s %7s %12s %10s %10s %10s %8s\n", "KEY", "TRADES", "P&L", "FEES", "GROSS %", "NET %", "WIN %")
+	for _, b := range buckets {
+		fmt.Fprintf(w, "%-22s %7d %12.4f %10.4f %10.4f %10.4f %8.1f\n",
+			b.Key, b.Trades, b.PnL, b.Fees, b.AverageGrossEdge, b.AverageNetEdge, b.WinRate)
+	}
+}
@@ -0,0 +1,50 @@
+// Package capture records raw venue messages, with the quotes normalized from
+// them, to compressed capture files and reads them back for backtests and
+// analysis. The file format is described in CAPTURE_FORMAT.md.
+package capture
+
+import (
+	"encoding/json"
+	"time"
+
+	"hft-arbitrage-bot/strategy"
+)
+
+const (
+	// Format identifies capture files in their header
+	Format = "hft-capture"
+	// Version is the format version written; readers accept up to this one
+	Version = 1
+	// FileExtension is the suffix of capture files
+	FileExtension = ".hftcap"
+)
+
+// Header is the first line of every capture file
+type Header struct {
+	Format   string    `json:"format"`
+	Version  int       `json:"version"`
+	Created  time.Time `json:"created"`
+	Sequence int       `json:"sequence"` // file number within one recording, from 1
+}
+
+// Record is one raw venue message
+type Record struct {
+	Received int64           `json:"t"` // receive time, unix nanoseconds
+	Venue    string          `json:"venue"`
+	Raw      json.RawMessage `json:"raw"`             // the message as received; non-JSON messages are stored as a JSON string
+	Quote    *strategy.Quote `json:"quote,omitempty"` // the quote the adapter produced from it, if any
+}
+
+// Time returns the receive time
+func (r Record) Time() time.Time {
+	return time.Unix(0, r.Received)
+}
+
+// rawMessage returns a message as a JSON value
+func rawMessage(message []byte) json.RawMessage {
+	if json.Valid(message) {
+		return message
+	}
+	quoted, _ := json.Marshal(string(message))
+	return quoted
+}
@@ -0,0 +1,185 @@
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"hft-arbitrage-bot/strategy"
+)
+
+// Reader reads the records of one capture file in the order they were written
+type Reader struct {
+	closer    io.Closer
+	gz        *gzip.Reader
+	buf       *bufio.Reader
+	header    Header
+	line      int
+	truncated bool
+}
+
+// Open opens a capture file and checks its header
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	r.closer = f
+	return r, nil
+}
+
+// NewReader reads a capture stream and checks its header
+func NewReader(in io.Reader) (*Reader, error) {
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		return nil, fmt.Errorf("not a capture file: %w", err)
+	}
+	r := &Reader{gz: gz, buf: bufio.NewReaderSize(gz, 64*1024)}
+
+	line, err := r.readLine()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	if err := json.Unmarshal(line, &r.header); err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	if r.header.Format != Format {
+		return nil, fmt.Errorf("not a capture file (format %q)", r.header.Format)
+	}
+	if r.header.Version < 1 || r.header.Version > Version {
+		return nil, fmt.Errorf("unsupported capture version %d (this build reads up to %d)", r.header.Version, Version)
+	}
+	return r, nil
+}
+
+// Header returns the file header
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Next returns the next record, or io.EOF after the last one
+func (r *Reader) Next() (Record, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return Record{}, err
+	}
+	var record Record
+	if err := json.Unmarshal(line, &record); err != nil {
+		return Record{}, fmt.Errorf("line %d: %w", r.line, err)
+	}
+	return record, nil
+}
+
+// Truncated reports whether the file ended mid-stream, as it does when the
+// recorder was killed. Everything up to the last flush is still returned.
+func (r *Reader) Truncated() bool {
+	return r.truncated
+}
+
+// Close closes the file
+func (r *Reader) Close() error {
+	r.gz.Close()
+	if r.closer != nil {
+		return r.closer.Close()
+	}
+	return nil
+}
+
+// readLine returns the next non-empty line. A torn line at the end of a
+// truncated stream is dropped.
+func (r *Reader) readLine() ([]byte, error) {
+	for {
+		line, err := r.buf.ReadBytes('\n')
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			r.truncated = true
+			return nil, io.EOF
+		}
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			return nil, err
+		}
+		r.line++
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			return line, nil
+		}
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+	}
+}
+
+// Files returns the capture files in dir in recording order
+func Files(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), FileExtension) {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Walk calls fn for every record of the files, in order, and stops at the
+// first error
+func Walk(paths []string, fn func(Record) error) error {
+	for _, path := range paths {
+		if err := walkFile(path, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkFile(path string, fn func(Record) error) error {
+	r, err := Open(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for {
+		record, err := r.Next()
+		if err == io.EOF {
+			if r.Truncated() {
+				logger.Warn("capture file is truncated", "file", path)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
+
+// ReadQuotes returns the normalized quotes recorded in the files, in
+// recording order
+func ReadQuotes(paths ...string) ([]strategy.Quote, error) {
+	var quotes []strategy.Quote
+	err := Walk(paths, func(record Record) error {
+		if record.Quote != nil {
+			quotes = append(quotes, *record.Quote)
+		}
+		return nil
+	})
+	return quotes, err
+}
@@ -0,0 +1,84 @@
+package capture
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+)
+
+// gzipped compresses lines; an unclosed stream ends without its trailer, as
+// a file does when the recorder is killed
+func gzipped(t *testing.T, content string, closed bool) *bytes.Buffer {
+	t.Helper()
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	if _, err := gz.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if closed {
+		gz.Close()
+	} else {
+		gz.Flush()
+	}
+	return &b
+}
+
+func TestNewReader(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            io.Reader
+		wantErr       bool
+		wantRecords   []string // venues
+		wantTruncated bool
+	}{
+		{
+			name:        "header and records",
+			in:          gzipped(t, `{"format":"hft-capture","version":1,"sequence":1}`+"\n"+`{"t":1,"venue":"okx","raw":{}}`+"\n\n"+`{"t":2,"venue":"kraken","raw":"pong"}`+"\n", true),
+			wantRecords: []string{"okx", "kraken"},
+		},
+		{
+			name:          "torn last line of a killed recorder",
+			in:            gzipped(t, `{"format":"hft-capture","version":1}`+"\n"+`{"t":1,"venue":"okx","raw":{}}`+"\n"+`{"t":2,"ven`, false),
+			wantRecords:   []string{"okx"},
+			wantTruncated: true,
+		},
+		{name: "not gzip", in: bytes.NewBufferString(`{"format":"hft-capture","version":1}`), wantErr: true},
+		{name: "another format", in: gzipped(t, `{"format":"pcap","version":1}`+"\n", true), wantErr: true},
+		{name: "newer version", in: gzipped(t, `{"format":"hft-capture","version":2}`+"\n", true), wantErr: true},
+		{name: "empty", in: gzipped(t, "", true), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewReader(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewReader() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer r.Close()
+
+			var venues []string
+			for {
+				record, err := r.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Next() error = %v", err)
+				}
+				venues = append(venues, record.Venue)
+			}
+			if !slices.Equal(venues, tt.wantRecords) {
+				t.Errorf("records from %v, want %v", venues, tt.wantRecords)
+			}
+			if r.Truncated() != tt.wantTruncated {
+				t.Errorf("Truncated() = %v, want %v", r.Truncated(), tt.wantTruncated)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package capture
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"hft-arbitrage-bot/logging"
+	"hft-arbitrage-bot/metrics"
+	"hft-arbitrage-bot/strategy"
+)
+
+var logger = logging.Component("capture")
+
+var (
+	recordsWritten = metrics.NewCounterVec("hft_capture_records_total",
+		"Raw venue messages written to capture files.", "venue")
+	recordsDropped = metrics.NewCounter("hft_capture_dropped_total",
+		"Raw venue messages dropped because the capture buffer was full or a write failed.")
+	filesOpened = metrics.NewCounter("hft_capture_files_total",
+		"Capture files opened, including rotations.")
+)
+
+// flushInterval bounds how much data a crash can lose
+const flushInterval = time.Second
+
+// Config configures the recorder
+type Config struct {
+	Dir          string        // recording is enabled when set
+	MaxFileBytes int64         // compressed size after which a new file is started
+	MaxFileAge   time.Duration // age after which a new file is started
+	BufferSize   int           // records queued before new ones are dropped
+}
+
+// DefaultConfig returns a disabled config with 256 MB or hourly files
+func DefaultConfig() Config {
+	return Config{
+		MaxFileBytes: 256 << 20,
+		MaxFileAge:   time.Hour,
+		BufferSize:   65536,
+	}
+}
+
+// ConfigFromEnv builds the config from HFT_CAPTURE_* variables on top of
+// DefaultConfig:
+//
+//	HFT_CAPTURE_DIR     directory to write capture files to; enables recording
+//	HFT_CAPTURE_MAX_MB  compressed file size that triggers rotation
+//	HFT_CAPTURE_ROTATE  file age that triggers rotation, e.g. 15m
+func ConfigFromEnv() (Config, error) {
+	config := DefaultConfig()
+	config.Dir = os.Getenv("HFT_CAPTURE_DIR")
+
+	if size := os.Getenv("HFT_CAPTURE_MAX_MB"); size != "" {
+		mb, err := strconv.ParseInt(size, 10, 64)
+		if err != nil || mb <= 0 {
+			return config, fmt.Errorf("invalid HFT_CAPTURE_MAX_MB %q", size)
+		}
+		config.MaxFileBytes = mb << 20
+	}
+	if age := os.Getenv("HFT_CAPTURE_ROTATE"); age != "" {
+		d, err := time.ParseDuration(age)
+		if err != nil || d <= 0 {
+			return config, fmt.Errorf("invalid HFT_CAPTURE_ROTATE %q", age)
+		}
+		config.MaxFileAge = d
+	}
+	return config, nil
+}
+
+// Enabled reports whether recording is configured
+func (c Config) Enabled() bool {
+	return c.Dir != ""
+}
+
+// Recorder writes raw venue messages to rotating capture files. Records are
+// handed to a background goroutine; when its buffer is full they are dropped
+// rather than blocking the feed.
+type Recorder struct {
+	config  Config
+	records chan Record
+	done    chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+
+	// Owned by the writer goroutine
+	file     *os.File
+	counter  *countingWriter
+	gz       *gzip.Writer
+	encoder  *json.Encoder
+	opened   time.Time
+	sequence int
+}
+
+// NewRecorder creates the capture directory and starts recording
+func NewRecorder(config Config) (*Recorder, error) {
+	if !config.Enabled() {
+		return nil, fmt.Errorf("no capture directory configured")
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = DefaultConfig().BufferSize
+	}
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	r := &Recorder{
+		config:  config,
+		records: make(chan Record, config.BufferSize),
+		done:    make(chan struct{}),
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	go r.run()
+	return r, nil
+}
+
+// Record implements exchange.Recorder. The message is not copied: the feeds
+// read every message into a fresh buffer.
+func (r *Recorder) Record(venue string, received time.Time, message []byte, quote *strategy.Quote) {
+	record := Record{
+		Received: received.UnixNano(),
+		Venue:    venue,
+		Raw:      rawMessage(message),
+		Quote:    quote,
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return
+	}
+	select {
+	case r.records <- record:
+	default:
+		recordsDropped.Inc()
+	}
+}
+
+// Close writes out queued records and closes the current file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.records)
+	r.mu.Unlock()
+
+	<-r.done
+	return r.closeFile()
+}
+
+func (r *Recorder) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case record, ok := <-r.records:
+			if !ok {
+				return
+			}
+			r.write(record)
+
+		case <-ticker.C:
+			if r.gz != nil {
+				if err := r.gz.Flush(); err != nil {
+					r.fail(err)
+				}
+			}
+			r.rotateIfDue()
+		}
+	}
+}
+
+func (r *Recorder) write(record Record) {
+	r.rotateIfDue()
+	if r.encoder == nil {
+		// A failed file is replaced on the next record
+		if err := r.open(); err != nil {
+			recordsDropped.Inc()
+			return
+		}
+	}
+	if err := r.encoder.Encode(record); err != nil {
+		recordsDropped.Inc()
+		r.fail(err)
+		return
+	}
+	recordsWritten.WithLabelValues(record.Venue).Inc()
+}
+
+func (r *Recorder) rotateIfDue() {
+	if r.encoder == nil {
+		return
+	}
+	if r.counter.n < r.config.MaxFileBytes && time.Since(r.opened) < r.config.MaxFileAge {
+		return
+	}
+	if err := r.closeFile(); err != nil {
+		logger.Error("closing capture file failed", "err", err)
+	}
+	if err := r.open(); err != nil {
+		logger.Error("opening capture file failed", "err", err)
+	}
+}
+
+// open starts a new file named after its creation time, so that names sort
+// in recording order
+func (r *Recorder) open() error {
+	now := time.Now().UTC()
+	name := filepath.Join(r.config.Dir, "capture-"+now.Format("20060102T150405.000000000Z")+FileExtension)
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	r.sequence++
+	r.file = file
+	r.counter = &countingWriter{w: file}
+	r.gz = gzip.NewWriter(r.counter)
+	r.encoder = json.NewEncoder(r.gz)
+	r.opened = now
+	filesOpened.Inc()
+
+	header := Header{Format: Format, Version: Version, Created: now, Sequence: r.sequence}
+	if err := r.encoder.Encode(header); err != nil {
+		r.fail(err)
+		return err
+	}
+	logger.Info("capture file opened", "file", name, "sequence", r.sequence)
+	return nil
+}
+
+func (r *Recorder) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.gz.Close()
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	r.file, r.counter, r.gz, r.encoder = nil, nil, nil, nil
+	return err
+}
+
+// fail abandons the current file after a write error
+func (r *Recorder) fail(err error) {
+	logger.Error("capture write failed", "err", err)
+	r.closeFile()
+}
+
+// countingWriter counts the compressed bytes written to a file
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
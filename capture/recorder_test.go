@@ -0,0 +1,124 @@
+package capture
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"hft-arbitrage-bot/strategy"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		wantBytes int64
+		wantAge   time.Duration
+		wantErr   bool
+	}{
+		{name: "defaults", wantBytes: 256 << 20, wantAge: time.Hour},
+		{name: "size and age", env: map[string]string{"HFT_CAPTURE_MAX_MB": "16", "HFT_CAPTURE_ROTATE": "15m"}, wantBytes: 16 << 20, wantAge: 15 * time.Minute},
+		{name: "zero size", env: map[string]string{"HFT_CAPTURE_MAX_MB": "0"}, wantErr: true},
+		{name: "bad age", env: map[string]string{"HFT_CAPTURE_ROTATE": "hourly"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"HFT_CAPTURE_DIR", "HFT_CAPTURE_MAX_MB", "HFT_CAPTURE_ROTATE"} {
+				t.Setenv(name, tt.env[name])
+			}
+			config, err := ConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConfigFromEnv() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if config.Enabled() {
+				t.Error("enabled without HFT_CAPTURE_DIR")
+			}
+			if config.MaxFileBytes != tt.wantBytes || config.MaxFileAge != tt.wantAge {
+				t.Errorf("got %d bytes, %s; want %d bytes, %s", config.MaxFileBytes, config.MaxFileAge, tt.wantBytes, tt.wantAge)
+			}
+		})
+	}
+}
+
+func TestRecorderRoundTrip(t *testing.T) {
+	received := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	quote := &strategy.Quote{Exchange: "okx", Symbol: "DOGE-USDT", Bid: 0.1, Ask: 0.1001, Timestamp: received}
+	messages := []struct {
+		venue   string
+		message string
+		quote   *strategy.Quote
+		wantRaw string
+	}{
+		{venue: "okx", message: `{"arg":{"channel":"tickers"}}`, quote: quote, wantRaw: `{"arg":{"channel":"tickers"}}`},
+		{venue: "kraken", message: `{"event":"heartbeat"}`, wantRaw: `{"event":"heartbeat"}`},
+		{venue: "bitstamp", message: "pong", wantRaw: `"pong"`},
+	}
+
+	tests := []struct {
+		name         string
+		maxFileBytes int64
+		wantFiles    int
+	}{
+		{name: "one file", maxFileBytes: 1 << 20, wantFiles: 1},
+		{name: "rotated after every record", maxFileBytes: 1, wantFiles: len(messages) + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.Dir = t.TempDir()
+			config.MaxFileBytes = tt.maxFileBytes
+			r, err := NewRecorder(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, m := range messages {
+				r.Record(m.venue, received.Add(time.Duration(i)*time.Millisecond), []byte(m.message), m.quote)
+			}
+			if err := r.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			r.Record("okx", received, []byte("{}"), nil) // ignored after Close
+
+			files, err := Files(config.Dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != tt.wantFiles {
+				t.Errorf("%d files, want %d", len(files), tt.wantFiles)
+			}
+
+			var records []Record
+			if err := Walk(files, func(record Record) error {
+				records = append(records, record)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != len(messages) {
+				t.Fatalf("read %d records, want %d", len(records), len(messages))
+			}
+			for i, m := range messages {
+				record := records[i]
+				if record.Venue != m.venue || string(record.Raw) != m.wantRaw || !record.Time().Equal(received.Add(time.Duration(i)*time.Millisecond)) {
+					t.Errorf("record %d = %s %s %s", i, record.Venue, record.Raw, record.Time())
+				}
+				if !json.Valid(record.Raw) {
+					t.Errorf("record %d raw is not JSON: %s", i, record.Raw)
+				}
+			}
+
+			quotes, err := ReadQuotes(files...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(quotes) != 1 || quotes[0].Symbol != quote.Symbol || quotes[0].Bid != quote.Bid || !quotes[0].Timestamp.Equal(quote.Timestamp) {
+				t.Errorf("ReadQuotes() = %+v, want the okx quote", quotes)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Sim is a clock that only moves when it is told to. Tickers fire while the
+// clock is advanced; like time.Ticker they drop ticks nobody has received.
+type Sim struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*simTicker
+}
+
+// NewSim returns a simulated clock set to start
+func NewSim(start time.Time) *Sim {
+	return &Sim{now: start}
+}
+
+// Now returns the simulated time
+func (s *Sim) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now
+}
+
+// NewTicker returns a ticker that fires every d of simulated time
+func (s *Sim) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := &simTicker{sim: s, c: make(chan time.Time, 1), interval: d, next: s.now.Add(d)}
+	s.tickers = append(s.tickers, t)
+	return t
+}
+
+// Set moves the clock to t, firing every tick due on the way. The clock
+// never moves backwards; earlier times are ignored.
+func (s *Sim) Set(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t.Before(s.now) {
+		return
+	}
+	s.now = t
+
+	for _, ticker := range s.tickers {
+		for !ticker.next.After(t) {
+			select {
+			case ticker.c <- ticker.next:
+			default:
+			}
+			ticker.next = ticker.next.Add(ticker.interval)
+		}
+	}
+}
+
+// Advance moves the clock forward by d
+func (s *Sim) Advance(d time.Duration) {
+	s.Set(s.Now().Add(d))
+}
+
+type simTicker struct {
+	sim      *Sim
+	c        chan time.Time
+	interval time.Duration
+	next     time.Time
+}
+
+func (t *simTicker) C() <-chan time.Time { return t.c }
+
+func (t *simTicker) Stop() {
+	t.sim.mu.Lock()
+	defer t.sim.mu.Unlock()
+	for i, ticker := range t.sim.tickers {
+		if ticker == t {
+			t.sim.tickers = append(t.sim.tickers[:i], t.sim.tickers[i+1:]...)
+			return
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package exchange
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -18,53 +20,139 @@ type BinanceBookTicker struct {
 	AskQty   string `json:"A"`
 }
 
-// Binance starts the Binance WebSocket connection and sends quotes to the provided channel
-func Binance(quoteChan chan<- strategy.Quote) {
-	url := "wss://stream.binance.com:9443/ws/dogeusdt@bookTicker"
-	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+// BinanceTrade is a message of the trade stream. Every key is declared:
+// encoding/json matches keys case-insensitively, so an undeclared "E" would
+// land in Event and fail the message, and "M" would overwrite BuyerIsMaker.
+type BinanceTrade struct {
+	Event        string `json:"e"` // "trade"
+	EventTime    int64  `json:"E"` // unix millis
+	Symbol       string `json:"s"`
+	TradeID      int64  `json:"t"`
+	Price        string `json:"p"`
+	Quantity     string `json:"q"`
+	TradeTime    int64  `json:"T"` // unix millis
+	BuyerIsMaker bool   `json:"m"` // the seller was the aggressor
+	Ignore       bool   `json:"M"`
+}
+
+// binanceCombined wraps each message of a combined stream
+type binanceCombined struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Binance streams quotes from the Binance WebSocket to the provided channel
+// until ctx is cancelled: DOGEUSDT plus any extra symbols, such as the other
+// markets of a triangle, with the trades of each.
+func Binance(ctx context.Context, quoteChan chan<- strategy.Quote, symbols ...string) {
+	symbols = append([]string{"DOGEUSDT"}, symbols...)
+	runFeed(ctx, "binance", func(connected func()) error {
+		return binanceSession(ctx, quoteChan, symbols, connected)
+	})
+}
+
+// binanceSession streams quotes over one connection until it fails or ctx
+// is cancelled
+func binanceSession(ctx context.Context, quoteChan chan<- strategy.Quote, symbols []string, connected func()) error {
+	var streams []string
+	seen := make(map[string]bool)
+	for _, symbol := range symbols {
+		for _, stream := range []string{strings.ToLower(symbol) + "@bookTicker", strings.ToLower(symbol) + "@trade"} {
+			if !seen[stream] {
+				seen[stream] = true
+				streams = append(streams, stream)
+			}
+		}
+	}
+	url := "wss://stream.binance.com:9443/ws/" + streams[0]
+	if len(streams) > 1 {
+		url = "wss://stream.binance.com:9443/stream?streams=" + strings.Join(streams, "/")
+	}
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
 	if err != nil {
-		log.Fatal("Error connecting to WebSocket:", err)
+		return fmt.Errorf("error connecting to WebSocket: %w", err)
 	}
 	defer conn.Close()
+	stop := closeOnCancel(ctx, conn)
+	defer stop()
 
-	log.Println("Connected to Binance stream for dogeusdt")
+	logger.Info("connected", "venue", "binance", "symbols", strings.Join(symbols, ","))
+	connected()
 
 	for {
 		_, message, err := conn.ReadMessage()
 		if err != nil {
-			log.Println("Error reading message:", err)
-			break
+			return fmt.Errorf("error reading message: %w", err)
 		}
+		received := time.Now()
+		quote, ok := parseBinance(message, received)
+		deliver(ctx, quoteChan, "binance", message, received, quote, ok)
+	}
+}
 
-		var ticker BinanceBookTicker
-		err = json.Unmarshal(message, &ticker)
-		if err != nil {
-			log.Println("Error unmarshalling message:", err)
-			continue
-		}
+// parseBinance normalizes a bookTicker or trade message, bare or wrapped by
+// a combined stream
+func parseBinance(message []byte, received time.Time) (strategy.Quote, bool) {
+	var combined binanceCombined
+	if err := json.Unmarshal(message, &combined); err == nil && len(combined.Data) > 0 {
+		message = combined.Data
+	}
+	if strings.HasSuffix(combined.Stream, "@trade") {
+		return parseBinanceTrade(message, received)
+	}
 
-		bid, err1 := strconv.ParseFloat(ticker.BidPrice, 64)
-		ask, err2 := strconv.ParseFloat(ticker.AskPrice, 64)
-		if err1 != nil || err2 != nil {
-			log.Println("Error parsing bid/ask:", err1, err2)
-			continue
-		}
+	var ticker BinanceBookTicker
+	err := json.Unmarshal(message, &ticker)
+	if err != nil {
+		logger.Warn("unmarshal failed", "venue", "binance", "err", err)
+		return strategy.Quote{}, false
+	}
 
-		quote := strategy.Quote{
-			Exchange:  "binance",
-			Symbol:    ticker.Symbol,
-			Bid:       bid,
-			Ask:       ask,
-			Timestamp: time.Now(),
-		}
+	bid, err1 := strconv.ParseFloat(ticker.BidPrice, 64)
+	ask, err2 := strconv.ParseFloat(ticker.AskPrice, 64)
+	if err1 != nil || err2 != nil {
+		logger.Warn("bad bid/ask", "venue", "binance", "bid_err", err1, "ask_err", err2)
+		return strategy.Quote{}, false
+	}
 
-		// Send quote to strategy
-		select {
-		case quoteChan <- quote:
-		default:
-			// Channel is full, skip this quote
-		}
+	// Sizes are informational; a bad size does not invalidate the quote
+	bidSize, _ := strconv.ParseFloat(ticker.BidQty, 64)
+	askSize, _ := strconv.ParseFloat(ticker.AskQty, 64)
+
+	return strategy.Quote{
+		Exchange:  "binance",
+		Symbol:    ticker.Symbol,
+		Bid:       bid,
+		Ask:       ask,
+		BidSize:   bidSize,
+		AskSize:   askSize,
+		Timestamp: received,
+	}, true
+}
+
+// parseBinanceTrade normalizes a trade message into a quote carrying only
+// the trade
+func parseBinanceTrade(message []byte, received time.Time) (strategy.Quote, bool) {
+	var trade BinanceTrade
+	if err := json.Unmarshal(message, &trade); err != nil {
+		logger.Warn("unmarshal failed", "venue", "binance", "err", err)
+		return strategy.Quote{}, false
+	}
+	price, err1 := strconv.ParseFloat(trade.Price, 64)
+	size, err2 := strconv.ParseFloat(trade.Quantity, 64)
+	if err1 != nil || err2 != nil || size <= 0 {
+		logger.Warn("bad trade", "venue", "binance", "price_err", err1, "size_err", err2)
+		return strategy.Quote{}, false
+	}
 
-		log.Printf("🟡 Binance: Bid=%.6f, Ask=%.6f", bid, ask)
+	side := "BUY"
+	if trade.BuyerIsMaker {
+		side = "SELL"
 	}
+	return strategy.Quote{
+		Exchange:  "binance",
+		Symbol:    trade.Symbol,
+		Trades:    []strategy.MarketTrade{{Price: price, Size: size, Side: side}},
+		Timestamp: received,
+	}, true
 }
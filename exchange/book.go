@@ -0,0 +1,99 @@
+package exchange
+
+import (
+	"sort"
+	"strconv"
+
+	"hft-arbitrage-bot/strategy"
+)
+
+// localBook maintains a venue order book from a snapshot and incremental
+// updates, keeping at most depth levels per side
+type localBook struct {
+	depth int
+	bids  map[float64]float64
+	asks  map[float64]float64
+}
+
+func newLocalBook(depth int) *localBook {
+	return &localBook{
+		depth: depth,
+		bids:  make(map[float64]float64),
+		asks:  make(map[float64]float64),
+	}
+}
+
+// reset clears the book before a new snapshot
+func (b *localBook) reset() {
+	b.bids = make(map[float64]float64)
+	b.asks = make(map[float64]float64)
+}
+
+// apply sets one level; a zero size removes it
+func (b *localBook) apply(bid bool, price, size float64) {
+	side := b.asks
+	if bid {
+		side = b.bids
+	}
+	if size == 0 {
+		delete(side, price)
+		return
+	}
+	side[price] = size
+}
+
+// snapshot returns the book sorted best first and drops levels beyond depth,
+// which the venue stops sending updates for
+func (b *localBook) snapshot() strategy.OrderBook {
+	book := strategy.OrderBook{
+		Bids: b.levels(b.bids, func(x, y float64) bool { return x > y }),
+		Asks: b.levels(b.asks, func(x, y float64) bool { return x < y }),
+	}
+	return book
+}
+
+func (b *localBook) levels(side map[float64]float64, better func(x, y float64) bool) []strategy.PriceLevel {
+	levels := make([]strategy.PriceLevel, 0, len(side))
+	for price, size := range side {
+		levels = append(levels, strategy.PriceLevel{Price: price, Size: size})
+	}
+	sort.Slice(levels, func(i, j int) bool { return better(levels[i].Price, levels[j].Price) })
+	if b.depth > 0 && len(levels) > b.depth {
+		for _, level := range levels[b.depth:] {
+			delete(side, level.Price)
+		}
+		levels = levels[:b.depth]
+	}
+	return levels
+}
+
+// parseLevels converts venue [price, size, ...] string arrays to price levels
+func parseLevels(raw [][]string) ([]strategy.PriceLevel, error) {
+	levels := make([]strategy.PriceLevel, 0, len(raw))
+	for _, entry := range raw {
+		if len(entry) < 2 {
+			continue
+		}
+		price, err := strconv.ParseFloat(entry[0], 64)
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.ParseFloat(entry[1], 64)
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, strategy.PriceLevel{Price: price, Size: size})
+	}
+	return levels, nil
+}
+
+// quoteFromBook fills a quote's top of book from its order book
+func quoteFromBook(quote *strategy.Quote, book strategy.OrderBook) bool {
+	if len(book.Bids) == 0 || len(book.Asks) == 0 {
+		return false
+	}
+	quote.Bid, quote.BidSize = book.Bids[0].Price, book.Bids[0].Size
+	quote.Ask, quote.AskSize = book.Asks[0].Price, book.Asks[0].Size
+	quote.Book = &book
+	return true
+}
@@ -1,8 +1,9 @@
 package exchange
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -14,60 +15,195 @@ type BybitBookTicker struct {
 	Topic string `json:"topic"`
 	Type  string `json:"type"`
 	Data  []struct {
-		Symbol string `json:"s"`
+		Symbol    string `json:"s"`
 		Bid1Price string `json:"b"`
 		Ask1Price string `json:"a"`
-		Time int64 `json:"T"`
+		Time      int64  `json:"T"`
 	} `json:"data"`
 }
 
-func Bybit(quoteChan chan<- strategy.Quote) {
+// Bybit streams quotes from the Bybit WebSocket to the provided channel until
+// ctx is cancelled
+func Bybit(ctx context.Context, quoteChan chan<- strategy.Quote) {
+	runFeed(ctx, "bybit", func(connected func()) error {
+		return bybitSession(ctx, quoteChan, connected)
+	})
+}
+
+// bybitSession streams quotes over one connection until it fails or ctx
+// is cancelled
+func bybitSession(ctx context.Context, quoteChan chan<- strategy.Quote, connected func()) error {
 	url := "wss://stream.bybit.com/v5/public/spot"
-	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
 	if err != nil {
-		log.Fatal("Error connecting to Bybit WebSocket:", err)
+		return fmt.Errorf("error connecting to Bybit WebSocket: %w", err)
 	}
 	defer conn.Close()
+	stop := closeOnCancel(ctx, conn)
+	defer stop()
 
 	subMsg := map[string]interface{}{
-		"op": "subscribe",
+		"op":   "subscribe",
 		"args": []string{"tickers.DOGEUSDT"},
 	}
 	if err := conn.WriteJSON(subMsg); err != nil {
-		log.Fatal("Bybit subscription failed:", err)
+		return fmt.Errorf("Bybit subscription failed: %w", err)
 	}
-	log.Println("🟠 Subscribed to Bybit DOGEUSDT ticker")
+	logger.Info("subscribed", "venue", "bybit", "symbol", "DOGEUSDT", "channel", "tickers")
+	connected()
 
 	for {
 		_, message, err := conn.ReadMessage()
 		if err != nil {
-			log.Println("Bybit read error:", err)
-			break
+			return fmt.Errorf("Bybit read error: %w", err)
 		}
+		received := time.Now()
+		quote, ok := parseBybit(message, received)
+		deliver(ctx, quoteChan, "bybit", message, received, quote, ok)
+	}
+}
 
-		var ticker BybitBookTicker
-		if err := json.Unmarshal(message, &ticker); err != nil {
-			continue
+// parseBybit normalizes a tickers message
+func parseBybit(message []byte, received time.Time) (strategy.Quote, bool) {
+	var ticker BybitBookTicker
+	if err := json.Unmarshal(message, &ticker); err != nil {
+		return strategy.Quote{}, false
+	}
+	if len(ticker.Data) == 0 {
+		return strategy.Quote{}, false
+	}
+	bid, err1 := strconv.ParseFloat(ticker.Data[0].Bid1Price, 64)
+	ask, err2 := strconv.ParseFloat(ticker.Data[0].Ask1Price, 64)
+	if err1 != nil || err2 != nil {
+		return strategy.Quote{}, false
+	}
+	return strategy.Quote{
+		Exchange:  "bybit",
+		Symbol:    "DOGEUSDT",
+		Bid:       bid,
+		Ask:       ask,
+		Timestamp: received,
+	}, true
+}
+
+// BybitLinearTicker is a tickers message of the linear perpetual stream. A
+// snapshot carries every field; a delta only the fields that changed.
+type BybitLinearTicker struct {
+	Topic string `json:"topic"`
+	Type  string `json:"type"` // "snapshot" or "delta"
+	Data  struct {
+		Symbol          string `json:"symbol"`
+		Bid1Price       string `json:"bid1Price"`
+		Bid1Size        string `json:"bid1Size"`
+		Ask1Price       string `json:"ask1Price"`
+		Ask1Size        string `json:"ask1Size"`
+		MarkPrice       string `json:"markPrice"`
+		FundingRate     string `json:"fundingRate"`
+		NextFundingTime string `json:"nextFundingTime"` // unix millis
+	} `json:"data"`
+}
+
+// BybitPerp streams DOGEUSDT-PERP quotes, with mark price and funding, from
+// the Bybit linear perpetual WebSocket to the provided channel until ctx is
+// cancelled. It runs as its own feed, "bybit-perp", next to the spot feed.
+func BybitPerp(ctx context.Context, quoteChan chan<- strategy.Quote) {
+	runFeed(ctx, "bybit-perp", func(connected func()) error {
+		return bybitPerpSession(ctx, quoteChan, connected)
+	})
+}
+
+// bybitPerpSession streams perpetual quotes over one connection until it
+// fails or ctx is cancelled
+func bybitPerpSession(ctx context.Context, quoteChan chan<- strategy.Quote, connected func()) error {
+	url := "wss://stream.bybit.com/v5/public/linear"
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return fmt.Errorf("error connecting to Bybit linear WebSocket: %w", err)
+	}
+	defer conn.Close()
+	stop := closeOnCancel(ctx, conn)
+	defer stop()
+
+	subMsg := map[string]interface{}{
+		"op":   "subscribe",
+		"args": []string{"tickers.DOGEUSDT"},
+	}
+	if err := conn.WriteJSON(subMsg); err != nil {
+		return fmt.Errorf("Bybit linear subscription failed: %w", err)
+	}
+	logger.Info("subscribed", "venue", "bybit", "symbol", "DOGEUSDT", "channel", "linear tickers")
+	connected()
+
+	// Deltas only make sense on top of this connection's snapshot
+	parser := &bybitPerpParser{}
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return fmt.Errorf("Bybit linear read error: %w", err)
 		}
-		if len(ticker.Data) == 0 {
-			continue
+		received := time.Now()
+		quote, ok := parser.Parse(message, received)
+		deliver(ctx, quoteChan, "bybit-perp", message, received, quote, ok)
+	}
+}
+
+// bybitPerpParser applies linear ticker snapshots and deltas to one quote
+type bybitPerpParser struct {
+	quote    strategy.Quote
+	snapshot bool // a snapshot has been applied
+}
+
+// Parse implements Parser. Deltas before the first snapshot are dropped.
+func (p *bybitPerpParser) Parse(message []byte, received time.Time) (strategy.Quote, bool) {
+	var ticker BybitLinearTicker
+	if err := json.Unmarshal(message, &ticker); err != nil || ticker.Topic == "" {
+		return strategy.Quote{}, false
+	}
+	switch ticker.Type {
+	case "snapshot":
+		p.quote = strategy.Quote{Exchange: "bybit", Symbol: strategy.PerpSymbol("DOGEUSDT")}
+		p.snapshot = true
+	case "delta":
+		if !p.snapshot {
+			return strategy.Quote{}, false
 		}
-		bid, err1 := strconv.ParseFloat(ticker.Data[0].Bid1Price, 64)
-		ask, err2 := strconv.ParseFloat(ticker.Data[0].Ask1Price, 64)
-		if err1 != nil || err2 != nil {
+	default:
+		return strategy.Quote{}, false
+	}
+
+	data := ticker.Data
+	for _, f := range []struct {
+		raw   string
+		field *float64
+	}{
+		{data.Bid1Price, &p.quote.Bid},
+		{data.Bid1Size, &p.quote.BidSize},
+		{data.Ask1Price, &p.quote.Ask},
+		{data.Ask1Size, &p.quote.AskSize},
+		{data.MarkPrice, &p.quote.MarkPrice},
+		{data.FundingRate, &p.quote.FundingRate},
+	} {
+		if f.raw == "" {
 			continue
 		}
-		quote := strategy.Quote{
-			Exchange:  "bybit",
-			Symbol:    "DOGEUSDT",
-			Bid:       bid,
-			Ask:       ask,
-			Timestamp: time.Now(),
+		v, err := strconv.ParseFloat(f.raw, 64)
+		if err != nil {
+			return strategy.Quote{}, false
 		}
-		select {
-		case quoteChan <- quote:
-		default:
+		*f.field = v
+	}
+	if data.NextFundingTime != "" {
+		millis, err := strconv.ParseInt(data.NextFundingTime, 10, 64)
+		if err != nil {
+			return strategy.Quote{}, false
 		}
-		log.Printf("🟠 Bybit: Bid=%.6f, Ask=%.6f", bid, ask)
+		p.quote.NextFunding = time.UnixMilli(millis)
 	}
-} 
\ No newline at end of file
+
+	if p.quote.Bid <= 0 || p.quote.Ask <= 0 {
+		return strategy.Quote{}, false
+	}
+	quote := p.quote
+	quote.Timestamp = received
+	return quote, true
+}
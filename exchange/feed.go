@@ -0,0 +1,235 @@
+package exchange
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"hft-arbitrage-bot/logging"
+	"hft-arbitrage-bot/metrics"
+	"hft-arbitrage-bot/strategy"
+)
+
+var logger = logging.Component("exchange")
+
+// quoteLogSampler keeps debug quote logging to a few records per venue per second
+var quoteLogSampler = logging.NewSampler(time.Second, 5)
+
+var (
+	quotesReceived = metrics.NewCounterVec("hft_quotes_received_total",
+		"Normalized quotes produced by each venue adapter.", "venue")
+	quotesDropped = metrics.NewCounterVec("hft_quotes_dropped_total",
+		"Quotes dropped because the strategy channel was full.", "venue")
+	feedReconnects = metrics.NewCounterVec("hft_feed_reconnects_total",
+		"WebSocket reconnections after a dropped or failed connection.", "venue")
+	feedConnected = metrics.NewGaugeVec("hft_feed_connected",
+		"1 while the venue WebSocket is connected, 0 otherwise.", "venue")
+)
+
+// FeedState describes the connection state of one venue feed
+type FeedState struct {
+	Venue       string    `json:"venue"`
+	Connected   bool      `json:"connected"`
+	ConnectedAt time.Time `json:"connected_at"`
+	LastMessage time.Time `json:"last_message"` // receive time of the last quote published
+	Reconnects  int       `json:"reconnects"`
+	LastError   string    `json:"last_error"`
+}
+
+var (
+	feedStatesLock sync.RWMutex
+	feedStates     = make(map[string]*FeedState)
+)
+
+// FeedVenue returns the venue a feed streams from, "bybit" for both the
+// "bybit" spot feed and the "bybit-perp" feed
+func FeedVenue(feed string) string {
+	venue, _, _ := strings.Cut(feed, "-")
+	return venue
+}
+
+// FeedStates returns the state of every feed started so far
+func FeedStates() []FeedState {
+	feedStatesLock.RLock()
+	defer feedStatesLock.RUnlock()
+
+	states := make([]FeedState, 0, len(feedStates))
+	for _, state := range feedStates {
+		states = append(states, *state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Venue < states[j].Venue })
+	return states
+}
+
+var (
+	latestQuotesLock sync.RWMutex
+	latestQuotes     = make(map[string]strategy.Quote) // venue/symbol -> quote
+)
+
+// LatestQuote returns the last quote a venue's feed produced for a symbol,
+// whether or not the strategy has consumed it yet
+func LatestQuote(venue, symbol string) (strategy.Quote, bool) {
+	latestQuotesLock.RLock()
+	defer latestQuotesLock.RUnlock()
+	quote, ok := latestQuotes[venue+"/"+strategy.NormalizeSymbol(symbol)]
+	return quote, ok
+}
+
+// updateFeedState applies a change to a venue's feed state
+func updateFeedState(venue string, update func(state *FeedState)) {
+	feedStatesLock.Lock()
+	defer feedStatesLock.Unlock()
+
+	state, ok := feedStates[venue]
+	if !ok {
+		state = &FeedState{Venue: venue}
+		feedStates[venue] = state
+	}
+	update(state)
+}
+
+const (
+	minReconnectDelay = 1 * time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// runFeed keeps a venue session running, redialing with exponential backoff
+// whenever the connection fails or drops, until ctx is cancelled. session
+// blocks for the lifetime of one connection and calls connected once the
+// subscription is in place; it must return when ctx is cancelled.
+func runFeed(ctx context.Context, venue string, session func(connected func()) error) {
+	updateFeedState(venue, func(state *FeedState) {})
+
+	delay := minReconnectDelay
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			feedReconnects.WithLabelValues(venue).Inc()
+			updateFeedState(venue, func(state *FeedState) { state.Reconnects++ })
+		}
+
+		start := time.Now()
+		err := session(func() {
+			feedConnected.WithLabelValues(venue).Set(1)
+			updateFeedState(venue, func(state *FeedState) {
+				state.Connected = true
+				state.ConnectedAt = time.Now()
+			})
+		})
+		feedConnected.WithLabelValues(venue).Set(0)
+		updateFeedState(venue, func(state *FeedState) {
+			state.Connected = false
+			if err != nil {
+				state.LastError = err.Error()
+			}
+		})
+
+		if ctx.Err() != nil {
+			logger.Info("feed stopped", "venue", venue)
+			return
+		}
+
+		// A connection that stayed up for a while resets the backoff
+		if time.Since(start) > maxReconnectDelay {
+			delay = minReconnectDelay
+		}
+
+		logger.Warn("feed disconnected", "venue", venue, "err", err, "reconnect_in", delay.String())
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			logger.Info("feed stopped", "venue", venue)
+			return
+		case <-timer.C:
+		}
+
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// closeOnCancel closes a session's connection when ctx is cancelled, which
+// unblocks its read loop. Call the returned function once the session ends.
+func closeOnCancel(ctx context.Context, conn *websocket.Conn) func() bool {
+	return context.AfterFunc(ctx, func() { conn.Close() })
+}
+
+// Recorder receives every raw venue message as read from the WebSocket, with
+// its receive time and the quote normalized from it, if any. Record is called
+// from the feed read loops and must not block.
+type Recorder interface {
+	Record(venue string, received time.Time, message []byte, quote *strategy.Quote)
+}
+
+var recorder Recorder
+
+// SetRecorder installs a recorder for all feeds; call it before starting them
+func SetRecorder(r Recorder) {
+	recorder = r
+}
+
+// deliver records a raw message and publishes the quote parsed from it. feed
+// is the name the feed runs under, usually the venue; a venue with more than
+// one connection, like Bybit's spot and perpetual streams, has one per
+// connection.
+func deliver(ctx context.Context, quoteChan chan<- strategy.Quote, feed string, message []byte, received time.Time, quote strategy.Quote, ok bool) {
+	if recorder != nil {
+		if ok {
+			recorder.Record(feed, received, message, &quote)
+		} else {
+			recorder.Record(feed, received, message, nil)
+		}
+	}
+	if ok {
+		publish(ctx, quoteChan, feed, quote)
+	}
+}
+
+// publish sends a quote to the strategy without blocking the read loop.
+// Once ctx is cancelled the strategy is shutting down and quotes are dropped.
+func publish(ctx context.Context, quoteChan chan<- strategy.Quote, feed string, quote strategy.Quote) {
+	if ctx.Err() != nil {
+		return
+	}
+	quotesReceived.WithLabelValues(feed).Inc()
+	updateFeedState(feed, func(state *FeedState) { state.LastMessage = quote.Timestamp })
+
+	// Trades carry no prices and must not replace the latest book
+	if !quote.TradesOnly() {
+		logQuote(quote)
+		latestQuotesLock.Lock()
+		latestQuotes[quote.Exchange+"/"+strategy.NormalizeSymbol(quote.Symbol)] = quote
+		latestQuotesLock.Unlock()
+	}
+
+	select {
+	case quoteChan <- quote:
+	default:
+		// Channel is full, skip this quote
+		quotesDropped.WithLabelValues(feed).Inc()
+	}
+}
+
+// logQuote logs a quote at debug level, sampled per venue so that a busy feed
+// cannot flood the log
+func logQuote(quote strategy.Quote) {
+	if !logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	ok, suppressed := quoteLogSampler.Allow(quote.Exchange)
+	if !ok {
+		return
+	}
+	logger.Debug("quote",
+		"venue", quote.Exchange,
+		"symbol", quote.Symbol,
+		"bid", quote.Bid,
+		"ask", quote.Ask,
+		"suppressed", suppressed)
+}
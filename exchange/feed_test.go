@@ -0,0 +1,96 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"hft-arbitrage-bot/strategy"
+)
+
+func TestRunFeedStopsOnCancel(t *testing.T) {
+	tests := []struct {
+		name    string
+		session func(ctx context.Context, connected func()) error
+	}{
+		{
+			name: "cancelled while connected",
+			session: func(ctx context.Context, connected func()) error {
+				connected()
+				<-ctx.Done()
+				return errors.New("read error: use of closed network connection")
+			},
+		},
+		{
+			name: "cancelled while waiting to reconnect",
+			session: func(ctx context.Context, connected func()) error {
+				return errors.New("connection refused")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			sessions := make(chan struct{}, 16)
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				runFeed(ctx, "test-"+tt.name, func(connected func()) error {
+					sessions <- struct{}{}
+					return tt.session(ctx, connected)
+				})
+			}()
+
+			<-sessions
+			cancel()
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("runFeed did not return after cancel")
+			}
+		})
+	}
+}
+
+func TestPublish(t *testing.T) {
+	quote := strategy.Quote{Exchange: "test-publish", Symbol: "DOGEUSDT", Bid: 0.1, Ask: 0.1001, Timestamp: time.Now()}
+
+	tests := []struct {
+		name      string
+		cancelled bool
+		full      bool
+		wantSent  bool
+	}{
+		{name: "sends to the strategy", wantSent: true},
+		{name: "drops when the channel is full", full: true},
+		{name: "drops after cancel", cancelled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+			quoteChan := make(chan strategy.Quote, 1)
+			if tt.full {
+				quoteChan <- strategy.Quote{}
+			}
+
+			publish(ctx, quoteChan, "test-publish", quote)
+
+			sent := false
+			for len(quoteChan) > 0 {
+				if q := <-quoteChan; q.Exchange == quote.Exchange {
+					sent = true
+				}
+			}
+			if sent != tt.wantSent {
+				t.Errorf("sent = %v, want %v", sent, tt.wantSent)
+			}
+		})
+	}
+}
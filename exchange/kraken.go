@@ -1,8 +1,9 @@
 package exchange
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -10,6 +11,9 @@ import (
 	"hft-arbitrage-bot/strategy"
 )
 
+// krakenBookDepth is the number of levels per side subscribed and kept locally
+const krakenBookDepth = 10
+
 type KrakenSubscribeMsg struct {
 	Event        string       `json:"event"`
 	Pair         []string     `json:"pair"`
@@ -21,81 +25,119 @@ type Subscription struct {
 	Depth int    `json:"depth"`
 }
 
-// Kraken starts the Kraken WebSocket connection and sends quotes to the provided channel
-func Kraken(quoteChan chan<- strategy.Quote) {
+// Kraken streams quotes from the Kraken WebSocket to the provided channel until
+// ctx is cancelled
+func Kraken(ctx context.Context, quoteChan chan<- strategy.Quote) {
+	runFeed(ctx, "kraken", func(connected func()) error {
+		return krakenSession(ctx, quoteChan, connected)
+	})
+}
+
+// krakenSession streams quotes over one connection until it fails or ctx
+// is cancelled
+func krakenSession(ctx context.Context, quoteChan chan<- strategy.Quote, connected func()) error {
 	url := "wss://ws.kraken.com"
-	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
 	if err != nil {
-		log.Fatal("WebSocket connection failed:", err)
+		return fmt.Errorf("WebSocket connection failed: %w", err)
 	}
 	defer conn.Close()
+	stop := closeOnCancel(ctx, conn)
+	defer stop()
 
 	subscribe := KrakenSubscribeMsg{
 		Event: "subscribe",
 		Pair:  []string{"DOGE/USD"},
 		Subscription: Subscription{
 			Name:  "book",
-			Depth: 10, // more depth = more data but slower
+			Depth: krakenBookDepth, // more depth = more data but slower
 		},
 	}
 
 	err = conn.WriteJSON(subscribe)
 	if err != nil {
-		log.Fatal("Failed to subscribe:", err)
+		return fmt.Errorf("failed to subscribe: %w", err)
 	}
 
-	log.Println("Subscribed to Kraken DOGE/USD order book")
+	logger.Info("subscribed", "venue", "kraken", "symbol", "DOGE/USD", "channel", "book")
+	connected()
 
-	var currentBid, currentAsk float64
+	parser := newKrakenParser()
 
 	for {
 		_, message, err := conn.ReadMessage()
 		if err != nil {
-			log.Println("Read error:", err)
-			break
+			return fmt.Errorf("read error: %w", err)
 		}
+		received := time.Now()
+		quote, ok := parser.Parse(message, received)
+		deliver(ctx, quoteChan, "kraken", message, received, quote, ok)
+	}
+}
 
-		var data []any
-		if err := json.Unmarshal(message, &data); err != nil {
-			continue
-		}
+// krakenParser keeps the local book that Kraken's incremental updates apply to
+type krakenParser struct {
+	book *localBook
+}
 
-		if len(data) >= 2 {
-			payload := data[1].(map[string]any)
+func newKrakenParser() *krakenParser {
+	return &krakenParser{book: newLocalBook(krakenBookDepth)}
+}
 
-			// Check for asks or bids
-			if asks, ok := payload["a"]; ok {
-				ask := asks.([]any)[0].([]any)[0].(string)
-				if askFloat, err := strconv.ParseFloat(ask, 64); err == nil {
-					currentAsk = askFloat
-				}
-			}
-			if bids, ok := payload["b"]; ok {
-				bid := bids.([]any)[0].([]any)[0].(string)
-				if bidFloat, err := strconv.ParseFloat(bid, 64); err == nil {
-					currentBid = bidFloat
-				}
-			}
+// Parse applies a book message and returns the resulting top of book
+func (p *krakenParser) Parse(message []byte, received time.Time) (strategy.Quote, bool) {
+	// Book messages are arrays: [channelID, {..}, ({..},) "book-10", pair].
+	// Events such as heartbeats are objects and fail to decode here.
+	var data []any
+	if err := json.Unmarshal(message, &data); err != nil || len(data) < 2 {
+		return strategy.Quote{}, false
+	}
 
-			// Send quote if we have both bid and ask
-			if currentBid > 0 && currentAsk > 0 {
-				quote := strategy.Quote{
-					Exchange:  "kraken",
-					Symbol:    "DOGEUSD",
-					Bid:       currentBid,
-					Ask:       currentAsk,
-					Timestamp: time.Now(),
-				}
-
-				// Send quote to strategy
-				select {
-				case quoteChan <- quote:
-				default:
-					// Channel is full, skip this quote
-				}
-
-				log.Printf("🟣 Kraken: Bid=%.6f, Ask=%.6f", currentBid, currentAsk)
+	updated := false
+	for _, element := range data[1:] {
+		payload, ok := element.(map[string]any)
+		if !ok {
+			continue
+		}
+		// "as"/"bs" carry a full snapshot, "a"/"b" carry level changes
+		if _, snapshot := payload["as"]; snapshot {
+			p.book.reset()
+		}
+		for key, bid := range map[string]bool{"as": false, "bs": true, "a": false, "b": true} {
+			if levels, ok := payload[key].([]any); ok {
+				applyKrakenLevels(p.book, bid, levels)
+				updated = true
 			}
 		}
 	}
+	if !updated {
+		return strategy.Quote{}, false
+	}
+
+	quote := strategy.Quote{
+		Exchange:  "kraken",
+		Symbol:    "DOGEUSD",
+		Timestamp: received,
+	}
+	// Only a book with both bid and ask makes a quote
+	return quote, quoteFromBook(&quote, p.book.snapshot())
+}
+
+// applyKrakenLevels applies [price, volume, timestamp(, "r")] entries; a zero
+// volume deletes the level
+func applyKrakenLevels(book *localBook, bid bool, levels []any) {
+	for _, raw := range levels {
+		entry, ok := raw.([]any)
+		if !ok || len(entry) < 2 {
+			continue
+		}
+		priceStr, _ := entry[0].(string)
+		volumeStr, _ := entry[1].(string)
+		price, err1 := strconv.ParseFloat(priceStr, 64)
+		volume, err2 := strconv.ParseFloat(volumeStr, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		book.apply(bid, price, volume)
+	}
 }
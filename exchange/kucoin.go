@@ -1,8 +1,9 @@
 package exchange
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -11,65 +12,85 @@ import (
 )
 
 type KuCoinMessage struct {
-	Type string `json:"type"`
-	Topic string `json:"topic"`
+	Type    string `json:"type"`
+	Topic   string `json:"topic"`
 	Subject string `json:"subject"`
-	Data struct {
-		BestBid string `json:"bestBid"`
-		BestAsk string `json:"bestAsk"`
-		Symbol string `json:"symbol"`
+	Data    struct {
+		BestBid     string `json:"bestBid"`
+		BestBidSize string `json:"bestBidSize"`
+		BestAsk     string `json:"bestAsk"`
+		BestAskSize string `json:"bestAskSize"`
+		Symbol      string `json:"symbol"`
 	} `json:"data"`
 }
 
-func Kucoin(quoteChan chan<- strategy.Quote) {
+// Kucoin streams quotes from the KuCoin WebSocket to the provided channel until
+// ctx is cancelled
+func Kucoin(ctx context.Context, quoteChan chan<- strategy.Quote) {
+	runFeed(ctx, "kucoin", func(connected func()) error {
+		return kucoinSession(ctx, quoteChan, connected)
+	})
+}
+
+// kucoinSession streams quotes over one connection until it fails or ctx
+// is cancelled
+func kucoinSession(ctx context.Context, quoteChan chan<- strategy.Quote, connected func()) error {
 	url := "wss://ws-api-spot.kucoin.com/endpoint"
-	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
 	if err != nil {
-		log.Fatal("Error connecting to KuCoin WebSocket:", err)
+		return fmt.Errorf("error connecting to KuCoin WebSocket: %w", err)
 	}
 	defer conn.Close()
+	stop := closeOnCancel(ctx, conn)
+	defer stop()
 
 	subMsg := map[string]interface{}{
-		"id":   "dogeusdt-arb",
-		"type": "subscribe",
-		"topic": "/market/ticker:DOGE-USDT",
+		"id":             "dogeusdt-arb",
+		"type":           "subscribe",
+		"topic":          "/market/ticker:DOGE-USDT",
 		"privateChannel": false,
-		"response": true,
+		"response":       true,
 	}
 	if err := conn.WriteJSON(subMsg); err != nil {
-		log.Fatal("KuCoin subscription failed:", err)
+		return fmt.Errorf("KuCoin subscription failed: %w", err)
 	}
-	log.Println("🟢 Subscribed to KuCoin DOGE-USDT ticker")
+	logger.Info("subscribed", "venue", "kucoin", "symbol", "DOGE-USDT", "channel", "ticker")
+	connected()
 
 	for {
 		_, message, err := conn.ReadMessage()
 		if err != nil {
-			log.Println("KuCoin read error:", err)
-			break
-		}
-		var msg KuCoinMessage
-		if err := json.Unmarshal(message, &msg); err != nil {
-			continue
-		}
-		if msg.Type != "message" || msg.Subject != "trade.ticker" {
-			continue
-		}
-		bid, err1 := strconv.ParseFloat(msg.Data.BestBid, 64)
-		ask, err2 := strconv.ParseFloat(msg.Data.BestAsk, 64)
-		if err1 != nil || err2 != nil {
-			continue
-		}
-		quote := strategy.Quote{
-			Exchange:  "kucoin",
-			Symbol:    "DOGEUSDT",
-			Bid:       bid,
-			Ask:       ask,
-			Timestamp: time.Now(),
-		}
-		select {
-		case quoteChan <- quote:
-		default:
+			return fmt.Errorf("KuCoin read error: %w", err)
 		}
-		log.Printf("🟢 KuCoin: Bid=%.6f, Ask=%.6f", bid, ask)
+		received := time.Now()
+		quote, ok := parseKucoin(message, received)
+		deliver(ctx, quoteChan, "kucoin", message, received, quote, ok)
 	}
-} 
\ No newline at end of file
+}
+
+// parseKucoin normalizes a ticker message
+func parseKucoin(message []byte, received time.Time) (strategy.Quote, bool) {
+	var msg KuCoinMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return strategy.Quote{}, false
+	}
+	if msg.Type != "message" || msg.Subject != "trade.ticker" {
+		return strategy.Quote{}, false
+	}
+	bid, err1 := strconv.ParseFloat(msg.Data.BestBid, 64)
+	ask, err2 := strconv.ParseFloat(msg.Data.BestAsk, 64)
+	if err1 != nil || err2 != nil {
+		return strategy.Quote{}, false
+	}
+	bidSize, _ := strconv.ParseFloat(msg.Data.BestBidSize, 64)
+	askSize, _ := strconv.ParseFloat(msg.Data.BestAskSize, 64)
+	return strategy.Quote{
+		Exchange:  "kucoin",
+		Symbol:    "DOGEUSDT",
+		Bid:       bid,
+		Ask:       ask,
+		BidSize:   bidSize,
+		AskSize:   askSize,
+		Timestamp: received,
+	}, true
+}
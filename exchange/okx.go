@@ -1,8 +1,9 @@
 package exchange
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -29,80 +30,195 @@ type OKXOrderBookMessage struct {
 		Asks [][]string `json:"asks"` // [price, size, liquidity]
 		Bids [][]string `json:"bids"`
 		Ts   string     `json:"ts"`
+
+		// trades pushes; side is the aggressor's
+		Px   string `json:"px"`
+		Sz   string `json:"sz"`
+		Side string `json:"side"`
+
+		// mark-price and funding-rate pushes
+		MarkPx      string `json:"markPx"`
+		FundingRate string `json:"fundingRate"`
+		FundingTime string `json:"fundingTime"` // unix millis of the settlement the rate applies to
 	} `json:"data"`
 }
 
-// OKX starts the OKX WebSocket connection and sends quotes to the provided channel
-func OKX(quoteChan chan<- strategy.Quote) {
+// okxSwap is the DOGE perpetual swap; its book sizes count contracts of
+// okxSwapContract DOGE each
+const (
+	okxSwap         = "DOGE-USDT-SWAP"
+	okxSwapContract = 1000
+)
+
+// OKX streams quotes from the OKX WebSocket to the provided channel until
+// ctx is cancelled
+func OKX(ctx context.Context, quoteChan chan<- strategy.Quote) {
+	runFeed(ctx, "okx", func(connected func()) error {
+		return okxSession(ctx, quoteChan, connected)
+	})
+}
+
+// okxSession streams quotes over one connection until it fails or ctx
+// is cancelled
+func okxSession(ctx context.Context, quoteChan chan<- strategy.Quote, connected func()) error {
 	url := "wss://ws.okx.com:8443/ws/v5/public"
-	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
 	if err != nil {
-		log.Fatal("WebSocket connection failed:", err)
+		return fmt.Errorf("WebSocket connection failed: %w", err)
 	}
 	defer conn.Close()
+	stop := closeOnCancel(ctx, conn)
+	defer stop()
 
 	subscribe := OKXSubscribe{
 		Op: "subscribe",
 		Args: []OKXSubscribeArg{
 			{
-				Channel: "books",
+				Channel: "books5", // full 5-level snapshot on every push
 				InstId:  "DOGE-USDT",
 			},
+			{Channel: "trades", InstId: "DOGE-USDT"},
+			{Channel: "books5", InstId: okxSwap},
+			{Channel: "mark-price", InstId: okxSwap},
+			{Channel: "funding-rate", InstId: okxSwap},
 		},
 	}
 
 	err = conn.WriteJSON(subscribe)
 	if err != nil {
-		log.Fatal("Subscription failed:", err)
+		return fmt.Errorf("subscription failed: %w", err)
 	}
 
-	log.Println("Subscribed to OKX DOGE-USDT order book")
+	logger.Info("subscribed", "venue", "okx", "symbol", "DOGE-USDT", "channel", "books5,trades")
+	logger.Info("subscribed", "venue", "okx", "symbol", okxSwap, "channel", "books5,mark-price,funding-rate")
+	connected()
 
+	// The parser merges the swap's book, mark price and funding, which
+	// arrive on separate channels, so it lives as long as the connection
+	parser := newOKXParser()
 	for {
 		_, message, err := conn.ReadMessage()
 		if err != nil {
-			log.Println("Read error:", err)
-			break
+			return fmt.Errorf("read error: %w", err)
 		}
+		received := time.Now()
+		quote, ok := parser.Parse(message, received)
+		deliver(ctx, quoteChan, "okx", message, received, quote, ok)
+	}
+}
 
-		var msg OKXOrderBookMessage
-		if err := json.Unmarshal(message, &msg); err != nil {
-			continue
-		}
+// okxParser normalizes spot books and merges the swap's channels into one
+// perpetual quote
+type okxParser struct {
+	perp strategy.Quote // the swap as of the last push on any of its channels
+}
 
-		if len(msg.Data) == 0 {
-			continue
-		}
-		ob := msg.Data[0]
-		if len(ob.Bids) == 0 || len(ob.Asks) == 0 {
-			continue
-		}
+func newOKXParser() *okxParser {
+	return &okxParser{perp: strategy.Quote{Exchange: "okx", Symbol: strategy.PerpSymbol("DOGEUSDT")}}
+}
 
-		bidStr := ob.Bids[0][0]
-		askStr := ob.Asks[0][0]
+// Parse implements Parser. A mark price or funding push publishes the swap
+// quote again once its book is known.
+func (p *okxParser) Parse(message []byte, received time.Time) (strategy.Quote, bool) {
+	var msg OKXOrderBookMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return strategy.Quote{}, false
+	}
+	if len(msg.Data) == 0 {
+		return strategy.Quote{}, false
+	}
+	if msg.Arg.Channel == "trades" {
+		return parseOKXTrades(msg, received)
+	}
+	if msg.Arg.InstId != okxSwap {
+		return parseOKX(msg, received)
+	}
 
-		bid, err1 := strconv.ParseFloat(bidStr, 64)
-		ask, err2 := strconv.ParseFloat(askStr, 64)
+	data := msg.Data[0]
+	switch msg.Arg.Channel {
+	case "mark-price":
+		mark, err := strconv.ParseFloat(data.MarkPx, 64)
+		if err != nil {
+			return strategy.Quote{}, false
+		}
+		p.perp.MarkPrice = mark
+	case "funding-rate":
+		rate, err1 := strconv.ParseFloat(data.FundingRate, 64)
+		millis, err2 := strconv.ParseInt(data.FundingTime, 10, 64)
 		if err1 != nil || err2 != nil {
-			log.Println("Error parsing bid/ask:", err1, err2)
-			continue
+			return strategy.Quote{}, false
 		}
-
-		quote := strategy.Quote{
-			Exchange:  "okx",
-			Symbol:    "DOGEUSDT",
-			Bid:       bid,
-			Ask:       ask,
-			Timestamp: time.Now(),
+		p.perp.FundingRate = rate
+		p.perp.NextFunding = time.UnixMilli(millis)
+	case "books5":
+		bids, err1 := parseLevels(data.Bids)
+		asks, err2 := parseLevels(data.Asks)
+		if err1 != nil || err2 != nil {
+			logger.Warn("bad book levels", "venue", "okx", "symbol", okxSwap, "bid_err", err1, "ask_err", err2)
+			return strategy.Quote{}, false
 		}
-
-		// Send quote to strategy
-		select {
-		case quoteChan <- quote:
-		default:
-			// Channel is full, skip this quote
+		for _, levels := range [][]strategy.PriceLevel{bids, asks} {
+			for i := range levels {
+				levels[i].Size *= okxSwapContract
+			}
+		}
+		if !quoteFromBook(&p.perp, strategy.OrderBook{Bids: bids, Asks: asks}) {
+			return strategy.Quote{}, false
 		}
+	default:
+		return strategy.Quote{}, false
+	}
 
-		log.Printf("⚫️ OKX: Bid=%.6f, Ask=%.6f", bid, ask)
+	if p.perp.Book == nil {
+		return strategy.Quote{}, false
+	}
+	quote := p.perp
+	quote.Timestamp = received
+	return quote, true
+}
+
+// parseOKX normalizes a spot books5 snapshot
+func parseOKX(msg OKXOrderBookMessage, received time.Time) (strategy.Quote, bool) {
+	ob := msg.Data[0]
+	if len(ob.Bids) == 0 || len(ob.Asks) == 0 {
+		return strategy.Quote{}, false
+	}
+
+	bids, err1 := parseLevels(ob.Bids)
+	asks, err2 := parseLevels(ob.Asks)
+	if err1 != nil || err2 != nil {
+		logger.Warn("bad book levels", "venue", "okx", "bid_err", err1, "ask_err", err2)
+		return strategy.Quote{}, false
+	}
+
+	quote := strategy.Quote{
+		Exchange:  "okx",
+		Symbol:    "DOGEUSDT",
+		Timestamp: received,
+	}
+	return quote, quoteFromBook(&quote, strategy.OrderBook{Bids: bids, Asks: asks})
+}
+
+// parseOKXTrades normalizes a spot trades push into a quote carrying only
+// its trades
+func parseOKXTrades(msg OKXOrderBookMessage, received time.Time) (strategy.Quote, bool) {
+	quote := strategy.Quote{
+		Exchange:  "okx",
+		Symbol:    "DOGEUSDT",
+		Timestamp: received,
+	}
+	for _, data := range msg.Data {
+		price, err1 := strconv.ParseFloat(data.Px, 64)
+		size, err2 := strconv.ParseFloat(data.Sz, 64)
+		if err1 != nil || err2 != nil || size <= 0 {
+			logger.Warn("bad trade", "venue", "okx", "price_err", err1, "size_err", err2)
+			continue
+		}
+		side := "BUY"
+		if data.Side == "sell" {
+			side = "SELL"
+		}
+		quote.Trades = append(quote.Trades, strategy.MarketTrade{Price: price, Size: size, Side: side})
 	}
+	return quote, len(quote.Trades) > 0
 }
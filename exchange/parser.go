@@ -0,0 +1,40 @@
+package exchange
+
+import (
+	"fmt"
+	"time"
+
+	"hft-arbitrage-bot/strategy"
+)
+
+// Parser turns raw venue messages into quotes exactly as the live feeds do.
+// A parser may keep state between messages, such as Kraken's local book, so
+// each stream of messages needs its own.
+type Parser interface {
+	Parse(message []byte, received time.Time) (strategy.Quote, bool)
+}
+
+// parserFunc adapts a stateless parse function to Parser
+type parserFunc func(message []byte, received time.Time) (strategy.Quote, bool)
+
+func (f parserFunc) Parse(message []byte, received time.Time) (strategy.Quote, bool) {
+	return f(message, received)
+}
+
+var parsers = map[string]func() Parser{
+	"binance":    func() Parser { return parserFunc(parseBinance) },
+	"kraken":     func() Parser { return newKrakenParser() },
+	"okx":        func() Parser { return newOKXParser() },
+	"bybit":      func() Parser { return parserFunc(parseBybit) },
+	"bybit-perp": func() Parser { return &bybitPerpParser{} },
+	"kucoin":     func() Parser { return parserFunc(parseKucoin) },
+}
+
+// NewParser returns a fresh parser for a venue's messages
+func NewParser(venue string) (Parser, error) {
+	newParser, ok := parsers[venue]
+	if !ok {
+		return nil, fmt.Errorf("no parser for venue %q", venue)
+	}
+	return newParser(), nil
+}
@@ -0,0 +1,102 @@
+package exchange
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"hft-arbitrage-bot/strategy"
+)
+
+func TestParsers(t *testing.T) {
+	received := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		venue      string
+		message    string
+		wantOK     bool
+		wantSymbol string
+		wantBid    float64
+		wantAsk    float64
+		wantTrades []strategy.MarketTrade
+	}{
+		{
+			name:       "binance book ticker",
+			venue:      "binance",
+			message:    `{"stream":"dogeusdt@bookTicker","data":{"u":1,"s":"DOGEUSDT","b":"0.09990","B":"500","a":"0.10000","A":"700"}}`,
+			wantOK:     true,
+			wantSymbol: "DOGEUSDT",
+			wantBid:    0.0999,
+			wantAsk:    0.1,
+		},
+		{
+			name:       "binance trade with every key",
+			venue:      "binance",
+			message:    `{"stream":"dogeusdt@trade","data":{"e":"trade","E":1767614400000,"s":"DOGEUSDT","t":42,"p":"0.09995","q":"1200","T":1767614399999,"m":true,"M":false}}`,
+			wantOK:     true,
+			wantSymbol: "DOGEUSDT",
+			wantTrades: []strategy.MarketTrade{{Price: 0.09995, Size: 1200, Side: "SELL"}},
+		},
+		{
+			name:       "binance aggressive buy ignores M",
+			venue:      "binance",
+			message:    `{"stream":"dogeusdt@trade","data":{"e":"trade","E":1767614400000,"s":"DOGEUSDT","t":43,"p":"0.1","q":"5","T":1767614400000,"m":false,"M":true}}`,
+			wantOK:     true,
+			wantSymbol: "DOGEUSDT",
+			wantTrades: []strategy.MarketTrade{{Price: 0.1, Size: 5, Side: "BUY"}},
+		},
+		{
+			name:    "binance trade without a size",
+			venue:   "binance",
+			message: `{"stream":"dogeusdt@trade","data":{"e":"trade","E":1767614400000,"s":"DOGEUSDT","t":44,"p":"0.1","q":"0","T":1767614400000,"m":false,"M":true}}`,
+		},
+		{
+			name:       "okx trades",
+			venue:      "okx",
+			message:    `{"arg":{"channel":"trades","instId":"DOGE-USDT"},"data":[{"px":"0.1001","sz":"300","side":"buy"},{"px":"0.1","sz":"bad","side":"sell"},{"px":"0.1","sz":"200","side":"sell"}]}`,
+			wantOK:     true,
+			wantSymbol: "DOGEUSDT",
+			wantTrades: []strategy.MarketTrade{{Price: 0.1001, Size: 300, Side: "BUY"}, {Price: 0.1, Size: 200, Side: "SELL"}},
+		},
+		{
+			name:       "okx spot book",
+			venue:      "okx",
+			message:    `{"arg":{"channel":"books5","instId":"DOGE-USDT"},"data":[{"bids":[["0.0999","500","0","1"]],"asks":[["0.1","700","0","1"]]}]}`,
+			wantOK:     true,
+			wantSymbol: "DOGEUSDT",
+			wantBid:    0.0999,
+			wantAsk:    0.1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewParser(tt.venue)
+			if err != nil {
+				t.Fatal(err)
+			}
+			quote, ok := parser.Parse([]byte(tt.message), received)
+			if ok != tt.wantOK {
+				t.Fatalf("Parse() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if quote.Exchange != tt.venue || quote.Symbol != tt.wantSymbol || !quote.Timestamp.Equal(received) {
+				t.Errorf("quote %s %s at %s", quote.Exchange, quote.Symbol, quote.Timestamp)
+			}
+			if quote.Bid != tt.wantBid || quote.Ask != tt.wantAsk {
+				t.Errorf("bid %v ask %v, want %v %v", quote.Bid, quote.Ask, tt.wantBid, tt.wantAsk)
+			}
+			if !slices.Equal(quote.Trades, tt.wantTrades) {
+				t.Errorf("Trades = %+v, want %+v", quote.Trades, tt.wantTrades)
+			}
+		})
+	}
+}
+
+func TestNewParserUnknownVenue(t *testing.T) {
+	if _, err := NewParser("mtgox"); err == nil {
+		t.Error("NewParser(\"mtgox\") returned no error")
+	}
+}
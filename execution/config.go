@@ -0,0 +1,154 @@
+// Package execution provides the backends that fill the legs of an
+// arbitrage: instant fills at the detected prices, and paper trading, which
+// fills against the market as it is once each venue's latency has passed.
+package execution
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"hft-arbitrage-bot/strategy"
+)
+
+// Backends selectable with HFT_EXECUTION
+const (
+	BackendPaper   = "paper"
+	BackendInstant = "instant"
+	BackendLive    = "live"
+)
+
+// ErrLiveUnavailable is returned for the live backend, which does not exist yet
+var ErrLiveUnavailable = errors.New("live execution is not available yet")
+
+// Config selects and tunes the execution backend
+type Config struct {
+	Backend      string
+	Latency      time.Duration            // order submission to fill, for venues without their own
+	VenueLatency map[string]time.Duration // per venue overrides
+	QueueAhead   float64                  // share of each displayed level taken by others before us, in [0, 1)
+	MinFillRatio float64                  // fills below this share of the requested quantity fail
+	MakerFees    map[string]float64       // venue -> maker fee rate; taker rates come from the strategy parameters
+}
+
+// DefaultConfig returns paper trading with latencies typical of a taker on
+// public internet connections
+func DefaultConfig() Config {
+	return Config{
+		Backend:      BackendPaper,
+		Latency:      50 * time.Millisecond,
+		VenueLatency: make(map[string]time.Duration),
+		QueueAhead:   0.25,
+		MinFillRatio: 0.1,
+		MakerFees:    strategy.DefaultMakerFees(),
+	}
+}
+
+// ConfigFromEnv builds the config from environment variables on top of
+// DefaultConfig:
+//
+//	HFT_EXECUTION            paper, instant or live
+//	HFT_PAPER_LATENCY        default submission latency, e.g. 50ms
+//	HFT_PAPER_VENUE_LATENCY  comma separated venue=latency pairs, e.g. kraken=120ms
+//	HFT_PAPER_QUEUE_AHEAD    share of displayed size filled by others first
+//	HFT_PAPER_MIN_FILL       smallest accepted fill as a share of the order
+//	HFT_PAPER_MAKER_FEES     comma separated venue=rate pairs
+func ConfigFromEnv() (Config, error) {
+	config := DefaultConfig()
+
+	if backend := strings.ToLower(os.Getenv("HFT_EXECUTION")); backend != "" {
+		config.Backend = backend
+	}
+	if latency := os.Getenv("HFT_PAPER_LATENCY"); latency != "" {
+		d, err := time.ParseDuration(latency)
+		if err != nil {
+			return config, fmt.Errorf("invalid HFT_PAPER_LATENCY %q", latency)
+		}
+		config.Latency = d
+	}
+	if err := parseVenueValues(os.Getenv("HFT_PAPER_VENUE_LATENCY"), time.ParseDuration, config.VenueLatency); err != nil {
+		return config, fmt.Errorf("invalid HFT_PAPER_VENUE_LATENCY: %w", err)
+	}
+	for name, field := range map[string]*float64{"HFT_PAPER_QUEUE_AHEAD": &config.QueueAhead, "HFT_PAPER_MIN_FILL": &config.MinFillRatio} {
+		if value := os.Getenv(name); value != "" {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return config, fmt.Errorf("invalid %s %q", name, value)
+			}
+			*field = v
+		}
+	}
+	parseRate := func(s string) (float64, error) { return strconv.ParseFloat(s, 64) }
+	if err := parseVenueValues(os.Getenv("HFT_PAPER_MAKER_FEES"), parseRate, config.MakerFees); err != nil {
+		return config, fmt.Errorf("invalid HFT_PAPER_MAKER_FEES: %w", err)
+	}
+	return config, config.Validate()
+}
+
+// Validate checks the config for values no backend can work with
+func (c Config) Validate() error {
+	switch c.Backend {
+	case BackendPaper, BackendInstant, BackendLive:
+	default:
+		return fmt.Errorf("unknown execution backend %q (want %s, %s or %s)", c.Backend, BackendPaper, BackendInstant, BackendLive)
+	}
+	if c.Latency < 0 {
+		return fmt.Errorf("latency must not be negative, got %s", c.Latency)
+	}
+	for venue, latency := range c.VenueLatency {
+		if latency < 0 {
+			return fmt.Errorf("latency for %s must not be negative, got %s", venue, latency)
+		}
+	}
+	if c.QueueAhead < 0 || c.QueueAhead >= 1 {
+		return fmt.Errorf("queue ahead must be in [0, 1), got %.4f", c.QueueAhead)
+	}
+	if c.MinFillRatio < 0 || c.MinFillRatio > 1 {
+		return fmt.Errorf("minimum fill ratio must be in [0, 1], got %.4f", c.MinFillRatio)
+	}
+	for venue, fee := range c.MakerFees {
+		if fee < -0.01 || fee >= 0.05 {
+			return fmt.Errorf("maker fee for %s must be in [-0.01, 0.05), got %.6f", venue, fee)
+		}
+	}
+	return nil
+}
+
+// New returns the configured backend. Paper trading fills against market,
+// typically the latest quotes of the live feeds.
+func New(config Config, market Market) (strategy.ExecutionModel, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	switch config.Backend {
+	case BackendInstant:
+		return strategy.InstantExecution{}, nil
+	case BackendLive:
+		return nil, ErrLiveUnavailable
+	default:
+		return NewPaper(market, config), nil
+	}
+}
+
+// parseVenueValues parses "venue=value,venue=value" into values
+func parseVenueValues[V any](s string, parse func(string) (V, error), values map[string]V) error {
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		venue, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("want venue=value, got %q", entry)
+		}
+		v, err := parse(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%s: %w", venue, err)
+		}
+		values[strings.ToLower(strings.TrimSpace(venue))] = v
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+package execution
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"hft-arbitrage-bot/strategy"
+)
+
+// Reasons a paper execution fills nothing
+var (
+	ErrNoMarket      = errors.New("no market data at fill time")
+	ErrPriceMoved    = errors.New("price moved away during latency")
+	ErrThinLiquidity = errors.New("fill below minimum fill ratio")
+)
+
+// Market returns a venue's quote for a symbol, with its book where the venue
+// streams depth, as it is at time t
+type Market interface {
+	At(venue, symbol string, t time.Time) (strategy.Quote, bool)
+}
+
+// LatestQuotes adapts a lookup of the latest quotes, such as
+// exchange.LatestQuote, to a Market for live paper trading. It cannot look
+// ahead, so every leg fills against the book as it is when the orders are
+// sent and t is ignored.
+type LatestQuotes func(venue, symbol string) (strategy.Quote, bool)
+
+// At returns the latest quote
+func (f LatestQuotes) At(venue, symbol string, t time.Time) (strategy.Quote, bool) {
+	return f(venue, symbol)
+}
+
+// Stats sums up what a paper backend did. Quantities of triangles count in
+// their start asset.
+type Stats struct {
+	Attempts          int     `json:"attempts"`
+	Filled            int     `json:"filled"`
+	PartialFills      int     `json:"partial_fills"`
+	PriceMoved        int     `json:"price_moved"`
+	ThinLiquidity     int     `json:"thin_liquidity"`
+	RequestedQuantity float64 `json:"requested_quantity"`
+	FilledQuantity    float64 `json:"filled_quantity"`
+}
+
+// FillRatio is the share of the requested quantity that was filled
+func (s Stats) FillRatio() float64 {
+	if s.RequestedQuantity == 0 {
+		return 0
+	}
+	return s.FilledQuantity / s.RequestedQuantity
+}
+
+// Paper fills arbitrages the way venues would fill the orders. Both legs are
+// sent when the opportunity is detected and reach their venues after that
+// venue's latency. They are immediate-or-cancel limit orders at the detected
+// price, so they only take levels that are still at least that good, only the
+// part of each level not queued ahead of us, and they pay taker fees. Both
+// legs are filled for the smaller of the two fills; the excess of the larger
+// leg is assumed to be unwound flat. The legs of a triangle are sent together
+// the same way and the cycle fills for the smallest share any leg got.
+type Paper struct {
+	market Market
+	config Config
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// NewPaper returns a backend that fills against market, live quotes or
+// recorded data, as of each leg's arrival. Execute never blocks the strategy:
+// fills are timestamped at each leg's arrival, now plus the venue's latency,
+// without waiting for it.
+func NewPaper(market Market, config Config) *Paper {
+	return &Paper{market: market, config: config}
+}
+
+// Stats returns the execution statistics so far
+func (p *Paper) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+// leg is one side of an arbitrage on its way to a venue
+type leg struct {
+	venue  string
+	symbol string
+	bid    bool    // a sell, which takes bids
+	limit  float64 // detected price
+	fee    float64 // taker rate
+	arrive time.Time
+	levels []strategy.PriceLevel // available at arrival, best first
+}
+
+// Execute implements strategy.ExecutionModel
+func (p *Paper) Execute(opp strategy.ArbitrageOpportunity, quantity float64, now time.Time) (strategy.Execution, error) {
+	if len(opp.Legs) > 0 {
+		return p.executeCycle(opp, quantity, now)
+	}
+
+	buySymbol, sellSymbol := opp.LegSymbols()
+	buy := &leg{venue: opp.BuyExchange, symbol: buySymbol, limit: opp.BuyPrice, fee: opp.BuyFee, arrive: now.Add(p.latency(opp.BuyExchange))}
+	sell := &leg{venue: opp.SellExchange, symbol: sellSymbol, bid: true, limit: opp.SellPrice, fee: opp.SellFee, arrive: now.Add(p.latency(opp.SellExchange))}
+	if !p.arrive([]*leg{buy, sell}) {
+		p.record(quantity, 0, ErrNoMarket)
+		return strategy.Execution{}, ErrNoMarket
+	}
+
+	filled := min(fillable(buy.levels, quantity), fillable(sell.levels, quantity))
+	if filled == 0 {
+		p.record(quantity, 0, ErrPriceMoved)
+		return strategy.Execution{}, ErrPriceMoved
+	}
+	if filled < quantity*p.config.MinFillRatio {
+		p.record(quantity, 0, ErrThinLiquidity)
+		return strategy.Execution{}, fmt.Errorf("%w: %.6f of %.6f", ErrThinLiquidity, filled, quantity)
+	}
+	p.record(quantity, filled, nil)
+
+	buyPrice := averagePrice(buy.levels, filled)
+	sellPrice := averagePrice(sell.levels, filled)
+	return strategy.Execution{
+		Quantity:      filled,
+		BuyPrice:      buyPrice * (1 + buy.fee),
+		SellPrice:     sellPrice * (1 - sell.fee),
+		Time:          later(buy.arrive, sell.arrive),
+		BuyFee:        filled * buyPrice * buy.fee,
+		SellFee:       filled * sellPrice * sell.fee,
+		BuyLiquidity:  strategy.LiquidityTaker,
+		SellLiquidity: strategy.LiquidityTaker,
+	}, nil
+}
+
+// executeCycle fills the legs of a triangle or graph cycle. Every leg is
+// sized from the same start quantity at detection, so a leg that gets less
+// than it asked for scales the whole cycle down. Transfers draw on inventory
+// already held at the destination, so they complete at once at their cost.
+func (p *Paper) executeCycle(opp strategy.ArbitrageOpportunity, quantity float64, now time.Time) (strategy.Execution, error) {
+	legs := make([]*leg, len(opp.Legs)) // nil for transfers
+	var orders []*leg
+	for i, l := range opp.Legs {
+		if l.Side == strategy.LegTransfer {
+			continue
+		}
+		legs[i] = &leg{venue: l.Venue, symbol: l.Symbol, bid: l.Side == "SELL", limit: l.Price, fee: l.Fee, arrive: now.Add(p.latency(l.Venue))}
+		orders = append(orders, legs[i])
+	}
+	if !p.arrive(orders) {
+		p.record(quantity, 0, ErrNoMarket)
+		return strategy.Execution{}, ErrNoMarket
+	}
+
+	ratio := 1.0
+	for i, l := range legs {
+		if l == nil {
+			continue
+		}
+		requested := quantity * opp.Legs[i].Quantity
+		ratio = min(ratio, fillable(l.levels, requested)/requested)
+	}
+	filled := quantity * ratio
+	if filled == 0 {
+		p.record(quantity, 0, ErrPriceMoved)
+		return strategy.Execution{}, ErrPriceMoved
+	}
+	if ratio < p.config.MinFillRatio {
+		p.record(quantity, 0, ErrThinLiquidity)
+		return strategy.Execution{}, fmt.Errorf("%w: %.6f of %.6f", ErrThinLiquidity, filled, quantity)
+	}
+	p.record(quantity, filled, nil)
+
+	fills := make([]strategy.LegFill, len(legs))
+	last := now
+	for i, l := range legs {
+		legQuantity := filled * opp.Legs[i].Quantity
+		if l == nil {
+			fills[i] = strategy.LegFill{Price: 1, Quantity: legQuantity, FeeRate: opp.Legs[i].Fee}
+			continue
+		}
+		fills[i] = strategy.LegFill{
+			Price:     averagePrice(l.levels, legQuantity),
+			Quantity:  legQuantity,
+			FeeRate:   l.fee,
+			Liquidity: strategy.LiquidityTaker,
+		}
+		last = later(last, l.arrive)
+	}
+	return strategy.CycleExecution(opp, filled, fills, last), nil
+}
+
+// arrive looks at each leg's venue as of the leg's arrival and keeps the
+// levels the leg can take. It reports false when a venue has no market data.
+func (p *Paper) arrive(legs []*leg) bool {
+	for _, l := range legs {
+		quote, ok := p.market.At(l.venue, l.symbol, l.arrive)
+		if !ok {
+			return false
+		}
+		l.levels = p.available(l, quote)
+	}
+	return true
+}
+
+func (p *Paper) latency(venue string) time.Duration {
+	if latency, ok := p.config.VenueLatency[venue]; ok {
+		return latency
+	}
+	return p.config.Latency
+}
+
+func (p *Paper) record(requested, filled float64, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stats.Attempts++
+	p.stats.RequestedQuantity += requested
+	p.stats.FilledQuantity += filled
+	switch {
+	case errors.Is(err, ErrPriceMoved):
+		p.stats.PriceMoved++
+	case errors.Is(err, ErrThinLiquidity):
+		p.stats.ThinLiquidity++
+	case err == nil:
+		p.stats.Filled++
+		if filled < requested {
+			p.stats.PartialFills++
+		}
+	}
+}
+
+// available keeps the levels within the leg's limit price, less the queue
+// ahead. A size of zero means the venue does not report sizes and is kept.
+func (p *Paper) available(l *leg, quote strategy.Quote) []strategy.PriceLevel {
+	levels := askLevels(quote)
+	withinLimit := func(price float64) bool { return price <= l.limit }
+	if l.bid {
+		levels = bidLevels(quote)
+		withinLimit = func(price float64) bool { return price >= l.limit }
+	}
+
+	var out []strategy.PriceLevel
+	for _, level := range levels {
+		if !withinLimit(level.Price) {
+			break
+		}
+		out = append(out, strategy.PriceLevel{Price: level.Price, Size: level.Size * (1 - p.config.QueueAhead)})
+	}
+	return out
+}
+
+// askLevels returns the asks of a quote, best first
+func askLevels(q strategy.Quote) []strategy.PriceLevel {
+	if q.Book != nil && len(q.Book.Asks) > 0 {
+		return q.Book.Asks
+	}
+	if q.Ask <= 0 {
+		return nil
+	}
+	return []strategy.PriceLevel{{Price: q.Ask, Size: q.AskSize}}
+}
+
+// bidLevels returns the bids of a quote, best first
+func bidLevels(q strategy.Quote) []strategy.PriceLevel {
+	if q.Book != nil && len(q.Book.Bids) > 0 {
+		return q.Book.Bids
+	}
+	if q.Bid <= 0 {
+		return nil
+	}
+	return []strategy.PriceLevel{{Price: q.Bid, Size: q.BidSize}}
+}
+
+// fillable returns how much of quantity the levels can take
+func fillable(levels []strategy.PriceLevel, quantity float64) float64 {
+	filled := 0.0
+	for _, level := range levels {
+		if level.Size == 0 {
+			return quantity
+		}
+		filled += level.Size
+		if filled >= quantity {
+			return quantity
+		}
+	}
+	return filled
+}
+
+// averagePrice walks the levels for quantity and returns the average price
+func averagePrice(levels []strategy.PriceLevel, quantity float64) float64 {
+	remaining, cost := quantity, 0.0
+	for _, level := range levels {
+		take := remaining
+		if level.Size > 0 {
+			take = min(level.Size, remaining)
+		}
+		cost += take * level.Price
+		remaining -= take
+		if remaining <= 0 {
+			break
+		}
+	}
+	return cost / quantity
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,158 @@
+package execution
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"hft-arbitrage-bot/strategy"
+)
+
+// quotes is a market of one quote per venue and symbol, valid from its
+// timestamp on
+type quotes map[string]strategy.Quote
+
+func (m quotes) At(venue, symbol string, t time.Time) (strategy.Quote, bool) {
+	q, ok := m[venue+"/"+strategy.NormalizeSymbol(symbol)]
+	if !ok || q.Timestamp.After(t) {
+		return strategy.Quote{}, false
+	}
+	return q, true
+}
+
+func TestPaperExecute(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	kraken := strategy.Quote{Exchange: "kraken", Symbol: "DOGE/USD", Bid: 0.0999, Ask: 0.1000, BidSize: 2000, AskSize: 2000, Timestamp: now}
+	binance := strategy.Quote{Exchange: "binance", Symbol: "DOGEUSDT", Bid: 0.1010, Ask: 0.1011, BidSize: 2000, AskSize: 2000, Timestamp: now}
+	opp := strategy.ArbitrageOpportunity{
+		BuyExchange: "kraken", SellExchange: "binance",
+		Symbol: "DOGE/USD", BuySymbol: "DOGE/USD", SellSymbol: "DOGEUSDT",
+		BuyPrice: 0.1000, SellPrice: 0.1010, BuyFee: 0.0026, SellFee: 0.001,
+	}
+
+	tests := []struct {
+		name         string
+		market       quotes
+		opp          func(o *strategy.ArbitrageOpportunity)
+		quantity     float64
+		wantQuantity float64
+		wantErr      error
+	}{
+		{name: "legs on their own venue's symbol", quantity: 1000, wantQuantity: 1000},
+		{name: "one symbol for both legs", opp: func(o *strategy.ArbitrageOpportunity) { o.BuySymbol, o.SellSymbol = "", "" }, quantity: 1000, wantErr: ErrNoMarket},
+		{name: "partial fill behind the queue", quantity: 2000, wantQuantity: 1500},
+		{name: "price moved away", opp: func(o *strategy.ArbitrageOpportunity) { o.BuyPrice = 0.0995 }, quantity: 1000, wantErr: ErrPriceMoved},
+		{name: "below the minimum fill ratio", quantity: 20000, wantErr: ErrThinLiquidity},
+		{
+			name:     "quote after arrival",
+			market:   quotes{"kraken/DOGEUSD": kraken, "binance/DOGEUSDT": func() strategy.Quote { q := binance; q.Timestamp = now.Add(time.Second); return q }()},
+			quantity: 1000,
+			wantErr:  ErrNoMarket,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			market := tt.market
+			if market == nil {
+				market = quotes{"kraken/DOGEUSD": kraken, "binance/DOGEUSDT": binance}
+			}
+			o := opp
+			if tt.opp != nil {
+				tt.opp(&o)
+			}
+			config := DefaultConfig()
+			config.VenueLatency["kraken"] = 120 * time.Millisecond
+			p := NewPaper(market, config)
+
+			fill, err := p.Execute(o, tt.quantity, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Execute() error = %v, want %v", err, tt.wantErr)
+			}
+			if stats := p.Stats(); stats.Attempts != 1 || (err == nil) != (stats.Filled == 1) {
+				t.Errorf("stats = %+v", stats)
+			}
+			if err != nil {
+				return
+			}
+			if math.Abs(fill.Quantity-tt.wantQuantity) > 1e-9 {
+				t.Errorf("Quantity = %v, want %v", fill.Quantity, tt.wantQuantity)
+			}
+			if !fill.Time.Equal(now.Add(120 * time.Millisecond)) {
+				t.Errorf("Time = %s, want the kraken leg's arrival", fill.Time)
+			}
+			if want := 0.1000 * (1 + 0.0026); math.Abs(fill.BuyPrice-want) > 1e-12 {
+				t.Errorf("BuyPrice = %v, want %v", fill.BuyPrice, want)
+			}
+			if want := 0.1010 * (1 - 0.001); math.Abs(fill.SellPrice-want) > 1e-12 {
+				t.Errorf("SellPrice = %v, want %v", fill.SellPrice, want)
+			}
+		})
+	}
+}
+
+func TestPaperDoesNotWait(t *testing.T) {
+	now := time.Now()
+	latest := LatestQuotes(func(venue, symbol string) (strategy.Quote, bool) {
+		return strategy.Quote{Exchange: venue, Symbol: symbol, Bid: 0.1010, Ask: 0.1000, Timestamp: now}, true
+	})
+	config := DefaultConfig()
+	config.Latency = time.Hour
+	p := NewPaper(latest, config)
+
+	opp := strategy.ArbitrageOpportunity{BuyExchange: "kraken", SellExchange: "binance", Symbol: "DOGEUSDT", BuyPrice: 0.1000, SellPrice: 0.1010}
+	done := make(chan strategy.Execution, 1)
+	go func() {
+		fill, err := p.Execute(opp, 1000, now)
+		if err != nil {
+			t.Errorf("Execute() error = %v", err)
+		}
+		done <- fill
+	}()
+	select {
+	case fill := <-done:
+		if !fill.Time.Equal(now.Add(time.Hour)) {
+			t.Errorf("Time = %s, want now plus the latency", fill.Time)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Execute waited out the latency")
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(c Config) bool
+		wantErr bool
+	}{
+		{name: "defaults", check: func(c Config) bool { return c.Backend == BackendPaper && c.Latency == 50*time.Millisecond }},
+		{
+			name: "venue latency and queue",
+			env:  map[string]string{"HFT_EXECUTION": "Instant", "HFT_PAPER_VENUE_LATENCY": "Kraken=120ms, binance=20ms", "HFT_PAPER_QUEUE_AHEAD": "0.5"},
+			check: func(c Config) bool {
+				return c.Backend == BackendInstant && c.VenueLatency["kraken"] == 120*time.Millisecond && c.VenueLatency["binance"] == 20*time.Millisecond && c.QueueAhead == 0.5
+			},
+		},
+		{name: "unknown backend", env: map[string]string{"HFT_EXECUTION": "fast"}, wantErr: true},
+		{name: "negative venue latency", env: map[string]string{"HFT_PAPER_VENUE_LATENCY": "kraken=-1ms"}, wantErr: true},
+		{name: "queue ahead of everything", env: map[string]string{"HFT_PAPER_QUEUE_AHEAD": "1"}, wantErr: true},
+		{name: "malformed maker fees", env: map[string]string{"HFT_PAPER_MAKER_FEES": "okx"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"HFT_EXECUTION", "HFT_PAPER_LATENCY", "HFT_PAPER_VENUE_LATENCY", "HFT_PAPER_QUEUE_AHEAD", "HFT_PAPER_MIN_FILL", "HFT_PAPER_MAKER_FEES"} {
+				t.Setenv(name, tt.env[name])
+			}
+			config, err := ConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConfigFromEnv() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && !tt.check(config) {
+				t.Errorf("ConfigFromEnv() = %+v", config)
+			}
+		})
+	}
+}
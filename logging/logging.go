@@ -0,0 +1,176 @@
+// Package logging configures the structured, leveled logger used across the
+// bot. Records are written as JSON (or text) through an asynchronous writer so
+// a slow terminal or disk never blocks the strategy loop.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"hft-arbitrage-bot/metrics"
+)
+
+var droppedLogs = metrics.NewCounter("hft_log_records_dropped_total",
+	"Log records dropped because the asynchronous log buffer was full.")
+
+// Options configures the process-wide logger
+type Options struct {
+	Level      slog.Level
+	Format     string // "json" or "text"
+	Output     io.Writer
+	BufferSize int // records buffered before new ones are dropped
+}
+
+// OptionsFromEnv reads HFT_LOG_LEVEL (debug, info, warn, error) and
+// HFT_LOG_FORMAT (json, text). Defaults are info and json.
+func OptionsFromEnv() (Options, error) {
+	opts := Options{Level: slog.LevelInfo, Format: "json", Output: os.Stderr, BufferSize: 4096}
+
+	if level := os.Getenv("HFT_LOG_LEVEL"); level != "" {
+		if err := opts.Level.UnmarshalText([]byte(level)); err != nil {
+			return opts, fmt.Errorf("invalid HFT_LOG_LEVEL %q: %w", level, err)
+		}
+	}
+	if format := strings.ToLower(os.Getenv("HFT_LOG_FORMAT")); format != "" {
+		if format != "json" && format != "text" {
+			return opts, fmt.Errorf("invalid HFT_LOG_FORMAT %q (want json or text)", format)
+		}
+		opts.Format = format
+	}
+	return opts, nil
+}
+
+// Setup installs the process-wide logger and returns a function that flushes
+// buffered records; call it before the process exits
+func Setup(opts Options) (flush func()) {
+	if opts.Output == nil {
+		opts.Output = os.Stderr
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 4096
+	}
+
+	writer := newAsyncWriter(opts.Output, opts.BufferSize)
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level}
+
+	var handler slog.Handler
+	if opts.Format == "text" {
+		handler = slog.NewTextHandler(writer, handlerOpts)
+	} else {
+		handler = slog.NewJSONHandler(writer, handlerOpts)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return writer.Close
+}
+
+// Component returns a logger tagged with component=name. It resolves the
+// process-wide handler on every record, so it is safe to create in package
+// variables before Setup has run.
+func Component(name string) *slog.Logger {
+	return slog.New(&deferredHandler{attrs: []slog.Attr{slog.String("component", name)}})
+}
+
+// deferredHandler forwards records to whatever handler slog.Default has at
+// the time of logging, adding its own attributes first
+type deferredHandler struct {
+	attrs []slog.Attr
+}
+
+func (h *deferredHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return slog.Default().Handler().Enabled(ctx, level)
+}
+
+func (h *deferredHandler) Handle(ctx context.Context, r slog.Record) error {
+	record := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	record.AddAttrs(h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		record.AddAttrs(a)
+		return true
+	})
+	return slog.Default().Handler().Handle(ctx, record)
+}
+
+func (h *deferredHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	merged := make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	merged = append(merged, h.attrs...)
+	merged = append(merged, attrs...)
+	return &deferredHandler{attrs: merged}
+}
+
+func (h *deferredHandler) WithGroup(name string) slog.Handler {
+	// Groups are not used on hot paths; bind to the current handler
+	return slog.Default().Handler().WithAttrs(h.attrs).WithGroup(name)
+}
+
+// asyncWriter hands formatted records to a background goroutine. When the
+// buffer is full records are dropped rather than blocking the caller.
+type asyncWriter struct {
+	out     io.Writer
+	records chan []byte
+	done    chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func newAsyncWriter(out io.Writer, size int) *asyncWriter {
+	w := &asyncWriter{
+		out:     out,
+		records: make(chan []byte, size),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write queues one record. slog handlers call Write once per record and may
+// reuse the buffer afterwards, so it is copied.
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	record := make([]byte, len(p))
+	copy(record, p)
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return w.out.Write(record)
+	}
+
+	select {
+	case w.records <- record:
+	default:
+		droppedLogs.Inc()
+	}
+	return len(p), nil
+}
+
+func (w *asyncWriter) run() {
+	defer close(w.done)
+	for record := range w.records {
+		w.out.Write(record)
+	}
+}
+
+// Close flushes queued records, waiting at most one second. Records logged
+// after Close are written synchronously.
+func (w *asyncWriter) Close() {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	close(w.records)
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+	case <-time.After(time.Second):
+	}
+}
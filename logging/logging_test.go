@@ -0,0 +1,138 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestOptionsFromEnv(t *testing.T) {
+	tests := []struct {
+		name       string
+		level      string
+		format     string
+		wantLevel  slog.Level
+		wantFormat string
+		wantErr    bool
+	}{
+		{name: "defaults", wantLevel: slog.LevelInfo, wantFormat: "json"},
+		{name: "debug text", level: "debug", format: "TEXT", wantLevel: slog.LevelDebug, wantFormat: "text"},
+		{name: "warn", level: "warn", wantLevel: slog.LevelWarn, wantFormat: "json"},
+		{name: "unknown level", level: "loud", wantErr: true},
+		{name: "unknown format", format: "xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HFT_LOG_LEVEL", tt.level)
+			t.Setenv("HFT_LOG_FORMAT", tt.format)
+			opts, err := OptionsFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OptionsFromEnv() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if opts.Level != tt.wantLevel || opts.Format != tt.wantFormat {
+				t.Errorf("got level %s format %s, want %s %s", opts.Level, opts.Format, tt.wantLevel, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestComponent(t *testing.T) {
+	previous := slog.Default()
+	defer slog.SetDefault(previous)
+
+	tests := []struct {
+		name    string
+		level   slog.Level
+		log     func(l *slog.Logger)
+		wantMsg string // empty when nothing should be written
+	}{
+		{name: "info at info", level: slog.LevelInfo, log: func(l *slog.Logger) { l.Info("connected", "venue", "okx") }, wantMsg: "connected"},
+		{name: "debug at info is dropped", level: slog.LevelInfo, log: func(l *slog.Logger) { l.Debug("quote", "venue", "okx") }},
+		{name: "debug at debug", level: slog.LevelDebug, log: func(l *slog.Logger) { l.Debug("quote", "venue", "okx") }, wantMsg: "quote"},
+	}
+
+	// Loggers are created before Setup, as package variables are
+	logger := Component("exchange")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			flush := Setup(Options{Level: tt.level, Format: "json", Output: &out})
+			tt.log(logger)
+			flush()
+
+			if tt.wantMsg == "" {
+				if out.Len() > 0 {
+					t.Errorf("unexpected record: %s", out.String())
+				}
+				return
+			}
+			var record map[string]any
+			if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+				t.Fatalf("decoding %q: %v", out.String(), err)
+			}
+			if record["msg"] != tt.wantMsg || record["component"] != "exchange" || record["venue"] != "okx" {
+				t.Errorf("record = %v", record)
+			}
+		})
+	}
+}
+
+func TestSampler(t *testing.T) {
+	tests := []struct {
+		name           string
+		interval       time.Duration
+		burst          int
+		calls          []string
+		wantAllowed    []bool
+		wantSuppressed []int
+	}{
+		{
+			name:           "burst per key",
+			interval:       time.Hour,
+			burst:          2,
+			calls:          []string{"okx", "okx", "okx", "binance", "okx"},
+			wantAllowed:    []bool{true, true, false, true, false},
+			wantSuppressed: []int{0, 0, 0, 0, 0},
+		},
+		{
+			name:           "zero burst logs nothing",
+			interval:       time.Hour,
+			burst:          0,
+			calls:          []string{"okx", "okx"},
+			wantAllowed:    []bool{false, false},
+			wantSuppressed: []int{0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSampler(tt.interval, tt.burst)
+			for i, key := range tt.calls {
+				ok, suppressed := s.Allow(key)
+				if ok != tt.wantAllowed[i] || suppressed != tt.wantSuppressed[i] {
+					t.Errorf("call %d (%s) = %v, %d; want %v, %d", i, key, ok, suppressed, tt.wantAllowed[i], tt.wantSuppressed[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSamplerReportsSuppressed(t *testing.T) {
+	s := NewSampler(50*time.Millisecond, 1)
+	s.Allow("okx")
+	for i := 0; i < 3; i++ {
+		if ok, _ := s.Allow("okx"); ok {
+			t.Fatalf("call %d allowed within the burst window", i)
+		}
+	}
+	time.Sleep(60 * time.Millisecond)
+	if ok, suppressed := s.Allow("okx"); !ok || suppressed != 3 {
+		t.Errorf("Allow() after the window = %v, %d; want true, 3", ok, suppressed)
+	}
+}
@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"sync"
+	"time"
+)
+
+// Sampler rate-limits hot-path log records per key. Each key may log burst
+// records per interval; the rest are counted and reported with the next
+// record that is let through.
+type Sampler struct {
+	interval time.Duration
+	burst    int
+
+	mu   sync.Mutex
+	keys map[string]*sampleWindow
+}
+
+type sampleWindow struct {
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+// NewSampler creates a sampler allowing burst records per key per interval
+func NewSampler(interval time.Duration, burst int) *Sampler {
+	return &Sampler{
+		interval: interval,
+		burst:    burst,
+		keys:     make(map[string]*sampleWindow),
+	}
+}
+
+// Allow reports whether a record for key may be logged now. When it returns
+// true, suppressed is the number of records dropped for key since the last
+// one that was allowed.
+func (s *Sampler) Allow(key string) (ok bool, suppressed int) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, exists := s.keys[key]
+	if !exists {
+		w = &sampleWindow{start: now}
+		s.keys[key] = w
+	}
+	if now.Sub(w.start) >= s.interval {
+		w.start = now
+		w.count = 0
+	}
+
+	if w.count >= s.burst {
+		w.suppressed++
+		return false, 0
+	}
+
+	w.count++
+	suppressed = w.suppressed
+	w.suppressed = 0
+	return true, suppressed
+}
@@ -2,7 +2,8 @@ package main
 
 import (
 	"bufio"
-	"log"
+	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"strings"
@@ -10,149 +11,458 @@ import (
 	"syscall"
 
 	"hft-arbitrage-bot/api"
+	"hft-arbitrage-bot/capture"
 	"hft-arbitrage-bot/exchange"
+	"hft-arbitrage-bot/execution"
+	"hft-arbitrage-bot/logging"
+	"hft-arbitrage-bot/metrics"
 	"hft-arbitrage-bot/strategy"
 )
 
+var logger = logging.Component("main")
+
 func main() {
-	log.Println("🚀 Starting HFT Arbitrage Bot")
+	// Structured logs go to stderr; the banner and console output stay on stdout
+	logOptions, err := logging.OptionsFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	flushLogs := logging.Setup(logOptions)
+	defer flushLogs()
+
+	// Two packages declaring one metric name with different types would
+	// leave one of them unexported
+	if err := metrics.Default.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("🚀 Starting HFT Arbitrage Bot")
+	logger.Info("starting", "log_level", logOptions.Level.String(), "log_format", logOptions.Format)
 
 	// Create a channel for quotes from all exchanges
 	quoteChan := make(chan strategy.Quote, 1000) // Buffered channel to handle high-frequency updates
 
-	// Create arbitrage strategy with 0.3% minimum spread, $1000 initial balance, $100 trade size
-	arbitrageStrategy := strategy.NewArbitrageStrategy(0.2, 1000.0, 100.0)
+	// Create the arbitrage strategies, $1000 initial balance and $100 trade size
+	// unless HFT_STRATEGIES sizes them. They share the exchange connections but
+	// each keeps its own P&L book and risk engine; the first one is served by
+	// the API and the console.
+	strategyConfigs, err := strategy.StrategiesFromEnv(1000.0, 100.0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	strategies := make([]*strategy.ArbitrageStrategy, len(strategyConfigs))
+	runtimeStrategies := make([]strategy.Strategy, len(strategyConfigs))
+	for i, config := range strategyConfigs {
+		strategies[i] = strategy.NewStrategy(config)
+		runtimeStrategies[i] = strategies[i]
+	}
+	arbitrageStrategy := strategies[0]
+	runtime := strategy.NewRuntime(runtimeStrategies...)
+
+	// Fill arbitrages with the configured backend; paper trading looks at the
+	// feeds' latest quotes once each venue's latency has passed
+	executionConfig, err := execution.ConfigFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	executionModel, err := execution.New(executionConfig, execution.LatestQuotes(exchange.LatestQuote))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ execution: %v\n", err)
+		os.Exit(1)
+	}
+	for _, s := range strategies {
+		s.GetPnLManager().SetExecutionModel(runtime.Executor(s.Name(), executionModel))
+	}
+
+	// Triangular cycles within one venue; the venue streams their extra books
+	triangles, err := strategy.TrianglesFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	for _, s := range strategies {
+		s.SetTriangles(triangles...)
+	}
 
-	// Start the arbitrage strategy in a goroutine
-	go arbitrageStrategy.RunArbitrageStrategy(quoteChan)
+	// N-leg cycles across venues, found by a bounded search of the market graph
+	graphConfig, err := strategy.GraphConfigFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	for _, s := range strategies {
+		s.SetGraph(graphConfig)
+	}
+
+	// Spot against the OKX and Bybit perpetuals, held for basis and funding
+	basisConfig, err := strategy.BasisConfigFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	for _, s := range strategies {
+		s.SetBasis(basisConfig)
+	}
+
+	// Passive orders on the cheapest maker venue, hedged with a taker order
+	// when they fill; priced with the maker fees the fills are charged
+	makerConfig, err := strategy.MakerConfigFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	makerConfig.Fees = executionConfig.MakerFees
+	if err := makerConfig.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	for _, s := range strategies {
+		s.SetMaker(makerConfig)
+	}
+
+	// Opportunities are selected by expected value, discounted for the
+	// latency and queue position the fills are simulated with
+	evConfig, err := strategy.EVConfigFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	evConfig.Latency, evConfig.VenueLatency, evConfig.QueueAhead = executionConfig.Latency, executionConfig.VenueLatency, executionConfig.QueueAhead
+	if err := evConfig.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	for _, s := range strategies {
+		s.SetEV(evConfig)
+	}
+
+	// Microstructure signals filter opportunities or raise the edge they need
+	signalConfig, err := strategy.SignalConfigFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	for _, s := range strategies {
+		s.SetSignals(signalConfig)
+	}
+
+	// Venue pairs cool down after an execution and a spread already entered
+	// is entered again only once it improved or its books moved
+	cooldownConfig, err := strategy.CooldownConfigFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	for _, s := range strategies {
+		s.SetCooldown(cooldownConfig)
+	}
+
+	// Start the strategy runtime in a goroutine; it fans the quotes out
+	go runtime.Run(quoteChan)
 
 	// Start P&L API server
-	pnlAPI := api.NewPnLAPI(arbitrageStrategy.GetPnLManager(), 8080)
+	apiConfig, err := api.ServerConfigFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	pnlAPI := api.NewPnLAPI(arbitrageStrategy, apiConfig)
+	pnlAPI.SetStrategies(strategies[1:]...)
 	pnlAPI.Start()
 
-	// Start all exchanges in separate goroutines
+	// Record raw market data for backtests when HFT_CAPTURE_DIR is set
+	captureConfig, err := capture.ConfigFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	var recorder *capture.Recorder
+	if captureConfig.Enabled() {
+		recorder, err = capture.NewRecorder(captureConfig)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ capture: %v\n", err)
+			os.Exit(1)
+		}
+		exchange.SetRecorder(recorder)
+	}
+
+	// Start all exchanges in separate goroutines; cancelling feedCtx stops them
+	feedCtx, stopFeeds := context.WithCancel(context.Background())
 	var wg sync.WaitGroup
 
 	// Start Binance
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		exchange.Binance(quoteChan)
+		exchange.Binance(feedCtx, quoteChan, strategy.VenueSymbols(triangles, "binance")...)
 	}()
 
 	// Start Kraken
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		exchange.Kraken(quoteChan)
+		exchange.Kraken(feedCtx, quoteChan)
 	}()
 
 	// Start OKX
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		exchange.OKX(quoteChan)
+		exchange.OKX(feedCtx, quoteChan)
 	}()
 
 	// Start Bybit
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		exchange.Bybit(quoteChan)
+		exchange.Bybit(feedCtx, quoteChan)
+	}()
+
+	// Start the Bybit perpetual stream
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		exchange.BybitPerp(feedCtx, quoteChan)
 	}()
 
 	// Start KuCoin
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		exchange.Kucoin(quoteChan)
+		exchange.Kucoin(feedCtx, quoteChan)
 	}()
 
-	log.Println("✅ All exchanges started successfully")
-	log.Println("📊 Monitoring for arbitrage opportunities...")
-	log.Println("💡 Minimum spread threshold: 0.3%")
-	log.Println("💰 Initial balance: $1000.00")
-	log.Println("📈 Trade size: $100.00")
-	log.Println("🌐 P&L API available at http://localhost:8080")
-	log.Println("")
-	log.Println("💡 Commands:")
-	log.Println("   - Press Enter to check P&L status")
-	log.Println("   - Press Ctrl+C to stop the bot")
-	log.Println("")
-	log.Println("🌐 API Endpoints:")
-	log.Println("   - GET /pnl - Full P&L status")
-	log.Println("   - GET /summary - P&L summary")
-	log.Println("   - GET /trades - Recent trades")
-	log.Println("   - GET /health - Health check")
-	log.Println("")
-	log.Println("📈 Exchanges:")
-	log.Println("   🟡 Binance")
-	log.Println("   🟣 Kraken")
-	log.Println("   ⚫️ OKX")
-	log.Println("   🟠 Bybit")
-	log.Println("   🟢 KuCoin")
+	fmt.Println("✅ All exchanges started successfully")
+	fmt.Println("📊 Monitoring for arbitrage opportunities...")
+	fmt.Println("💡 Minimum spread threshold: 0.3%")
+	if len(strategyConfigs) == 1 {
+		fmt.Printf("💰 Initial balance: $%.2f\n", strategyConfigs[0].InitialBalance)
+		fmt.Printf("📈 Trade size: $%.2f\n", strategyConfigs[0].TradeSize)
+	} else {
+		for i, config := range strategyConfigs {
+			fmt.Printf("🧩 Strategy %s: %s, $%.2f balance, $%.2f trades, $%.2f daily loss limit\n",
+				config.Name, strings.Join(strategies[i].Types(), ", "), config.InitialBalance, config.TradeSize, config.Limits.MaxDailyLoss)
+		}
+	}
+	if executionConfig.Backend == execution.BackendPaper {
+		fmt.Printf("🧪 Execution: paper trading (%s latency, %.0f%% queue ahead)\n", executionConfig.Latency, executionConfig.QueueAhead*100)
+	} else {
+		fmt.Printf("🧪 Execution: %s\n", executionConfig.Backend)
+	}
+	for _, t := range triangles {
+		if t.Venue != "binance" {
+			fmt.Printf("⚠️  Triangle %s: only binance streams the extra books, it will not trade\n", t)
+			continue
+		}
+		fmt.Printf("🔺 Triangular arbitrage: %s\n", t)
+	}
+	if graphConfig.Enabled {
+		fmt.Printf("🕸️  Cycle search: up to %d legs within %d edge relaxations\n", graphConfig.MaxLegs, graphConfig.Budget)
+	}
+	if basisConfig.Enabled {
+		fmt.Printf("⏳ Basis trade: %s, open above %.1f%%/yr, close below %.1f%%/yr\n",
+			strings.Join(basisConfig.Venues, ", "), basisConfig.EntryPercent, basisConfig.ExitPercent)
+	}
+	if evConfig.Enabled {
+		fmt.Printf("🎲 Expected value: above %.3f%% after quote decay, adverse moves and partial fills (%s latency)\n",
+			evConfig.MinEVPercent, evConfig.Latency)
+	}
+	if signalConfig.Enabled() {
+		fmt.Printf("📡 Signals: microprice weight %.2f, drift weight %.2f over %s, filters imbalance %.2f, flow %.2f, drift %.3f%% (0 = off)\n",
+			signalConfig.MicropriceWeight, signalConfig.DriftWeight, signalConfig.Horizon,
+			signalConfig.MaxImbalance, signalConfig.MaxTradeFlow, signalConfig.MaxDriftPercent)
+	}
+	fmt.Printf("⏱️  Cooldown: %s per venue pair, %d open per pair, re-entry on +%.2f%% edge or refreshed books\n",
+		cooldownConfig.Cooldown, cooldownConfig.MaxOpen, cooldownConfig.ReentryEdge)
+	if makerConfig.Enabled {
+		fmt.Printf("🪤 Maker-taker: resting on %s for %.2f%% net, re-priced past %.2f%%, cancelled on quotes older than %s\n",
+			strings.Join(makerConfig.Venues, ", "), makerConfig.EdgePercent, makerConfig.Reprice, makerConfig.MaxQuoteAge)
+	}
+	scheme := "http"
+	if apiConfig.TLS() {
+		scheme = "https"
+	}
+	fmt.Printf("🌐 P&L API available at %s://%s\n", scheme, apiConfig.Addr())
+	if recorder != nil {
+		fmt.Printf("🎥 Recording market data to %s\n", captureConfig.Dir)
+	}
+	if !apiConfig.AuthRequired() {
+		fmt.Println("⚠️  No API credentials configured: read endpoints are open, admin endpoints disabled")
+	}
+	fmt.Println("")
+	fmt.Println("💡 Commands:")
+	fmt.Println("   - Press Enter to check P&L status")
+	fmt.Println("   - Type 'kill' to halt trading, 'resume' to continue")
+	fmt.Println("   - Press Ctrl+C to stop the bot")
+	fmt.Println("")
+	fmt.Println("🌐 API Endpoints (under /api/v1, OpenAPI at /api/v1/openapi.json):")
+	fmt.Println("   - GET /pnl - Full P&L status")
+	fmt.Println("   - GET /summary - P&L summary")
+	fmt.Println("   - GET /trades - Recent trades")
+	fmt.Println("   - GET /attribution - P&L by venue pair, symbol and hour")
+	fmt.Println("   - GET /basis - Open basis positions with funding and carry")
+	fmt.Println("   - GET /orders - Resting maker orders and fills waiting for a hedge")
+	fmt.Println("   - GET /strategies - Every strategy with its P&L and kill switch state")
+	fmt.Println("   - GET /health - Health check")
+	fmt.Println("   - GET /health/live, /health/ready - Liveness and readiness probes")
+	fmt.Println("   - GET /risk - Risk limits, exposure and kill switch state")
+	fmt.Println("   - POST /risk/kill, /risk/resume - Kill switch")
+	fmt.Println("   - GET /breakers - Circuit breaker state per venue")
+	fmt.Println("   - GET /quotes - Latest quote per venue with age")
+	fmt.Println("   - GET /book/{venue}/{symbol}?depth=N - Top N levels of a venue's book")
+	fmt.Println("   - GET /spreads - Gross and net edge for every venue pair")
+	fmt.Println("   - GET /control, POST /control/... - Runtime parameters (admin scope)")
+	fmt.Println("   - GET /stream?topics=quotes,opportunities,trades,pnl - Live events (WebSocket or SSE)")
+	fmt.Println("   - GET /metrics (unversioned) - Prometheus metrics")
+	fmt.Println("")
+	fmt.Println("📈 Exchanges:")
+	fmt.Println("   🟡 Binance")
+	fmt.Println("   🟣 Kraken")
+	fmt.Println("   ⚫️ OKX (spot and perpetual)")
+	fmt.Println("   🟠 Bybit (spot and perpetual)")
+	fmt.Println("   🟢 KuCoin")
 
 	// Start a goroutine to handle user input for P&L checking
-	go handleUserInput(arbitrageStrategy)
+	go handleUserInput(strategies)
 
 	// Wait for interrupt signal to gracefully shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	<-sigChan
 
-	log.Println("🛑 Shutting down HFT Arbitrage Bot...")
-	
+	fmt.Println("🛑 Shutting down HFT Arbitrage Bot...")
+	logger.Info("shutting down")
+
 	// Stop the API server
 	pnlAPI.Stop()
-	
+
+	// Stop the feeds and wait for them to exit; only then is nothing left to
+	// send on the quote channel or to the recorder
+	stopFeeds()
+	wg.Wait()
+
+	// Write out buffered market data
+	if recorder != nil {
+		if err := recorder.Close(); err != nil {
+			logger.Error("closing capture failed", "err", err)
+		}
+	}
+
 	// Close the quote channel to stop the strategy
 	close(quoteChan)
-	
-	// Wait for all goroutines to finish
-	wg.Wait()
-	
+
 	// Print final P&L status
-	log.Println("")
-	log.Println("=== FINAL P&L REPORT ===")
-	arbitrageStrategy.GetPnLManager().PrintPnLStatus()
-	
-	log.Println("✅ HFT Arbitrage Bot stopped successfully")
+	fmt.Println("")
+	fmt.Println("=== FINAL P&L REPORT ===")
+	for _, s := range strategies {
+		if len(strategies) > 1 {
+			fmt.Printf("--- %s ---\n", s.Name())
+		}
+		s.GetPnLManager().PrintPnLStatus()
+	}
+
+	fmt.Println("✅ HFT Arbitrage Bot stopped successfully")
+	logger.Info("stopped")
 }
 
-// handleUserInput handles user input for checking P&L status
-func handleUserInput(arbitrageStrategy *strategy.ArbitrageStrategy) {
+// handleUserInput handles user input for checking P&L status. Reports are
+// about the first strategy; the kill switch halts them all.
+func handleUserInput(strategies []*strategy.ArbitrageStrategy) {
+	arbitrageStrategy := strategies[0]
 	scanner := bufio.NewScanner(os.Stdin)
 	for scanner.Scan() {
 		input := strings.TrimSpace(scanner.Text())
-		
+
+		// "kill" accepts an optional free-text reason
+		if fields := strings.Fields(input); len(fields) > 0 && fields[0] == "kill" {
+			reason := strings.TrimSpace(strings.TrimPrefix(input, "kill"))
+			if reason == "" {
+				reason = "manual halt from console"
+			}
+			for _, s := range strategies {
+				s.GetRiskEngine().Halt(reason)
+			}
+			continue
+		}
+
 		switch input {
 		case "":
 			// Just Enter pressed - show P&L status
 			arbitrageStrategy.GetPnLManager().PrintPnLStatus()
-			
+
 		case "pnl", "P&L", "status":
 			// Explicit P&L request
 			arbitrageStrategy.GetPnLManager().PrintPnLStatus()
-			
+
 		case "trades", "history":
 			// Show recent trade history
 			trades := arbitrageStrategy.GetPnLManager().GetTradeHistory(10)
-			log.Println("=== RECENT TRADES ===")
+			fmt.Println("=== RECENT TRADES ===")
 			for i, trade := range trades {
-				log.Printf("%d. %s %s %.4f %s at $%.2f on %s", 
+				fmt.Printf("%d. %s %s %.4f %s at $%.2f on %s\n",
 					i+1, trade.Type, trade.Symbol, trade.Quantity, trade.Exchange, trade.Price, trade.Timestamp.Format("15:04:05"))
 			}
-			log.Println("====================")
-			
+			fmt.Println("====================")
+
+		case "resume":
+			for _, s := range strategies {
+				s.GetRiskEngine().Resume()
+			}
+
+		case "strategies":
+			fmt.Println("=== STRATEGIES ===")
+			for _, s := range strategies {
+				halted, reason := s.GetRiskEngine().Halted()
+				state := "✅"
+				if halted {
+					state = "⛔"
+				}
+				fmt.Printf("%s %s (%s): %s %s\n", state, s.Name(), strings.Join(s.Types(), ", "), s.GetPnLManager().GetPnLSummary(), reason)
+			}
+			fmt.Println("==================")
+
+		case "risk":
+			status := arbitrageStrategy.GetRiskEngine().Status()
+			fmt.Println("=== RISK STATUS ===")
+			fmt.Printf("Halted: %v %s\n", status.Halted, status.HaltReason)
+			fmt.Printf("Daily P&L: $%.2f (limit -$%.2f)\n", status.DailyPnL, status.Limits.MaxDailyLoss)
+			fmt.Printf("Consecutive losses: %d (limit %d)\n", status.ConsecutiveLosses, status.Limits.MaxConsecutiveLosses)
+			fmt.Printf("Orders last second: %d (limit %d)\n", status.OrdersLastSecond, status.Limits.MaxOrdersPerSecond)
+			for key, exposure := range status.Exposure {
+				fmt.Printf("Exposure %s: $%.2f (limit $%.2f)\n", key, exposure, status.Limits.MaxVenueExposure)
+			}
+			fmt.Printf("Rejections: %d %s\n", status.Rejections, status.LastRejection)
+			fmt.Println("===================")
+
+		case "breakers":
+			fmt.Println("=== CIRCUIT BREAKERS ===")
+			for _, b := range arbitrageStrategy.GetCircuitBreakers().Status() {
+				if b.Paused {
+					fmt.Printf("⛔ %s paused until %s: %s\n", b.Venue, b.PausedUntil.Format("15:04:05"), b.Reason)
+				} else {
+					fmt.Printf("✅ %s ok (trips: %d)\n", b.Venue, b.Trips)
+				}
+			}
+			fmt.Println("========================")
+
 		case "help":
-			log.Println("Available commands:")
-			log.Println("  Enter - Check P&L status")
-			log.Println("  pnl   - Check P&L status")
-			log.Println("  trades - Show recent trade history")
-			log.Println("  help  - Show this help")
-			
+			fmt.Println("Available commands:")
+			fmt.Println("  Enter - Check P&L status")
+			fmt.Println("  pnl   - Check P&L status")
+			fmt.Println("  trades - Show recent trade history")
+			fmt.Println("  risk  - Show risk limits and exposure")
+			fmt.Println("  strategies - Show every strategy's P&L")
+			fmt.Println("  kill [reason] - Halt all trading")
+			fmt.Println("  resume - Release the kill switch")
+			fmt.Println("  breakers - Show circuit breaker state per venue")
+			fmt.Println("  help  - Show this help")
+
 		default:
-			log.Printf("Unknown command: %s. Type 'help' for available commands.", input)
+			fmt.Printf("Unknown command: %s. Type 'help' for available commands.\n", input)
 		}
 	}
 }
@@ -0,0 +1,381 @@
+// Package metrics implements the small subset of Prometheus instrumentation
+// the bot needs (counters, gauges and histograms, optionally labelled) and
+// writes them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Default is the registry served on /metrics
+var Default = NewRegistry()
+
+// collector is a named metric family that can write its samples
+type collector interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Registry holds metric families in registration order
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []collector
+	byName     map[string]collector
+	errs       []error
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{byName: make(map[string]collector)}
+}
+
+// register adds a metric family. Registering a name twice returns the
+// existing family so that package-level metrics can be declared anywhere.
+func (r *Registry) register(c collector) collector {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.byName[c.name()]; ok {
+		return existing
+	}
+	r.byName[c.name()] = c
+	r.collectors = append(r.collectors, c)
+	return c
+}
+
+// Err returns the registration conflicts seen so far: a name registered
+// twice with a different type or label set. The later metric still counts
+// but is not exported.
+func (r *Registry) Err() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return errors.Join(r.errs...)
+}
+
+// conflict records a registration conflict for Err
+func (r *Registry) conflict(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errs = append(r.errs, err)
+}
+
+// WritePrometheus writes every metric family in the Prometheus text format
+func (r *Registry) WritePrometheus(w io.Writer) {
+	r.mu.RLock()
+	collectors := make([]collector, len(r.collectors))
+	copy(collectors, r.collectors)
+	r.mu.RUnlock()
+
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// ContentType is the content type of the text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// atomicFloat is a float64 updated with compare-and-swap
+type atomicFloat struct {
+	bits uint64
+}
+
+func (f *atomicFloat) add(v float64) {
+	for {
+		old := atomic.LoadUint64(&f.bits)
+		next := math.Float64bits(math.Float64frombits(old) + v)
+		if atomic.CompareAndSwapUint64(&f.bits, old, next) {
+			return
+		}
+	}
+}
+
+func (f *atomicFloat) set(v float64) {
+	atomic.StoreUint64(&f.bits, math.Float64bits(v))
+}
+
+func (f *atomicFloat) load() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&f.bits))
+}
+
+// Counter is a monotonically increasing value
+type Counter struct {
+	value atomicFloat
+}
+
+// Inc adds one to the counter
+func (c *Counter) Inc() { c.value.add(1) }
+
+// Add adds a non-negative value to the counter
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		return
+	}
+	c.value.add(v)
+}
+
+// Value returns the current count
+func (c *Counter) Value() float64 { return c.value.load() }
+
+// Gauge is a value that can go up and down
+type Gauge struct {
+	value atomicFloat
+}
+
+// Set sets the gauge
+func (g *Gauge) Set(v float64) { g.value.set(v) }
+
+// Add adds to the gauge
+func (g *Gauge) Add(v float64) { g.value.add(v) }
+
+// Value returns the current gauge value
+func (g *Gauge) Value() float64 { return g.value.load() }
+
+// Histogram counts observations into cumulative buckets
+type Histogram struct {
+	mu      sync.Mutex
+	bounds  []float64
+	counts  []uint64
+	sum     float64
+	samples uint64
+}
+
+func newHistogram(bounds []float64) *Histogram {
+	return &Histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+// Observe records one observation
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.samples++
+}
+
+// LatencyBuckets covers decision latencies from 10µs to 1s
+var LatencyBuckets = []float64{0.00001, 0.000025, 0.00005, 0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+
+// DurationBuckets covers how long market conditions last, from 100ms to 10
+// minutes
+var DurationBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600}
+
+// family is the shared implementation behind every metric type. Unlabelled
+// metrics are a family with a single child under the empty key.
+type family[T any] struct {
+	metricName string
+	help       string
+	kind       string
+	labels     []string
+	newChild   func() T
+	writeChild func(w io.Writer, name, labels string, child T)
+
+	mu       sync.RWMutex
+	children map[string]T
+	values   map[string][]string
+}
+
+func (f *family[T]) name() string { return f.metricName }
+
+// with returns the child for a set of label values, creating it on first use
+func (f *family[T]) with(values ...string) T {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.metricName, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	f.mu.RLock()
+	child, ok := f.children[key]
+	f.mu.RUnlock()
+	if ok {
+		return child
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if child, ok := f.children[key]; ok {
+		return child
+	}
+	child = f.newChild()
+	f.children[key] = child
+	f.values[key] = append([]string(nil), values...)
+	return child
+}
+
+func (f *family[T]) write(w io.Writer) {
+	f.mu.RLock()
+	keys := make([]string, 0, len(f.children))
+	for k := range f.children {
+		keys = append(keys, k)
+	}
+	f.mu.RUnlock()
+	sort.Strings(keys)
+
+	fmt.Fprintf(w, "# HELP %s %s\n", f.metricName, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.metricName, f.kind)
+	for _, k := range keys {
+		f.mu.RLock()
+		child, values := f.children[k], f.values[k]
+		f.mu.RUnlock()
+		f.writeChild(w, f.metricName, formatLabels(f.labels, values), child)
+	}
+}
+
+// newFamily registers a family on r, or returns the one already registered
+// under the name. When that one has a different type or label set it returns
+// the new, unregistered family with an error, which is also kept for Err.
+func newFamily[T any](r *Registry, name, help, kind string, labels []string, newChild func() T, writeChild func(io.Writer, string, string, T)) (*family[T], error) {
+	f := &family[T]{
+		metricName: name,
+		help:       help,
+		kind:       kind,
+		labels:     labels,
+		newChild:   newChild,
+		writeChild: writeChild,
+		children:   make(map[string]T),
+		values:     make(map[string][]string),
+	}
+	existing, ok := r.register(f).(*family[T])
+	if !ok || existing.kind != kind || !slices.Equal(existing.labels, labels) {
+		err := fmt.Errorf("metrics: %s is already registered with a different type or labels", name)
+		r.conflict(err)
+		return f, err
+	}
+	return existing, nil
+}
+
+func writeCounter(w io.Writer, name, labels string, c *Counter) {
+	fmt.Fprintf(w, "%s%s %s\n", name, labels, formatValue(c.Value()))
+}
+
+func writeGauge(w io.Writer, name, labels string, g *Gauge) {
+	fmt.Fprintf(w, "%s%s %s\n", name, labels, formatValue(g.Value()))
+}
+
+func writeHistogram(w io.Writer, name, labels string, h *Histogram) {
+	h.mu.Lock()
+	counts := append([]uint64(nil), h.counts...)
+	sum, samples := h.sum, h.samples
+	h.mu.Unlock()
+
+	for i, bound := range h.bounds {
+		fmt.Fprintf(w, "%s_bucket%s %d\n", name, addLabel(labels, "le", formatValue(bound)), counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket%s %d\n", name, addLabel(labels, "le", "+Inf"), samples)
+	fmt.Fprintf(w, "%s_sum%s %s\n", name, labels, formatValue(sum))
+	fmt.Fprintf(w, "%s_count%s %d\n", name, labels, samples)
+}
+
+// CounterVec is a counter partitioned by labels
+type CounterVec struct{ f *family[*Counter] }
+
+// WithLabelValues returns the counter for the given label values
+func (v *CounterVec) WithLabelValues(values ...string) *Counter { return v.f.with(values...) }
+
+// GaugeVec is a gauge partitioned by labels
+type GaugeVec struct{ f *family[*Gauge] }
+
+// WithLabelValues returns the gauge for the given label values
+func (v *GaugeVec) WithLabelValues(values ...string) *Gauge { return v.f.with(values...) }
+
+// HistogramVec is a histogram partitioned by labels
+type HistogramVec struct{ f *family[*Histogram] }
+
+// WithLabelValues returns the histogram for the given label values
+func (v *HistogramVec) WithLabelValues(values ...string) *Histogram { return v.f.with(values...) }
+
+// NewCounter registers an unlabelled counter on the default registry
+func NewCounter(name, help string) *Counter {
+	return NewCounterVec(name, help).WithLabelValues()
+}
+
+// NewCounterVec registers a labelled counter on the default registry. A
+// conflicting registration is reported by Default.Err.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	f, _ := newFamily(Default, name, help, "counter", labels, func() *Counter { return &Counter{} }, writeCounter)
+	return &CounterVec{f}
+}
+
+// NewGauge registers an unlabelled gauge on the default registry
+func NewGauge(name, help string) *Gauge {
+	return NewGaugeVec(name, help).WithLabelValues()
+}
+
+// NewGaugeVec registers a labelled gauge on the default registry
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	f, _ := newFamily(Default, name, help, "gauge", labels, func() *Gauge { return &Gauge{} }, writeGauge)
+	return &GaugeVec{f}
+}
+
+// NewHistogram registers an unlabelled histogram on the default registry
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	return NewHistogramVec(name, help, buckets).WithLabelValues()
+}
+
+// NewHistogramVec registers a labelled histogram on the default registry
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	bounds := append([]float64(nil), buckets...)
+	sort.Float64s(bounds)
+	f, _ := newFamily(Default, name, help, "histogram", labels, func() *Histogram { return newHistogram(bounds) }, writeHistogram)
+	return &HistogramVec{f}
+}
+
+// formatLabels renders {a="x",b="y"}, or nothing for unlabelled metrics
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, n := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(n)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(values[i]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// addLabel appends one more label to an already formatted label set
+func addLabel(labels, name, value string) string {
+	pair := name + `="` + escapeLabel(value) + `"`
+	if labels == "" {
+		return "{" + pair + "}"
+	}
+	return labels[:len(labels)-1] + "," + pair + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	tests := []struct {
+		name   string
+		record func(r *Registry)
+		want   string
+	}{
+		{
+			name: "unlabelled counter",
+			record: func(r *Registry) {
+				f, _ := newFamily(r, "test_total", "Things counted.", "counter", nil, func() *Counter { return &Counter{} }, writeCounter)
+				c := f.with()
+				c.Inc()
+				c.Add(2)
+				c.Add(-1)
+			},
+			want: "# HELP test_total Things counted.\n# TYPE test_total counter\ntest_total 3\n",
+		},
+		{
+			name: "labelled gauge, children sorted and escaped",
+			record: func(r *Registry) {
+				f, _ := newFamily(r, "test_gauge", "A gauge\nover two lines.", "gauge", []string{"venue"}, func() *Gauge { return &Gauge{} }, writeGauge)
+				f.with("okx").Set(1.5)
+				f.with(`bin"ance`).Set(math.Inf(1))
+			},
+			want: "# HELP test_gauge A gauge\\nover two lines.\n# TYPE test_gauge gauge\n" +
+				"test_gauge{venue=\"bin\\\"ance\"} +Inf\ntest_gauge{venue=\"okx\"} 1.5\n",
+		},
+		{
+			name: "histogram buckets are cumulative",
+			record: func(r *Registry) {
+				f, _ := newFamily(r, "test_seconds", "Durations.", "histogram", []string{"op"}, func() *Histogram { return newHistogram([]float64{0.1, 1}) }, writeHistogram)
+				h := f.with("scan")
+				h.Observe(0.05)
+				h.Observe(0.5)
+				h.Observe(2)
+			},
+			want: "# HELP test_seconds Durations.\n# TYPE test_seconds histogram\n" +
+				"test_seconds_bucket{op=\"scan\",le=\"0.1\"} 1\n" +
+				"test_seconds_bucket{op=\"scan\",le=\"1\"} 2\n" +
+				"test_seconds_bucket{op=\"scan\",le=\"+Inf\"} 3\n" +
+				"test_seconds_sum{op=\"scan\"} 2.55\n" +
+				"test_seconds_count{op=\"scan\"} 3\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			tt.record(r)
+			var b strings.Builder
+			r.WritePrometheus(&b)
+			if b.String() != tt.want {
+				t.Errorf("WritePrometheus() =\n%s\nwant\n%s", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+	newCounter := func() *Counter { return &Counter{} }
+	newGauge := func() *Gauge { return &Gauge{} }
+
+	tests := []struct {
+		name     string
+		register func(r *Registry) error
+		wantErr  bool
+	}{
+		{
+			name: "same type and labels share the family",
+			register: func(r *Registry) error {
+				_, err := newFamily(r, "test_total", "", "counter", []string{"venue"}, newCounter, writeCounter)
+				return err
+			},
+		},
+		{
+			name: "different type",
+			register: func(r *Registry) error {
+				_, err := newFamily(r, "test_total", "", "gauge", []string{"venue"}, newGauge, writeGauge)
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "different labels",
+			register: func(r *Registry) error {
+				_, err := newFamily(r, "test_total", "", "counter", []string{"symbol"}, newCounter, writeCounter)
+				return err
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			first, _ := newFamily(r, "test_total", "", "counter", []string{"venue"}, newCounter, writeCounter)
+			first.with("okx").Inc()
+
+			err := tt.register(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("second registration error = %v, want error %v", err, tt.wantErr)
+			}
+			if (r.Err() != nil) != tt.wantErr {
+				t.Errorf("Err() = %v, want error %v", r.Err(), tt.wantErr)
+			}
+			var b strings.Builder
+			r.WritePrometheus(&b)
+			if !strings.Contains(b.String(), "# TYPE test_total counter\n") || strings.Count(b.String(), "# TYPE") != 1 {
+				t.Errorf("exposition changed by the second registration:\n%s", b.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package replay
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"hft-arbitrage-bot/strategy"
+)
+
+// DecisionLog writes one line per strategy decision: opportunities detected,
+// rejected with their reason, executed and closed, basis positions opened
+// and their funding settlements, and maker orders posted, repriced and
+// cancelled. Lines hold only simulated times,
+// ids derived from them and fixed precision numbers, so two runs over the
+// same recording produce identical logs and a change shows up in a line diff.
+type DecisionLog struct {
+	w   *bufio.Writer
+	err error
+}
+
+// NewDecisionLog returns a log writing to w
+func NewDecisionLog(w io.Writer) *DecisionLog {
+	return &DecisionLog{w: bufio.NewWriter(w)}
+}
+
+// Event logs one strategy event; it is meant for backtest.Config.OnEvent
+func (l *DecisionLog) Event(now time.Time, event strategy.Event) {
+	var line string
+	switch data := event.Data.(type) {
+	case strategy.ArbitrageOpportunity:
+		line = fmt.Sprintf("detected %s %s buy=%s@%s sell=%s@%s eff_buy=%s eff_sell=%s spread_pct=%s",
+			data.ID, data.Symbol,
+			data.BuyExchange, price(data.BuyPrice), data.SellExchange, price(data.SellPrice),
+			price(data.EffBuyPrice), price(data.EffSellPrice), percent(data.SpreadPercent))
+		for _, leg := range data.Legs {
+			if leg.Side == strategy.LegTransfer {
+				line += fmt.Sprintf(" transfer=%s:%s>%s", leg.From, leg.Venue, leg.ToVenue)
+				continue
+			}
+			line += fmt.Sprintf(" %s=%s@%s", strings.ToLower(leg.Side), leg.Symbol, price(leg.Price))
+		}
+		if data.EV != nil {
+			line += fmt.Sprintf(" ev_pct=%s live=%s fill=%s", percent(data.EV.EVPercent), percent(data.EV.LiveProbability), percent(data.EV.FillRatio))
+		}
+	case strategy.RejectedOpportunity:
+		line = fmt.Sprintf("rejected %s reason=%s", data.Opportunity.ID, data.Reason)
+		if data.Error != "" {
+			line += " error=" + strconv.Quote(data.Error)
+		}
+	case strategy.RoundTrip:
+		line = fmt.Sprintf("executed %s %s %s->%s qty=%s buy=%s sell=%s fees=%s pnl=%s",
+			data.ID, data.Symbol, data.BuyExchange, data.SellExchange,
+			price(data.Quantity), price(data.BuyPrice), price(data.SellPrice), price(data.Fees), price(data.PnL))
+		if data.Funding != 0 {
+			line += " funding=" + price(data.Funding)
+		}
+	case strategy.BasisPosition:
+		line = fmt.Sprintf("opened %s %s %s %s qty=%s spot=%s perp=%s fees=%s",
+			data.ID, data.Venue, data.Symbol, data.Direction,
+			price(data.Quantity), price(data.SpotEntry), price(data.PerpEntry), price(data.Fees))
+	case strategy.RestingOrder:
+		line = fmt.Sprintf("%s %s %s %s %s qty=%s price=%s hedge=%s@%s",
+			event.Type, data.ID, data.Venue, data.Symbol, strings.ToLower(data.Side),
+			price(data.Quantity), price(data.Price), data.HedgeVenue, price(data.HedgePrice))
+		if data.CancelReason != "" {
+			line += " reason=" + data.CancelReason
+		}
+	case strategy.OpportunityLifecycle:
+		line = fmt.Sprintf("closed %s %s %s->%s outcome=%s sightings=%d duration_ms=%s peak_edge=%s",
+			data.ID, data.Type, data.BuyExchange, data.SellExchange, data.Outcome,
+			data.Sightings, strconv.FormatFloat(data.DurationMs, 'f', 3, 64), percent(data.PeakEdge))
+	case strategy.FundingPayment:
+		line = fmt.Sprintf("funding %s %s rate=%s mark=%s amount=%s",
+			data.PositionID, data.Symbol, price(data.Rate), price(data.MarkPrice), price(data.Amount))
+	default:
+		return
+	}
+	l.write(now.UTC().Format("2006-01-02T15:04:05.000000000Z") + " " + line + "\n")
+}
+
+// Err flushes the log and returns the first write error
+func (l *DecisionLog) Err() error {
+	if l.err == nil {
+		l.err = l.w.Flush()
+	}
+	return l.err
+}
+
+func (l *DecisionLog) write(line string) {
+	if l.err != nil {
+		return
+	}
+	_, l.err = l.w.WriteString(line)
+}
+
+func price(v float64) string {
+	return strconv.FormatFloat(v, 'f', 8, 64)
+}
+
+func percent(v float64) string {
+	return strconv.FormatFloat(v, 'f', 4, 64)
+}
+
+// Diff compares a decision log against a golden file and describes the first
+// differences, or returns "" when they match
+func Diff(golden string, got []byte) (string, error) {
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		return "", err
+	}
+	wantLines := strings.Split(string(want), "\n")
+	gotLines := strings.Split(string(got), "\n")
+
+	const maxShown = 10
+	var b strings.Builder
+	shown := 0
+	for i := 0; i < max(len(wantLines), len(gotLines)) && shown < maxShown; i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w == g {
+			continue
+		}
+		fmt.Fprintf(&b, "line %d:\n  - %s\n  + %s\n", i+1, w, g)
+		shown++
+	}
+	if shown == 0 {
+		return "", nil
+	}
+	fmt.Fprintf(&b, "golden has %d lines, replay produced %d\n", len(wantLines)-1, len(gotLines)-1)
+	return b.String(), nil
+}
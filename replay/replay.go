@@ -0,0 +1,118 @@
+// Package replay reproduces a live session from a capture recording: raw
+// venue messages go through the real adapter parsers and then the strategy on
+// a simulated clock, and every decision is written to a diffable log.
+package replay
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+
+	"hft-arbitrage-bot/backtest"
+	"hft-arbitrage-bot/capture"
+	"hft-arbitrage-bot/exchange"
+	"hft-arbitrage-bot/strategy"
+)
+
+// ParseResult is the outcome of running recorded messages through the parsers
+type ParseResult struct {
+	Quotes     []strategy.Quote // in receive time order
+	Records    int
+	Unparsed   int            // messages that produced no quote, like acknowledgements and heartbeats
+	Mismatches int            // quotes that differ from the quote recorded live
+	Skipped    map[string]int // venue -> messages without a parser
+}
+
+// Parse reads capture files and feeds every raw message, in receive time
+// order, through a fresh parser per venue. Quotes that differ from the ones
+// the live adapter recorded are counted as mismatches: a parser change, or a
+// replay that starts after a stateful venue's book snapshot.
+func Parse(paths ...string) (*ParseResult, error) {
+	var records []capture.Record
+	if err := capture.Walk(paths, func(record capture.Record) error {
+		records = append(records, record)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Received < records[j].Received })
+
+	result := &ParseResult{Records: len(records), Skipped: make(map[string]int)}
+	parsers := make(map[string]exchange.Parser)
+	for _, record := range records {
+		parser, ok := parsers[record.Venue]
+		if !ok {
+			var err error
+			if parser, err = exchange.NewParser(record.Venue); err != nil {
+				result.Skipped[record.Venue]++
+				continue
+			}
+			parsers[record.Venue] = parser
+		}
+
+		quote, ok := parser.Parse(record.Raw, record.Time())
+		if !ok {
+			result.Unparsed++
+			if record.Quote != nil {
+				result.Mismatches++
+			}
+			continue
+		}
+		if record.Quote == nil || !sameQuote(quote, *record.Quote) {
+			result.Mismatches++
+		}
+		result.Quotes = append(result.Quotes, quote)
+	}
+	return result, nil
+}
+
+// Run replays capture files through the parsers and the strategy and writes
+// the decision log to w. cfg.Execution selects how fills are made.
+func Run(paths []string, cfg backtest.Config, w io.Writer) (*ParseResult, *backtest.Report, error) {
+	parsed, err := Parse(paths...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(parsed.Quotes) == 0 {
+		return parsed, nil, fmt.Errorf("no quotes in %d recorded messages", parsed.Records)
+	}
+
+	log := NewDecisionLog(w)
+	cfg.OnEvent = log.Event
+	report, err := backtest.Run(parsed.Quotes, cfg)
+	if err != nil {
+		return parsed, nil, err
+	}
+	return parsed, report, log.Err()
+}
+
+// sameQuote compares quotes field by field; timestamps read back from JSON
+// lose their location, so they are compared as instants
+func sameQuote(a, b strategy.Quote) bool {
+	if a.Exchange != b.Exchange || a.Symbol != b.Symbol || a.Bid != b.Bid || a.Ask != b.Ask ||
+		a.BidSize != b.BidSize || a.AskSize != b.AskSize || !a.Timestamp.Equal(b.Timestamp) ||
+		a.MarkPrice != b.MarkPrice || a.FundingRate != b.FundingRate || !a.NextFunding.Equal(b.NextFunding) ||
+		!slices.Equal(a.Trades, b.Trades) {
+		return false
+	}
+	if (a.Book == nil) != (b.Book == nil) {
+		return false
+	}
+	if a.Book == nil {
+		return true
+	}
+	return sameLevels(a.Book.Bids, b.Book.Bids) && sameLevels(a.Book.Asks, b.Book.Asks)
+}
+
+func sameLevels(a, b []strategy.PriceLevel) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
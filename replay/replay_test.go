@@ -0,0 +1,146 @@
+package replay
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"hft-arbitrage-bot/backtest"
+	"hft-arbitrage-bot/execution"
+	"hft-arbitrage-bot/strategy"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden decision logs in testdata")
+
+// session.hftcap holds three seconds of binance and okx DOGE messages, as the
+// adapters receive them, in which an okx premium opens and closes again
+const sessionCapture = "testdata/session.hftcap"
+
+func TestParse(t *testing.T) {
+	parsed, err := Parse(sessionCapture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Records != 127 || len(parsed.Quotes) != 126 || parsed.Unparsed != 1 || parsed.Mismatches != 0 || len(parsed.Skipped) != 0 {
+		t.Errorf("Parse() = %d records, %d quotes, %d unparsed, %d mismatches, skipped %v",
+			parsed.Records, len(parsed.Quotes), parsed.Unparsed, parsed.Mismatches, parsed.Skipped)
+	}
+	trades := 0
+	for i, q := range parsed.Quotes {
+		if i > 0 && q.Timestamp.Before(parsed.Quotes[i-1].Timestamp) {
+			t.Fatalf("quote %d is out of receive time order", i)
+		}
+		if q.TradesOnly() {
+			trades++
+		}
+	}
+	if trades != 6 {
+		t.Errorf("Parse() = %d binance trade messages, want 6", trades)
+	}
+}
+
+func TestRunGolden(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend string
+		golden  string
+	}{
+		{name: "instant fills", backend: execution.BackendInstant, golden: "testdata/session.golden"},
+		{name: "simulated fills", backend: execution.BackendPaper, golden: "testdata/session_paper.golden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := backtest.DefaultConfig()
+			cfg.Execution.Backend = tt.backend
+			var log bytes.Buffer
+			if _, _, err := Run([]string{sessionCapture}, cfg, &log); err != nil {
+				t.Fatal(err)
+			}
+			if *update {
+				if err := os.WriteFile(tt.golden, log.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			diff, err := Diff(tt.golden, log.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff != "" {
+				t.Errorf("decision log differs from %s (go test ./replay -update to accept):\n%s", tt.golden, diff)
+			}
+			if !strings.Contains(log.String(), " executed ") {
+				t.Errorf("the session executed nothing:\n%s", log.String())
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		golden   string
+		got      string
+		wantDiff []string // substrings of the description; none when equal
+	}{
+		{name: "equal", golden: "a\nb\n", got: "a\nb\n"},
+		{name: "changed line", golden: "a\nb\n", got: "a\nc\n", wantDiff: []string{"line 2:", "- b", "+ c", "golden has 2 lines, replay produced 2"}},
+		{name: "missing line", golden: "a\nb\n", got: "a\n", wantDiff: []string{"line 2:", "- b", "golden has 2 lines, replay produced 1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			golden := filepath.Join(t.TempDir(), "golden")
+			if err := os.WriteFile(golden, []byte(tt.golden), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			diff, err := Diff(golden, []byte(tt.got))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (diff == "") != (len(tt.wantDiff) == 0) {
+				t.Fatalf("Diff() = %q", diff)
+			}
+			for _, want := range tt.wantDiff {
+				if !strings.Contains(diff, want) {
+					t.Errorf("Diff() = %q, want it to contain %q", diff, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSameQuote(t *testing.T) {
+	at := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	base := strategy.Quote{
+		Exchange: "okx", Symbol: "DOGEUSDT", Bid: 0.1, Ask: 0.1001, Timestamp: at,
+		Book: &strategy.OrderBook{Bids: []strategy.PriceLevel{{Price: 0.1, Size: 10}}, Asks: []strategy.PriceLevel{{Price: 0.1001, Size: 10}}},
+	}
+	tests := []struct {
+		name   string
+		change func(q *strategy.Quote)
+		want   bool
+	}{
+		{name: "identical", change: func(q *strategy.Quote) {}, want: true},
+		{name: "same instant in another location", change: func(q *strategy.Quote) { q.Timestamp = at.In(time.FixedZone("CET", 3600)) }, want: true},
+		{name: "bid", change: func(q *strategy.Quote) { q.Bid = 0.0999 }},
+		{name: "book level", change: func(q *strategy.Quote) {
+			q.Book = &strategy.OrderBook{Bids: []strategy.PriceLevel{{Price: 0.1, Size: 11}}, Asks: base.Book.Asks}
+		}},
+		{name: "no book", change: func(q *strategy.Quote) { q.Book = nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := base
+			tt.change(&q)
+			if got := sameQuote(base, q); got != tt.want {
+				t.Errorf("sameQuote() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
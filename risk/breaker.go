@@ -0,0 +1,252 @@
+package risk
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"hft-arbitrage-bot/clock"
+)
+
+// BreakerConfig configures the per-venue market data sanity filters
+type BreakerConfig struct {
+	MaxSpreadPercent      float64       // widest acceptable bid/ask spread on one venue
+	JumpSigma             float64       // mid moves beyond this many sigma of recent moves trip
+	MinJumpPercent        float64       // mid moves smaller than this never trip the jump check
+	JumpWindow            int           // number of recent mids used for the jump check
+	MaxConsensusDeviation float64       // max percent distance from the median mid of the other venues
+	ConsensusMaxAge       time.Duration // other venues' mids older than this are left out of the median
+	PauseDuration         time.Duration // how long a tripped venue stays paused
+}
+
+// DefaultBreakerConfig returns sanity filters tuned for liquid spot pairs
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		MaxSpreadPercent:      1.0,
+		JumpSigma:             6.0,
+		MinJumpPercent:        0.5,
+		JumpWindow:            100,
+		MaxConsensusDeviation: 2.0,
+		ConsensusMaxAge:       10 * time.Second,
+		PauseDuration:         30 * time.Second,
+	}
+}
+
+// BreakerStatus reports the state of one venue's circuit breaker
+type BreakerStatus struct {
+	Venue       string    `json:"venue"`
+	Paused      bool      `json:"paused"`
+	PausedUntil time.Time `json:"paused_until"`
+	Reason      string    `json:"reason"`
+	TrippedAt   time.Time `json:"tripped_at"`
+	Trips       int       `json:"trips"`
+	LastMid     float64   `json:"last_mid"`
+}
+
+// venueBreaker holds the recent market state of one venue
+type venueBreaker struct {
+	mids        []float64
+	lastMid     float64
+	lastUpdate  time.Time
+	pausedUntil time.Time
+	reason      string
+	trippedAt   time.Time
+	trips       int
+}
+
+// Breakers runs the circuit breakers for every venue
+type Breakers struct {
+	mu     sync.Mutex
+	config BreakerConfig
+	clock  clock.Clock
+	venues map[string]*venueBreaker
+}
+
+// NewBreakers creates circuit breakers with the given configuration
+func NewBreakers(config BreakerConfig) *Breakers {
+	return &Breakers{
+		config: config,
+		clock:  clock.Real,
+		venues: make(map[string]*venueBreaker),
+	}
+}
+
+// SetClock makes Status read the time from c; quotes carry their own time
+func (b *Breakers) SetClock(c clock.Clock) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clock = c
+}
+
+// CheckQuote validates a top-of-book update. A non-nil error means the quote
+// is abnormal: it must be discarded and the venue is paused.
+func (b *Breakers) CheckQuote(venue string, bid, ask float64, ts time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	vb := b.venue(venue)
+
+	if bid <= 0 || ask <= 0 {
+		return b.trip(venue, vb, ts, fmt.Sprintf("non-positive price bid=%.6f ask=%.6f", bid, ask))
+	}
+	if bid > ask {
+		return b.trip(venue, vb, ts, fmt.Sprintf("crossed book bid=%.6f > ask=%.6f", bid, ask))
+	}
+	if bid == ask {
+		return b.trip(venue, vb, ts, fmt.Sprintf("locked book bid=ask=%.6f", bid))
+	}
+
+	mid := (bid + ask) / 2
+	spreadPercent := (ask - bid) / mid * 100
+	if b.config.MaxSpreadPercent > 0 && spreadPercent > b.config.MaxSpreadPercent {
+		return b.trip(venue, vb, ts, fmt.Sprintf("spread %.4f%% wider than cap %.4f%%", spreadPercent, b.config.MaxSpreadPercent))
+	}
+
+	if vb.lastMid > 0 && b.config.JumpSigma > 0 {
+		move := (mid - vb.lastMid) / vb.lastMid * 100
+		sigma := moveSigma(vb.mids)
+		if math.Abs(move) > b.config.MinJumpPercent && len(vb.mids) >= 10 && math.Abs(move) > b.config.JumpSigma*sigma {
+			return b.trip(venue, vb, ts, fmt.Sprintf("mid jumped %.4f%%, more than %.1f sigma (%.4f%%)", move, b.config.JumpSigma, sigma))
+		}
+	}
+
+	if b.config.MaxConsensusDeviation > 0 {
+		if median, n := b.consensusMid(venue, ts); n >= 2 {
+			deviation := (mid - median) / median * 100
+			if math.Abs(deviation) > b.config.MaxConsensusDeviation {
+				return b.trip(venue, vb, ts, fmt.Sprintf("mid %.6f deviates %.4f%% from median %.6f of %d other venues", mid, deviation, median, n))
+			}
+		}
+	}
+
+	vb.lastMid = mid
+	vb.lastUpdate = ts
+	vb.mids = append(vb.mids, mid)
+	if window := b.config.JumpWindow; window > 0 && len(vb.mids) > window {
+		vb.mids = vb.mids[len(vb.mids)-window:]
+	}
+	return nil
+}
+
+// Paused reports whether a venue is paused by a tripped breaker and why
+func (b *Breakers) Paused(venue string, now time.Time) (bool, string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	vb, ok := b.venues[venue]
+	if !ok || !now.Before(vb.pausedUntil) {
+		venuePaused.WithLabelValues(venue).Set(0)
+		return false, ""
+	}
+	venuePaused.WithLabelValues(venue).Set(1)
+	return true, vb.reason
+}
+
+// Reset clears a venue's breaker so it trades again immediately
+func (b *Breakers) Reset(venue string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if vb, ok := b.venues[venue]; ok {
+		vb.pausedUntil = time.Time{}
+		logger.Info("circuit breaker reset", "venue", venue)
+	}
+}
+
+// Status returns the breaker state of every venue seen so far
+func (b *Breakers) Status() []BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock.Now()
+	statuses := make([]BreakerStatus, 0, len(b.venues))
+	for venue, vb := range b.venues {
+		statuses = append(statuses, BreakerStatus{
+			Venue:       venue,
+			Paused:      now.Before(vb.pausedUntil),
+			PausedUntil: vb.pausedUntil,
+			Reason:      vb.reason,
+			TrippedAt:   vb.trippedAt,
+			Trips:       vb.trips,
+			LastMid:     vb.lastMid,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Venue < statuses[j].Venue })
+	return statuses
+}
+
+// venue returns the breaker state of a venue, creating it on first use
+func (b *Breakers) venue(venue string) *venueBreaker {
+	vb, ok := b.venues[venue]
+	if !ok {
+		vb = &venueBreaker{}
+		b.venues[venue] = vb
+	}
+	return vb
+}
+
+// trip pauses a venue; the caller must hold the lock
+func (b *Breakers) trip(venue string, vb *venueBreaker, ts time.Time, reason string) error {
+	if !ts.Before(vb.pausedUntil) {
+		logger.Warn("circuit breaker tripped", "venue", venue, "reason", reason, "pause", b.config.PauseDuration.String())
+	}
+	vb.pausedUntil = ts.Add(b.config.PauseDuration)
+	vb.reason = reason
+	vb.trippedAt = ts
+	vb.trips++
+	breakerTrips.WithLabelValues(venue).Inc()
+	// The move that tripped the breaker may be a genuine regime change, so the
+	// jump statistics are rebuilt from scratch once quotes are accepted again
+	vb.mids = vb.mids[:0]
+	vb.lastMid = 0
+	return fmt.Errorf("%s: %s", venue, reason)
+}
+
+// consensusMid returns the median mid of the other venues with a recent
+// accepted quote; the caller must hold the lock
+func (b *Breakers) consensusMid(venue string, now time.Time) (float64, int) {
+	mids := make([]float64, 0, len(b.venues))
+	for other, vb := range b.venues {
+		if other == venue || vb.lastMid <= 0 || now.Before(vb.pausedUntil) {
+			continue
+		}
+		if b.config.ConsensusMaxAge > 0 && now.Sub(vb.lastUpdate) > b.config.ConsensusMaxAge {
+			continue
+		}
+		mids = append(mids, vb.lastMid)
+	}
+	if len(mids) == 0 {
+		return 0, 0
+	}
+
+	sort.Float64s(mids)
+	n := len(mids)
+	if n%2 == 1 {
+		return mids[n/2], n
+	}
+	return (mids[n/2-1] + mids[n/2]) / 2, n
+}
+
+// moveSigma returns the standard deviation of successive mid moves in percent
+func moveSigma(mids []float64) float64 {
+	if len(mids) < 3 {
+		return 0
+	}
+
+	var sum, sumSq float64
+	n := 0
+	for i := 1; i < len(mids); i++ {
+		move := (mids[i] - mids[i-1]) / mids[i-1] * 100
+		sum += move
+		sumSq += move * move
+		n++
+	}
+	mean := sum / float64(n)
+	variance := sumSq/float64(n) - mean*mean
+	if variance < 0 {
+		return 0
+	}
+	return math.Sqrt(variance)
+}
@@ -0,0 +1,104 @@
+package risk
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckQuote(t *testing.T) {
+	start := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+
+	// quote is one top of book update; history builds the state before it
+	type quote struct {
+		venue    string
+		bid, ask float64
+	}
+	steady := func(venue string, n int) []quote {
+		var quotes []quote
+		for i := 0; i < n; i++ {
+			jitter := float64(i%2) * 0.0001
+			quotes = append(quotes, quote{venue, 0.1000 + jitter, 0.1001 + jitter})
+		}
+		return quotes
+	}
+
+	tests := []struct {
+		name    string
+		history []quote
+		quote   quote
+		wantErr bool
+	}{
+		{name: "normal quote", quote: quote{"okx", 0.1, 0.1001}},
+		{name: "non-positive price", quote: quote{"okx", 0, 0.1001}, wantErr: true},
+		{name: "crossed book", quote: quote{"okx", 0.1002, 0.1001}, wantErr: true},
+		{name: "locked book", quote: quote{"okx", 0.1, 0.1}, wantErr: true},
+		{name: "spread wider than cap", quote: quote{"okx", 0.1, 0.102}, wantErr: true},
+		{name: "jump beyond sigma", history: steady("okx", 20), quote: quote{"okx", 0.1010, 0.1011}, wantErr: true},
+		{name: "jump below the minimum", history: steady("okx", 20), quote: quote{"okx", 0.1004, 0.1005}},
+		{name: "jump without enough history", history: steady("okx", 5), quote: quote{"okx", 0.1010, 0.1011}},
+		{
+			name:    "deviation from consensus",
+			history: append(steady("binance", 1), steady("kraken", 1)...),
+			quote:   quote{"okx", 0.1030, 0.1031},
+			wantErr: true,
+		},
+		{
+			name:    "consensus needs two other venues",
+			history: steady("binance", 1),
+			quote:   quote{"okx", 0.1030, 0.1031},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBreakers(DefaultBreakerConfig())
+			now := start
+			for _, q := range tt.history {
+				now = now.Add(100 * time.Millisecond)
+				if err := b.CheckQuote(q.venue, q.bid, q.ask, now); err != nil {
+					t.Fatalf("history quote rejected: %v", err)
+				}
+			}
+			now = now.Add(100 * time.Millisecond)
+			err := b.CheckQuote(tt.quote.venue, tt.quote.bid, tt.quote.ask, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckQuote() error = %v, want error %v", err, tt.wantErr)
+			}
+			paused, _ := b.Paused(tt.quote.venue, now)
+			if paused != tt.wantErr {
+				t.Errorf("paused = %v, want %v", paused, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBreakerPauseAndReset(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	config := DefaultBreakerConfig()
+	b := NewBreakers(config)
+
+	if err := b.CheckQuote("okx", 0.1002, 0.1001, now); err == nil {
+		t.Fatal("crossed book accepted")
+	}
+	tests := []struct {
+		name  string
+		at    time.Time
+		reset bool
+		want  bool
+	}{
+		{name: "paused after tripping", at: now.Add(time.Second), want: true},
+		{name: "paused until the pause ends", at: now.Add(config.PauseDuration - time.Millisecond), want: true},
+		{name: "trades again after the pause", at: now.Add(config.PauseDuration)},
+		{name: "trades again after a reset", at: now.Add(time.Second), reset: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.reset {
+				b.Reset("okx")
+			}
+			if paused, _ := b.Paused("okx", tt.at); paused != tt.want {
+				t.Errorf("Paused() = %v, want %v", paused, tt.want)
+			}
+		})
+	}
+}
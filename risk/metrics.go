@@ -0,0 +1,14 @@
+package risk
+
+import "hft-arbitrage-bot/metrics"
+
+var (
+	riskRejections = metrics.NewCounter("hft_risk_rejections_total",
+		"Order groups rejected by the pre-trade risk engine.")
+	killSwitchEngaged = metrics.NewGauge("hft_kill_switch_engaged",
+		"1 while the kill switch halts trading, 0 otherwise.")
+	breakerTrips = metrics.NewCounterVec("hft_breaker_trips_total",
+		"Quotes rejected by the circuit breakers.", "venue")
+	venuePaused = metrics.NewGaugeVec("hft_venue_paused",
+		"1 while a venue is paused by a tripped circuit breaker.", "venue")
+)
@@ -0,0 +1,379 @@
+package risk
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hft-arbitrage-bot/clock"
+	"hft-arbitrage-bot/logging"
+)
+
+var logger = logging.Component("risk")
+
+// ErrHalted is returned for every order while the kill switch is engaged
+var ErrHalted = errors.New("trading halted by kill switch")
+
+// Limits configures the hard pre-trade limits. A zero value disables the
+// corresponding check.
+type Limits struct {
+	MaxOrderNotional     float64 `json:"max_order_notional"`     // max notional of a single order
+	MaxVenueExposure     float64 `json:"max_venue_exposure"`     // max absolute open notional per venue/asset
+	MaxOrdersPerSecond   int     `json:"max_orders_per_second"`  // max orders sent in any one second window
+	MaxDailyLoss         float64 `json:"max_daily_loss"`         // realized loss (positive number) that halts trading for the UTC day
+	MaxConsecutiveLosses int     `json:"max_consecutive_losses"` // losing arbitrages in a row that halt trading
+}
+
+// DefaultLimits returns conservative limits sized for the default $100 trade size
+func DefaultLimits() Limits {
+	return Limits{
+		MaxOrderNotional:     250.0,
+		MaxVenueExposure:     1000.0,
+		MaxOrdersPerSecond:   10,
+		MaxDailyLoss:         50.0,
+		MaxConsecutiveLosses: 5,
+	}
+}
+
+// LimitNames lists the names Set accepts, in the order of the Limits fields
+var LimitNames = []string{"order", "exposure", "rate", "daily_loss", "streak"}
+
+// Set parses value into the limit called name, one of LimitNames. Limits are
+// non-negative; zero disables the check.
+func (l *Limits) Set(name, value string) error {
+	value = strings.TrimSpace(value)
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "order":
+		return parseLimit(value, &l.MaxOrderNotional)
+	case "exposure":
+		return parseLimit(value, &l.MaxVenueExposure)
+	case "daily_loss":
+		return parseLimit(value, &l.MaxDailyLoss)
+	case "rate":
+		return parseCountLimit(value, &l.MaxOrdersPerSecond)
+	case "streak":
+		return parseCountLimit(value, &l.MaxConsecutiveLosses)
+	}
+	return fmt.Errorf("unknown risk limit %q (want one of %s)", name, strings.Join(LimitNames, ", "))
+}
+
+func parseLimit(value string, limit *float64) error {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil || v < 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return fmt.Errorf("invalid risk limit %q", value)
+	}
+	*limit = v
+	return nil
+}
+
+func parseCountLimit(value string, limit *int) error {
+	v, err := strconv.Atoi(value)
+	if err != nil || v < 0 {
+		return fmt.Errorf("invalid risk limit %q", value)
+	}
+	*limit = v
+	return nil
+}
+
+// Order is a single order leg as seen by the risk engine
+type Order struct {
+	Venue    string  `json:"venue"`
+	Symbol   string  `json:"symbol"`
+	Side     string  `json:"side"` // "BUY" or "SELL"
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+}
+
+// Notional returns the order value in quote currency
+func (o Order) Notional() float64 {
+	return o.Price * o.Quantity
+}
+
+// Status is a snapshot of the risk engine state
+type Status struct {
+	Halted            bool               `json:"halted"`
+	HaltReason        string             `json:"halt_reason"`
+	HaltedAt          time.Time          `json:"halted_at"`
+	Limits            Limits             `json:"limits"`
+	Exposure          map[string]float64 `json:"exposure"` // "venue/asset" -> signed open notional
+	DailyPnL          float64            `json:"daily_pnl"`
+	ConsecutiveLosses int                `json:"consecutive_losses"`
+	OrdersLastSecond  int                `json:"orders_last_second"`
+	Rejections        int                `json:"rejections"`
+	LastRejection     string             `json:"last_rejection"`
+}
+
+// Engine checks every order against the configured limits before it is sent
+// and owns the global kill switch
+type Engine struct {
+	mu     sync.Mutex
+	limits Limits
+	clock  clock.Clock
+
+	exposure          map[string]float64
+	lastPrice         map[string]float64
+	orderTimes        []time.Time
+	day               string
+	dailyPnL          float64
+	consecutiveLosses int
+
+	halted     bool
+	haltReason string
+	haltedAt   time.Time
+
+	rejections    int
+	lastRejection string
+}
+
+// NewEngine creates a risk engine with the given limits
+func NewEngine(limits Limits) *Engine {
+	return &Engine{
+		limits:    limits,
+		clock:     clock.Real,
+		exposure:  make(map[string]float64),
+		lastPrice: make(map[string]float64),
+	}
+}
+
+// SetLimits replaces the configured limits
+func (e *Engine) SetLimits(limits Limits) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.limits = limits
+}
+
+// SetClock makes the engine read the time from c, e.g. a simulated clock in
+// backtests. It must be called before the engine is used.
+func (e *Engine) SetClock(c clock.Clock) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.clock = c
+}
+
+// CheckOrders validates a group of orders that will be sent together (for
+// example both legs of an arbitrage). Either every order passes or none do.
+// Accepted orders count towards the order rate limit.
+func (e *Engine) CheckOrders(orders ...Order) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.clock.Now()
+
+	if e.halted {
+		return e.reject(ErrHalted)
+	}
+
+	if e.limits.MaxOrdersPerSecond > 0 {
+		e.pruneOrderTimes(now)
+		if len(e.orderTimes)+len(orders) > e.limits.MaxOrdersPerSecond {
+			return e.reject(fmt.Errorf("order rate limit: %d orders in the last second, limit %d",
+				len(e.orderTimes)+len(orders), e.limits.MaxOrdersPerSecond))
+		}
+	}
+
+	// Exposure is checked against the combined effect of the whole group so
+	// that two legs on the same venue are not evaluated independently
+	pending := make(map[string]float64)
+	for _, o := range orders {
+		if o.Quantity <= 0 || o.Price <= 0 {
+			return e.reject(fmt.Errorf("invalid order %s %s on %s: price %.6f quantity %.6f",
+				o.Side, o.Symbol, o.Venue, o.Price, o.Quantity))
+		}
+
+		if e.limits.MaxOrderNotional > 0 && o.Notional() > e.limits.MaxOrderNotional {
+			return e.reject(fmt.Errorf("order notional %.2f on %s exceeds limit %.2f",
+				o.Notional(), o.Venue, e.limits.MaxOrderNotional))
+		}
+
+		key := exposureKey(o.Venue, o.Symbol)
+		pending[key] += signedNotional(o)
+	}
+
+	if e.limits.MaxVenueExposure > 0 {
+		for key, delta := range pending {
+			current := e.exposure[key]
+			next := current + delta
+			// Orders that reduce exposure are always allowed
+			if math.Abs(next) > e.limits.MaxVenueExposure && math.Abs(next) > math.Abs(current) {
+				return e.reject(fmt.Errorf("exposure on %s would reach %.2f, limit %.2f",
+					key, next, e.limits.MaxVenueExposure))
+			}
+		}
+	}
+
+	for range orders {
+		e.orderTimes = append(e.orderTimes, now)
+	}
+	return nil
+}
+
+// OnFill updates open exposure after an order has been filled
+func (e *Engine) OnFill(o Order) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	key := exposureKey(o.Venue, o.Symbol)
+	e.exposure[key] += signedNotional(o)
+	e.lastPrice[key] = o.Price
+}
+
+// OnArbitrageClosed records the realized P&L of a completed arbitrage and
+// engages the kill switch if the daily loss or losing streak limit is breached
+func (e *Engine) OnArbitrageClosed(pnl float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.rollDay(e.clock.Now())
+	e.dailyPnL += pnl
+
+	if pnl < 0 {
+		e.consecutiveLosses++
+	} else {
+		e.consecutiveLosses = 0
+	}
+
+	if e.limits.MaxDailyLoss > 0 && -e.dailyPnL >= e.limits.MaxDailyLoss {
+		e.halt(fmt.Sprintf("daily loss %.2f reached limit %.2f", -e.dailyPnL, e.limits.MaxDailyLoss))
+		return
+	}
+	if e.limits.MaxConsecutiveLosses > 0 && e.consecutiveLosses >= e.limits.MaxConsecutiveLosses {
+		e.halt(fmt.Sprintf("%d consecutive losing arbitrages, limit %d",
+			e.consecutiveLosses, e.limits.MaxConsecutiveLosses))
+	}
+}
+
+// Halt engages the kill switch. Every order is rejected until Resume is called.
+func (e *Engine) Halt(reason string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.halt(reason)
+}
+
+// Resume releases the kill switch and resets the losing streak and the
+// day's realized loss, which the operator has acknowledged by resuming;
+// otherwise the next losing arbitrage would engage it again at once
+func (e *Engine) Resume() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.halted {
+		return
+	}
+	logger.Warn("kill switch released", "previous_reason", e.haltReason)
+	killSwitchEngaged.Set(0)
+	e.halted = false
+	e.haltReason = ""
+	e.haltedAt = time.Time{}
+	e.consecutiveLosses = 0
+	e.rollDay(e.clock.Now())
+	e.dailyPnL = 0
+}
+
+// Halted reports whether the kill switch is engaged and why
+func (e *Engine) Halted() (bool, string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.halted, e.haltReason
+}
+
+// Status returns a snapshot of the engine state
+func (e *Engine) Status() Status {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.clock.Now()
+	e.pruneOrderTimes(now)
+	e.rollDay(now)
+
+	exposure := make(map[string]float64, len(e.exposure))
+	for k, v := range e.exposure {
+		exposure[k] = v
+	}
+
+	return Status{
+		Halted:            e.halted,
+		HaltReason:        e.haltReason,
+		HaltedAt:          e.haltedAt,
+		Limits:            e.limits,
+		Exposure:          exposure,
+		DailyPnL:          e.dailyPnL,
+		ConsecutiveLosses: e.consecutiveLosses,
+		OrdersLastSecond:  len(e.orderTimes),
+		Rejections:        e.rejections,
+		LastRejection:     e.lastRejection,
+	}
+}
+
+// halt engages the kill switch; the caller must hold the lock
+func (e *Engine) halt(reason string) {
+	if e.halted {
+		return
+	}
+	e.halted = true
+	killSwitchEngaged.Set(1)
+	e.haltReason = reason
+	e.haltedAt = e.clock.Now()
+	logger.Error("kill switch engaged", "reason", reason)
+}
+
+// reject records a rejected order group; the caller must hold the lock
+func (e *Engine) reject(err error) error {
+	e.rejections++
+	riskRejections.Inc()
+	e.lastRejection = err.Error()
+	return err
+}
+
+// pruneOrderTimes drops order timestamps older than one second
+func (e *Engine) pruneOrderTimes(now time.Time) {
+	cutoff := now.Add(-time.Second)
+	i := 0
+	for i < len(e.orderTimes) && !e.orderTimes[i].After(cutoff) {
+		i++
+	}
+	e.orderTimes = e.orderTimes[i:]
+}
+
+// rollDay resets the daily P&L when the UTC day changes
+func (e *Engine) rollDay(now time.Time) {
+	day := now.UTC().Format("2006-01-02")
+	if day != e.day {
+		e.day = day
+		e.dailyPnL = 0
+	}
+}
+
+// signedNotional is positive for buys and negative for sells
+func signedNotional(o Order) float64 {
+	if o.Side == "SELL" {
+		return -o.Notional()
+	}
+	return o.Notional()
+}
+
+// exposureKey identifies the asset held on a venue
+func exposureKey(venue, symbol string) string {
+	return venue + "/" + BaseAsset(symbol)
+}
+
+// quoteAssets are stripped from a symbol to find the traded asset
+var quoteAssets = []string{"USDT", "USDC", "USD", "EUR", "BTC", "ETH"}
+
+// BaseAsset returns the asset being bought or sold in a symbol such as
+// "DOGEUSDT", "DOGE-USDT" or "DOGE/USD"
+func BaseAsset(symbol string) string {
+	s := strings.ToUpper(symbol)
+	if i := strings.IndexAny(s, "-/"); i > 0 {
+		return s[:i]
+	}
+	for _, q := range quoteAssets {
+		if len(s) > len(q) && strings.HasSuffix(s, q) {
+			return strings.TrimSuffix(s, q)
+		}
+	}
+	return s
+}
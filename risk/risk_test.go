@@ -0,0 +1,150 @@
+package risk
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"hft-arbitrage-bot/clock"
+)
+
+func TestCheckOrders(t *testing.T) {
+	limits := Limits{MaxOrderNotional: 250, MaxVenueExposure: 1000, MaxOrdersPerSecond: 4}
+	buy := func(venue string, notional float64) Order {
+		return Order{Venue: venue, Symbol: "DOGEUSDT", Side: "BUY", Price: 0.1, Quantity: notional / 0.1}
+	}
+	sell := func(venue string, notional float64) Order {
+		return Order{Venue: venue, Symbol: "DOGEUSDT", Side: "SELL", Price: 0.1, Quantity: notional / 0.1}
+	}
+
+	tests := []struct {
+		name     string
+		exposure map[string]float64
+		orders   []Order
+		wantErr  bool
+	}{
+		{name: "within limits", orders: []Order{buy("okx", 100), sell("binance", 100)}},
+		{name: "order notional over limit", orders: []Order{buy("okx", 300), sell("binance", 300)}, wantErr: true},
+		{name: "zero quantity", orders: []Order{{Venue: "okx", Symbol: "DOGEUSDT", Side: "BUY", Price: 0.1}}, wantErr: true},
+		{name: "rate limit counts the whole group", orders: []Order{buy("okx", 10), buy("okx", 10), buy("okx", 10), buy("okx", 10), buy("okx", 10)}, wantErr: true},
+		{name: "exposure over limit", exposure: map[string]float64{"okx/DOGE": 900}, orders: []Order{buy("okx", 200)}, wantErr: true},
+		{name: "legs on one venue are netted", exposure: map[string]float64{"okx/DOGE": 900}, orders: []Order{buy("okx", 200), sell("okx", 200)}},
+		{name: "reducing exposure is allowed", exposure: map[string]float64{"okx/DOGE": 1100}, orders: []Order{sell("okx", 100)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEngine(limits)
+			for key, notional := range tt.exposure {
+				e.exposure[key] = notional
+			}
+			err := e.CheckOrders(tt.orders...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckOrders() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && e.Status().Rejections != 1 {
+				t.Errorf("rejections = %d, want 1", e.Status().Rejections)
+			}
+		})
+	}
+}
+
+func TestKillSwitch(t *testing.T) {
+	tests := []struct {
+		name       string
+		limits     Limits
+		pnls       []float64
+		wantHalted bool
+	}{
+		{name: "daily loss reached", limits: Limits{MaxDailyLoss: 10}, pnls: []float64{-4, 2, -8}, wantHalted: true},
+		{name: "daily loss not reached", limits: Limits{MaxDailyLoss: 10}, pnls: []float64{-4, 2, -7}},
+		{name: "losing streak", limits: Limits{MaxConsecutiveLosses: 3}, pnls: []float64{-1, -1, -1}, wantHalted: true},
+		{name: "streak broken by a win", limits: Limits{MaxConsecutiveLosses: 3}, pnls: []float64{-1, -1, 1, -1, -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEngine(tt.limits)
+			e.SetClock(clock.NewSim(time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)))
+			for _, pnl := range tt.pnls {
+				e.OnArbitrageClosed(pnl)
+			}
+			if halted, reason := e.Halted(); halted != tt.wantHalted {
+				t.Fatalf("halted = %v (%q), want %v", halted, reason, tt.wantHalted)
+			}
+			if !tt.wantHalted {
+				return
+			}
+			order := Order{Venue: "okx", Symbol: "DOGEUSDT", Side: "BUY", Price: 0.1, Quantity: 100}
+			if err := e.CheckOrders(order); !errors.Is(err, ErrHalted) {
+				t.Errorf("CheckOrders() while halted = %v, want ErrHalted", err)
+			}
+		})
+	}
+}
+
+func TestResumeResetsDailyLoss(t *testing.T) {
+	sim := clock.NewSim(time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC))
+	e := NewEngine(Limits{MaxDailyLoss: 10, MaxConsecutiveLosses: 3})
+	e.SetClock(sim)
+
+	e.OnArbitrageClosed(-12)
+	if halted, _ := e.Halted(); !halted {
+		t.Fatal("daily loss did not engage the kill switch")
+	}
+	e.Resume()
+	if status := e.Status(); status.Halted || status.DailyPnL != 0 || status.ConsecutiveLosses != 0 {
+		t.Fatalf("after resume: halted %v, daily P&L %.2f, streak %d", status.Halted, status.DailyPnL, status.ConsecutiveLosses)
+	}
+
+	// A small loss after resuming must not engage it again
+	sim.Advance(time.Minute)
+	e.OnArbitrageClosed(-1)
+	if halted, reason := e.Halted(); halted {
+		t.Fatalf("kill switch engaged again after resume: %s", reason)
+	}
+}
+
+func TestDailyLossRollsOverAtMidnight(t *testing.T) {
+	sim := clock.NewSim(time.Date(2026, 1, 5, 23, 59, 0, 0, time.UTC))
+	e := NewEngine(Limits{MaxDailyLoss: 10})
+	e.SetClock(sim)
+
+	e.OnArbitrageClosed(-8)
+	sim.Advance(2 * time.Minute)
+	e.OnArbitrageClosed(-8)
+	if halted, reason := e.Halted(); halted {
+		t.Fatalf("losses on two days engaged the kill switch: %s", reason)
+	}
+}
+
+func TestLimitsSet(t *testing.T) {
+	tests := []struct {
+		name, value string
+		want        Limits
+		wantErr     bool
+	}{
+		{name: "order", value: "500", want: Limits{MaxOrderNotional: 500}},
+		{name: "Exposure", value: " 2000 ", want: Limits{MaxVenueExposure: 2000}},
+		{name: "rate", value: "20", want: Limits{MaxOrdersPerSecond: 20}},
+		{name: "daily_loss", value: "0", want: Limits{}},
+		{name: "streak", value: "3", want: Limits{MaxConsecutiveLosses: 3}},
+		{name: "streak", value: "1.5", wantErr: true},
+		{name: "order", value: "-1", wantErr: true},
+		{name: "order", value: "Inf", wantErr: true},
+		{name: "loss", value: "10", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+"="+tt.value, func(t *testing.T) {
+			var limits Limits
+			err := limits.Set(tt.name, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Set(%q, %q) error = %v, want error %v", tt.name, tt.value, err, tt.wantErr)
+			}
+			if err == nil && limits != tt.want {
+				t.Errorf("Set(%q, %q) = %+v, want %+v", tt.name, tt.value, limits, tt.want)
+			}
+		})
+	}
+}
@@ -1,19 +1,51 @@
 package strategy
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"hft-arbitrage-bot/clock"
+	"hft-arbitrage-bot/logging"
+	"hft-arbitrage-bot/risk"
 )
 
+var logger = logging.Component("strategy")
+
+// missedLogSampler keeps "missed opportunity" logging to one record per venue
+// pair every few seconds; the same spread is otherwise re-logged every tick
+var missedLogSampler = logging.NewSampler(5*time.Second, 1)
+
+// EvaluationInterval is how often the strategy loop looks for opportunities
+const EvaluationInterval = 100 * time.Millisecond
+
+// DefaultMinSpreadPercent is the minimum net edge the live bot trades at;
+// lowered to 0 for more aggressive trading
+const DefaultMinSpreadPercent = 0.0
+
 // Quote represents a price quote from an exchange
 type Quote struct {
-	Exchange  string
-	Symbol    string
-	Bid       float64
-	Ask       float64
-	Timestamp time.Time
+	Exchange  string     `json:"exchange"`
+	Symbol    string     `json:"symbol"`
+	Bid       float64    `json:"bid"`
+	Ask       float64    `json:"ask"`
+	BidSize   float64    `json:"bid_size"` // zero when the venue does not report sizes
+	AskSize   float64    `json:"ask_size"`
+	Book      *OrderBook `json:"book,omitempty"` // best levels, for venues streaming depth
+	Timestamp time.Time  `json:"timestamp"`
+
+	// Perpetual swaps only
+	MarkPrice   float64   `json:"mark_price,omitempty"`
+	FundingRate float64   `json:"funding_rate,omitempty"` // paid by longs to shorts at NextFunding, per interval
+	NextFunding time.Time `json:"next_funding,omitzero"`
+
+	// Trade stream messages only; they carry no prices
+	Trades []MarketTrade `json:"trades,omitempty"`
 }
 
 // Add fee and slippage config
@@ -31,58 +63,259 @@ var exchangeSlippage = map[string]float64{
 
 // ArbitrageOpportunity represents a potential arbitrage opportunity
 type ArbitrageOpportunity struct {
-	BuyExchange  string
-	SellExchange string
-	Symbol       string
-	BuyPrice     float64
-	SellPrice    float64
-	Spread       float64
-	SpreadPercent float64
-	Timestamp    time.Time
-
-	BuyFee       float64
-	SellFee      float64
-	BuySlippage  float64
-	SellSlippage float64
-	EffBuyPrice  float64
-	EffSellPrice float64
+	ID            string    `json:"id"`
+	Type          string    `json:"type"` // one of the Opportunity types
+	BuyExchange   string    `json:"buy_exchange"`
+	SellExchange  string    `json:"sell_exchange"`
+	Symbol        string    `json:"symbol"`
+	BuySymbol     string    `json:"buy_symbol,omitempty"`  // the buy venue's symbol, when it is not Symbol
+	SellSymbol    string    `json:"sell_symbol,omitempty"` // the sell venue's symbol, when it is not Symbol
+	BuyPrice      float64   `json:"buy_price"`
+	SellPrice     float64   `json:"sell_price"`
+	Spread        float64   `json:"spread"`
+	SpreadPercent float64   `json:"spread_percent"`
+	Timestamp     time.Time `json:"timestamp"`
+
+	BuyFee       float64 `json:"buy_fee"`
+	SellFee      float64 `json:"sell_fee"`
+	BuySlippage  float64 `json:"buy_slippage"`
+	SellSlippage float64 `json:"sell_slippage"`
+	EffBuyPrice  float64 `json:"eff_buy_price"`
+	EffSellPrice float64 `json:"eff_sell_price"`
+
+	QuoteTime time.Time `json:"quote_time"` // receive time of the oldest quote used
+
+	Legs []OpportunityLeg `json:"legs,omitempty"` // the orders of a multi-leg opportunity, in order
+
+	// Basis trades only
+	FundingRate       float64 `json:"funding_rate,omitempty"`       // the perpetual's next funding rate
+	AnnualizedPercent float64 `json:"annualized_percent,omitempty"` // expected annual return after costs, funding included
+
+	EV      *ExpectedValue `json:"ev,omitempty"`      // set when the expected value model selected it
+	Signals *SignalCheck   `json:"signals,omitempty"` // set when the microstructure signals were checked
+}
+
+// LegSymbols returns the symbols the buy and sell legs of a two venue
+// opportunity trade. Venues list the same market under different symbols,
+// such as Kraken's DOGEUSD against Binance's DOGEUSDT.
+func (o ArbitrageOpportunity) LegSymbols() (buy, sell string) {
+	buy, sell = o.BuySymbol, o.SellSymbol
+	if buy == "" {
+		buy = o.Symbol
+	}
+	if sell == "" {
+		sell = o.Symbol
+	}
+	return buy, sell
 }
 
 // ArbitrageStrategy manages the arbitrage detection across multiple exchanges
 type ArbitrageStrategy struct {
-	quotes     map[string]Quote
-	quotesLock sync.RWMutex
-	minSpread  float64 // minimum spread percentage to consider arbitrage
-	pnlManager *PnLManager
+	name        string
+	types       map[string]bool // opportunity types traded; nil for all
+	quotes      map[string]Quote
+	books       map[string]Quote       // venue/symbol -> latest quote, for triangles
+	quoteStats  map[string]*quoteStats // venue/symbol -> quote lifetime and volatility
+	quotesLock  sync.RWMutex
+	signals     map[string]*bookSignals // venue/symbol -> microstructure signals
+	triangles   []Triangle
+	graph       GraphConfig
+	ev          EVConfig
+	signal      SignalConfig
+	basis       BasisConfig
+	maker       MakerConfig
+	makerLock   sync.Mutex // guards makerOrders and makerSeq
+	makerOrders []*RestingOrder
+	makerSeq    int
+	tracker     *opportunityTracker
+	params      atomic.Pointer[Params]
+	paramsLock  sync.Mutex // serializes UpdateParams
+	pnlManager  *PnLManager
+	riskEngine  *risk.Engine
+	breakers    *risk.Breakers
+	events      *EventBus
+	clock       clock.Clock
+	heartbeat   atomic.Int64 // unix nanos of the last strategy loop iteration
+	oppSeq      atomic.Uint64
+	lastPnLLog  time.Time // touched by OnTimer only
 }
 
 // NewArbitrageStrategy creates a new arbitrage strategy instance
 func NewArbitrageStrategy(minSpreadPercent float64, initialBalance, tradeSize float64) *ArbitrageStrategy {
-	return &ArbitrageStrategy{
-		quotes:    make(map[string]Quote),
-		minSpread: 0.0, // Lowered to 0 for more aggressive trading
-		pnlManager: NewPnLManager(initialBalance, tradeSize),
+	return newArbitrageStrategy(DefaultStrategyName, initialBalance, tradeSize)
+}
+
+func newArbitrageStrategy(name string, initialBalance, tradeSize float64) *ArbitrageStrategy {
+	as := &ArbitrageStrategy{
+		name:       name,
+		quotes:     make(map[string]Quote),
+		books:      make(map[string]Quote),
+		quoteStats: make(map[string]*quoteStats),
+		signals:    make(map[string]*bookSignals),
+		graph:      DefaultGraphConfig(),
+		ev:         DefaultEVConfig(),
+		signal:     DefaultSignalConfig(),
+		basis:      DefaultBasisConfig(),
+		maker:      DefaultMakerConfig(),
+		tracker:    newOpportunityTracker(),
+		pnlManager: newPnLManager(name, initialBalance, tradeSize),
+		riskEngine: risk.NewEngine(risk.DefaultLimits()),
+		breakers:   risk.NewBreakers(risk.DefaultBreakerConfig()),
+		events:     NewEventBus(),
+		clock:      clock.Real,
 	}
+	as.params.Store(&Params{
+		MinSpreadPercent: DefaultMinSpreadPercent,
+		TradeSize:        tradeSize,
+	})
+	return as
+}
+
+// SetClock makes the strategy, its ledger and its risk checks read the time
+// from c instead of the wall clock. It must be called before the strategy
+// receives quotes.
+func (as *ArbitrageStrategy) SetClock(c clock.Clock) {
+	as.clock = c
+	as.pnlManager.SetClock(c)
+	as.riskEngine.SetClock(c)
+	as.breakers.SetClock(c)
 }
 
-// UpdateQuote updates the latest quote for an exchange
+// UpdateQuote updates the latest quote for an exchange. Quotes rejected by
+// the circuit breakers are discarded. Quotes of markets other than the
+// cross-venue pairs, such as triangle cross rates and perpetuals, only update
+// the book store and bypass the breakers, which compare venues with each
+// other. Trade stream messages only feed the microstructure signals.
 func (as *ArbitrageStrategy) UpdateQuote(quote Quote) {
+	if as.params.Load().DisabledVenues[quote.Exchange] {
+		return
+	}
+	if quote.TradesOnly() {
+		as.recordTrades(quote)
+		return
+	}
+	if as.bookOnly(quote) {
+		as.updateBook(quote)
+		return
+	}
+	if err := as.breakers.CheckQuote(quote.Exchange, quote.Bid, quote.Ask, quote.Timestamp); err != nil {
+		return
+	}
+
 	as.quotesLock.Lock()
-	defer as.quotesLock.Unlock()
-	
 	as.quotes[quote.Exchange] = quote
+	as.books[bookKey(quote.Exchange, quote.Symbol)] = quote
+	as.recordQuote(quote)
+	as.recordSignals(quote)
+	as.quotesLock.Unlock()
+
+	as.events.Publish(TopicQuotes, EventQuote, quote)
+	as.fillResting(quote)
+}
+
+// missedOpportunity is a positive pre-fee spread that fees turned negative
+type missedOpportunity struct {
+	buyExchange      string
+	sellExchange     string
+	symbol           string
+	buyPrice         float64
+	sellPrice        float64
+	spreadPercent    float64
+	netProfitPercent float64
 }
 
 // FindArbitrageOpportunities analyzes current quotes and finds arbitrage opportunities
 func (as *ArbitrageStrategy) FindArbitrageOpportunities() []ArbitrageOpportunity {
+	start := time.Now()
+	var opportunities []ArbitrageOpportunity
+	var missed []missedOpportunity
+	if as.trades(OpportunityCrossVenue) {
+		opportunities, missed = as.scanQuotes()
+	}
+	if as.trades(OpportunityTriangular) {
+		opportunities = append(opportunities, as.scanTriangles()...)
+	}
+	if as.trades(OpportunityCycle) {
+		opportunities = append(opportunities, as.scanGraph()...)
+	}
+	if as.trades(OpportunityBasis) {
+		opportunities = append(opportunities, as.scanBasis()...)
+	}
+	opportunities, negative := as.selectByEV(opportunities)
+	opportunities, signalled := as.selectBySignals(opportunities)
+	evaluationDuration.Observe(time.Since(start).Seconds())
+
+	// Logging happens after the quotes lock is released and is sampled per
+	// venue pair, so it cannot hold up quote updates or the next evaluation
+	for _, m := range missed {
+		ok, suppressed := missedLogSampler.Allow(m.buyExchange + "->" + m.sellExchange)
+		if !ok {
+			continue
+		}
+		logger.Info("missed opportunity",
+			"buy_venue", m.buyExchange,
+			"sell_venue", m.sellExchange,
+			"buy_price", m.buyPrice,
+			"sell_price", m.sellPrice,
+			"spread_pct", m.spreadPercent,
+			"net_pct", m.netProfitPercent,
+			"suppressed", suppressed)
+	}
+
+	// Every spread seen, traded or not, starts or continues a lifecycle.
+	// Only spreads admitted by the cooldown and re-entry rules are returned,
+	// so a spread that persists is not executed on every tick.
+	sightings := make([]sighting, 0, len(opportunities)+len(negative)+len(signalled)+len(missed))
+	for _, opp := range opportunities {
+		sightings = append(sightings, sighting{opp: opp, outcome: reasonDetected, edge: netEdge(opp)})
+	}
+	for _, opp := range negative {
+		sightings = append(sightings, sighting{opp: opp, outcome: reasonNegativeEV, edge: netEdge(opp)})
+	}
+	for _, opp := range signalled {
+		sightings = append(sightings, sighting{opp: opp, outcome: reasonSignal, edge: netEdge(opp)})
+	}
+	for _, m := range missed {
+		opp := ArbitrageOpportunity{
+			Type:          OpportunityCrossVenue,
+			BuyExchange:   m.buyExchange,
+			SellExchange:  m.sellExchange,
+			Symbol:        m.symbol,
+			BuyPrice:      m.buyPrice,
+			SellPrice:     m.sellPrice,
+			SpreadPercent: m.spreadPercent,
+		}
+		sightings = append(sightings, sighting{opp: opp, outcome: reasonBelowThreshold, edge: m.netProfitPercent})
+	}
+	fresh, closed := as.tracker.observe(sightings, as.clock.Now(), func() string {
+		return fmt.Sprintf("%sopp-%d", as.idPrefix(), as.oppSeq.Add(1))
+	}, as.booksRefreshed)
+	for _, l := range closed {
+		if l.Entries > 0 {
+			as.events.Publish(TopicOpportunities, EventClosed, l)
+		}
+	}
+	return fresh
+}
+
+// scanQuotes compares every pair of venues under the quotes read lock
+func (as *ArbitrageStrategy) scanQuotes() ([]ArbitrageOpportunity, []missedOpportunity) {
 	as.quotesLock.RLock()
 	defer as.quotesLock.RUnlock()
 
 	var opportunities []ArbitrageOpportunity
+	var missed []missedOpportunity
 	exchanges := make([]string, 0, len(as.quotes))
-	
-	// Collect all exchanges with valid quotes
+
+	// Collect all exchanges with valid quotes that are not paused by a
+	// breaker or an operator
+	now := as.clock.Now()
+	params := as.params.Load()
 	for exchange, quote := range as.quotes {
+		quoteAge.WithLabelValues(exchange).Set(now.Sub(quote.Timestamp).Seconds())
+		if paused, _ := as.breakers.Paused(exchange, now); paused || !params.Tradable(exchange) {
+			continue
+		}
 		if quote.Bid > 0 && quote.Ask > 0 {
 			exchanges = append(exchanges, exchange)
 		}
@@ -90,15 +323,18 @@ func (as *ArbitrageStrategy) FindArbitrageOpportunities() []ArbitrageOpportunity
 
 	// Need at least 2 exchanges to find arbitrage
 	if len(exchanges) < 2 {
-		return opportunities
+		return opportunities, missed
 	}
+	// Map order is random; a fixed order makes runs over the same quotes
+	// produce the same opportunities
+	sort.Strings(exchanges)
 
 	// Compare all pairs of exchanges
 	for i := 0; i < len(exchanges); i++ {
 		for j := i + 1; j < len(exchanges); j++ {
 			exchange1 := exchanges[i]
 			exchange2 := exchanges[j]
-			
+
 			quote1 := as.quotes[exchange1]
 			quote2 := as.quotes[exchange2]
 
@@ -106,29 +342,42 @@ func (as *ArbitrageStrategy) FindArbitrageOpportunities() []ArbitrageOpportunity
 			if quote1.Ask < quote2.Bid {
 				spread := quote2.Bid - quote1.Ask
 				spreadPercent := (spread / quote1.Ask) * 100
-				effBuy := quote1.Ask * (1 + exchangeFees[exchange1] + exchangeSlippage[exchange1])
-				effSell := quote2.Bid * (1 - exchangeFees[exchange2] - exchangeSlippage[exchange2])
+				effBuy, effSell := params.effectivePrices(exchange1, quote1.Ask, exchange2, quote2.Bid)
 				netProfit := effSell - effBuy
 				netProfitPercent := (netProfit / effBuy) * 100
-				if netProfit > 0 {
+				if netProfit > 0 && netProfitPercent >= params.MinSpreadPercent {
 					opportunities = append(opportunities, ArbitrageOpportunity{
+						Type:          OpportunityCrossVenue,
 						BuyExchange:   exchange1,
 						SellExchange:  exchange2,
 						Symbol:        quote1.Symbol,
+						BuySymbol:     quote1.Symbol,
+						SellSymbol:    quote2.Symbol,
 						BuyPrice:      quote1.Ask,
 						SellPrice:     quote2.Bid,
 						Spread:        spread,
 						SpreadPercent: spreadPercent,
-						Timestamp:     time.Now(),
-						BuyFee:        exchangeFees[exchange1],
-						SellFee:       exchangeFees[exchange2],
+						Timestamp:     now,
+						BuyFee:        params.Fee(exchange1),
+						SellFee:       params.Fee(exchange2),
 						BuySlippage:   exchangeSlippage[exchange1],
 						SellSlippage:  exchangeSlippage[exchange2],
 						EffBuyPrice:   effBuy,
 						EffSellPrice:  effSell,
+						QuoteTime:     olderOf(quote1.Timestamp, quote2.Timestamp),
 					})
+					opportunitiesTotal.WithLabelValues(reasonDetected).Inc()
 				} else if spreadPercent > 0 {
-					log.Printf("⚠️ Missed opportunity (pre-fee spread %.4f%%, net profit %.4f%%): BUY %s at %.2f, SELL %s at %.2f", spreadPercent, netProfitPercent, exchange1, quote1.Ask, exchange2, quote2.Bid)
+					opportunitiesTotal.WithLabelValues(reasonBelowThreshold).Inc()
+					missed = append(missed, missedOpportunity{
+						buyExchange:      exchange1,
+						sellExchange:     exchange2,
+						symbol:           quote1.Symbol,
+						buyPrice:         quote1.Ask,
+						sellPrice:        quote2.Bid,
+						spreadPercent:    spreadPercent,
+						netProfitPercent: netProfitPercent,
+					})
 				}
 			}
 
@@ -136,35 +385,56 @@ func (as *ArbitrageStrategy) FindArbitrageOpportunities() []ArbitrageOpportunity
 			if quote2.Ask < quote1.Bid {
 				spread := quote1.Bid - quote2.Ask
 				spreadPercent := (spread / quote2.Ask) * 100
-				effBuy := quote2.Ask * (1 + exchangeFees[exchange2] + exchangeSlippage[exchange2])
-				effSell := quote1.Bid * (1 - exchangeFees[exchange1] - exchangeSlippage[exchange1])
+				effBuy, effSell := params.effectivePrices(exchange2, quote2.Ask, exchange1, quote1.Bid)
 				netProfit := effSell - effBuy
 				netProfitPercent := (netProfit / effBuy) * 100
-				if netProfit > 0 {
+				if netProfit > 0 && netProfitPercent >= params.MinSpreadPercent {
 					opportunities = append(opportunities, ArbitrageOpportunity{
+						Type:          OpportunityCrossVenue,
 						BuyExchange:   exchange2,
 						SellExchange:  exchange1,
 						Symbol:        quote2.Symbol,
+						BuySymbol:     quote2.Symbol,
+						SellSymbol:    quote1.Symbol,
 						BuyPrice:      quote2.Ask,
 						SellPrice:     quote1.Bid,
 						Spread:        spread,
 						SpreadPercent: spreadPercent,
-						Timestamp:     time.Now(),
-						BuyFee:        exchangeFees[exchange2],
-						SellFee:       exchangeFees[exchange1],
+						Timestamp:     now,
+						BuyFee:        params.Fee(exchange2),
+						SellFee:       params.Fee(exchange1),
 						BuySlippage:   exchangeSlippage[exchange2],
 						SellSlippage:  exchangeSlippage[exchange1],
 						EffBuyPrice:   effBuy,
 						EffSellPrice:  effSell,
+						QuoteTime:     olderOf(quote2.Timestamp, quote1.Timestamp),
 					})
+					opportunitiesTotal.WithLabelValues(reasonDetected).Inc()
 				} else if spreadPercent > 0 {
-					log.Printf("⚠️ Missed opportunity (pre-fee spread %.4f%%, net profit %.4f%%): BUY %s at %.2f, SELL %s at %.2f", spreadPercent, netProfitPercent, exchange2, quote2.Ask, exchange1, quote1.Bid)
+					opportunitiesTotal.WithLabelValues(reasonBelowThreshold).Inc()
+					missed = append(missed, missedOpportunity{
+						buyExchange:      exchange2,
+						sellExchange:     exchange1,
+						symbol:           quote2.Symbol,
+						buyPrice:         quote2.Ask,
+						sellPrice:        quote1.Bid,
+						spreadPercent:    spreadPercent,
+						netProfitPercent: netProfitPercent,
+					})
 				}
 			}
 		}
 	}
 
-	return opportunities
+	return opportunities, missed
+}
+
+// olderOf returns the earlier of two timestamps
+func olderOf(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
 }
 
 // PrintOpportunities prints arbitrage opportunities in a formatted way
@@ -173,21 +443,116 @@ func (as *ArbitrageStrategy) PrintOpportunities(opportunities []ArbitrageOpportu
 		return
 	}
 
-	log.Println("=== ARBITRAGE OPPORTUNITIES ===")
 	for _, opp := range opportunities {
-		log.Printf("💰 BUY on %s at %.2f, SELL on %s at %.2f", 
-			opp.BuyExchange, opp.BuyPrice, opp.SellExchange, opp.SellPrice)
-		log.Printf("   Spread: $%.2f (%.2f%%)", opp.Spread, opp.SpreadPercent)
-		log.Printf("   Time: %s", opp.Timestamp.Format("15:04:05.000"))
-		
-		// Execute the arbitrage opportunity
-		err := as.pnlManager.ExecuteArbitrage(opp)
-		if err != nil {
-			log.Printf("❌ Failed to execute arbitrage: %v", err)
+		attrs := []any{
+			"opp_id", opp.ID,
+			"symbol", opp.Symbol,
+			"buy_venue", opp.BuyExchange,
+			"sell_venue", opp.SellExchange,
+			"buy_price", opp.BuyPrice,
+			"sell_price", opp.SellPrice,
+			"spread", opp.Spread,
+			"spread_pct", opp.SpreadPercent,
 		}
-		
-		log.Println("---")
+		if opp.EV != nil {
+			attrs = append(attrs,
+				"edge_pct", opp.EV.EdgePercent,
+				"live_probability", opp.EV.LiveProbability,
+				"fill_ratio", opp.EV.FillRatio,
+				"adverse_pct", opp.EV.AdversePercent,
+				"leg_risk_pct", opp.EV.LegRiskPercent,
+				"ev_pct", opp.EV.EVPercent)
+		}
+		if opp.Signals != nil {
+			attrs = append(attrs,
+				"microprice_pct", opp.Signals.MicropricePercent,
+				"imbalance", opp.Signals.Imbalance,
+				"trade_flow", opp.Signals.TradeFlow,
+				"drift_pct", opp.Signals.DriftPercent,
+				"required_pct", opp.Signals.RequiredPercent)
+		}
+		logger.Info("arbitrage opportunity", attrs...)
+		as.events.Publish(TopicOpportunities, EventDetected, opp)
+
+		// Execute the arbitrage opportunity
+		as.tracker.settle(opp.ID, as.executeOpportunity(opp), as.clock.Now())
+	}
+}
+
+// logRiskRejection logs an opportunity the risk engine rejected. While the
+// kill switch is engaged every tick is rejected, and the engine logged the
+// halt when it engaged, so those rejections are only logged at debug level.
+func logRiskRejection(msg, oppID string, err error) {
+	level := slog.LevelWarn
+	if errors.Is(err, risk.ErrHalted) {
+		level = slog.LevelDebug
 	}
+	logger.Log(context.Background(), level, msg, "opp_id", oppID, "err", err)
+}
+
+// executeOpportunity runs both legs of an opportunity through the risk
+// engine and executes them only if every check passes. It returns the
+// outcome, as counted in hft_opportunities_total.
+func (as *ArbitrageStrategy) executeOpportunity(opp ArbitrageOpportunity) string {
+	start := time.Now()
+	if !opp.QuoteTime.IsZero() {
+		quoteToDecision.Observe(as.clock.Now().Sub(opp.QuoteTime).Seconds())
+	}
+
+	if as.params.Load().TradingPaused {
+		opportunitiesTotal.WithLabelValues(reasonPaused).Inc()
+		as.events.Publish(TopicOpportunities, EventRejected,
+			RejectedOpportunity{Opportunity: opp, Reason: reasonPaused})
+		return reasonPaused
+	}
+
+	if opp.Type == OpportunityBasis {
+		return as.openBasis(opp)
+	}
+
+	quantity := as.pnlManager.TradeQuantity(opp)
+	buySymbol, sellSymbol := opp.LegSymbols()
+	orders := []risk.Order{
+		{Venue: opp.BuyExchange, Symbol: buySymbol, Side: "BUY", Price: opp.BuyPrice, Quantity: quantity},
+		{Venue: opp.SellExchange, Symbol: sellSymbol, Side: "SELL", Price: opp.SellPrice, Quantity: quantity},
+	}
+	var conversions []risk.Order
+	if len(opp.Legs) > 0 {
+		orders, conversions = legOrders(opp, quantity)
+	}
+
+	if err := as.riskEngine.CheckOrders(orders...); err != nil {
+		opportunitiesTotal.WithLabelValues(reasonRiskRejected).Inc()
+		logRiskRejection("risk check rejected arbitrage", opp.ID, err)
+		as.events.Publish(TopicOpportunities, EventRejected,
+			RejectedOpportunity{Opportunity: opp, Reason: reasonRiskRejected, Error: err.Error()})
+		return reasonRiskRejected
+	}
+
+	roundTrip, err := as.pnlManager.ExecuteArbitrage(opp)
+	if err != nil {
+		opportunitiesTotal.WithLabelValues(reasonExecutionFailed).Inc()
+		logger.Error("arbitrage execution failed", "opp_id", opp.ID, "err", err)
+		as.events.Publish(TopicOpportunities, EventRejected,
+			RejectedOpportunity{Opportunity: opp, Reason: reasonExecutionFailed, Error: err.Error()})
+		return reasonExecutionFailed
+	}
+	opportunitiesTotal.WithLabelValues(reasonExecuted).Inc()
+	executionsTotal.WithLabelValues(opp.BuyExchange, opp.SellExchange).Inc()
+	executionDuration.Observe(time.Since(start).Seconds())
+
+	// Fills are scaled by the share of the requested quantity that filled
+	for _, o := range append(orders, conversions...) {
+		o.Quantity *= roundTrip.Quantity / quantity
+		as.riskEngine.OnFill(o)
+	}
+	as.riskEngine.OnArbitrageClosed(roundTrip.PnL)
+
+	as.events.Publish(TopicTrades, EventExecuted, roundTrip)
+	if as.events.Active() {
+		as.events.Publish(TopicPnL, EventPnL, as.pnlManager.GetCurrentPnL())
+	}
+	return reasonExecuted
 }
 
 // GetQuoteSummary returns a summary of all current quotes
@@ -197,41 +562,60 @@ func (as *ArbitrageStrategy) GetQuoteSummary() string {
 
 	summary := "Current Quotes:\n"
 	for exchange, quote := range as.quotes {
-		summary += fmt.Sprintf("  %s: Bid=%.2f, Ask=%.2f, Spread=%.2f%%\n", 
-			exchange, quote.Bid, quote.Ask, 
+		summary += fmt.Sprintf("  %s: Bid=%.2f, Ask=%.2f, Spread=%.2f%%\n",
+			exchange, quote.Bid, quote.Ask,
 			((quote.Ask-quote.Bid)/quote.Bid)*100)
 	}
 	return summary
 }
 
-// RunArbitrageStrategy runs the main arbitrage strategy loop
+// Evaluate runs one iteration of the strategy loop: it manages the open
+// basis positions and resting maker orders, then looks for opportunities in
+// the current quotes and executes them
+func (as *ArbitrageStrategy) Evaluate() {
+	as.heartbeat.Store(as.clock.Now().UnixNano())
+	as.manageBasis()
+	as.manageMaker()
+	opportunities := as.FindArbitrageOpportunities()
+	if len(opportunities) > 0 {
+		as.PrintOpportunities(opportunities)
+	}
+}
+
+// RunArbitrageStrategy runs the strategy on its own runtime until
+// quoteChan is closed
 func (as *ArbitrageStrategy) RunArbitrageStrategy(quoteChan <-chan Quote) {
-	log.Println("Starting arbitrage strategy...")
-	
-	ticker := time.NewTicker(100 * time.Millisecond) // Check every 100ms
-	pnlTicker := time.NewTicker(5 * time.Second) // Print P&L every 5 seconds
-	defer ticker.Stop()
-	defer pnlTicker.Stop()
-
-	for {
-		select {
-		case quote := <-quoteChan:
-			as.UpdateQuote(quote)
-			
-		case <-ticker.C:
-			opportunities := as.FindArbitrageOpportunities()
-			if len(opportunities) > 0 {
-				as.PrintOpportunities(opportunities)
-			}
-			
-		case <-pnlTicker.C:
-			// Print P&L status periodically
-			as.pnlManager.PrintPnLStatus()
-		}
+	runtime := NewRuntime(as)
+	runtime.SetClock(as.clock)
+	runtime.Run(quoteChan)
+}
+
+// LastHeartbeat returns when the strategy loop last ran an evaluation; zero
+// if the loop has not started
+func (as *ArbitrageStrategy) LastHeartbeat() time.Time {
+	nanos := as.heartbeat.Load()
+	if nanos == 0 {
+		return time.Time{}
 	}
+	return time.Unix(0, nanos)
 }
 
 // GetPnLManager returns the P&L manager for external access
 func (as *ArbitrageStrategy) GetPnLManager() *PnLManager {
 	return as.pnlManager
-} 
\ No newline at end of file
+}
+
+// GetRiskEngine returns the pre-trade risk engine for external access
+func (as *ArbitrageStrategy) GetRiskEngine() *risk.Engine {
+	return as.riskEngine
+}
+
+// GetEventBus returns the bus strategy events are published on
+func (as *ArbitrageStrategy) GetEventBus() *EventBus {
+	return as.events
+}
+
+// GetCircuitBreakers returns the market data circuit breakers for external access
+func (as *ArbitrageStrategy) GetCircuitBreakers() *risk.Breakers {
+	return as.breakers
+}
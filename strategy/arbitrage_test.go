@@ -0,0 +1,94 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCrossVenueLegSymbols(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name              string
+		kraken, binance   Quote
+		wantBuy, wantSell string // venues
+		wantBuySymbol     string
+		wantSellSymbol    string
+	}{
+		{
+			name:           "buy kraken, sell binance",
+			kraken:         Quote{Bid: 0.0999, Ask: 0.1000},
+			binance:        Quote{Bid: 0.1020, Ask: 0.1021},
+			wantBuy:        "kraken",
+			wantSell:       "binance",
+			wantBuySymbol:  "DOGE/USD",
+			wantSellSymbol: "DOGEUSDT",
+		},
+		{
+			name:           "buy binance, sell kraken",
+			kraken:         Quote{Bid: 0.1020, Ask: 0.1021},
+			binance:        Quote{Bid: 0.0999, Ask: 0.1000},
+			wantBuy:        "binance",
+			wantSell:       "kraken",
+			wantBuySymbol:  "DOGEUSDT",
+			wantSellSymbol: "DOGE/USD",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as := newTestStrategy(now)
+			kraken, binance := tt.kraken, tt.binance
+			kraken.Exchange, kraken.Symbol, kraken.Timestamp = "kraken", "DOGE/USD", now
+			binance.Exchange, binance.Symbol, binance.Timestamp = "binance", "DOGEUSDT", now
+			as.UpdateQuote(kraken)
+			as.UpdateQuote(binance)
+
+			opportunities, _ := as.scanQuotes()
+			if len(opportunities) != 1 {
+				t.Fatalf("found %d opportunities, want 1", len(opportunities))
+			}
+			opp := opportunities[0]
+			buy, sell := opp.LegSymbols()
+			if opp.BuyExchange != tt.wantBuy || opp.SellExchange != tt.wantSell || buy != tt.wantBuySymbol || sell != tt.wantSellSymbol {
+				t.Errorf("buy %s %s, sell %s %s; want buy %s %s, sell %s %s",
+					opp.BuyExchange, buy, opp.SellExchange, sell, tt.wantBuy, tt.wantBuySymbol, tt.wantSell, tt.wantSellSymbol)
+			}
+
+			as.Evaluate()
+			page, err := as.GetPnLManager().QueryTrades(TradeQuery{Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(page.Trades) != 2 {
+				t.Fatalf("booked %d trades, want 2", len(page.Trades))
+			}
+			for _, trade := range page.Trades {
+				want := tt.wantSellSymbol
+				if trade.Type == "BUY" {
+					want = tt.wantBuySymbol
+				}
+				if trade.Symbol != want {
+					t.Errorf("%s trade on %s booked as %s, want %s", trade.Type, trade.Exchange, trade.Symbol, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLegSymbols(t *testing.T) {
+	tests := []struct {
+		name              string
+		opp               ArbitrageOpportunity
+		wantBuy, wantSell string
+	}{
+		{name: "per leg", opp: ArbitrageOpportunity{Symbol: "DOGE/USD", BuySymbol: "DOGE/USD", SellSymbol: "DOGEUSDT"}, wantBuy: "DOGE/USD", wantSell: "DOGEUSDT"},
+		{name: "falls back to the symbol", opp: ArbitrageOpportunity{Symbol: "DOGEUSDT"}, wantBuy: "DOGEUSDT", wantSell: "DOGEUSDT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if buy, sell := tt.opp.LegSymbols(); buy != tt.wantBuy || sell != tt.wantSell {
+				t.Errorf("LegSymbols() = %s, %s; want %s, %s", buy, sell, tt.wantBuy, tt.wantSell)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// RoundTrip records one executed arbitrage (the buy leg and the sell leg together)
+type RoundTrip struct {
+	ID           string    `json:"id"`
+	Type         string    `json:"type"` // one of the Opportunity types
+	BuyExchange  string    `json:"buy_exchange"`
+	SellExchange string    `json:"sell_exchange"`
+	Symbol       string    `json:"symbol"`
+	Quantity     float64   `json:"quantity"`
+	BuyPrice     float64   `json:"buy_price"`  // quoted ask on the buy venue
+	SellPrice    float64   `json:"sell_price"` // quoted bid on the sell venue
+	GrossEdge    float64   `json:"gross_edge"` // pre-fee spread in percent
+	NetEdge      float64   `json:"net_edge"`   // spread after fees and slippage in percent
+	Fees         float64   `json:"fees"`
+	Funding      float64   `json:"funding,omitempty"` // settled while a basis position was open, included in PnL
+	PnL          float64   `json:"pnl"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// AttributionBucket aggregates the round trips that share a key
+type AttributionBucket struct {
+	Key              string  `json:"key"`
+	Trades           int     `json:"trades"`
+	PnL              float64 `json:"pnl"`
+	Fees             float64 `json:"fees"`
+	AverageGrossEdge float64 `json:"average_gross_edge"`
+	AverageNetEdge   float64 `json:"average_net_edge"`
+	WinRate          float64 `json:"win_rate"`
+}
+
+// PnLAttribution breaks P&L down by venue pair, symbol and hour of day
+type PnLAttribution struct {
+	ByVenuePair []AttributionBucket `json:"by_venue_pair"`
+	BySymbol    []AttributionBucket `json:"by_symbol"`
+	ByHour      []AttributionBucket `json:"by_hour"`
+	RoundTrips  int                 `json:"round_trips"`
+}
+
+// Attribution dimensions accepted by GetAttributionBy
+const (
+	AttributionByVenuePair = "pair"
+	AttributionBySymbol    = "symbol"
+	AttributionByHour      = "hour"
+)
+
+// newRoundTrip builds the attribution record for an executed opportunity
+func newRoundTrip(id string, opp ArbitrageOpportunity, fill Execution, pnl float64) RoundTrip {
+	netEdge := 0.0
+	if opp.EffBuyPrice > 0 {
+		netEdge = (opp.EffSellPrice - opp.EffBuyPrice) / opp.EffBuyPrice * 100
+	}
+
+	return RoundTrip{
+		ID:           id,
+		Type:         opp.Type,
+		BuyExchange:  opp.BuyExchange,
+		SellExchange: opp.SellExchange,
+		Symbol:       opp.Symbol,
+		Quantity:     fill.Quantity,
+		BuyPrice:     opp.BuyPrice,
+		SellPrice:    opp.SellPrice,
+		GrossEdge:    opp.SpreadPercent,
+		NetEdge:      netEdge,
+		Fees:         fill.BuyFee + fill.SellFee,
+		PnL:          pnl,
+		Timestamp:    fill.Time,
+	}
+}
+
+// VenuePair returns the directed "buy->sell" key of the round trip
+func (rt RoundTrip) VenuePair() string {
+	return fmt.Sprintf("%s->%s", rt.BuyExchange, rt.SellExchange)
+}
+
+// Hour returns the UTC hour of day the round trip was executed in
+func (rt RoundTrip) Hour() string {
+	return fmt.Sprintf("%02d:00", rt.Timestamp.UTC().Hour())
+}
+
+// GetAttribution returns P&L broken down by every supported dimension
+func (pm *PnLManager) GetAttribution() PnLAttribution {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	return PnLAttribution{
+		ByVenuePair: attribute(pm.roundTrips, RoundTrip.VenuePair, byPnL),
+		BySymbol:    attribute(pm.roundTrips, func(rt RoundTrip) string { return rt.Symbol }, byPnL),
+		ByHour:      attribute(pm.roundTrips, RoundTrip.Hour, byKey),
+		RoundTrips:  len(pm.roundTrips),
+	}
+}
+
+// GetAttributionBy returns P&L broken down by a single dimension
+func (pm *PnLManager) GetAttributionBy(dimension string) ([]AttributionBucket, error) {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	switch dimension {
+	case AttributionByVenuePair:
+		return attribute(pm.roundTrips, RoundTrip.VenuePair, byPnL), nil
+	case AttributionBySymbol:
+		return attribute(pm.roundTrips, func(rt RoundTrip) string { return rt.Symbol }, byPnL), nil
+	case AttributionByHour:
+		return attribute(pm.roundTrips, RoundTrip.Hour, byKey), nil
+	default:
+		return nil, fmt.Errorf("unknown attribution dimension %q (want %s, %s or %s)",
+			dimension, AttributionByVenuePair, AttributionBySymbol, AttributionByHour)
+	}
+}
+
+// bucketOrder sorts attribution buckets
+type bucketOrder func(a, b AttributionBucket) bool
+
+// byPnL puts the most profitable bucket first
+func byPnL(a, b AttributionBucket) bool {
+	if a.PnL != b.PnL {
+		return a.PnL > b.PnL
+	}
+	return a.Key < b.Key
+}
+
+// byKey sorts buckets by key, which keeps hours in chronological order
+func byKey(a, b AttributionBucket) bool {
+	return a.Key < b.Key
+}
+
+// attribute groups round trips by key and aggregates each group
+func attribute(roundTrips []RoundTrip, key func(RoundTrip) string, less bucketOrder) []AttributionBucket {
+	index := make(map[string]int)
+	buckets := make([]AttributionBucket, 0)
+	wins := make([]int, 0)
+
+	for _, rt := range roundTrips {
+		k := key(rt)
+		i, ok := index[k]
+		if !ok {
+			i = len(buckets)
+			index[k] = i
+			buckets = append(buckets, AttributionBucket{Key: k})
+			wins = append(wins, 0)
+		}
+
+		b := &buckets[i]
+		b.Trades++
+		b.PnL += rt.PnL
+		b.Fees += rt.Fees
+		// Accumulate sums here and turn them into averages below
+		b.AverageGrossEdge += rt.GrossEdge
+		b.AverageNetEdge += rt.NetEdge
+		if rt.PnL > 0 {
+			wins[i]++
+		}
+	}
+
+	for i := range buckets {
+		b := &buckets[i]
+		b.AverageGrossEdge /= float64(b.Trades)
+		b.AverageNetEdge /= float64(b.Trades)
+		b.WinRate = float64(wins[i]) / float64(b.Trades) * 100
+	}
+
+	sort.Slice(buckets, func(i, j int) bool { return less(buckets[i], buckets[j]) })
+	return buckets
+}
@@ -0,0 +1,86 @@
+package strategy
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAttribute(t *testing.T) {
+	at := func(hour int) time.Time { return time.Date(2026, 1, 5, hour, 30, 0, 0, time.UTC) }
+	roundTrips := []RoundTrip{
+		{BuyExchange: "okx", SellExchange: "binance", Symbol: "DOGEUSDT", GrossEdge: 0.4, NetEdge: 0.2, Fees: 0.2, PnL: 1, Timestamp: at(9)},
+		{BuyExchange: "okx", SellExchange: "binance", Symbol: "DOGEUSDT", GrossEdge: 0.2, NetEdge: 0, Fees: 0.2, PnL: -0.5, Timestamp: at(14)},
+		{BuyExchange: "binance", SellExchange: "okx", Symbol: "DOGEUSDT", GrossEdge: 0.6, NetEdge: 0.4, Fees: 0.2, PnL: 2, Timestamp: at(9)},
+		{BuyExchange: "kraken", SellExchange: "okx", Symbol: "DOGEUSD", GrossEdge: 0.3, NetEdge: -0.1, Fees: 0.3, PnL: -1, Timestamp: at(23)},
+	}
+
+	tests := []struct {
+		name  string
+		trips []RoundTrip
+		key   func(RoundTrip) string
+		less  bucketOrder
+		want  []AttributionBucket
+	}{
+		{
+			name:  "venue pair, most profitable first",
+			trips: roundTrips,
+			key:   RoundTrip.VenuePair,
+			less:  byPnL,
+			want: []AttributionBucket{
+				{Key: "binance->okx", Trades: 1, PnL: 2, Fees: 0.2, AverageGrossEdge: 0.6, AverageNetEdge: 0.4, WinRate: 100},
+				{Key: "okx->binance", Trades: 2, PnL: 0.5, Fees: 0.4, AverageGrossEdge: 0.3, AverageNetEdge: 0.1, WinRate: 50},
+				{Key: "kraken->okx", Trades: 1, PnL: -1, Fees: 0.3, AverageGrossEdge: 0.3, AverageNetEdge: -0.1, WinRate: 0},
+			},
+		},
+		{
+			name:  "hour, chronological",
+			trips: roundTrips,
+			key:   RoundTrip.Hour,
+			less:  byKey,
+			want: []AttributionBucket{
+				{Key: "09:00", Trades: 2, PnL: 3, Fees: 0.4, AverageGrossEdge: 0.5, AverageNetEdge: 0.3, WinRate: 100},
+				{Key: "14:00", Trades: 1, PnL: -0.5, Fees: 0.2, AverageGrossEdge: 0.2, AverageNetEdge: 0, WinRate: 0},
+				{Key: "23:00", Trades: 1, PnL: -1, Fees: 0.3, AverageGrossEdge: 0.3, AverageNetEdge: -0.1, WinRate: 0},
+			},
+		},
+		{
+			name: "no round trips",
+			key:  RoundTrip.VenuePair,
+			less: byPnL,
+			want: []AttributionBucket{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := attribute(tt.trips, tt.key, tt.less)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d buckets, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, want := range tt.want {
+				if !sameBucket(got[i], want) {
+					t.Errorf("bucket %d = %+v, want %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetAttributionBy(t *testing.T) {
+	pm := NewPnLManager(1000, 100)
+	for _, dimension := range []string{AttributionByVenuePair, AttributionBySymbol, AttributionByHour} {
+		if _, err := pm.GetAttributionBy(dimension); err != nil {
+			t.Errorf("GetAttributionBy(%q) = %v", dimension, err)
+		}
+	}
+	if _, err := pm.GetAttributionBy("venue"); err == nil {
+		t.Error("GetAttributionBy accepted an unknown dimension")
+	}
+}
+
+func sameBucket(a, b AttributionBucket) bool {
+	near := func(x, y float64) bool { return math.Abs(x-y) < 1e-9 }
+	return a.Key == b.Key && a.Trades == b.Trades && near(a.PnL, b.PnL) && near(a.Fees, b.Fees) &&
+		near(a.AverageGrossEdge, b.AverageGrossEdge) && near(a.AverageNetEdge, b.AverageNetEdge) && near(a.WinRate, b.WinRate)
+}
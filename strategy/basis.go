@@ -0,0 +1,414 @@
+package strategy
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"hft-arbitrage-bot/risk"
+)
+
+// PerpSuffix marks the symbol of a perpetual swap, e.g. DOGEUSDT-PERP
+const PerpSuffix = "-PERP"
+
+// FundingInterval is how often the perpetuals traded settle funding
+const FundingInterval = 8 * time.Hour
+
+// year annualizes basis and funding
+const year = 365 * 24 * time.Hour
+
+// Directions of a basis position
+const (
+	BasisLongSpot  = "long_spot"  // spot bought, perpetual shorted
+	BasisShortSpot = "short_spot" // spot sold short, perpetual bought
+)
+
+// perpTakerFees are the base tier taker rates of the perpetuals; venues
+// without one pay their spot rate
+var perpTakerFees = map[string]float64{
+	"okx":   0.0005, // 0.05%
+	"bybit": 0.00055,
+}
+
+// PerpSymbol returns the symbol of the perpetual on a spot market, e.g.
+// DOGEUSDT-PERP for DOGE-USDT
+func PerpSymbol(spot string) string {
+	return NormalizeSymbol(spot) + PerpSuffix
+}
+
+// IsPerp reports whether a symbol is a perpetual swap
+func IsPerp(symbol string) bool {
+	return strings.HasSuffix(strings.ToUpper(symbol), PerpSuffix)
+}
+
+// BasisConfig configures the basis trade: spot against the same venue's
+// perpetual, opened when the annualized basis plus the expected funding
+// exceeds a threshold after costs and held until the basis converges.
+type BasisConfig struct {
+	Enabled      bool
+	Venues       []string      // venues whose perpetuals are traded
+	EntryPercent float64       // annualized edge after round trip costs needed to open
+	ExitPercent  float64       // annualized edge of staying in below which a position closes
+	Horizon      time.Duration // how long the basis is expected to take to converge; annualizes it and amortizes costs
+	MaxHold      time.Duration // positions close after this long regardless
+	MaxPositions int           // open positions per venue
+	AllowReverse bool          // short spot against a long perpetual when basis and funding are negative
+}
+
+// DefaultBasisConfig trades the OKX and Bybit perpetuals long spot only: a
+// spot short needs a margin borrow whose cost is not modelled
+func DefaultBasisConfig() BasisConfig {
+	return BasisConfig{
+		Enabled:      true,
+		Venues:       []string{"bybit", "okx"},
+		EntryPercent: 15,
+		ExitPercent:  2,
+		Horizon:      7 * 24 * time.Hour,
+		MaxHold:      30 * 24 * time.Hour,
+		MaxPositions: 1,
+	}
+}
+
+// BasisConfigFromEnv builds the config from environment variables on top of
+// DefaultBasisConfig:
+//
+//	HFT_BASIS                off disables the basis trade
+//	HFT_BASIS_VENUES         comma separated venues
+//	HFT_BASIS_ENTRY          annualized percent needed to open
+//	HFT_BASIS_EXIT           annualized percent below which positions close
+//	HFT_BASIS_HORIZON        expected convergence time, e.g. 168h
+//	HFT_BASIS_MAX_HOLD       longest a position is held, e.g. 720h
+//	HFT_BASIS_MAX_POSITIONS  open positions per venue
+//	HFT_BASIS_REVERSE        on allows short spot, long perpetual
+func BasisConfigFromEnv() (BasisConfig, error) {
+	config := DefaultBasisConfig()
+
+	for name, field := range map[string]*bool{"HFT_BASIS": &config.Enabled, "HFT_BASIS_REVERSE": &config.AllowReverse} {
+		switch strings.ToLower(os.Getenv(name)) {
+		case "":
+		case "on", "true", "1":
+			*field = true
+		case "off", "false", "0":
+			*field = false
+		default:
+			return config, fmt.Errorf("invalid %s %q (want on or off)", name, os.Getenv(name))
+		}
+	}
+	if value := os.Getenv("HFT_BASIS_VENUES"); value != "" {
+		config.Venues = nil
+		for _, venue := range strings.Split(value, ",") {
+			if venue = strings.ToLower(strings.TrimSpace(venue)); venue != "" {
+				config.Venues = append(config.Venues, venue)
+			}
+		}
+	}
+	for name, field := range map[string]*float64{"HFT_BASIS_ENTRY": &config.EntryPercent, "HFT_BASIS_EXIT": &config.ExitPercent} {
+		if value := os.Getenv(name); value != "" {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return config, fmt.Errorf("invalid %s %q", name, value)
+			}
+			*field = v
+		}
+	}
+	for name, field := range map[string]*time.Duration{"HFT_BASIS_HORIZON": &config.Horizon, "HFT_BASIS_MAX_HOLD": &config.MaxHold} {
+		if value := os.Getenv(name); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return config, fmt.Errorf("invalid %s %q", name, value)
+			}
+			*field = d
+		}
+	}
+	if value := os.Getenv("HFT_BASIS_MAX_POSITIONS"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return config, fmt.Errorf("invalid HFT_BASIS_MAX_POSITIONS %q", value)
+		}
+		config.MaxPositions = n
+	}
+	return config, config.Validate()
+}
+
+// Validate checks the config for values the basis trade cannot work with
+func (c BasisConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.EntryPercent <= c.ExitPercent {
+		return fmt.Errorf("basis entry %.2f%% must be above exit %.2f%%", c.EntryPercent, c.ExitPercent)
+	}
+	if c.Horizon <= 0 {
+		return fmt.Errorf("basis horizon must be positive, got %s", c.Horizon)
+	}
+	if c.MaxHold <= 0 {
+		return fmt.Errorf("basis max hold must be positive, got %s", c.MaxHold)
+	}
+	if c.MaxPositions < 1 {
+		return fmt.Errorf("basis max positions must be at least 1, got %d", c.MaxPositions)
+	}
+	return nil
+}
+
+// SetBasis configures the basis trade. It must be called before the
+// strategy receives quotes.
+func (as *ArbitrageStrategy) SetBasis(config BasisConfig) {
+	as.quotesLock.Lock()
+	defer as.quotesLock.Unlock()
+	config.Venues = append([]string(nil), config.Venues...)
+	as.basis = config
+}
+
+// annualize turns a return over the horizon and a funding rate per interval
+// into an annual percentage
+func (c BasisConfig) annualize(basis, funding float64) float64 {
+	return (basis*float64(year)/float64(c.Horizon) + funding*float64(year/FundingInterval)) * 100
+}
+
+// perpFee returns the taker rate of a venue's perpetual
+func perpFee(venue string, params *Params) float64 {
+	if fee, ok := perpTakerFees[venue]; ok {
+		return fee
+	}
+	return params.Fee(venue)
+}
+
+// scanBasis prices opening a basis position on every configured venue with
+// fresh spot and perpetual books and room for another position
+func (as *ArbitrageStrategy) scanBasis() []ArbitrageOpportunity {
+	as.quotesLock.RLock()
+	defer as.quotesLock.RUnlock()
+
+	if !as.basis.Enabled {
+		return nil
+	}
+	now := as.clock.Now()
+	params := as.params.Load()
+	open := make(map[string]int)
+	for _, pos := range as.pnlManager.GetBasisPositions() {
+		open[pos.Venue]++
+	}
+
+	var opportunities []ArbitrageOpportunity
+	for _, venue := range as.basis.Venues {
+		if open[venue] >= as.basis.MaxPositions {
+			continue
+		}
+		if paused, _ := as.breakers.Paused(venue, now); paused || !params.Tradable(venue) {
+			continue
+		}
+		for key, perp := range as.books {
+			if !strings.HasPrefix(key, venue+"/") || !IsPerp(perp.Symbol) {
+				continue
+			}
+			spot, ok := as.books[bookKey(venue, strings.TrimSuffix(strings.ToUpper(perp.Symbol), PerpSuffix))]
+			if !ok || now.Sub(spot.Timestamp) > maxBookAge || now.Sub(perp.Timestamp) > maxBookAge {
+				continue
+			}
+			opp := as.basisOpportunity(BasisLongSpot, spot, perp, params, now)
+			if opp.AnnualizedPercent < as.basis.EntryPercent && as.basis.AllowReverse {
+				opp = as.basisOpportunity(BasisShortSpot, spot, perp, params, now)
+			}
+			if opp.AnnualizedPercent >= as.basis.EntryPercent {
+				opportunities = append(opportunities, opp)
+				opportunitiesTotal.WithLabelValues(reasonDetected).Inc()
+			} else if opp.SpreadPercent > 0 {
+				opportunitiesTotal.WithLabelValues(reasonBelowThreshold).Inc()
+			}
+		}
+	}
+	// Map order is random; sorting keeps runs over the same quotes identical
+	sort.Slice(opportunities, func(i, j int) bool {
+		a, b := opportunities[i], opportunities[j]
+		return a.BuyExchange < b.BuyExchange || a.BuyExchange == b.BuyExchange && a.Symbol < b.Symbol
+	})
+	return opportunities
+}
+
+// basisOpportunity prices opening a position in one direction. The spot leg
+// comes first, then the perpetual leg; both trade the same base quantity.
+// The buy side is the leg taking the ask, so SpreadPercent is the basis
+// captured and AnnualizedPercent what it is expected to earn a year,
+// funding included, after the fees and slippage of opening and closing.
+func (as *ArbitrageStrategy) basisOpportunity(direction string, spot, perp Quote, params *Params, now time.Time) ArbitrageOpportunity {
+	venue := spot.Exchange
+	spotLeg := OpportunityLeg{Venue: venue, Symbol: spot.Symbol, Fee: params.Fee(venue), Slippage: exchangeSlippage[venue], Quantity: 1}
+	perpLeg := OpportunityLeg{Venue: venue, Symbol: perp.Symbol, Fee: perpFee(venue, params), Slippage: exchangeSlippage[venue], Quantity: 1}
+	base, quoteAsset, _ := splitSymbol(NormalizeSymbol(spot.Symbol))
+
+	buy, sell := &spotLeg, &perpLeg
+	funding := perp.FundingRate
+	spotLeg.Side, spotLeg.From, spotLeg.To, spotLeg.Price = "BUY", quoteAsset, base, spot.Ask
+	perpLeg.Side, perpLeg.From, perpLeg.To, perpLeg.Price = "SELL", base, quoteAsset, perp.Bid
+	if direction == BasisShortSpot {
+		buy, sell = &perpLeg, &spotLeg
+		funding = -funding
+		spotLeg.Side, spotLeg.From, spotLeg.To, spotLeg.Price = "SELL", base, quoteAsset, spot.Bid
+		perpLeg.Side, perpLeg.From, perpLeg.To, perpLeg.Price = "BUY", quoteAsset, base, perp.Ask
+	}
+
+	basis := (sell.Price - buy.Price) / buy.Price
+	costs := 2 * (spotLeg.Fee + spotLeg.Slippage + perpLeg.Fee + perpLeg.Slippage)
+	return ArbitrageOpportunity{
+		Type:              OpportunityBasis,
+		BuyExchange:       venue,
+		SellExchange:      venue,
+		Symbol:            NormalizeSymbol(spot.Symbol),
+		BuyPrice:          buy.Price,
+		SellPrice:         sell.Price,
+		Spread:            sell.Price - buy.Price,
+		SpreadPercent:     basis * 100,
+		Timestamp:         now,
+		BuyFee:            buy.Fee,
+		SellFee:           sell.Fee,
+		BuySlippage:       buy.Slippage,
+		SellSlippage:      sell.Slippage,
+		EffBuyPrice:       buy.Price * (1 + buy.Fee + buy.Slippage),
+		EffSellPrice:      sell.Price * (1 - sell.Fee - sell.Slippage),
+		QuoteTime:         olderOf(spot.Timestamp, perp.Timestamp),
+		Legs:              []OpportunityLeg{spotLeg, perpLeg},
+		FundingRate:       perp.FundingRate,
+		AnnualizedPercent: as.basis.annualize(basis-costs, funding),
+	}
+}
+
+// basisOrders returns the risk orders of a basis opportunity: the spot leg
+// and the perpetual leg, each for the full quantity at its detected price
+func basisOrders(opp ArbitrageOpportunity, quantity float64) []risk.Order {
+	orders := make([]risk.Order, len(opp.Legs))
+	for i, leg := range opp.Legs {
+		orders[i] = risk.Order{Venue: leg.Venue, Symbol: leg.Symbol, Side: leg.Side, Price: leg.Price, Quantity: quantity * leg.Quantity}
+	}
+	return orders
+}
+
+// openBasis runs a basis opportunity through the risk engine and opens the
+// position. Unlike an arbitrage nothing is realized yet: the position is
+// held, marked and collects funding until manageBasis closes it. It returns
+// the outcome like executeOpportunity.
+func (as *ArbitrageStrategy) openBasis(opp ArbitrageOpportunity) string {
+	start := time.Now()
+	quantity := as.pnlManager.TradeQuantity(opp)
+	orders := basisOrders(opp, quantity)
+	if err := as.riskEngine.CheckOrders(orders...); err != nil {
+		opportunitiesTotal.WithLabelValues(reasonRiskRejected).Inc()
+		logRiskRejection("risk check rejected basis trade", opp.ID, err)
+		as.events.Publish(TopicOpportunities, EventRejected,
+			RejectedOpportunity{Opportunity: opp, Reason: reasonRiskRejected, Error: err.Error()})
+		return reasonRiskRejected
+	}
+
+	position, err := as.pnlManager.OpenBasis(opp, quantity)
+	if err != nil {
+		opportunitiesTotal.WithLabelValues(reasonExecutionFailed).Inc()
+		logger.Error("basis execution failed", "opp_id", opp.ID, "err", err)
+		as.events.Publish(TopicOpportunities, EventRejected,
+			RejectedOpportunity{Opportunity: opp, Reason: reasonExecutionFailed, Error: err.Error()})
+		return reasonExecutionFailed
+	}
+	opportunitiesTotal.WithLabelValues(reasonExecuted).Inc()
+	executionsTotal.WithLabelValues(opp.BuyExchange, opp.SellExchange).Inc()
+	executionDuration.Observe(time.Since(start).Seconds())
+
+	for _, o := range orders {
+		o.Quantity *= position.Quantity / quantity
+		as.riskEngine.OnFill(o)
+	}
+	as.events.Publish(TopicTrades, EventOpened, position)
+	return reasonExecuted
+}
+
+// manageBasis marks the open basis positions, settles funding that fell due
+// and closes positions whose remaining edge is gone or that were held too
+// long. Positions are marked from the latest books even when they are old;
+// closing needs fresh ones.
+func (as *ArbitrageStrategy) manageBasis() {
+	positions := as.pnlManager.GetBasisPositions()
+	if len(positions) == 0 {
+		return
+	}
+	now := as.clock.Now()
+	params := as.params.Load()
+
+	for _, pos := range positions {
+		as.quotesLock.RLock()
+		spot, spotOK := as.books[bookKey(pos.Venue, pos.Symbol)]
+		perp, perpOK := as.books[bookKey(pos.Venue, pos.PerpSymbol)]
+		config := as.basis
+		as.quotesLock.RUnlock()
+		if !spotOK || !perpOK {
+			continue
+		}
+
+		if payment, ok := as.pnlManager.MarkBasis(pos.ID, spot, perp, now); ok {
+			as.events.Publish(TopicTrades, EventFunding, payment)
+		}
+
+		reason := config.exitReason(pos, spot, perp, now)
+		if reason == "" || params.TradingPaused || !params.Tradable(pos.Venue) ||
+			now.Sub(spot.Timestamp) > maxBookAge || now.Sub(perp.Timestamp) > maxBookAge {
+			continue
+		}
+		as.closeBasis(pos, spot, perp, reason)
+	}
+}
+
+// exitReason returns why a position should close, or "" to keep it. Staying
+// in is worth the basis still to converge, mid to mid, plus the expected
+// funding; the spread and fees of closing are paid either way.
+func (c BasisConfig) exitReason(pos BasisPosition, spot, perp Quote, now time.Time) string {
+	if now.Sub(pos.Opened) >= c.MaxHold {
+		return "max_hold"
+	}
+	spotMid, perpMid := (spot.Bid+spot.Ask)/2, (perp.Bid+perp.Ask)/2
+	remaining := pos.sign() * (perpMid - spotMid) / spotMid
+	funding := pos.sign() * perp.FundingRate
+	if c.annualize(remaining, funding) < c.ExitPercent {
+		return "converged"
+	}
+	return ""
+}
+
+// closeBasis unwinds both legs of a position. Exits skip the pre-trade risk
+// checks: they only take exposure off.
+func (as *ArbitrageStrategy) closeBasis(pos BasisPosition, spot, perp Quote, reason string) {
+	params := as.params.Load()
+	spotLeg := OpportunityLeg{Venue: pos.Venue, Symbol: spot.Symbol, Fee: params.Fee(pos.Venue), Slippage: exchangeSlippage[pos.Venue], Quantity: 1}
+	perpLeg := OpportunityLeg{Venue: pos.Venue, Symbol: perp.Symbol, Fee: perpFee(pos.Venue, params), Slippage: exchangeSlippage[pos.Venue], Quantity: 1}
+	spotLeg.Side, spotLeg.Price = "SELL", spot.Bid
+	perpLeg.Side, perpLeg.Price = "BUY", perp.Ask
+	if pos.Direction == BasisShortSpot {
+		spotLeg.Side, spotLeg.Price = "BUY", spot.Ask
+		perpLeg.Side, perpLeg.Price = "SELL", perp.Bid
+	}
+	opp := ArbitrageOpportunity{
+		ID:           pos.ID,
+		Type:         OpportunityBasis,
+		BuyExchange:  pos.Venue,
+		SellExchange: pos.Venue,
+		Symbol:       pos.Symbol,
+		Timestamp:    as.clock.Now(),
+		QuoteTime:    olderOf(spot.Timestamp, perp.Timestamp),
+		Legs:         []OpportunityLeg{spotLeg, perpLeg},
+		FundingRate:  perp.FundingRate,
+	}
+
+	roundTrip, err := as.pnlManager.CloseBasis(pos.ID, opp)
+	if err != nil {
+		logger.Warn("basis close failed", "position_id", pos.ID, "reason", reason, "err", err)
+		return
+	}
+	for _, o := range basisOrders(opp, roundTrip.Quantity) {
+		as.riskEngine.OnFill(o)
+	}
+	as.riskEngine.OnArbitrageClosed(roundTrip.PnL)
+	logger.Info("closed basis position", "position_id", pos.ID, "reason", reason, "pnl", roundTrip.PnL, "funding", roundTrip.Funding)
+
+	as.events.Publish(TopicTrades, EventExecuted, roundTrip)
+	if as.events.Active() {
+		as.events.Publish(TopicPnL, EventPnL, as.pnlManager.GetCurrentPnL())
+	}
+}
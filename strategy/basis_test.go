@@ -0,0 +1,164 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBasisConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(c BasisConfig) bool
+		wantErr bool
+	}{
+		{name: "defaults", check: func(c BasisConfig) bool { return c.Enabled && !c.AllowReverse && len(c.Venues) == 2 }},
+		{
+			name: "venues and reverse",
+			env:  map[string]string{"HFT_BASIS_VENUES": " OKX ,", "HFT_BASIS_REVERSE": "on", "HFT_BASIS_HORIZON": "24h"},
+			check: func(c BasisConfig) bool {
+				return c.AllowReverse && len(c.Venues) == 1 && c.Venues[0] == "okx" && c.Horizon == 24*time.Hour
+			},
+		},
+		{name: "off skips validation", env: map[string]string{"HFT_BASIS": "off", "HFT_BASIS_ENTRY": "1"}, check: func(c BasisConfig) bool { return !c.Enabled }},
+		{name: "entry below exit", env: map[string]string{"HFT_BASIS_ENTRY": "1"}, wantErr: true},
+		{name: "no positions", env: map[string]string{"HFT_BASIS_MAX_POSITIONS": "0"}, wantErr: true},
+		{name: "bad switch", env: map[string]string{"HFT_BASIS_REVERSE": "sometimes"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"HFT_BASIS", "HFT_BASIS_VENUES", "HFT_BASIS_ENTRY", "HFT_BASIS_EXIT", "HFT_BASIS_HORIZON", "HFT_BASIS_MAX_HOLD", "HFT_BASIS_MAX_POSITIONS", "HFT_BASIS_REVERSE"} {
+				t.Setenv(name, tt.env[name])
+			}
+			config, err := BasisConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BasisConfigFromEnv() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && !tt.check(config) {
+				t.Errorf("BasisConfigFromEnv() = %+v", config)
+			}
+		})
+	}
+}
+
+func TestScanBasis(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	spot := Quote{Exchange: "okx", Symbol: "DOGEUSDT", Bid: 0.0999, Ask: 0.1000, Timestamp: now}
+	perp := func(bid float64, funding float64, age time.Duration) Quote {
+		return Quote{Exchange: "okx", Symbol: PerpSymbol("DOGE-USDT"), Bid: bid, Ask: bid + 0.0001, FundingRate: funding, Timestamp: now.Add(-age)}
+	}
+
+	tests := []struct {
+		name          string
+		perp          Quote
+		configure     func(c *BasisConfig)
+		wantDirection string // of the one opportunity expected, empty for none
+	}{
+		{name: "premium with positive funding", perp: perp(0.1010, 0.0001, 0), wantDirection: BasisLongSpot},
+		{name: "premium below the entry threshold", perp: perp(0.1002, 0, 0)},
+		{name: "discount, long spot only", perp: perp(0.0985, -0.0001, 0)},
+		{name: "discount with reverse allowed", perp: perp(0.0985, -0.0001, 0), configure: func(c *BasisConfig) { c.AllowReverse = true }, wantDirection: BasisShortSpot},
+		{name: "stale perpetual", perp: perp(0.1010, 0.0001, 10*time.Second)},
+		{name: "venue not configured", perp: perp(0.1010, 0.0001, 0), configure: func(c *BasisConfig) { c.Venues = []string{"bybit"} }},
+		{name: "disabled", perp: perp(0.1010, 0.0001, 0), configure: func(c *BasisConfig) { c.Enabled = false }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as := newTestStrategy(now)
+			config := DefaultBasisConfig()
+			if tt.configure != nil {
+				tt.configure(&config)
+			}
+			as.SetBasis(config)
+			as.UpdateQuote(spot)
+			as.UpdateQuote(tt.perp)
+
+			opportunities := as.scanBasis()
+			if tt.wantDirection == "" {
+				if len(opportunities) != 0 {
+					t.Fatalf("found %+v, want nothing", opportunities)
+				}
+				return
+			}
+			if len(opportunities) != 1 {
+				t.Fatalf("found %d opportunities, want 1", len(opportunities))
+			}
+			opp := opportunities[0]
+			spotLeg := opp.Legs[0]
+			wantSpotSide := "BUY"
+			if tt.wantDirection == BasisShortSpot {
+				wantSpotSide = "SELL"
+			}
+			if opp.Type != OpportunityBasis || spotLeg.Side != wantSpotSide || opp.Legs[1].Symbol != "DOGEUSDT-PERP" {
+				t.Errorf("opportunity %s with spot %s and perpetual %s", opp.Type, spotLeg.Side, opp.Legs[1].Symbol)
+			}
+			if opp.AnnualizedPercent < config.EntryPercent {
+				t.Errorf("annualized %.2f%% below entry %.2f%%", opp.AnnualizedPercent, config.EntryPercent)
+			}
+		})
+	}
+}
+
+func TestBasisExitReason(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	config := DefaultBasisConfig()
+	spot := Quote{Bid: 0.0999, Ask: 0.1001}
+	tests := []struct {
+		name      string
+		direction string
+		held      time.Duration
+		perp      Quote
+		want      string
+	}{
+		{name: "premium left", direction: BasisLongSpot, held: time.Hour, perp: Quote{Bid: 0.1009, Ask: 0.1011}},
+		{name: "converged", direction: BasisLongSpot, held: time.Hour, perp: Quote{Bid: 0.0999, Ask: 0.1001}, want: "converged"},
+		{name: "funding keeps it open", direction: BasisLongSpot, held: time.Hour, perp: Quote{Bid: 0.0999, Ask: 0.1001, FundingRate: 0.0001}},
+		{name: "discount left on a short spot", direction: BasisShortSpot, held: time.Hour, perp: Quote{Bid: 0.0989, Ask: 0.0991}},
+		{name: "held too long", direction: BasisLongSpot, held: config.MaxHold, perp: Quote{Bid: 0.1009, Ask: 0.1011}, want: "max_hold"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos := BasisPosition{Direction: tt.direction, Opened: now.Add(-tt.held)}
+			if got := config.exitReason(pos, spot, tt.perp, now); got != tt.want {
+				t.Errorf("exitReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUntradedSymbolsKeepTheCrossVenueQuote(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		quote Quote
+	}{
+		{name: "symbol nothing trades", quote: Quote{Exchange: "binance", Symbol: "ETHUSDT", Bid: 3000, Ask: 3001, Timestamp: now}},
+		{name: "cross rate of an unconfigured triangle", quote: Quote{Exchange: "binance", Symbol: "DOGEBTC", Bid: 0.000002, Ask: 0.00000201, Timestamp: now}},
+		{name: "perpetual", quote: Quote{Exchange: "binance", Symbol: "DOGEUSDT-PERP", Bid: 0.1010, Ask: 0.1011, Timestamp: now}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as := newTestStrategy(now)
+			as.SetTriangles()
+			as.UpdateQuote(Quote{Exchange: "binance", Symbol: "DOGEUSDT", Bid: 0.0999, Ask: 0.1000, Timestamp: now})
+			as.UpdateQuote(Quote{Exchange: "okx", Symbol: "DOGE-USDT", Bid: 0.0999, Ask: 0.1000, Timestamp: now})
+			as.UpdateQuote(tt.quote)
+
+			if opportunities, _ := as.scanQuotes(); len(opportunities) != 0 {
+				t.Errorf("cross-venue scan found %+v", opportunities)
+			}
+			as.quotesLock.RLock()
+			defer as.quotesLock.RUnlock()
+			if got := as.quotes["binance"].Symbol; got != "DOGEUSDT" {
+				t.Errorf("binance cross-venue quote is %s, want DOGEUSDT", got)
+			}
+			if _, ok := as.books[bookKey(tt.quote.Exchange, tt.quote.Symbol)]; !ok {
+				t.Error("the book store did not keep the quote")
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package strategy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNoQuote is returned when a venue or symbol has no quote yet
+var ErrNoQuote = errors.New("no quote")
+
+// PriceLevel is one price level of an order book
+type PriceLevel struct {
+	Price float64 `json:"price"`
+	Size  float64 `json:"size"`
+}
+
+// OrderBook holds the best levels of a venue's book, bids descending and
+// asks ascending
+type OrderBook struct {
+	Bids []PriceLevel `json:"bids"`
+	Asks []PriceLevel `json:"asks"`
+}
+
+// Top returns a copy of the book limited to depth levels per side
+func (ob OrderBook) Top(depth int) OrderBook {
+	top := func(levels []PriceLevel) []PriceLevel {
+		if depth > 0 && len(levels) > depth {
+			levels = levels[:depth]
+		}
+		return append([]PriceLevel(nil), levels...)
+	}
+	return OrderBook{Bids: top(ob.Bids), Asks: top(ob.Asks)}
+}
+
+// VenueQuote is the latest quote of one venue as the strategy sees it
+type VenueQuote struct {
+	Venue         string    `json:"venue"`
+	Symbol        string    `json:"symbol"`
+	Bid           float64   `json:"bid"`
+	Ask           float64   `json:"ask"`
+	BidSize       float64   `json:"bid_size"`
+	AskSize       float64   `json:"ask_size"`
+	Mid           float64   `json:"mid"`
+	SpreadPercent float64   `json:"spread_percent"`
+	Depth         int       `json:"depth"` // levels per side available from /book
+	Timestamp     time.Time `json:"timestamp"`
+	AgeMs         float64   `json:"age_ms"`
+	Paused        bool      `json:"paused"`
+	PauseReason   string    `json:"pause_reason,omitempty"`
+}
+
+// PairSpread is the current edge of buying on one venue and selling on another
+type PairSpread struct {
+	BuyVenue         string  `json:"buy_venue"`
+	SellVenue        string  `json:"sell_venue"`
+	Symbol           string  `json:"symbol"`
+	BuyPrice         float64 `json:"buy_price"`  // best ask on the buy venue
+	SellPrice        float64 `json:"sell_price"` // best bid on the sell venue
+	GrossEdgePercent float64 `json:"gross_edge_percent"`
+	NetEdgePercent   float64 `json:"net_edge_percent"` // after fees and slippage on both legs
+	EffBuyPrice      float64 `json:"eff_buy_price"`
+	EffSellPrice     float64 `json:"eff_sell_price"`
+	Paused           bool    `json:"paused"` // either venue is paused by a circuit breaker or an operator
+}
+
+// GetQuotes returns the latest quote of every venue, sorted by venue
+func (as *ArbitrageStrategy) GetQuotes() []VenueQuote {
+	as.quotesLock.RLock()
+	quotes := make([]Quote, 0, len(as.quotes))
+	for _, quote := range as.quotes {
+		quotes = append(quotes, quote)
+	}
+	as.quotesLock.RUnlock()
+
+	now := as.clock.Now()
+	params := as.params.Load()
+	views := make([]VenueQuote, 0, len(quotes))
+	for _, quote := range quotes {
+		mid := (quote.Bid + quote.Ask) / 2
+		view := VenueQuote{
+			Venue:     quote.Exchange,
+			Symbol:    quote.Symbol,
+			Bid:       quote.Bid,
+			Ask:       quote.Ask,
+			BidSize:   quote.BidSize,
+			AskSize:   quote.AskSize,
+			Mid:       mid,
+			Depth:     1,
+			Timestamp: quote.Timestamp,
+			AgeMs:     float64(now.Sub(quote.Timestamp)) / float64(time.Millisecond),
+		}
+		if mid > 0 {
+			view.SpreadPercent = (quote.Ask - quote.Bid) / mid * 100
+		}
+		if quote.Book != nil {
+			view.Depth = min(len(quote.Book.Bids), len(quote.Book.Asks))
+		}
+		view.Paused, view.PauseReason = as.breakers.Paused(quote.Exchange, now)
+		if params.PausedVenues[quote.Exchange] {
+			view.Paused, view.PauseReason = true, "paused by operator"
+		}
+		views = append(views, view)
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].Venue < views[j].Venue })
+	return views
+}
+
+// GetOrderBook returns up to depth levels of a venue's latest book, including
+// the books streamed only for triangles. Venues that only stream top of book
+// return a single level.
+func (as *ArbitrageStrategy) GetOrderBook(venue, symbol string, depth int) (OrderBook, time.Time, error) {
+	venue = strings.ToLower(venue)
+	as.quotesLock.RLock()
+	quote, ok := as.books[bookKey(venue, symbol)]
+	crossVenue, streams := as.quotes[venue]
+	as.quotesLock.RUnlock()
+
+	if !ok && !streams {
+		return OrderBook{}, time.Time{}, fmt.Errorf("%w for venue %q", ErrNoQuote, venue)
+	}
+	if !ok {
+		return OrderBook{}, time.Time{}, fmt.Errorf("%w for %s on %s (venue streams %s)", ErrNoQuote, symbol, venue, crossVenue.Symbol)
+	}
+
+	if quote.Book != nil {
+		return quote.Book.Top(depth), quote.Timestamp, nil
+	}
+	return OrderBook{
+		Bids: []PriceLevel{{Price: quote.Bid, Size: quote.BidSize}},
+		Asks: []PriceLevel{{Price: quote.Ask, Size: quote.AskSize}},
+	}, quote.Timestamp, nil
+}
+
+// GetSpreads returns the current gross and net edge for every directed venue
+// pair, best net edge first
+func (as *ArbitrageStrategy) GetSpreads() []PairSpread {
+	as.quotesLock.RLock()
+	quotes := make([]Quote, 0, len(as.quotes))
+	for _, quote := range as.quotes {
+		if quote.Bid > 0 && quote.Ask > 0 {
+			quotes = append(quotes, quote)
+		}
+	}
+	as.quotesLock.RUnlock()
+
+	now := as.clock.Now()
+	params := as.params.Load()
+	paused := make(map[string]bool, len(quotes))
+	for _, quote := range quotes {
+		paused[quote.Exchange], _ = as.breakers.Paused(quote.Exchange, now)
+		paused[quote.Exchange] = paused[quote.Exchange] || !params.Tradable(quote.Exchange)
+	}
+
+	spreads := make([]PairSpread, 0, len(quotes)*(len(quotes)-1))
+	for _, buy := range quotes {
+		for _, sell := range quotes {
+			if buy.Exchange == sell.Exchange {
+				continue
+			}
+			effBuy, effSell := params.effectivePrices(buy.Exchange, buy.Ask, sell.Exchange, sell.Bid)
+			spreads = append(spreads, PairSpread{
+				BuyVenue:         buy.Exchange,
+				SellVenue:        sell.Exchange,
+				Symbol:           buy.Symbol,
+				BuyPrice:         buy.Ask,
+				SellPrice:        sell.Bid,
+				GrossEdgePercent: (sell.Bid - buy.Ask) / buy.Ask * 100,
+				NetEdgePercent:   (effSell - effBuy) / effBuy * 100,
+				EffBuyPrice:      effBuy,
+				EffSellPrice:     effSell,
+				Paused:           paused[buy.Exchange] || paused[sell.Exchange],
+			})
+		}
+	}
+	sort.Slice(spreads, func(i, j int) bool { return spreads[i].NetEdgePercent > spreads[j].NetEdgePercent })
+	return spreads
+}
+
+// NormalizeSymbol makes "DOGE-USDT", "doge/usdt" and "DOGEUSDT" compare equal
+func NormalizeSymbol(symbol string) string {
+	return strings.NewReplacer("-", "", "/", "", "_", "").Replace(strings.ToUpper(symbol))
+}
@@ -0,0 +1,146 @@
+package strategy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"hft-arbitrage-bot/clock"
+)
+
+// newTestStrategy returns a strategy on a simulated clock with a $1000
+// balance and $100 trades
+func newTestStrategy(now time.Time) *ArbitrageStrategy {
+	as := NewArbitrageStrategy(0, 1000, 100)
+	as.SetClock(clock.NewSim(now))
+	return as
+}
+
+func TestOrderBookTop(t *testing.T) {
+	book := OrderBook{
+		Bids: []PriceLevel{{0.1000, 10}, {0.0999, 20}, {0.0998, 30}},
+		Asks: []PriceLevel{{0.1001, 10}, {0.1002, 20}},
+	}
+	tests := []struct {
+		name               string
+		depth              int
+		wantBids, wantAsks int
+	}{
+		{name: "limits both sides", depth: 1, wantBids: 1, wantAsks: 1},
+		{name: "shorter side kept whole", depth: 2, wantBids: 2, wantAsks: 2},
+		{name: "depth beyond the book", depth: 10, wantBids: 3, wantAsks: 2},
+		{name: "zero depth keeps everything", depth: 0, wantBids: 3, wantAsks: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			top := book.Top(tt.depth)
+			if len(top.Bids) != tt.wantBids || len(top.Asks) != tt.wantAsks {
+				t.Fatalf("Top(%d) has %d bids and %d asks, want %d and %d", tt.depth, len(top.Bids), len(top.Asks), tt.wantBids, tt.wantAsks)
+			}
+			top.Bids[0].Price = 1
+			if book.Bids[0].Price == 1 {
+				t.Error("Top shares its levels with the book")
+			}
+		})
+	}
+}
+
+func TestGetOrderBook(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	as := newTestStrategy(now)
+	as.UpdateQuote(Quote{Exchange: "binance", Symbol: "DOGEUSDT", Bid: 0.1000, Ask: 0.1001, BidSize: 500, AskSize: 700, Timestamp: now})
+	as.UpdateQuote(Quote{
+		Exchange: "okx", Symbol: "DOGE-USDT", Bid: 0.1000, Ask: 0.1001, Timestamp: now,
+		Book: &OrderBook{
+			Bids: []PriceLevel{{0.1000, 10}, {0.0999, 20}, {0.0998, 30}},
+			Asks: []PriceLevel{{0.1001, 10}, {0.1002, 20}, {0.1003, 30}},
+		},
+	})
+
+	tests := []struct {
+		name      string
+		venue     string
+		symbol    string
+		depth     int
+		wantBids  int
+		wantNotOK bool
+	}{
+		{name: "book venue", venue: "okx", symbol: "DOGE-USDT", depth: 2, wantBids: 2},
+		{name: "venue name is case insensitive", venue: "OKX", symbol: "DOGEUSDT", depth: 5, wantBids: 3},
+		{name: "top of book venue", venue: "binance", symbol: "DOGEUSDT", depth: 5, wantBids: 1},
+		{name: "unknown venue", venue: "kraken", symbol: "DOGEUSD", depth: 5, wantNotOK: true},
+		{name: "symbol the venue does not stream", venue: "binance", symbol: "BTCUSDT", depth: 5, wantNotOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book, updated, err := as.GetOrderBook(tt.venue, tt.symbol, tt.depth)
+			if tt.wantNotOK {
+				if !errors.Is(err, ErrNoQuote) {
+					t.Fatalf("GetOrderBook() error = %v, want ErrNoQuote", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetOrderBook() error = %v", err)
+			}
+			if len(book.Bids) != tt.wantBids || !updated.Equal(now) {
+				t.Errorf("got %d bids updated %s, want %d updated %s", len(book.Bids), updated, tt.wantBids, now)
+			}
+		})
+	}
+}
+
+func TestGetSpreads(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	as := newTestStrategy(now)
+	as.UpdateQuote(Quote{Exchange: "binance", Symbol: "DOGEUSDT", Bid: 0.1000, Ask: 0.1001, Timestamp: now})
+	as.UpdateQuote(Quote{Exchange: "okx", Symbol: "DOGEUSDT", Bid: 0.1010, Ask: 0.1011, Timestamp: now})
+
+	spreads := as.GetSpreads()
+	tests := []struct {
+		name      string
+		index     int
+		buy, sell string
+		wantGross float64
+	}{
+		{name: "best net edge first", index: 0, buy: "binance", sell: "okx", wantGross: (0.1010 - 0.1001) / 0.1001 * 100},
+		{name: "reverse direction last", index: 1, buy: "okx", sell: "binance", wantGross: (0.1000 - 0.1011) / 0.1011 * 100},
+	}
+	if len(spreads) != len(tests) {
+		t.Fatalf("got %d spreads, want %d", len(spreads), len(tests))
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := spreads[tt.index]
+			if s.BuyVenue != tt.buy || s.SellVenue != tt.sell {
+				t.Fatalf("spread %d is %s->%s, want %s->%s", tt.index, s.BuyVenue, s.SellVenue, tt.buy, tt.sell)
+			}
+			if diff := s.GrossEdgePercent - tt.wantGross; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("gross edge = %.6f, want %.6f", s.GrossEdgePercent, tt.wantGross)
+			}
+			if s.NetEdgePercent >= s.GrossEdgePercent {
+				t.Errorf("net edge %.6f not below gross %.6f", s.NetEdgePercent, s.GrossEdgePercent)
+			}
+		})
+	}
+}
+
+func TestNormalizeSymbol(t *testing.T) {
+	tests := []struct {
+		symbol string
+		want   string
+	}{
+		{"DOGEUSDT", "DOGEUSDT"},
+		{"DOGE-USDT", "DOGEUSDT"},
+		{"doge/usdt", "DOGEUSDT"},
+		{"DOGE_USDT", "DOGEUSDT"},
+		{"DOGE-USDT-SWAP", "DOGEUSDTSWAP"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeSymbol(tt.symbol); got != tt.want {
+			t.Errorf("NormalizeSymbol(%q) = %q, want %q", tt.symbol, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// BasisPosition is an open spot position hedged with the same venue's
+// perpetual. Both legs hold the same base quantity. The position is marked
+// on every evaluation and collects funding at each settlement; nothing is
+// realized on the legs until it closes.
+type BasisPosition struct {
+	ID          string    `json:"id"`
+	Venue       string    `json:"venue"`
+	Symbol      string    `json:"symbol"` // spot market
+	PerpSymbol  string    `json:"perp_symbol"`
+	Direction   string    `json:"direction"`  // BasisLongSpot or BasisShortSpot
+	Quantity    float64   `json:"quantity"`   // base asset on each leg
+	SpotEntry   float64   `json:"spot_entry"` // average fill prices before fees
+	PerpEntry   float64   `json:"perp_entry"`
+	EntryBasis  float64   `json:"entry_basis"`  // perpetual over spot at the fills, in percent
+	EntryEdge   float64   `json:"entry_edge"`   // annualized percent expected at detection
+	Fees        float64   `json:"fees"`         // paid opening, in quote currency
+	Funding     float64   `json:"funding"`      // settled so far, positive when received
+	Carry       float64   `json:"carry"`        // unrealized P&L of both legs at the last mark, before fees
+	FundingRate float64   `json:"funding_rate"` // rate of the next settlement
+	NextFunding time.Time `json:"next_funding,omitzero"`
+	Opened      time.Time `json:"opened"`
+	Marked      time.Time `json:"marked,omitzero"`
+
+	settled time.Time // the last settlement, or the opening time
+}
+
+// FundingPayment is one funding settlement of a basis position
+type FundingPayment struct {
+	PositionID string    `json:"position_id"`
+	Venue      string    `json:"venue"`
+	Symbol     string    `json:"symbol"` // the perpetual
+	Rate       float64   `json:"rate"`
+	MarkPrice  float64   `json:"mark_price"`
+	Amount     float64   `json:"amount"` // positive when received
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// sign is +1 for a position long spot and short the perpetual, -1 for the reverse
+func (p *BasisPosition) sign() float64 {
+	if p.Direction == BasisShortSpot {
+		return -1
+	}
+	return 1
+}
+
+// OpenBasis fills both legs of a basis opportunity for quantity base units
+// and holds the position. The balance and P&L only move as funding settles
+// and when the position closes, so the balance counts realized P&L only.
+func (pm *PnLManager) OpenBasis(opp ArbitrageOpportunity, quantity float64) (BasisPosition, error) {
+	pm.mutex.RLock()
+	balance, tradeSize, execution, now := pm.balance, pm.tradeSize, pm.execution, pm.clock.Now()
+	pm.mutex.RUnlock()
+
+	if balance < tradeSize {
+		return BasisPosition{}, fmt.Errorf("insufficient balance: %.2f < %.2f", balance, tradeSize)
+	}
+	fill, err := execution.Execute(opp, quantity, now)
+	if err != nil {
+		return BasisPosition{}, err
+	}
+
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	idNanos := pm.nextIDNanos(fill.Time)
+	spotFill, perpFill := fill.Legs[0], fill.Legs[1]
+	pos := &BasisPosition{
+		ID:          fmt.Sprintf("basis_%d", idNanos),
+		Venue:       opp.BuyExchange,
+		Symbol:      opp.Symbol,
+		PerpSymbol:  opp.Legs[1].Symbol,
+		Direction:   BasisLongSpot,
+		Quantity:    spotFill.Quantity,
+		SpotEntry:   spotFill.Price,
+		PerpEntry:   perpFill.Price,
+		EntryBasis:  (perpFill.Price - spotFill.Price) / spotFill.Price * 100,
+		EntryEdge:   opp.AnnualizedPercent,
+		Fees:        spotFill.Quantity*spotFill.Price*spotFill.FeeRate + perpFill.Quantity*perpFill.Price*perpFill.FeeRate,
+		FundingRate: opp.FundingRate,
+		Opened:      fill.Time,
+		settled:     fill.Time,
+	}
+	if opp.Legs[0].Side == "SELL" {
+		pos.Direction = BasisShortSpot
+	}
+	pm.basis[pos.ID] = pos
+
+	trades := legTrades(opp, fill, idNanos, pos.ID)
+	pm.trades = append(pm.trades, trades...)
+	pm.totalTrades += len(trades)
+	pm.updateGauges()
+
+	logger.Info("opened basis position",
+		"opp_id", opp.ID,
+		"position_id", pos.ID,
+		"venue", pos.Venue,
+		"direction", pos.Direction,
+		"quantity", pos.Quantity,
+		"spot_price", pos.SpotEntry,
+		"perp_price", pos.PerpEntry,
+		"basis_pct", pos.EntryBasis,
+		"annualized_pct", pos.EntryEdge)
+	return *pos, nil
+}
+
+// MarkBasis revalues a position at the latest spot and perpetual quotes. If
+// a funding settlement fell due since the last mark it is paid first, at the
+// rate the perpetual last announced for it, and returned.
+func (pm *PnLManager) MarkBasis(id string, spot, perp Quote, now time.Time) (FundingPayment, bool) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	pos, ok := pm.basis[id]
+	if !ok {
+		return FundingPayment{}, false
+	}
+	mark := perp.MarkPrice
+	if mark <= 0 {
+		mark = (perp.Bid + perp.Ask) / 2
+	}
+
+	var payment FundingPayment
+	settled := false
+	if !pos.NextFunding.IsZero() && !now.Before(pos.NextFunding) {
+		// A short perpetual receives positive funding
+		amount := pos.sign() * pos.Quantity * mark * pos.FundingRate
+		pos.Funding += amount
+		pm.balance += amount
+		pm.totalPnL += amount
+		pm.fundingPnL += amount
+		pm.updateGauges()
+
+		payment = FundingPayment{
+			PositionID: pos.ID,
+			Venue:      pos.Venue,
+			Symbol:     pos.PerpSymbol,
+			Rate:       pos.FundingRate,
+			MarkPrice:  mark,
+			Amount:     amount,
+			Timestamp:  pos.NextFunding,
+		}
+		settled = true
+		pos.settled, pos.NextFunding = pos.NextFunding, time.Time{}
+		logger.Info("settled funding", "position_id", pos.ID, "rate", payment.Rate, "mark_price", mark, "amount", amount)
+	}
+	// The venue keeps announcing the settlement just paid until it rolls over
+	if perp.NextFunding.After(pos.settled) {
+		pos.FundingRate, pos.NextFunding = perp.FundingRate, perp.NextFunding
+	}
+
+	spotMid := (spot.Bid + spot.Ask) / 2
+	pos.Carry = pos.sign() * pos.Quantity * ((spotMid - pos.SpotEntry) - (mark - pos.PerpEntry))
+	pos.Marked = now
+	return payment, settled
+}
+
+// CloseBasis unwinds a position with the legs of opp, spot first. A partial
+// fill closes that share of the position and leaves the rest open. The round
+// trip's P&L is both legs less all fees plus the funding of the share
+// closed; the funding was already added to the balance as it settled.
+func (pm *PnLManager) CloseBasis(id string, opp ArbitrageOpportunity) (RoundTrip, error) {
+	pm.mutex.RLock()
+	pos, ok := pm.basis[id]
+	var quantity float64
+	if ok {
+		quantity = pos.Quantity
+	}
+	execution, now := pm.execution, pm.clock.Now()
+	pm.mutex.RUnlock()
+	if !ok {
+		return RoundTrip{}, fmt.Errorf("no open basis position %s", id)
+	}
+
+	fill, err := execution.Execute(opp, quantity, now)
+	if err != nil {
+		return RoundTrip{}, err
+	}
+
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	if pos, ok = pm.basis[id]; !ok {
+		return RoundTrip{}, fmt.Errorf("basis position %s closed while unwinding", id)
+	}
+	spotFill, perpFill := fill.Legs[0], fill.Legs[1]
+	closed := min(spotFill.Quantity, pos.Quantity)
+	share := closed / pos.Quantity
+
+	legs := pos.sign() * closed * ((spotFill.Price - pos.SpotEntry) - (perpFill.Price - pos.PerpEntry))
+	fees := pos.Fees*share + closed*spotFill.Price*spotFill.FeeRate + closed*perpFill.Price*perpFill.FeeRate
+	funding := pos.Funding * share
+	realized := legs - fees
+	pnl := realized + funding
+
+	pm.balance += realized
+	pm.totalPnL += realized
+	trades := legTrades(opp, fill, pm.nextIDNanos(fill.Time), pos.ID)
+	pm.trades = append(pm.trades, trades...)
+	pm.totalTrades += len(trades)
+	if pnl > 0 {
+		pm.winningTrades++
+		pm.largestWin = max(pm.largestWin, pnl)
+	} else {
+		pm.losingTrades++
+		pm.largestLoss = min(pm.largestLoss, pnl)
+	}
+
+	buyPrice, sellPrice := pos.SpotEntry, pos.PerpEntry
+	if pos.Direction == BasisShortSpot {
+		buyPrice, sellPrice = pos.PerpEntry, pos.SpotEntry
+	}
+	roundTrip := RoundTrip{
+		ID:           pos.ID,
+		Type:         OpportunityBasis,
+		BuyExchange:  pos.Venue,
+		SellExchange: pos.Venue,
+		Symbol:       pos.Symbol,
+		Quantity:     closed,
+		BuyPrice:     buyPrice,
+		SellPrice:    sellPrice,
+		GrossEdge:    pos.sign() * pos.EntryBasis,
+		NetEdge:      pnl / (closed * pos.SpotEntry) * 100,
+		Fees:         fees,
+		Funding:      funding,
+		PnL:          pnl,
+		Timestamp:    fill.Time,
+	}
+	pm.roundTrips = append(pm.roundTrips, roundTrip)
+
+	if closed >= pos.Quantity {
+		delete(pm.basis, id)
+	} else {
+		pos.Quantity -= closed
+		pos.Fees -= pos.Fees * share
+		pos.Funding -= funding
+		pos.Carry -= pos.Carry * share
+	}
+	pm.updateGauges()
+	return roundTrip, nil
+}
+
+// GetBasisPositions returns the open basis positions, oldest first
+func (pm *PnLManager) GetBasisPositions() []BasisPosition {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	positions := make([]BasisPosition, 0, len(pm.basis))
+	for _, pos := range pm.basis {
+		positions = append(positions, *pos)
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i].ID < positions[j].ID })
+	return positions
+}
+
+// carry returns the unrealized P&L of the open basis positions; the caller
+// must hold the lock
+func (pm *PnLManager) carry() float64 {
+	total := 0.0
+	for _, pos := range pm.basis {
+		total += pos.Carry
+	}
+	return total
+}
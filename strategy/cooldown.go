@@ -0,0 +1,159 @@
+package strategy
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// CooldownConfig limits how often a venue pair is traded. Pairs are directed
+// and per symbol: okx->binance DOGEUSDT is one pair, binance->okx another.
+type CooldownConfig struct {
+	Cooldown    time.Duration // after an execution, before the pair is entered again; 0 disables
+	MaxOpen     int           // executions on a pair whose spread is still there; 0 for no limit
+	ReentryEdge float64       // percentage points the edge must improve by to enter a spread again
+}
+
+// DefaultCooldownConfig enters each spread once and a pair at most once a second
+func DefaultCooldownConfig() CooldownConfig {
+	return CooldownConfig{
+		Cooldown:    time.Second,
+		MaxOpen:     1,
+		ReentryEdge: 0.05,
+	}
+}
+
+// CooldownConfigFromEnv builds the config from environment variables on top
+// of DefaultCooldownConfig:
+//
+//	HFT_COOLDOWN           time between executions on a pair, e.g. 1s; 0 disables
+//	HFT_MAX_OPEN_PER_PAIR  executions on a pair while its spread lasts; 0 for no limit
+//	HFT_REENTRY_EDGE       edge improvement in percentage points that allows re-entry
+func CooldownConfigFromEnv() (CooldownConfig, error) {
+	config := DefaultCooldownConfig()
+
+	if value := os.Getenv("HFT_COOLDOWN"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return config, fmt.Errorf("invalid HFT_COOLDOWN %q", value)
+		}
+		config.Cooldown = d
+	}
+	if value := os.Getenv("HFT_MAX_OPEN_PER_PAIR"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return config, fmt.Errorf("invalid HFT_MAX_OPEN_PER_PAIR %q", value)
+		}
+		config.MaxOpen = n
+	}
+	if value := os.Getenv("HFT_REENTRY_EDGE"); value != "" {
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return config, fmt.Errorf("invalid HFT_REENTRY_EDGE %q", value)
+		}
+		config.ReentryEdge = v
+	}
+	return config, config.Validate()
+}
+
+// Validate checks the config for values the rules cannot work with
+func (c CooldownConfig) Validate() error {
+	if c.Cooldown < 0 {
+		return fmt.Errorf("cooldown must not be negative, got %s", c.Cooldown)
+	}
+	if c.MaxOpen < 0 {
+		return fmt.Errorf("max open arbitrages per pair must not be negative, got %d", c.MaxOpen)
+	}
+	if c.ReentryEdge <= 0 {
+		return fmt.Errorf("re-entry edge must be positive, got %.4f", c.ReentryEdge)
+	}
+	return nil
+}
+
+// SetCooldown sets the cooldown and re-entry rules. It must be called before
+// the strategy receives quotes.
+func (as *ArbitrageStrategy) SetCooldown(config CooldownConfig) {
+	as.tracker.mu.Lock()
+	defer as.tracker.mu.Unlock()
+	as.tracker.cooldown = config
+}
+
+// pair returns the directed venue pair and symbol the lifecycle trades
+func (l *OpportunityLifecycle) pair() string {
+	return l.BuyExchange + "->" + l.SellExchange + " " + l.Symbol
+}
+
+// admit decides whether a detected spread is handed to execution. A spread
+// already entered is entered again only when its edge improved by
+// ReentryEdge or every one of its books changed since; it then returns no
+// reason. Otherwise the pair must be out of its cooldown and below MaxOpen,
+// counting only the executions on its other spreads. The caller must hold
+// the lock.
+func (t *opportunityTracker) admit(l *OpportunityLifecycle, s sighting, now time.Time, refreshed func(ArbitrageOpportunity, time.Time) bool) (string, bool) {
+	reentry := ""
+	if l.Entries > 0 {
+		switch {
+		case s.edge >= l.entryEdge+t.cooldown.ReentryEdge:
+			reentry = "edge_improved"
+		case refreshed(s.opp, l.entered):
+			reentry = "books_refreshed"
+		default:
+			return "", false
+		}
+	}
+
+	pair := l.pair()
+	if last, ok := t.executed[pair]; ok && t.cooldown.Cooldown > 0 && now.Sub(last) < t.cooldown.Cooldown {
+		return reasonCooldown, false
+	}
+	if t.cooldown.MaxOpen > 0 && t.openExecutions(pair, l) >= t.cooldown.MaxOpen {
+		return reasonMaxOpen, false
+	}
+	if reentry != "" {
+		logger.Info("re-entering opportunity",
+			"opp_id", l.ID,
+			"reason", reentry,
+			"entries", l.Entries,
+			"entry_edge", l.entryEdge,
+			"edge", s.edge)
+	}
+	return "", true
+}
+
+// openExecutions counts the executions on a pair whose spreads are still
+// there, leaving out those of except; the caller must hold the lock
+func (t *opportunityTracker) openExecutions(pair string, except *OpportunityLifecycle) int {
+	n := 0
+	for _, l := range t.open {
+		if l != except && l.Executions > 0 && l.pair() == pair {
+			n += l.Executions
+		}
+	}
+	return n
+}
+
+// booksRefreshed reports whether the top of every book an opportunity
+// trades changed after since
+func (as *ArbitrageStrategy) booksRefreshed(opp ArbitrageOpportunity, since time.Time) bool {
+	buySymbol, sellSymbol := opp.LegSymbols()
+	keys := []string{bookKey(opp.BuyExchange, buySymbol), bookKey(opp.SellExchange, sellSymbol)}
+	if len(opp.Legs) > 0 {
+		keys = keys[:0]
+		for _, leg := range opp.Legs {
+			if leg.Side != LegTransfer {
+				keys = append(keys, bookKey(leg.Venue, leg.Symbol))
+			}
+		}
+	}
+
+	as.quotesLock.RLock()
+	defer as.quotesLock.RUnlock()
+	for _, key := range keys {
+		s, ok := as.quoteStats[key]
+		if !ok || !s.changed.After(since) {
+			return false
+		}
+	}
+	return len(keys) > 0
+}
@@ -0,0 +1,163 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCooldownConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    CooldownConfig
+		wantErr bool
+	}{
+		{name: "defaults", want: DefaultCooldownConfig()},
+		{
+			name: "all set",
+			env:  map[string]string{"HFT_COOLDOWN": "250ms", "HFT_MAX_OPEN_PER_PAIR": "0", "HFT_REENTRY_EDGE": "0.1"},
+			want: CooldownConfig{Cooldown: 250 * time.Millisecond, ReentryEdge: 0.1},
+		},
+		{name: "cooldown without a unit", env: map[string]string{"HFT_COOLDOWN": "1"}, wantErr: true},
+		{name: "negative cooldown", env: map[string]string{"HFT_COOLDOWN": "-1s"}, wantErr: true},
+		{name: "negative max open", env: map[string]string{"HFT_MAX_OPEN_PER_PAIR": "-1"}, wantErr: true},
+		{name: "zero re-entry edge", env: map[string]string{"HFT_REENTRY_EDGE": "0"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"HFT_COOLDOWN", "HFT_MAX_OPEN_PER_PAIR", "HFT_REENTRY_EDGE"} {
+				t.Setenv(name, tt.env[name])
+			}
+			config, err := CooldownConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CooldownConfigFromEnv() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && config != tt.want {
+				t.Errorf("CooldownConfigFromEnv() = %+v, want %+v", config, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdmit(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		config     CooldownConfig
+		entered    bool          // the spread was entered 200ms ago at 0.2%
+		executed   time.Duration // since the pair last executed; 0 for never
+		openOnPair int           // executions on other open spreads of the pair
+		edge       float64
+		refreshed  bool
+		want       string
+		wantOK     bool
+	}{
+		{name: "new spread", config: DefaultCooldownConfig(), edge: 0.2, wantOK: true},
+		{name: "entered, unchanged", config: DefaultCooldownConfig(), entered: true, edge: 0.22},
+		{name: "entered, edge improved", config: DefaultCooldownConfig(), entered: true, edge: 0.25, wantOK: true},
+		{name: "entered, books refreshed", config: DefaultCooldownConfig(), entered: true, edge: 0.2, refreshed: true, wantOK: true},
+		{name: "pair cooling down", config: DefaultCooldownConfig(), executed: 500 * time.Millisecond, edge: 0.2, want: reasonCooldown},
+		{name: "pair cooled down", config: DefaultCooldownConfig(), executed: 2 * time.Second, edge: 0.2, wantOK: true},
+		{name: "re-entry still cools down", config: DefaultCooldownConfig(), entered: true, executed: 200 * time.Millisecond, edge: 0.3, want: reasonCooldown},
+		{name: "max open on the pair", config: CooldownConfig{MaxOpen: 1, ReentryEdge: 0.05}, openOnPair: 1, edge: 0.2, want: reasonMaxOpen},
+		{name: "below max open", config: CooldownConfig{MaxOpen: 2, ReentryEdge: 0.05}, openOnPair: 1, edge: 0.2, wantOK: true},
+		{name: "no limits", config: CooldownConfig{ReentryEdge: 0.05}, executed: time.Millisecond, openOnPair: 5, edge: 0.2, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newOpportunityTracker()
+			tracker.cooldown = tt.config
+			s := spread("binance", "okx", tt.edge, reasonDetected)
+			l := &OpportunityLifecycle{ID: "opp-1", BuyExchange: "binance", SellExchange: "okx", Symbol: "DOGEUSDT", Status: LifecycleOpen}
+			if tt.entered {
+				l.Entries, l.entered, l.entryEdge = 1, now.Add(-200*time.Millisecond), 0.2
+			}
+			if tt.executed > 0 {
+				tracker.executed[l.pair()] = now.Add(-tt.executed)
+			}
+			if tt.openOnPair > 0 {
+				// the same pair seen through another of its spreads
+				tracker.open["other"] = &OpportunityLifecycle{ID: "opp-0", BuyExchange: "binance", SellExchange: "okx", Symbol: "DOGEUSDT", Executions: tt.openOnPair}
+			}
+
+			var since time.Time
+			reason, ok := tracker.admit(l, s, now, func(_ ArbitrageOpportunity, t time.Time) bool { since = t; return tt.refreshed })
+			if reason != tt.want || ok != tt.wantOK {
+				t.Errorf("admit() = %q, %v; want %q, %v", reason, ok, tt.want, tt.wantOK)
+			}
+			if !since.IsZero() && !since.Equal(l.entered) {
+				t.Errorf("books checked for changes since %s, want the entry at %s", since, l.entered)
+			}
+		})
+	}
+}
+
+func TestReentryAfterExecution(t *testing.T) {
+	start := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	later := start.Add(5 * time.Second)
+	tests := []struct {
+		name      string
+		edge      float64 // at the second sighting, after entering at 0.2%
+		refreshed bool
+		wantOK    bool
+	}{
+		{name: "edge improved", edge: 0.5, wantOK: true},
+		{name: "books refreshed", edge: 0.2, refreshed: true, wantOK: true},
+		{name: "unchanged", edge: 0.2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newOpportunityTracker()
+			newID := func() string { return "opp-1" }
+			refreshed := func(ArbitrageOpportunity, time.Time) bool { return tt.refreshed }
+
+			fresh, _ := tracker.observe([]sighting{spread("binance", "okx", 0.2, reasonDetected)}, start, newID, refreshed)
+			if len(fresh) != 1 {
+				t.Fatalf("first sighting admitted %d opportunities, want 1", len(fresh))
+			}
+			tracker.settle(fresh[0].ID, reasonExecuted, start)
+
+			fresh, _ = tracker.observe([]sighting{spread("binance", "okx", tt.edge, reasonDetected)}, later, newID, refreshed)
+			if got := len(fresh) == 1; got != tt.wantOK {
+				t.Errorf("second sighting admitted = %v, want %v", got, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestBooksRefreshed(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	entered := now.Add(-time.Second)
+	opp := evOpportunity()
+	tests := []struct {
+		name        string
+		binance     Quote // after the entry
+		okxChanged  bool
+		wantRefresh bool
+	}{
+		{name: "both books changed", binance: Quote{Bid: 0.0998, Ask: 0.0999}, okxChanged: true, wantRefresh: true},
+		{name: "one book changed", binance: Quote{Bid: 0.0998, Ask: 0.0999}},
+		{name: "same prices again", binance: Quote{Bid: 0.0999, Ask: 0.1000}, okxChanged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as := newTestStrategy(now)
+			as.UpdateQuote(Quote{Exchange: "binance", Symbol: "DOGEUSDT", Bid: 0.0999, Ask: 0.1000, Timestamp: entered.Add(-time.Second)})
+			as.UpdateQuote(Quote{Exchange: "okx", Symbol: "DOGE-USDT", Bid: 0.1010, Ask: 0.1011, Timestamp: entered.Add(-time.Second)})
+
+			binance := tt.binance
+			binance.Exchange, binance.Symbol, binance.Timestamp = "binance", "DOGEUSDT", now
+			as.UpdateQuote(binance)
+			if tt.okxChanged {
+				as.UpdateQuote(Quote{Exchange: "okx", Symbol: "DOGE-USDT", Bid: 0.1009, Ask: 0.1010, Timestamp: now})
+			}
+			if got := as.booksRefreshed(opp, entered); got != tt.wantRefresh {
+				t.Errorf("booksRefreshed() = %v, want %v", got, tt.wantRefresh)
+			}
+		})
+	}
+}
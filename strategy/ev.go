@@ -0,0 +1,336 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"hft-arbitrage-bot/logging"
+)
+
+// evSmoothing is the weight of each new sample in a book's quote statistics,
+// which therefore follow roughly the last 20 price changes
+const evSmoothing = 0.05
+
+// negativeEVLogSampler keeps "negative EV" logging to one record per venue
+// pair and type every few seconds
+var negativeEVLogSampler = logging.NewSampler(5*time.Second, 1)
+
+// EVConfig configures the expected value model that selects opportunities.
+// A net spread is only worth what survives the trip to the venues: the quotes
+// must still be there when the orders arrive, prices drift against us in the
+// meantime, and a thin level fills only part of the order, leaving the other
+// legs to unwind.
+type EVConfig struct {
+	Enabled       bool
+	Latency       time.Duration            // detection to fill, for venues without their own
+	VenueLatency  map[string]time.Duration // per venue overrides
+	QueueAhead    float64                  // share of each displayed level taken by others before us
+	PriorLifetime time.Duration            // quote lifetime assumed for a book before its first price changes
+	MinEVPercent  float64                  // expected value, in percent of the trade, an opportunity must exceed
+}
+
+// DefaultEVConfig matches the paper trading defaults
+func DefaultEVConfig() EVConfig {
+	return EVConfig{
+		Enabled:       true,
+		Latency:       50 * time.Millisecond,
+		VenueLatency:  make(map[string]time.Duration),
+		QueueAhead:    0.25,
+		PriorLifetime: time.Second,
+	}
+}
+
+// EVConfigFromEnv builds the config from environment variables on top of
+// DefaultEVConfig. Latencies and queue position are those of the execution
+// config and are set by the caller.
+//
+//	HFT_EV           off selects every opportunity with a positive net spread
+//	HFT_EV_MIN       expected value in percent an opportunity must exceed
+//	HFT_EV_LIFETIME  quote lifetime assumed until a book has history, e.g. 1s
+func EVConfigFromEnv() (EVConfig, error) {
+	config := DefaultEVConfig()
+
+	switch value := strings.ToLower(os.Getenv("HFT_EV")); value {
+	case "":
+	case "on", "true", "1":
+		config.Enabled = true
+	case "off", "false", "0":
+		config.Enabled = false
+	default:
+		return config, fmt.Errorf("invalid HFT_EV %q (want on or off)", value)
+	}
+	if value := os.Getenv("HFT_EV_MIN"); value != "" {
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return config, fmt.Errorf("invalid HFT_EV_MIN %q", value)
+		}
+		config.MinEVPercent = v
+	}
+	if value := os.Getenv("HFT_EV_LIFETIME"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return config, fmt.Errorf("invalid HFT_EV_LIFETIME %q", value)
+		}
+		config.PriorLifetime = d
+	}
+	return config, config.Validate()
+}
+
+// Validate checks the config for values the model cannot work with
+func (c EVConfig) Validate() error {
+	if c.Latency < 0 {
+		return fmt.Errorf("EV latency must not be negative, got %s", c.Latency)
+	}
+	for venue, latency := range c.VenueLatency {
+		if latency < 0 {
+			return fmt.Errorf("EV latency for %s must not be negative, got %s", venue, latency)
+		}
+	}
+	if c.QueueAhead < 0 || c.QueueAhead >= 1 {
+		return fmt.Errorf("EV queue ahead must be in [0, 1), got %.4f", c.QueueAhead)
+	}
+	if c.PriorLifetime <= 0 {
+		return fmt.Errorf("EV prior quote lifetime must be positive, got %s", c.PriorLifetime)
+	}
+	return nil
+}
+
+// SetEV sets the expected value model's configuration. It must be called
+// before the strategy receives quotes.
+func (as *ArbitrageStrategy) SetEV(config EVConfig) {
+	config.VenueLatency = copyMap(config.VenueLatency)
+	as.ev = config
+}
+
+// latency returns the detection to fill latency of a venue
+func (c EVConfig) latency(venue string) time.Duration {
+	if latency, ok := c.VenueLatency[venue]; ok {
+		return latency
+	}
+	return c.Latency
+}
+
+// ExpectedValue is an opportunity's net spread discounted for what can go
+// wrong between detection and fill. Percentages are of the trade size.
+type ExpectedValue struct {
+	EdgePercent     float64 `json:"edge_pct"`         // net spread at the detected prices, after fees and slippage
+	LiveProbability float64 `json:"live_probability"` // chance every leg's quote is still there when its order arrives
+	FillRatio       float64 `json:"fill_ratio"`       // share of the order the thinnest leg is expected to fill
+	AdversePercent  float64 `json:"adverse_pct"`      // expected move against us over the latency, summed over legs
+	LegRiskPercent  float64 `json:"leg_risk_pct"`     // expected cost of unwinding legs that filled without the others
+	EVPercent       float64 `json:"ev_pct"`
+}
+
+// quoteStats tracks how long a book's prices last and how much they move
+type quoteStats struct {
+	bid, ask float64
+	changed  time.Time // when the top of book last changed
+	lifetime float64   // smoothed seconds between changes
+	moveSq   float64   // smoothed squared log change of the mid
+	interval float64   // smoothed seconds between the changes behind moveSq
+	samples  int
+}
+
+// volatility returns the mid's standard deviation per square root second
+func (s *quoteStats) volatility() float64 {
+	if s.samples == 0 || s.interval <= 0 {
+		return 0
+	}
+	return math.Sqrt(s.moveSq / s.interval)
+}
+
+// recordQuote updates the statistics of a quote's book with a new quote; the
+// caller must hold the quotes write lock
+func (as *ArbitrageStrategy) recordQuote(quote Quote) {
+	key := bookKey(quote.Exchange, quote.Symbol)
+	s, ok := as.quoteStats[key]
+	if !ok {
+		as.quoteStats[key] = &quoteStats{
+			bid:      quote.Bid,
+			ask:      quote.Ask,
+			changed:  quote.Timestamp,
+			lifetime: as.ev.PriorLifetime.Seconds(),
+		}
+		return
+	}
+	if quote.Bid == s.bid && quote.Ask == s.ask {
+		return
+	}
+	elapsed := quote.Timestamp.Sub(s.changed).Seconds()
+	if elapsed < 0 {
+		return
+	}
+	move := math.Log((quote.Bid + quote.Ask) / (s.bid + s.ask))
+	if s.samples == 0 {
+		s.moveSq, s.interval = move*move, elapsed
+	} else {
+		s.moveSq += evSmoothing * (move*move - s.moveSq)
+		s.interval += evSmoothing * (elapsed - s.interval)
+	}
+	s.lifetime += evSmoothing * (elapsed - s.lifetime)
+	s.samples++
+	s.bid, s.ask, s.changed = quote.Bid, quote.Ask, quote.Timestamp
+	quoteLifetime.WithLabelValues(key).Set(s.lifetime)
+}
+
+// evLeg is one order of an opportunity with the quote it takes
+type evLeg struct {
+	venue    string
+	side     string
+	quote    Quote
+	stats    *quoteStats
+	quantity float64 // base asset
+	cost     float64 // taker fee and slippage
+}
+
+// evLegs returns the orders of an opportunity for quantity, with their
+// quotes; transfers are skipped. The caller must hold the quotes read lock.
+func (as *ArbitrageStrategy) evLegs(opp ArbitrageOpportunity, quantity float64) ([]evLeg, bool) {
+	var legs []evLeg
+	add := func(venue, symbol, side string, quantity, cost float64) bool {
+		key := bookKey(venue, symbol)
+		quote, ok := as.books[key]
+		if !ok {
+			return false
+		}
+		legs = append(legs, evLeg{venue: venue, side: side, quote: quote, stats: as.quoteStats[key], quantity: quantity, cost: cost})
+		return true
+	}
+
+	if len(opp.Legs) == 0 {
+		buySymbol, sellSymbol := opp.LegSymbols()
+		ok := add(opp.BuyExchange, buySymbol, "BUY", quantity, opp.BuyFee+opp.BuySlippage) &&
+			add(opp.SellExchange, sellSymbol, "SELL", quantity, opp.SellFee+opp.SellSlippage)
+		return legs, ok
+	}
+	for _, leg := range opp.Legs {
+		if leg.Side == LegTransfer {
+			continue
+		}
+		if !add(leg.Venue, leg.Symbol, leg.Side, leg.Quantity*quantity, leg.Fee+leg.Slippage) {
+			return nil, false
+		}
+	}
+	return legs, true
+}
+
+// expectedValue estimates an opportunity's expected value for quantity at
+// now. Each leg's quote is taken to live for an exponentially distributed
+// time with its book's mean lifetime, so it is still there when the order
+// arrives with probability exp(-(age+latency)/lifetime). Over the latency the
+// price moves with the book's volatility, and the move against us averages
+// sigma*sqrt(latency)/sqrt(2*pi). The thinnest leg fills what is displayed
+// behind the queue ahead of us; whatever the other legs fill beyond that,
+// and everything when only some quotes survive, is unwound across the spread.
+// The caller must hold the quotes read lock.
+func (as *ArbitrageStrategy) expectedValue(opp ArbitrageOpportunity, quantity float64, now time.Time) (ExpectedValue, bool) {
+	legs, ok := as.evLegs(opp, quantity)
+	if !ok || len(legs) == 0 {
+		return ExpectedValue{}, false
+	}
+
+	ev := ExpectedValue{
+		EdgePercent:     (opp.EffSellPrice - opp.EffBuyPrice) / opp.EffBuyPrice * 100,
+		LiveProbability: 1,
+		FillRatio:       1,
+	}
+	allMissed := 1.0
+	unwind := 0.0
+	for _, leg := range legs {
+		latency := as.ev.latency(leg.venue).Seconds()
+		age := math.Max(0, now.Sub(leg.quote.Timestamp).Seconds())
+		lifetime, sigma := as.ev.PriorLifetime.Seconds(), 0.0
+		if leg.stats != nil {
+			lifetime, sigma = leg.stats.lifetime, leg.stats.volatility()
+		}
+		live := 1.0
+		if lifetime > 0 {
+			live = math.Exp(-(age + latency) / lifetime)
+		}
+		ev.LiveProbability *= live
+		allMissed *= 1 - live
+		ev.AdversePercent += sigma * math.Sqrt(latency) / math.Sqrt(2*math.Pi) * 100
+
+		size := leg.quote.AskSize
+		if leg.side == "SELL" {
+			size = leg.quote.BidSize
+		}
+		if size > 0 && leg.quantity > 0 {
+			ev.FillRatio = math.Min(ev.FillRatio, size*(1-as.ev.QueueAhead)/leg.quantity)
+		}
+
+		// Unwinding crosses the spread and pays the costs of a second order
+		mid := (leg.quote.Bid + leg.quote.Ask) / 2
+		unwind = math.Max(unwind, ((leg.quote.Ask-leg.quote.Bid)/mid+leg.cost)*100)
+	}
+
+	legged := math.Max(0, 1-ev.LiveProbability-allMissed)
+	ev.LegRiskPercent = (legged + ev.LiveProbability*(1-ev.FillRatio)) * unwind
+	ev.EVPercent = ev.LiveProbability*ev.FillRatio*ev.EdgePercent - ev.AdversePercent - ev.LegRiskPercent
+	return ev, true
+}
+
+// selectByEV attaches the expected value to every opportunity and keeps
+// those worth more than the configured minimum; it returns the others
+// separately. Basis trades are held, not crossed, and are selected by their
+// own annualized model.
+func (as *ArbitrageStrategy) selectByEV(opportunities []ArbitrageOpportunity) (selected, negative []ArbitrageOpportunity) {
+	if !as.ev.Enabled || len(opportunities) == 0 {
+		return opportunities, nil
+	}
+	now := as.clock.Now()
+
+	type rejected struct {
+		opp ArbitrageOpportunity
+		ev  ExpectedValue
+	}
+	var dropped []rejected
+	selected = opportunities[:0]
+
+	as.quotesLock.RLock()
+	for _, opp := range opportunities {
+		if opp.Type == OpportunityBasis {
+			selected = append(selected, opp)
+			continue
+		}
+		ev, ok := as.expectedValue(opp, as.pnlManager.TradeQuantity(opp), now)
+		if !ok {
+			selected = append(selected, opp)
+			continue
+		}
+		opp.EV = &ev
+		if ev.EVPercent > as.ev.MinEVPercent {
+			selected = append(selected, opp)
+			continue
+		}
+		opportunitiesTotal.WithLabelValues(reasonNegativeEV).Inc()
+		dropped = append(dropped, rejected{opp, ev})
+		negative = append(negative, opp)
+	}
+	as.quotesLock.RUnlock()
+
+	// Logged after the lock is released and sampled, like missed opportunities
+	for _, d := range dropped {
+		ok, suppressed := negativeEVLogSampler.Allow(d.opp.Type + ":" + d.opp.BuyExchange + "->" + d.opp.SellExchange)
+		if !ok {
+			continue
+		}
+		logger.Info("negative EV opportunity",
+			"type", d.opp.Type,
+			"buy_venue", d.opp.BuyExchange,
+			"sell_venue", d.opp.SellExchange,
+			"symbol", d.opp.Symbol,
+			"edge_pct", d.ev.EdgePercent,
+			"live_probability", d.ev.LiveProbability,
+			"fill_ratio", d.ev.FillRatio,
+			"adverse_pct", d.ev.AdversePercent,
+			"leg_risk_pct", d.ev.LegRiskPercent,
+			"ev_pct", d.ev.EVPercent,
+			"suppressed", suppressed)
+	}
+	return selected, negative
+}
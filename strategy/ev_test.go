@@ -0,0 +1,168 @@
+package strategy
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEVConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(c EVConfig) bool
+		wantErr bool
+	}{
+		{name: "defaults", check: func(c EVConfig) bool { return c.Enabled && c.MinEVPercent == 0 && c.PriorLifetime == time.Second }},
+		{
+			name:  "minimum and lifetime",
+			env:   map[string]string{"HFT_EV_MIN": "0.02", "HFT_EV_LIFETIME": "250ms"},
+			check: func(c EVConfig) bool { return c.MinEVPercent == 0.02 && c.PriorLifetime == 250*time.Millisecond },
+		},
+		{name: "off", env: map[string]string{"HFT_EV": "OFF"}, check: func(c EVConfig) bool { return !c.Enabled }},
+		{name: "unknown switch", env: map[string]string{"HFT_EV": "auto"}, wantErr: true},
+		{name: "malformed minimum", env: map[string]string{"HFT_EV_MIN": "2%"}, wantErr: true},
+		{name: "zero lifetime", env: map[string]string{"HFT_EV_LIFETIME": "0s"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"HFT_EV", "HFT_EV_MIN", "HFT_EV_LIFETIME"} {
+				t.Setenv(name, tt.env[name])
+			}
+			config, err := EVConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EVConfigFromEnv() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && !tt.check(config) {
+				t.Errorf("EVConfigFromEnv() = %+v", config)
+			}
+		})
+	}
+}
+
+// evOpportunity buys DOGE on binance at 0.1000 and sells it on okx at 0.1010
+func evOpportunity() ArbitrageOpportunity {
+	opp := ArbitrageOpportunity{
+		Type:        OpportunityCrossVenue,
+		BuyExchange: "binance", SellExchange: "okx",
+		Symbol:   "DOGEUSDT",
+		BuyPrice: 0.1000, SellPrice: 0.1010,
+		BuyFee: 0.001, SellFee: 0.001, BuySlippage: 0.0002, SellSlippage: 0.0002,
+	}
+	opp.EffBuyPrice = opp.BuyPrice * (1 + opp.BuyFee + opp.BuySlippage)
+	opp.EffSellPrice = opp.SellPrice * (1 - opp.SellFee - opp.SellSlippage)
+	return opp
+}
+
+func TestExpectedValue(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	books := func(age time.Duration, size float64) []Quote {
+		return []Quote{
+			{Exchange: "binance", Symbol: "DOGEUSDT", Bid: 0.0999, Ask: 0.1000, BidSize: size, AskSize: size, Timestamp: now.Add(-age)},
+			{Exchange: "okx", Symbol: "DOGE-USDT", Bid: 0.1010, Ask: 0.1011, BidSize: size, AskSize: size, Timestamp: now.Add(-age)},
+		}
+	}
+	// each leg lives 1s on average and the order takes 50ms to arrive
+	fresh := math.Exp(-0.05)
+
+	tests := []struct {
+		name        string
+		quotes      []Quote
+		history     bool // a second of mid moves on binance before the books
+		quantity    float64
+		wantOK      bool
+		wantLive    float64
+		wantFill    float64
+		wantAdverse bool // a positive adverse move
+	}{
+		{name: "fresh deep books", quotes: books(0, 10000), quantity: 1000, wantOK: true, wantLive: fresh * fresh, wantFill: 1},
+		{name: "aged quotes", quotes: books(500*time.Millisecond, 10000), quantity: 1000, wantOK: true, wantLive: math.Exp(-0.55) * math.Exp(-0.55), wantFill: 1},
+		{name: "thin level", quotes: books(0, 1000), quantity: 1000, wantOK: true, wantLive: fresh * fresh, wantFill: 0.75},
+		{name: "unknown sizes fill in full", quotes: books(0, 0), quantity: 1000, wantOK: true, wantLive: fresh * fresh, wantFill: 1},
+		{name: "volatile book", quotes: books(0, 10000), history: true, quantity: 1000, wantOK: true, wantFill: 1, wantAdverse: true},
+		{name: "missing book", quotes: books(0, 10000)[:1], quantity: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as := newTestStrategy(now)
+			if tt.history {
+				for i := 0; i < 10; i++ {
+					mid := 0.09995 + 0.00005*float64(i%2)
+					as.UpdateQuote(Quote{Exchange: "binance", Symbol: "DOGEUSDT", Bid: mid - 0.00005, Ask: mid + 0.00005, Timestamp: now.Add(time.Duration(i-10) * 100 * time.Millisecond)})
+				}
+			}
+			for _, q := range tt.quotes {
+				as.UpdateQuote(q)
+			}
+			opp := evOpportunity()
+
+			as.quotesLock.RLock()
+			ev, ok := as.expectedValue(opp, tt.quantity, now)
+			as.quotesLock.RUnlock()
+			if ok != tt.wantOK {
+				t.Fatalf("expectedValue() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if wantEdge := (opp.EffSellPrice - opp.EffBuyPrice) / opp.EffBuyPrice * 100; math.Abs(ev.EdgePercent-wantEdge) > 1e-9 {
+				t.Errorf("EdgePercent = %v, want %v", ev.EdgePercent, wantEdge)
+			}
+			if tt.wantLive != 0 && math.Abs(ev.LiveProbability-tt.wantLive) > 1e-9 {
+				t.Errorf("LiveProbability = %v, want %v", ev.LiveProbability, tt.wantLive)
+			}
+			if math.Abs(ev.FillRatio-tt.wantFill) > 1e-9 {
+				t.Errorf("FillRatio = %v, want %v", ev.FillRatio, tt.wantFill)
+			}
+			if (ev.AdversePercent > 0) != tt.wantAdverse {
+				t.Errorf("AdversePercent = %v", ev.AdversePercent)
+			}
+			want := ev.LiveProbability*ev.FillRatio*ev.EdgePercent - ev.AdversePercent - ev.LegRiskPercent
+			if ev.LegRiskPercent <= 0 || math.Abs(ev.EVPercent-want) > 1e-12 || ev.EVPercent >= ev.EdgePercent {
+				t.Errorf("EV %v with %v leg risk from a %v edge", ev.EVPercent, ev.LegRiskPercent, ev.EdgePercent)
+			}
+		})
+	}
+}
+
+func TestSelectByEV(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	basis := ArbitrageOpportunity{Type: OpportunityBasis, BuyExchange: "okx", SellExchange: "okx", Symbol: "DOGEUSDT"}
+	tests := []struct {
+		name         string
+		configure    func(c *EVConfig)
+		age          time.Duration
+		wantSelected int // of the cross-venue opportunity and a basis trade
+		wantEV       bool
+	}{
+		{name: "positive EV", wantSelected: 2, wantEV: true},
+		{name: "quotes too old to still be there", age: 3 * time.Second, wantSelected: 1, wantEV: true},
+		{name: "above the minimum EV", configure: func(c *EVConfig) { c.MinEVPercent = 5 }, wantSelected: 1, wantEV: true},
+		{name: "disabled", configure: func(c *EVConfig) { c.Enabled = false }, wantSelected: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as := newTestStrategy(now)
+			config := DefaultEVConfig()
+			if tt.configure != nil {
+				tt.configure(&config)
+			}
+			as.SetEV(config)
+			as.UpdateQuote(Quote{Exchange: "binance", Symbol: "DOGEUSDT", Bid: 0.0999, Ask: 0.1000, Timestamp: now.Add(-tt.age)})
+			as.UpdateQuote(Quote{Exchange: "okx", Symbol: "DOGE-USDT", Bid: 0.1010, Ask: 0.1011, Timestamp: now.Add(-tt.age)})
+
+			selected, negative := as.selectByEV([]ArbitrageOpportunity{evOpportunity(), basis})
+			if len(selected) != tt.wantSelected || len(selected)+len(negative) != 2 {
+				t.Fatalf("selected %d and dropped %d, want %d selected", len(selected), len(negative), tt.wantSelected)
+			}
+			for _, opp := range append(selected, negative...) {
+				if wantEV := tt.wantEV && opp.Type != OpportunityBasis; (opp.EV != nil) != wantEV {
+					t.Errorf("%s has an EV: %v, want %v", opp.Type, opp.EV != nil, wantEV)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package strategy
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"hft-arbitrage-bot/metrics"
+)
+
+// Event topics published by the strategy
+const (
+	TopicQuotes        = "quotes"
+	TopicOpportunities = "opportunities"
+	TopicTrades        = "trades"
+	TopicPnL           = "pnl"
+)
+
+// Topics lists every topic a subscriber can ask for
+var Topics = []string{TopicQuotes, TopicOpportunities, TopicTrades, TopicPnL}
+
+// Event types within the topics
+const (
+	EventQuote     = "quote"
+	EventDetected  = "detected"
+	EventRejected  = "rejected"
+	EventExecuted  = "executed"
+	EventOpened    = "opened"    // a basis position was opened
+	EventFunding   = "funding"   // a basis position settled funding
+	EventPosted    = "posted"    // a maker order started resting
+	EventRepriced  = "repriced"  // a resting maker order moved to a new price
+	EventCancelled = "cancelled" // a resting maker order was pulled
+	EventClosed    = "closed"    // an opportunity that was acted on is gone
+	EventPnL       = "pnl"
+)
+
+var eventsDropped = metrics.NewCounterVec("hft_stream_events_dropped_total",
+	"Events dropped because a stream subscriber was not keeping up.", "topic")
+
+// Event is a single update pushed to stream subscribers
+type Event struct {
+	Topic     string      `json:"topic"`
+	Type      string      `json:"type"`
+	Data      interface{} `json:"data"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// RejectedOpportunity is published when a detected opportunity is not executed
+type RejectedOpportunity struct {
+	Opportunity ArbitrageOpportunity `json:"opportunity"`
+	Reason      string               `json:"reason"` // paused, risk_rejected or execution_failed
+	Error       string               `json:"error,omitempty"`
+}
+
+// Subscription receives the events of the topics it is subscribed to
+type Subscription struct {
+	bus    *EventBus
+	events chan Event
+
+	mu     sync.RWMutex
+	topics map[string]bool
+}
+
+// Events returns the channel events are delivered on. It is closed when the
+// subscription is cancelled.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// SetTopics replaces the topics the subscription receives
+func (s *Subscription) SetTopics(topics ...string) {
+	set := make(map[string]bool, len(topics))
+	for _, topic := range topics {
+		set[topic] = true
+	}
+	s.mu.Lock()
+	s.topics = set
+	s.mu.Unlock()
+}
+
+// Subscribed reports whether the subscription receives a topic
+func (s *Subscription) Subscribed(topic string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.topics[topic]
+}
+
+// Close cancels the subscription
+func (s *Subscription) Close() {
+	s.bus.unsubscribe(s)
+}
+
+// EventBus fans strategy events out to stream subscribers. Publishing never
+// blocks: a subscriber whose buffer is full misses the event.
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers map[*Subscription]struct{}
+	count       atomic.Int32
+}
+
+// NewEventBus creates an event bus with no subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{subscribers: make(map[*Subscription]struct{})}
+}
+
+// Subscribe registers a subscriber for the given topics with a buffer of
+// size events
+func (b *EventBus) Subscribe(size int, topics ...string) *Subscription {
+	sub := &Subscription{bus: b, events: make(chan Event, size)}
+	sub.SetTopics(topics...)
+
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.count.Store(int32(len(b.subscribers)))
+	b.mu.Unlock()
+	return sub
+}
+
+// Active reports whether anyone is subscribed, so callers can skip building
+// events nobody will receive
+func (b *EventBus) Active() bool {
+	return b.count.Load() > 0
+}
+
+// Publish delivers an event to every subscriber of its topic
+func (b *EventBus) Publish(topic, eventType string, data interface{}) {
+	if !b.Active() {
+		return
+	}
+	event := Event{Topic: topic, Type: eventType, Data: data, Timestamp: time.Now()}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subscribers {
+		if !sub.Subscribed(topic) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			eventsDropped.WithLabelValues(topic).Inc()
+		}
+	}
+}
+
+func (b *EventBus) unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+	delete(b.subscribers, sub)
+	b.count.Store(int32(len(b.subscribers)))
+	close(sub.events)
+}
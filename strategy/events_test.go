@@ -0,0 +1,62 @@
+package strategy
+
+import "testing"
+
+func TestEventBusPublish(t *testing.T) {
+	tests := []struct {
+		name      string
+		topics    []string
+		buffer    int
+		publish   []string // topics published, in order
+		wantTypes []string // event types received
+	}{
+		{name: "only subscribed topics", topics: []string{TopicTrades}, buffer: 4, publish: []string{TopicQuotes, TopicTrades, TopicPnL}, wantTypes: []string{TopicTrades}},
+		{name: "several topics in order", topics: []string{TopicQuotes, TopicPnL}, buffer: 4, publish: []string{TopicQuotes, TopicTrades, TopicPnL}, wantTypes: []string{TopicQuotes, TopicPnL}},
+		{name: "full buffer drops", topics: Topics, buffer: 2, publish: []string{TopicQuotes, TopicTrades, TopicPnL}, wantTypes: []string{TopicQuotes, TopicTrades}},
+		{name: "no topics", buffer: 4, publish: []string{TopicQuotes}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewEventBus()
+			sub := bus.Subscribe(tt.buffer, tt.topics...)
+			for _, topic := range tt.publish {
+				// The type echoes the topic so the order can be checked
+				bus.Publish(topic, topic, nil)
+			}
+			sub.Close()
+
+			var got []string
+			for event := range sub.Events() {
+				got = append(got, event.Type)
+			}
+			if len(got) != len(tt.wantTypes) {
+				t.Fatalf("received %v, want %v", got, tt.wantTypes)
+			}
+			for i := range got {
+				if got[i] != tt.wantTypes[i] {
+					t.Errorf("received %v, want %v", got, tt.wantTypes)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestEventBusActive(t *testing.T) {
+	bus := NewEventBus()
+	if bus.Active() {
+		t.Fatal("new bus is active")
+	}
+	first := bus.Subscribe(1, TopicQuotes)
+	second := bus.Subscribe(1, TopicQuotes)
+	first.Close()
+	first.Close()
+	if !bus.Active() {
+		t.Fatal("bus inactive with a subscriber left")
+	}
+	second.Close()
+	if bus.Active() {
+		t.Fatal("bus active after every subscriber left")
+	}
+}
@@ -0,0 +1,63 @@
+package strategy
+
+import "time"
+
+// Liquidity says whether a fill took liquidity from the book or provided it;
+// venues charge different fees for each
+const (
+	LiquidityTaker = "taker"
+	LiquidityMaker = "maker"
+)
+
+// Execution is the outcome of sending both legs of an arbitrage. Both legs
+// are filled for the same quantity, which may be less than requested. A
+// triangular arbitrage also reports each of its legs (see CycleExecution).
+type Execution struct {
+	Quantity  float64   // filled on each leg
+	BuyPrice  float64   // average buy price including fees
+	SellPrice float64   // average sell price net of fees
+	Time      time.Time // when the last leg filled
+
+	BuyFee        float64 // fees paid on each leg, in quote currency
+	SellFee       float64
+	BuyLiquidity  string // LiquidityTaker or LiquidityMaker
+	SellLiquidity string
+
+	Legs []LegFill // one per opportunity leg, for multi-leg opportunities
+}
+
+// ExecutionModel decides how the legs of an arbitrage are filled. An error
+// means nothing was filled.
+type ExecutionModel interface {
+	Execute(opp ArbitrageOpportunity, quantity float64, now time.Time) (Execution, error)
+}
+
+// InstantExecution fills both legs in full at the opportunity's effective
+// prices the moment it is detected, paying taker fees
+type InstantExecution struct{}
+
+// Execute fills the requested quantity at EffBuyPrice and EffSellPrice, or
+// every leg of a triangle at its detected price less slippage
+func (InstantExecution) Execute(opp ArbitrageOpportunity, quantity float64, now time.Time) (Execution, error) {
+	if len(opp.Legs) > 0 {
+		fills := make([]LegFill, len(opp.Legs))
+		for i, leg := range opp.Legs {
+			price := leg.Price * (1 + leg.Slippage)
+			if leg.Side != "BUY" {
+				price = leg.Price * (1 - leg.Slippage)
+			}
+			fills[i] = LegFill{Price: price, Quantity: quantity * leg.Quantity, FeeRate: leg.Fee, Liquidity: LiquidityTaker}
+		}
+		return CycleExecution(opp, quantity, fills, now), nil
+	}
+	return Execution{
+		Quantity:      quantity,
+		BuyPrice:      opp.EffBuyPrice,
+		SellPrice:     opp.EffSellPrice,
+		Time:          now,
+		BuyFee:        quantity * opp.BuyPrice * opp.BuyFee,
+		SellFee:       quantity * opp.SellPrice * opp.SellFee,
+		BuyLiquidity:  LiquidityTaker,
+		SellLiquidity: LiquidityTaker,
+	}, nil
+}
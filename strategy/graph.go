@@ -0,0 +1,407 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GraphConfig configures the cycle search. The graph has a node per asset
+// held on a venue; every book adds an edge each way and every transferable
+// asset links its nodes across venues. A cycle whose fee-adjusted rates
+// multiply to more than one is a negative cycle in -log weights.
+type GraphConfig struct {
+	Enabled    bool
+	MaxLegs    int                // longest cycle searched, transfers included
+	Budget     int                // edge relaxations per evaluation; the search stops when they run out
+	StartAsset string             // cycles start and end in this asset, the currency of the trade size
+	Transfers  map[string]float64 // asset -> cost rate of moving it between venues; other assets stay put
+}
+
+// DefaultGraphConfig searches cycles of up to six legs with 200,000 edge
+// relaxations per evaluation, about 2ms. The budget counts work rather than
+// time so that a backtest or replay makes the same decisions on any machine.
+// Transfers are free because they draw on inventory already held on both
+// venues, as the cross-venue arbitrage does.
+func DefaultGraphConfig() GraphConfig {
+	return GraphConfig{
+		Enabled:    true,
+		MaxLegs:    6,
+		Budget:     200000,
+		StartAsset: "USDT",
+		Transfers:  map[string]float64{"USDT": 0, "DOGE": 0, "BTC": 0},
+	}
+}
+
+// GraphConfigFromEnv builds the config from environment variables on top of
+// DefaultGraphConfig:
+//
+//	HFT_GRAPH            off disables the search
+//	HFT_GRAPH_MAX_LEGS   longest cycle, transfers included
+//	HFT_GRAPH_BUDGET     edge relaxations per evaluation
+//	HFT_GRAPH_TRANSFERS  comma separated asset=cost pairs, replacing the default
+func GraphConfigFromEnv() (GraphConfig, error) {
+	config := DefaultGraphConfig()
+
+	switch strings.ToLower(os.Getenv("HFT_GRAPH")) {
+	case "", "on", "true", "1":
+	case "off", "false", "0":
+		config.Enabled = false
+	default:
+		return config, fmt.Errorf("invalid HFT_GRAPH %q (want on or off)", os.Getenv("HFT_GRAPH"))
+	}
+	if value := os.Getenv("HFT_GRAPH_MAX_LEGS"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return config, fmt.Errorf("invalid HFT_GRAPH_MAX_LEGS %q", value)
+		}
+		config.MaxLegs = n
+	}
+	if value := os.Getenv("HFT_GRAPH_BUDGET"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return config, fmt.Errorf("invalid HFT_GRAPH_BUDGET %q", value)
+		}
+		config.Budget = n
+	}
+	if value := os.Getenv("HFT_GRAPH_TRANSFERS"); value != "" {
+		config.Transfers = make(map[string]float64)
+		for _, entry := range strings.Split(value, ",") {
+			asset, cost, ok := strings.Cut(strings.TrimSpace(entry), "=")
+			rate, err := strconv.ParseFloat(strings.TrimSpace(cost), 64)
+			if !ok || err != nil {
+				return config, fmt.Errorf("invalid HFT_GRAPH_TRANSFERS entry %q (want asset=cost)", entry)
+			}
+			config.Transfers[strings.ToUpper(strings.TrimSpace(asset))] = rate
+		}
+	}
+	return config, config.Validate()
+}
+
+// Validate checks the config for values the search cannot work with
+func (c GraphConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.MaxLegs < 3 || c.MaxLegs > 10 {
+		return fmt.Errorf("graph max legs must be in [3, 10], got %d", c.MaxLegs)
+	}
+	if c.Budget <= 0 {
+		return fmt.Errorf("graph budget must be positive, got %d", c.Budget)
+	}
+	if c.StartAsset == "" {
+		return fmt.Errorf("graph start asset must be set")
+	}
+	for asset, cost := range c.Transfers {
+		if cost < 0 || cost >= 0.05 {
+			return fmt.Errorf("transfer cost for %s must be in [0, 0.05), got %.6f", asset, cost)
+		}
+	}
+	return nil
+}
+
+// SetGraph configures the cycle search. It must be called before the
+// strategy receives quotes.
+func (as *ArbitrageStrategy) SetGraph(config GraphConfig) {
+	as.quotesLock.Lock()
+	defer as.quotesLock.Unlock()
+	config.Transfers = copyMap(config.Transfers)
+	as.graph = config
+}
+
+// splitSymbol splits a normalized symbol into its base and quote assets
+func splitSymbol(symbol string) (base, quote string, ok bool) {
+	for _, q := range quotePriority {
+		if len(symbol) > len(q) && strings.HasSuffix(symbol, q) {
+			return strings.TrimSuffix(symbol, q), q, true
+		}
+	}
+	return "", "", false
+}
+
+// graphEdge converts the asset of one node into that of another
+type graphEdge struct {
+	from, to  int
+	weight    float64 // -log of the rate after fees and slippage
+	leg       OpportunityLeg
+	quoteTime time.Time
+}
+
+// cycleGraph is the market as the search sees it in one evaluation
+type cycleGraph struct {
+	nodes []string // venue/ASSET, in the order of the sorted books
+	index map[string]int
+	edges []graphEdge
+	out   [][]int // edges leaving each node
+}
+
+func (g *cycleGraph) node(venue, asset string) int {
+	key := venue + "/" + asset
+	if i, ok := g.index[key]; ok {
+		return i
+	}
+	g.index[key] = len(g.nodes)
+	g.nodes = append(g.nodes, key)
+	g.out = append(g.out, nil)
+	return len(g.nodes) - 1
+}
+
+func (g *cycleGraph) addEdge(from, to int, rate float64, leg OpportunityLeg, quoteTime time.Time) {
+	g.out[from] = append(g.out[from], len(g.edges))
+	g.edges = append(g.edges, graphEdge{from: from, to: to, weight: -math.Log(rate), leg: leg, quoteTime: quoteTime})
+}
+
+// buildGraph turns the fresh books of tradable venues into a graph. It must
+// be called with the quotes lock held.
+func (as *ArbitrageStrategy) buildGraph(params *Params, now time.Time) *cycleGraph {
+	g := &cycleGraph{index: make(map[string]int)}
+
+	// Sorted so that the search, and the cycle it picks on a tie, is the
+	// same from run to run
+	keys := make([]string, 0, len(as.books))
+	for key := range as.books {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	tradable := make(map[string]bool)
+	assetVenues := make(map[string][]string)
+	for _, key := range keys {
+		quote := as.books[key]
+		venue := quote.Exchange
+		if _, seen := tradable[venue]; !seen {
+			paused, _ := as.breakers.Paused(venue, now)
+			tradable[venue] = !paused && params.Tradable(venue)
+		}
+		base, quoteAsset, ok := splitSymbol(NormalizeSymbol(quote.Symbol))
+		if !tradable[venue] || !ok || quote.Bid <= 0 || quote.Ask <= 0 || now.Sub(quote.Timestamp) > maxBookAge {
+			continue
+		}
+
+		baseNode, quoteNode := g.node(venue, base), g.node(venue, quoteAsset)
+		cost := params.Fee(venue) + exchangeSlippage[venue]
+		leg := OpportunityLeg{Venue: venue, Symbol: quote.Symbol, Fee: params.Fee(venue), Slippage: exchangeSlippage[venue]}
+
+		buy := leg
+		buy.Side, buy.From, buy.To, buy.Price = "BUY", quoteAsset, base, quote.Ask
+		g.addEdge(quoteNode, baseNode, (1-cost)/quote.Ask, buy, quote.Timestamp)
+
+		sell := leg
+		sell.Side, sell.From, sell.To, sell.Price = "SELL", base, quoteAsset, quote.Bid
+		g.addEdge(baseNode, quoteNode, quote.Bid*(1-cost), sell, quote.Timestamp)
+
+		for _, asset := range []string{base, quoteAsset} {
+			if venues := assetVenues[asset]; len(venues) == 0 || venues[len(venues)-1] != venue {
+				assetVenues[asset] = append(venues, venue)
+			}
+		}
+	}
+
+	assets := make([]string, 0, len(as.graph.Transfers))
+	for asset := range as.graph.Transfers {
+		assets = append(assets, asset)
+	}
+	sort.Strings(assets)
+	for _, asset := range assets {
+		venues := uniqueSorted(assetVenues[asset])
+		cost := as.graph.Transfers[asset]
+		for _, from := range venues {
+			for _, to := range venues {
+				if from == to {
+					continue
+				}
+				leg := OpportunityLeg{Venue: from, ToVenue: to, Side: LegTransfer, From: asset, To: asset, Price: 1, Fee: cost}
+				g.addEdge(g.index[from+"/"+asset], g.index[to+"/"+asset], 1-cost, leg, now)
+			}
+		}
+	}
+	return g
+}
+
+func uniqueSorted(values []string) []string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	out := sorted[:0]
+	for i, v := range sorted {
+		if i == 0 || v != sorted[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// bestCycle runs a Bellman-Ford relaxation bounded to maxLegs edges from
+// start and returns the most negative simple cycle back to start, as edge
+// indices. It keeps only the best path per node and length, so it may miss a
+// simple cycle behind a better non-simple one. Every edge relaxed takes one
+// from budget; when it runs out the search stops with the best cycle found so
+// far and expired reports that not every length was searched.
+func (g *cycleGraph) bestCycle(start, maxLegs int, budget *int) (cycle []int, expired bool) {
+	n := len(g.nodes)
+	dist := make([]float64, n)
+	for v := range dist {
+		dist[v] = math.Inf(1)
+	}
+	dist[start] = 0
+	pred := make([][]int, maxLegs+1) // edge into each node on the best path of each length
+
+	best := 0.0 // only negative cycles are of interest
+	for k := 1; k <= maxLegs; k++ {
+		next := make([]float64, n)
+		pred[k] = make([]int, n)
+		for v := range next {
+			next[v] = math.Inf(1)
+			pred[k][v] = -1
+		}
+		for u, d := range dist {
+			if math.IsInf(d, 1) {
+				continue
+			}
+			for _, e := range g.out[u] {
+				if *budget <= 0 {
+					return cycle, true
+				}
+				*budget--
+				edge := g.edges[e]
+				w := d + edge.weight
+				if edge.to == start {
+					if k > 1 && w < best {
+						if path := g.walk(pred, k, e); path != nil {
+							best, cycle = w, path
+						}
+					}
+					continue
+				}
+				if w < next[edge.to] {
+					next[edge.to] = w
+					pred[k][edge.to] = e
+				}
+			}
+		}
+		dist = next
+	}
+	return cycle, false
+}
+
+// walk rebuilds the path of k edges that ends with edge last, or returns nil
+// when it visits a node twice
+func (g *cycleGraph) walk(pred [][]int, k, last int) []int {
+	path := make([]int, k)
+	path[k-1] = last
+	seen := map[int]bool{g.edges[last].to: true}
+	node := g.edges[last].from
+	for j := k - 1; j >= 1; j-- {
+		if seen[node] {
+			return nil
+		}
+		seen[node] = true
+		e := pred[j][node]
+		path[j-1] = e
+		node = g.edges[e].from
+	}
+	return path
+}
+
+// scanGraph searches the book store for cycles the pair and triangle
+// detectors do not cover: at least three trades, and not a configured
+// triangle
+func (as *ArbitrageStrategy) scanGraph() []ArbitrageOpportunity {
+	as.quotesLock.RLock()
+	defer as.quotesLock.RUnlock()
+
+	if !as.graph.Enabled {
+		return nil
+	}
+	start := time.Now()
+	budget := as.graph.Budget
+	now := as.clock.Now()
+	params := as.params.Load()
+	g := as.buildGraph(params, now)
+
+	seen := make(map[string]bool)
+	var opportunities []ArbitrageOpportunity
+	expired := false
+	for node, key := range g.nodes {
+		if expired {
+			break
+		}
+		if !strings.HasSuffix(key, "/"+as.graph.StartAsset) {
+			continue
+		}
+		// A search cut short still returns the best cycle it found
+		var cycle []int
+		cycle, expired = g.bestCycle(node, as.graph.MaxLegs, &budget)
+		if cycle == nil || g.edges[cycle[0]].leg.Side == LegTransfer {
+			continue
+		}
+		// The same cycle is found again from the start asset's other venues
+		id := cycleKey(cycle)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		legs := make([]OpportunityLeg, len(cycle))
+		quoteTime := now
+		for i, e := range cycle {
+			legs[i] = g.edges[e].leg
+			quoteTime = olderOf(quoteTime, g.edges[e].quoteTime)
+		}
+		if !as.novelCycle(legs) {
+			continue
+		}
+		opp := newCycleOpportunity(OpportunityCycle, legs, quoteTime, now)
+		if (opp.EffSellPrice-1)*100 >= params.MinSpreadPercent {
+			opportunities = append(opportunities, opp)
+			opportunitiesTotal.WithLabelValues(reasonDetected).Inc()
+		} else {
+			opportunitiesTotal.WithLabelValues(reasonBelowThreshold).Inc()
+		}
+	}
+	if expired {
+		graphBudgetExceeded.Inc()
+	}
+	graphSearchDuration.Observe(time.Since(start).Seconds())
+	return opportunities
+}
+
+// cycleKey identifies a cycle regardless of where it starts
+func cycleKey(cycle []int) string {
+	sorted := append([]int(nil), cycle...)
+	sort.Ints(sorted)
+	return fmt.Sprint(sorted)
+}
+
+// novelCycle reports whether a cycle is left to the graph search: cycles of
+// two trades are venue pairs, and three trades on one venue without
+// transfers may be a configured triangle
+func (as *ArbitrageStrategy) novelCycle(legs []OpportunityLeg) bool {
+	trades, transfers := 0, 0
+	venues := make(map[string]bool)
+	assets := make(map[string]bool)
+	for _, leg := range legs {
+		if leg.Side == LegTransfer {
+			transfers++
+			continue
+		}
+		trades++
+		venues[leg.Venue] = true
+		assets[leg.From] = true
+	}
+	if trades < 3 {
+		return false
+	}
+	if trades > 3 || transfers > 0 || len(venues) > 1 {
+		return true
+	}
+	for _, t := range as.triangles {
+		if venues[t.Venue] && assets[t.Assets[0]] && assets[t.Assets[1]] && assets[t.Assets[2]] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,155 @@
+package strategy
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGraphConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(c GraphConfig) bool
+		wantErr bool
+	}{
+		{name: "defaults", check: func(c GraphConfig) bool { return c.Enabled && c.MaxLegs == 6 && len(c.Transfers) == 3 }},
+		{name: "off", env: map[string]string{"HFT_GRAPH": "off"}, check: func(c GraphConfig) bool { return !c.Enabled }},
+		{
+			name: "transfers replace the default",
+			env:  map[string]string{"HFT_GRAPH_MAX_LEGS": "4", "HFT_GRAPH_TRANSFERS": "usdt=0, eth=0.001"},
+			check: func(c GraphConfig) bool {
+				return c.MaxLegs == 4 && len(c.Transfers) == 2 && c.Transfers["USDT"] == 0 && c.Transfers["ETH"] == 0.001
+			},
+		},
+		{name: "unknown switch", env: map[string]string{"HFT_GRAPH": "maybe"}, wantErr: true},
+		{name: "too many legs", env: map[string]string{"HFT_GRAPH_MAX_LEGS": "11"}, wantErr: true},
+		{name: "zero budget", env: map[string]string{"HFT_GRAPH_BUDGET": "0"}, wantErr: true},
+		{name: "budget as a duration", env: map[string]string{"HFT_GRAPH_BUDGET": "2ms"}, wantErr: true},
+		{name: "malformed transfer", env: map[string]string{"HFT_GRAPH_TRANSFERS": "DOGE"}, wantErr: true},
+		{name: "expensive transfer", env: map[string]string{"HFT_GRAPH_TRANSFERS": "DOGE=0.1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"HFT_GRAPH", "HFT_GRAPH_MAX_LEGS", "HFT_GRAPH_BUDGET", "HFT_GRAPH_TRANSFERS"} {
+				t.Setenv(name, tt.env[name])
+			}
+			config, err := GraphConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GraphConfigFromEnv() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && !tt.check(config) {
+				t.Errorf("GraphConfigFromEnv() = %+v", config)
+			}
+		})
+	}
+}
+
+func TestSplitSymbol(t *testing.T) {
+	tests := []struct {
+		symbol              string
+		wantBase, wantQuote string
+		wantOK              bool
+	}{
+		{symbol: "DOGEUSDT", wantBase: "DOGE", wantQuote: "USDT", wantOK: true},
+		{symbol: "DOGEUSD", wantBase: "DOGE", wantQuote: "USD", wantOK: true},
+		{symbol: "DOGEBTC", wantBase: "DOGE", wantQuote: "BTC", wantOK: true},
+		{symbol: "ETHBTC", wantBase: "ETH", wantQuote: "BTC", wantOK: true},
+		{symbol: "USDT"},
+		{symbol: "DOGEXRP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.symbol, func(t *testing.T) {
+			base, quote, ok := splitSymbol(tt.symbol)
+			if base != tt.wantBase || quote != tt.wantQuote || ok != tt.wantOK {
+				t.Errorf("splitSymbol(%q) = %q, %q, %v; want %q, %q, %v", tt.symbol, base, quote, ok, tt.wantBase, tt.wantQuote, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestScanGraph(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	// DOGE bought with USDT on binance is worth more in BTC on okx
+	acrossVenues := []Quote{
+		{Exchange: "binance", Symbol: "DOGEUSDT", Bid: 0.0999, Ask: 0.1000, Timestamp: now},
+		{Exchange: "binance", Symbol: "BTCUSDT", Bid: 50000, Ask: 50010, Timestamp: now},
+		{Exchange: "okx", Symbol: "DOGE-BTC", Bid: 0.00000203, Ask: 0.00000204, Timestamp: now},
+	}
+	oneVenue := []Quote{
+		{Exchange: "binance", Symbol: "DOGEUSDT", Bid: 0.0999, Ask: 0.1000, Timestamp: now},
+		{Exchange: "binance", Symbol: "BTCUSDT", Bid: 50000, Ask: 50010, Timestamp: now},
+		{Exchange: "binance", Symbol: "DOGEBTC", Bid: 0.00000203, Ask: 0.00000204, Timestamp: now},
+	}
+
+	tests := []struct {
+		name      string
+		quotes    []Quote
+		configure func(c *GraphConfig)
+		triangles []Triangle
+		wantLegs  []string // side and venue of each leg of the one cycle expected
+	}{
+		{
+			name:     "trades on two venues joined by transfers",
+			quotes:   acrossVenues,
+			wantLegs: []string{"BUY binance", "TRANSFER binance", "SELL okx", "TRANSFER okx", "SELL binance"},
+		},
+		// The search of acrossVenues relaxes 34 edges and closes the cycle
+		// on the 19th
+		{
+			name:      "budget runs out after the cycle is found",
+			quotes:    acrossVenues,
+			configure: func(c *GraphConfig) { c.Budget = 20 },
+			wantLegs:  []string{"BUY binance", "TRANSFER binance", "SELL okx", "TRANSFER okx", "SELL binance"},
+		},
+		{name: "budget runs out before the cycle is found", quotes: acrossVenues, configure: func(c *GraphConfig) { c.Budget = 10 }},
+		{name: "longer than max legs", quotes: acrossVenues, configure: func(c *GraphConfig) { c.MaxLegs = 4 }},
+		{name: "asset that cannot be moved", quotes: acrossVenues, configure: func(c *GraphConfig) { delete(c.Transfers, "BTC") }},
+		{name: "disabled", quotes: acrossVenues, configure: func(c *GraphConfig) { c.Enabled = false }},
+		{
+			name:     "unconfigured triangle",
+			quotes:   oneVenue,
+			wantLegs: []string{"BUY binance", "SELL binance", "SELL binance"},
+		},
+		{name: "configured triangle is left to the triangle scan", quotes: oneVenue, triangles: DefaultTriangles},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as := newTestStrategy(now)
+			config := DefaultGraphConfig()
+			if tt.configure != nil {
+				tt.configure(&config)
+			}
+			as.SetGraph(config)
+			as.SetTriangles(tt.triangles...)
+			for _, q := range tt.quotes {
+				as.UpdateQuote(q)
+			}
+
+			opportunities := as.scanGraph()
+			if len(tt.wantLegs) == 0 {
+				if len(opportunities) != 0 {
+					t.Fatalf("found %d cycles, want none", len(opportunities))
+				}
+				return
+			}
+			if len(opportunities) != 1 {
+				t.Fatalf("found %d cycles, want 1", len(opportunities))
+			}
+			opp := opportunities[0]
+			var legs []string
+			for _, leg := range opp.Legs {
+				legs = append(legs, leg.Side+" "+leg.Venue)
+			}
+			if !slices.Equal(legs, tt.wantLegs) {
+				t.Errorf("legs = %v, want %v", legs, tt.wantLegs)
+			}
+			if opp.Type != OpportunityCycle || math.Abs(opp.SellPrice-1.015) > 1e-9 || opp.EffSellPrice <= 1 {
+				t.Errorf("cycle %s returns %v gross, %v net", opp.Type, opp.SellPrice, opp.EffSellPrice)
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxClosedOpportunities bounds the closed lifecycles kept for queries;
+// the statistics cover every lifecycle regardless
+const maxClosedOpportunities = 1000
+
+// Lifecycle states
+const (
+	LifecycleOpen   = "open"
+	LifecycleClosed = "closed"
+)
+
+// OpportunityLifecycle follows one spread from the evaluation it first
+// appears in to the first one it is gone from. The same spread seen on
+// later evaluations keeps its id and is only acted on again under the
+// re-entry rules of CooldownConfig.
+type OpportunityLifecycle struct {
+	ID           string    `json:"id"`
+	Type         string    `json:"type"`
+	BuyExchange  string    `json:"buy_exchange"`
+	SellExchange string    `json:"sell_exchange"`
+	Symbol       string    `json:"symbol"`
+	Status       string    `json:"status"`  // open or closed
+	Outcome      string    `json:"outcome"` // what was last done with it, or why it was passed over
+	FirstSeen    time.Time `json:"first_seen"`
+	LastSeen     time.Time `json:"last_seen"`
+	Closed       time.Time `json:"closed,omitzero"`
+	DurationMs   float64   `json:"duration_ms"` // first seen to closed, or to last seen while open
+	Sightings    int       `json:"sightings"`   // evaluations it was seen in
+	FirstEdge    float64   `json:"first_edge"`  // net edge in percent; annualized for basis trades
+	PeakEdge     float64   `json:"peak_edge"`
+	LastEdge     float64   `json:"last_edge"`
+	Entries      int       `json:"entries"`    // times it was handed to execution
+	Executions   int       `json:"executions"` // entries that executed
+
+	key       string
+	entered   time.Time // the last entry
+	entryEdge float64   // edge at the last entry
+}
+
+// OpportunityStats aggregates the closed lifecycles of one type and
+// directed venue pair
+type OpportunityStats struct {
+	Type              string         `json:"type"`
+	VenuePair         string         `json:"venue_pair"`
+	Opportunities     int            `json:"opportunities"`
+	Executed          int            `json:"executed"`
+	Executions        int            `json:"executions"`
+	Missed            int            `json:"missed"`   // closed without being executed
+	Outcomes          map[string]int `json:"outcomes"` // outcome -> lifecycles
+	AverageDurationMs float64        `json:"average_duration_ms"`
+	MaxDurationMs     float64        `json:"max_duration_ms"`
+	AveragePeakEdge   float64        `json:"average_peak_edge"`
+	MaxPeakEdge       float64        `json:"max_peak_edge"`
+	PerHour           float64        `json:"per_hour"` // lifecycles closed per hour since tracking started
+}
+
+// sighting is one spread seen by an evaluation, with what the evaluation
+// made of it
+type sighting struct {
+	opp     ArbitrageOpportunity
+	outcome string // reasonDetected, reasonBelowThreshold, reasonNegativeEV or reasonSignal
+	edge    float64
+}
+
+// opportunityTracker keeps the lifecycles of the spreads the strategy sees
+// and decides which of them may be entered
+type opportunityTracker struct {
+	mu       sync.Mutex
+	started  time.Time
+	cooldown CooldownConfig
+	open     map[string]*OpportunityLifecycle
+	closed   []OpportunityLifecycle // oldest first
+	stats    map[string]*OpportunityStats
+	executed map[string]time.Time // venue pair and symbol -> last execution
+}
+
+func newOpportunityTracker() *opportunityTracker {
+	return &opportunityTracker{
+		cooldown: DefaultCooldownConfig(),
+		open:     make(map[string]*OpportunityLifecycle),
+		stats:    make(map[string]*OpportunityStats),
+		executed: make(map[string]time.Time),
+	}
+}
+
+// opportunityKey identifies a spread across evaluations: its type and the
+// orders it is made of
+func opportunityKey(opp ArbitrageOpportunity) string {
+	if len(opp.Legs) == 0 {
+		return fmt.Sprintf("%s %s->%s %s", opp.Type, opp.BuyExchange, opp.SellExchange, NormalizeSymbol(opp.Symbol))
+	}
+	parts := []string{opp.Type}
+	for _, leg := range opp.Legs {
+		if leg.Side == LegTransfer {
+			parts = append(parts, fmt.Sprintf("%s:%s>%s", leg.From, leg.Venue, leg.ToVenue))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s:%s/%s", leg.Side, leg.Venue, NormalizeSymbol(leg.Symbol)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// netEdge returns an opportunity's edge after costs in percent, annualized
+// for basis trades
+func netEdge(opp ArbitrageOpportunity) float64 {
+	if opp.Type == OpportunityBasis {
+		return opp.AnnualizedPercent
+	}
+	if opp.EffBuyPrice <= 0 {
+		return 0
+	}
+	return (opp.EffSellPrice - opp.EffBuyPrice) / opp.EffBuyPrice * 100
+}
+
+// observe records the spreads one evaluation saw. New spreads get an id from
+// newID and existing ones keep theirs. It returns the detected opportunities
+// admitted for execution, carrying their lifecycle ids, and the lifecycles
+// of the spreads that are gone. refreshed reports whether every book of an
+// opportunity changed since a time.
+func (t *opportunityTracker) observe(sightings []sighting, now time.Time, newID func() string, refreshed func(ArbitrageOpportunity, time.Time) bool) (fresh []ArbitrageOpportunity, closed []OpportunityLifecycle) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.started.IsZero() {
+		t.started = now
+	}
+
+	seen := make(map[string]bool, len(sightings))
+	for _, s := range sightings {
+		key := opportunityKey(s.opp)
+		seen[key] = true
+		l, ok := t.open[key]
+		if !ok {
+			l = &OpportunityLifecycle{
+				ID:           newID(),
+				Type:         s.opp.Type,
+				BuyExchange:  s.opp.BuyExchange,
+				SellExchange: s.opp.SellExchange,
+				Symbol:       s.opp.Symbol,
+				Status:       LifecycleOpen,
+				FirstSeen:    now,
+				FirstEdge:    s.edge,
+				PeakEdge:     s.edge,
+				key:          key,
+			}
+			t.open[key] = l
+		}
+		l.LastSeen = now
+		l.Sightings++
+		l.LastEdge = s.edge
+		l.PeakEdge = max(l.PeakEdge, s.edge)
+		l.DurationMs = float64(now.Sub(l.FirstSeen)) / float64(time.Millisecond)
+		if s.outcome != reasonDetected {
+			if l.Entries == 0 {
+				l.Outcome = s.outcome
+			}
+			continue
+		}
+		if reason, ok := t.admit(l, s, now, refreshed); !ok {
+			if reason != "" {
+				opportunitiesTotal.WithLabelValues(reason).Inc()
+				if l.Entries == 0 {
+					l.Outcome = reason
+				}
+			}
+			continue
+		}
+		l.Entries++
+		l.entered, l.entryEdge = now, s.edge
+		l.Outcome = reasonDetected
+		s.opp.ID = l.ID
+		fresh = append(fresh, s.opp)
+	}
+
+	keys := make([]string, 0, len(t.open))
+	for key := range t.open {
+		if !seen[key] {
+			keys = append(keys, key)
+		}
+	}
+	// A fixed order keeps replays of the same quotes identical
+	sort.Strings(keys)
+	for _, key := range keys {
+		l := t.open[key]
+		delete(t.open, key)
+		l.Status = LifecycleClosed
+		l.Closed = now
+		l.DurationMs = float64(now.Sub(l.FirstSeen)) / float64(time.Millisecond)
+		t.record(*l)
+		closed = append(closed, *l)
+	}
+	return fresh, closed
+}
+
+// settle records what execution made of an opportunity entered at now
+func (t *opportunityTracker) settle(id, outcome string, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, l := range t.open {
+		if l.ID != id {
+			continue
+		}
+		l.Outcome = outcome
+		if outcome == reasonExecuted {
+			l.Executions++
+			t.executed[l.pair()] = now
+		}
+		return
+	}
+}
+
+// record adds a closed lifecycle to the history and the statistics; the
+// caller must hold the lock
+func (t *opportunityTracker) record(l OpportunityLifecycle) {
+	t.closed = append(t.closed, l)
+	if len(t.closed) > maxClosedOpportunities {
+		t.closed = t.closed[len(t.closed)-maxClosedOpportunities:]
+	}
+	opportunityDuration.Observe(l.DurationMs / 1000)
+
+	pair := l.BuyExchange + "->" + l.SellExchange
+	key := l.Type + " " + pair
+	s, ok := t.stats[key]
+	if !ok {
+		s = &OpportunityStats{Type: l.Type, VenuePair: pair, Outcomes: make(map[string]int), MaxPeakEdge: l.PeakEdge}
+		t.stats[key] = s
+	}
+	n := float64(s.Opportunities)
+	s.Opportunities++
+	s.Outcomes[l.Outcome]++
+	s.Executions += l.Executions
+	if l.Executions > 0 {
+		s.Executed++
+	} else {
+		s.Missed++
+	}
+	s.AverageDurationMs = (s.AverageDurationMs*n + l.DurationMs) / (n + 1)
+	s.AveragePeakEdge = (s.AveragePeakEdge*n + l.PeakEdge) / (n + 1)
+	s.MaxDurationMs = max(s.MaxDurationMs, l.DurationMs)
+	s.MaxPeakEdge = max(s.MaxPeakEdge, l.PeakEdge)
+}
+
+// lifecycles returns the open lifecycles, then the closed ones newest first,
+// at most limit in all; status restricts them to one state when not empty
+func (t *opportunityTracker) lifecycles(status string, limit int) []OpportunityLifecycle {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := make([]OpportunityLifecycle, 0, min(limit, len(t.open)+len(t.closed)))
+	if status != LifecycleClosed {
+		for _, l := range t.open {
+			result = append(result, *l)
+		}
+		sort.Slice(result, func(i, j int) bool { return result[i].FirstSeen.After(result[j].FirstSeen) })
+	}
+	if status != LifecycleOpen {
+		for i := len(t.closed) - 1; i >= 0; i-- {
+			result = append(result, t.closed[i])
+		}
+	}
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// statistics returns the statistics of every type and venue pair, by type
+// then pair, with frequencies per hour up to now
+func (t *opportunityTracker) statistics(now time.Time) []OpportunityStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	hours := now.Sub(t.started).Hours()
+	stats := make([]OpportunityStats, 0, len(t.stats))
+	for _, s := range t.stats {
+		c := *s
+		c.Outcomes = copyMap(s.Outcomes)
+		if hours > 0 {
+			c.PerHour = float64(c.Opportunities) / hours
+		}
+		stats = append(stats, c)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Type != stats[j].Type {
+			return stats[i].Type < stats[j].Type
+		}
+		return stats[i].VenuePair < stats[j].VenuePair
+	})
+	return stats
+}
+
+// Opportunities returns the lifecycles of the spreads the strategy has seen:
+// open ones, then closed ones newest first, at most limit. status is
+// LifecycleOpen, LifecycleClosed or empty for both.
+func (as *ArbitrageStrategy) Opportunities(status string, limit int) []OpportunityLifecycle {
+	return as.tracker.lifecycles(status, limit)
+}
+
+// OpportunityStats returns how often spreads of each type and venue pair
+// appear, how long they last and how many were executed
+func (as *ArbitrageStrategy) OpportunityStats() []OpportunityStats {
+	return as.tracker.statistics(as.clock.Now())
+}
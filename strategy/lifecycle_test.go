@@ -0,0 +1,188 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestOpportunityKey(t *testing.T) {
+	cycle := []OpportunityLeg{
+		{Venue: "binance", Symbol: "DOGEUSDT", Side: "BUY"},
+		{Venue: "binance", ToVenue: "okx", From: "DOGE", Side: LegTransfer},
+		{Venue: "okx", Symbol: "DOGE-BTC", Side: "SELL"},
+	}
+	tests := []struct {
+		name string
+		opp  ArbitrageOpportunity
+		want string
+	}{
+		{
+			name: "cross-venue symbols are normalized",
+			opp:  ArbitrageOpportunity{Type: OpportunityCrossVenue, BuyExchange: "okx", SellExchange: "binance", Symbol: "DOGE-USDT"},
+			want: "cross_venue okx->binance DOGEUSDT",
+		},
+		{
+			name: "cycle legs and transfers",
+			opp:  ArbitrageOpportunity{Type: OpportunityCycle, Legs: cycle},
+			want: "cycle BUY:binance/DOGEUSDT DOGE:binance>okx SELL:okx/DOGEBTC",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := opportunityKey(tt.opp); got != tt.want {
+				t.Errorf("opportunityKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// spread is a cross-venue sighting of DOGE bought on buy and sold on sell
+// with a net edge in percent
+func spread(buy, sell string, edge float64, outcome string) sighting {
+	return sighting{
+		opp:     ArbitrageOpportunity{Type: OpportunityCrossVenue, BuyExchange: buy, SellExchange: sell, Symbol: "DOGEUSDT"},
+		outcome: outcome,
+		edge:    edge,
+	}
+}
+
+// observeTicks feeds one evaluation 100ms apart per element of ticks to a
+// tracker without cooldowns, executing every admitted opportunity, and
+// returns the ids admitted and closed at each
+func observeTicks(tracker *opportunityTracker, start time.Time, ticks [][]sighting) (admitted, closed [][]string) {
+	seq := 0
+	newID := func() string { seq++; return fmt.Sprintf("opp-%d", seq) }
+	notRefreshed := func(ArbitrageOpportunity, time.Time) bool { return false }
+	for i, sightings := range ticks {
+		now := start.Add(time.Duration(i) * 100 * time.Millisecond)
+		fresh, gone := tracker.observe(sightings, now, newID, notRefreshed)
+		var ids, goneIDs []string
+		for _, opp := range fresh {
+			ids = append(ids, opp.ID)
+			tracker.settle(opp.ID, reasonExecuted, now)
+		}
+		for _, l := range gone {
+			goneIDs = append(goneIDs, l.ID)
+		}
+		admitted, closed = append(admitted, ids), append(closed, goneIDs)
+	}
+	return admitted, closed
+}
+
+func TestTrackerObserve(t *testing.T) {
+	start := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		ticks        [][]sighting
+		wantAdmitted [][]string
+		wantClosed   [][]string
+	}{
+		{
+			name: "one persistent spread is entered once",
+			ticks: [][]sighting{
+				{spread("binance", "okx", 0.2, reasonDetected)},
+				{spread("binance", "okx", 0.21, reasonDetected)},
+				{spread("binance", "okx", 0.19, reasonDetected)},
+				nil,
+			},
+			wantAdmitted: [][]string{{"opp-1"}, nil, nil, nil},
+			wantClosed:   [][]string{nil, nil, nil, {"opp-1"}},
+		},
+		{
+			name: "a spread that returns is a new opportunity",
+			ticks: [][]sighting{
+				{spread("binance", "okx", 0.2, reasonDetected)},
+				nil,
+				{spread("binance", "okx", 0.2, reasonDetected)},
+			},
+			wantAdmitted: [][]string{{"opp-1"}, nil, {"opp-2"}},
+			wantClosed:   [][]string{nil, {"opp-1"}, nil},
+		},
+		{
+			name: "re-entered when the edge improves",
+			ticks: [][]sighting{
+				{spread("binance", "okx", 0.2, reasonDetected)},
+				{spread("binance", "okx", 0.26, reasonDetected)},
+			},
+			wantAdmitted: [][]string{{"opp-1"}, {"opp-1"}},
+			wantClosed:   [][]string{nil, nil},
+		},
+		{
+			name: "spreads passed over are tracked but not entered",
+			ticks: [][]sighting{
+				{spread("binance", "okx", -0.1, reasonBelowThreshold), spread("okx", "binance", 0.2, reasonNegativeEV)},
+				{spread("binance", "okx", 0.2, reasonDetected)},
+			},
+			wantAdmitted: [][]string{nil, {"opp-1"}},
+			wantClosed:   [][]string{nil, {"opp-2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newOpportunityTracker()
+			tracker.cooldown = CooldownConfig{ReentryEdge: 0.05}
+			admitted, closed := observeTicks(tracker, start, tt.ticks)
+			for i := range tt.ticks {
+				if !slices.Equal(admitted[i], tt.wantAdmitted[i]) || !slices.Equal(closed[i], tt.wantClosed[i]) {
+					t.Errorf("tick %d admitted %v and closed %v, want %v and %v", i, admitted[i], closed[i], tt.wantAdmitted[i], tt.wantClosed[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTrackerLifecycles(t *testing.T) {
+	start := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	tracker := newOpportunityTracker()
+	tracker.cooldown = CooldownConfig{ReentryEdge: 0.05}
+	// binance->okx lasts 300ms and peaks at 0.3%; okx->binance is passed
+	// over for its EV and is still open at the end
+	observeTicks(tracker, start, [][]sighting{
+		{spread("binance", "okx", 0.2, reasonDetected)},
+		{spread("binance", "okx", 0.3, reasonDetected)},
+		{spread("binance", "okx", 0.22, reasonDetected), spread("okx", "binance", 0.1, reasonNegativeEV)},
+		{spread("okx", "binance", 0.1, reasonNegativeEV)},
+	})
+
+	tests := []struct {
+		status  string
+		limit   int
+		wantIDs []string
+	}{
+		{status: "", limit: 10, wantIDs: []string{"opp-2", "opp-1"}},
+		{status: "", limit: 1, wantIDs: []string{"opp-2"}},
+		{status: LifecycleOpen, limit: 10, wantIDs: []string{"opp-2"}},
+		{status: LifecycleClosed, limit: 10, wantIDs: []string{"opp-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q limit %d", tt.status, tt.limit), func(t *testing.T) {
+			var ids []string
+			for _, l := range tracker.lifecycles(tt.status, tt.limit) {
+				ids = append(ids, l.ID)
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("lifecycles() = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+
+	closed := tracker.lifecycles(LifecycleClosed, 1)[0]
+	if closed.DurationMs != 300 || closed.Sightings != 3 || closed.FirstEdge != 0.2 || closed.PeakEdge != 0.3 || closed.LastEdge != 0.22 ||
+		closed.Entries != 2 || closed.Executions != 2 || closed.Outcome != reasonExecuted {
+		t.Errorf("closed lifecycle = %+v", closed)
+	}
+
+	stats := tracker.statistics(start.Add(time.Hour))
+	if len(stats) != 1 {
+		t.Fatalf("statistics() = %+v, want the closed pair only", stats)
+	}
+	s := stats[0]
+	if s.VenuePair != "binance->okx" || s.Opportunities != 1 || s.Executed != 1 || s.Executions != 2 || s.Missed != 0 ||
+		s.Outcomes[reasonExecuted] != 1 || s.AverageDurationMs != 300 || s.MaxPeakEdge != 0.3 || math.Abs(s.PerHour-1) > 1e-9 {
+		t.Errorf("statistics() = %+v", s)
+	}
+}
@@ -0,0 +1,607 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"hft-arbitrage-bot/risk"
+)
+
+// Statuses of a resting order
+const (
+	OrderResting   = "resting"
+	OrderFilled    = "filled"
+	OrderCancelled = "cancelled"
+)
+
+// makerFees are the base tier maker rates
+var makerFees = map[string]float64{
+	"binance": 0.0010,
+	"kraken":  0.0016,
+	"okx":     0.0008,
+	"bybit":   0.0010,
+	"kucoin":  0.0010,
+}
+
+// DefaultMakerFees returns a copy of the base tier maker rates per venue
+func DefaultMakerFees() map[string]float64 {
+	return copyMap(makerFees)
+}
+
+// MakerConfig configures maker-taker arbitrage: a passive order rests on the
+// venue with the lowest maker fee, priced so that hedging it with a taker
+// order on another venue makes at least EdgePercent, and the hedge is sent
+// as soon as it fills.
+type MakerConfig struct {
+	Enabled     bool
+	Venues      []string           // venues that may rest orders
+	Fees        map[string]float64 // venue -> maker fee rate
+	EdgePercent float64            // net edge after the maker fee, the hedge's taker fee and slippage
+	Reprice     float64            // percent the target price must move before the order is replaced
+	MaxQuoteAge time.Duration      // orders are cancelled when their venue's or the hedge venue's quote is older
+}
+
+// DefaultMakerConfig is off; when enabled it may rest on any venue with a
+// maker fee
+func DefaultMakerConfig() MakerConfig {
+	return MakerConfig{
+		Venues:      []string{"binance", "bybit", "kraken", "kucoin", "okx"},
+		Fees:        DefaultMakerFees(),
+		EdgePercent: 0.05,
+		Reprice:     0.02,
+		MaxQuoteAge: 2 * time.Second,
+	}
+}
+
+// MakerConfigFromEnv builds the config from environment variables on top of
+// DefaultMakerConfig:
+//
+//	HFT_MAKER            on enables maker-taker arbitrage
+//	HFT_MAKER_VENUES     comma separated venues that may rest orders
+//	HFT_MAKER_EDGE       net edge in percent a resting order is priced for
+//	HFT_MAKER_REPRICE    percent move of the target price that replaces the order
+//	HFT_MAKER_STALE      quote age that cancels orders, e.g. 2s
+func MakerConfigFromEnv() (MakerConfig, error) {
+	config := DefaultMakerConfig()
+
+	switch value := strings.ToLower(os.Getenv("HFT_MAKER")); value {
+	case "":
+	case "on", "true", "1":
+		config.Enabled = true
+	case "off", "false", "0":
+		config.Enabled = false
+	default:
+		return config, fmt.Errorf("invalid HFT_MAKER %q (want on or off)", value)
+	}
+	if value := os.Getenv("HFT_MAKER_VENUES"); value != "" {
+		config.Venues = nil
+		for _, venue := range strings.Split(value, ",") {
+			if venue = strings.ToLower(strings.TrimSpace(venue)); venue != "" {
+				config.Venues = append(config.Venues, venue)
+			}
+		}
+	}
+	for name, field := range map[string]*float64{"HFT_MAKER_EDGE": &config.EdgePercent, "HFT_MAKER_REPRICE": &config.Reprice} {
+		if value := os.Getenv(name); value != "" {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return config, fmt.Errorf("invalid %s %q", name, value)
+			}
+			*field = v
+		}
+	}
+	if value := os.Getenv("HFT_MAKER_STALE"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return config, fmt.Errorf("invalid HFT_MAKER_STALE %q", value)
+		}
+		config.MaxQuoteAge = d
+	}
+	return config, config.Validate()
+}
+
+// Validate checks the config for values maker-taker arbitrage cannot work with
+func (c MakerConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.EdgePercent < 0 {
+		return fmt.Errorf("maker edge must not be negative, got %.4f", c.EdgePercent)
+	}
+	if c.Reprice <= 0 {
+		return fmt.Errorf("maker reprice threshold must be positive, got %.4f", c.Reprice)
+	}
+	if c.MaxQuoteAge <= 0 {
+		return fmt.Errorf("maker stale quote age must be positive, got %s", c.MaxQuoteAge)
+	}
+	for _, venue := range c.Venues {
+		if _, ok := c.Fees[venue]; !ok {
+			return fmt.Errorf("no maker fee for %s", venue)
+		}
+	}
+	return nil
+}
+
+// SetMaker sets the maker-taker configuration. It must be called before the
+// strategy receives quotes.
+func (as *ArbitrageStrategy) SetMaker(config MakerConfig) {
+	config.Venues = append([]string(nil), config.Venues...)
+	config.Fees = copyMap(config.Fees)
+	as.maker = config
+}
+
+// RestingOrder is a passive order resting on a venue's book, and the hedge it
+// was priced against
+type RestingOrder struct {
+	ID           string    `json:"id"`
+	Status       string    `json:"status"` // OrderResting, OrderFilled or OrderCancelled
+	CancelReason string    `json:"cancel_reason,omitempty"`
+	Venue        string    `json:"venue"`
+	Symbol       string    `json:"symbol"`
+	Side         string    `json:"side"` // BUY rests on the bid, SELL on the ask
+	Price        float64   `json:"price"`
+	Fee          float64   `json:"fee"`      // maker rate
+	Quantity     float64   `json:"quantity"` // still resting
+	Filled       float64   `json:"filled"`   // so far, at Price
+	Unhedged     float64   `json:"unhedged"` // filled but not hedged yet
+	HedgeVenue   string    `json:"hedge_venue"`
+	HedgePrice   float64   `json:"hedge_price"`  // the taker price it was priced against
+	EdgePercent  float64   `json:"edge_percent"` // expected net edge at HedgePrice
+	Reprices     int       `json:"reprices"`
+	Posted       time.Time `json:"posted"`
+	Updated      time.Time `json:"updated"` // last priced
+}
+
+// riskOrder returns the order as the risk engine sees it
+func (o *RestingOrder) riskOrder(quantity float64) risk.Order {
+	return risk.Order{Venue: o.Venue, Symbol: o.Symbol, Side: o.Side, Price: o.Price, Quantity: quantity}
+}
+
+// makerTarget is where a passive order should rest and the hedge behind it
+type makerTarget struct {
+	price       float64
+	hedgeVenue  string
+	hedgeSymbol string
+	hedgePrice  float64
+	edge        float64
+}
+
+// RestingOrders returns the maker orders that are resting or waiting for
+// their hedge, oldest first
+func (as *ArbitrageStrategy) RestingOrders() []RestingOrder {
+	as.makerLock.Lock()
+	defer as.makerLock.Unlock()
+
+	orders := make([]RestingOrder, len(as.makerOrders))
+	for i, o := range as.makerOrders {
+		orders[i] = *o
+	}
+	return orders
+}
+
+// quoteSnapshot copies the cross-venue quotes
+func (as *ArbitrageStrategy) quoteSnapshot() map[string]Quote {
+	as.quotesLock.RLock()
+	defer as.quotesLock.RUnlock()
+	return copyMap(as.quotes)
+}
+
+// makerUsable reports whether a venue's quote may be rested on or hedged
+// against now
+func (as *ArbitrageStrategy) makerUsable(quote Quote, ok bool, params *Params, now time.Time) bool {
+	if !ok || quote.Bid <= 0 || quote.Ask <= 0 || now.Sub(quote.Timestamp) > as.maker.MaxQuoteAge {
+		return false
+	}
+	paused, _ := as.breakers.Paused(quote.Exchange, now)
+	return !paused && params.Tradable(quote.Exchange)
+}
+
+// makerVenue returns the usable venue with the lowest maker fee
+func (as *ArbitrageStrategy) makerVenue(quotes map[string]Quote, params *Params, now time.Time) (string, bool) {
+	best, found := "", false
+	for _, venue := range as.maker.Venues {
+		quote, ok := quotes[venue]
+		if !as.makerUsable(quote, ok, params, now) {
+			continue
+		}
+		if !found || as.maker.Fees[venue] < as.maker.Fees[best] || (as.maker.Fees[venue] == as.maker.Fees[best] && venue < best) {
+			best, found = venue, true
+		}
+	}
+	return best, found
+}
+
+// makerTarget prices a passive order on a venue against the best hedge on
+// any other usable venue. A bid joins the venue's best bid unless that is
+// too high for the edge, and an ask joins its best ask likewise, so the order
+// never takes liquidity.
+func (as *ArbitrageStrategy) makerTarget(venue, side string, quotes map[string]Quote, params *Params, now time.Time) (makerTarget, bool) {
+	maker, ok := quotes[venue]
+	if !as.makerUsable(maker, ok, params, now) {
+		return makerTarget{}, false
+	}
+	fee := as.maker.Fees[venue]
+	edge := 1 + as.maker.EdgePercent/100
+
+	var best makerTarget
+	found := false
+	for hedgeVenue, hedge := range quotes {
+		if hedgeVenue == venue || !as.makerUsable(hedge, true, params, now) {
+			continue
+		}
+		cost := params.Fee(hedgeVenue) + exchangeSlippage[hedgeVenue]
+		t := makerTarget{hedgeVenue: hedgeVenue, hedgeSymbol: hedge.Symbol}
+		var better bool
+		if side == "BUY" {
+			// Bought passively, sold to the hedge venue's bid
+			t.hedgePrice = hedge.Bid
+			t.price = hedge.Bid * (1 - cost) / (1 + fee) / edge
+			better = !found || t.price > best.price || (t.price == best.price && hedgeVenue < best.hedgeVenue)
+		} else {
+			// Sold passively, bought back at the hedge venue's ask
+			t.hedgePrice = hedge.Ask
+			t.price = hedge.Ask * (1 + cost) / (1 - fee) * edge
+			better = !found || t.price < best.price || (t.price == best.price && hedgeVenue < best.hedgeVenue)
+		}
+		if better {
+			best, found = t, true
+		}
+	}
+	if !found || best.price <= 0 {
+		return makerTarget{}, false
+	}
+
+	cost := params.Fee(best.hedgeVenue) + exchangeSlippage[best.hedgeVenue]
+	if side == "BUY" {
+		best.price = math.Min(best.price, maker.Bid)
+		net := best.hedgePrice * (1 - cost)
+		best.edge = (net - best.price*(1+fee)) / (best.price * (1 + fee)) * 100
+	} else {
+		best.price = math.Max(best.price, maker.Ask)
+		net := best.hedgePrice * (1 + cost)
+		best.edge = (best.price*(1-fee) - net) / net * 100
+	}
+	return best, true
+}
+
+// manageMaker hedges fills still unhedged, re-prices or cancels resting
+// orders and posts new ones where none rest. It runs at every evaluation.
+func (as *ArbitrageStrategy) manageMaker() {
+	if !as.maker.Enabled || !as.trades(OpportunityMakerTaker) {
+		return
+	}
+	now := as.clock.Now()
+	quotes := as.quoteSnapshot()
+	params := as.params.Load()
+	halted, _ := as.riskEngine.Halted()
+
+	as.makerLock.Lock()
+	defer as.makerLock.Unlock()
+
+	for _, o := range as.makerOrders {
+		if o.Unhedged > 0 {
+			as.hedge(o, quotes, params, now)
+		}
+	}
+
+	resting := make(map[string]bool)
+	for _, o := range as.makerOrders {
+		if o.Status != OrderResting {
+			continue
+		}
+		target, ok := as.makerTarget(o.Venue, o.Side, quotes, params, now)
+		switch {
+		case halted:
+			as.cancel(o, "halted", now)
+		case params.TradingPaused:
+			as.cancel(o, reasonPaused, now)
+		case !as.makerUsable(quotes[o.Venue], true, params, now):
+			as.cancel(o, "stale", now)
+		case !ok:
+			as.cancel(o, "no_hedge", now)
+		case target.hedgeVenue != o.HedgeVenue || math.Abs(target.price-o.Price)/o.Price*100 > as.maker.Reprice:
+			as.reprice(o, target, now)
+		}
+		if o.Status == OrderResting {
+			resting[o.Side] = true
+		}
+	}
+
+	if venue, ok := as.makerVenue(quotes, params, now); ok && !halted && !params.TradingPaused {
+		for _, side := range []string{"BUY", "SELL"} {
+			if resting[side] {
+				continue
+			}
+			if target, ok := as.makerTarget(venue, side, quotes, params, now); ok {
+				as.post(venue, side, quotes[venue].Symbol, target, now)
+			}
+		}
+	}
+	as.pruneMakerOrders()
+}
+
+// post rests a new order if the risk engine accepts it and its hedge;
+// the caller must hold the maker lock
+func (as *ArbitrageStrategy) post(venue, side, symbol string, target makerTarget, now time.Time) {
+	tradeSize := as.params.Load().TradeSize
+	if as.pnlManager.GetCurrentPnL().CurrentBalance < tradeSize {
+		return
+	}
+	as.makerSeq++
+	o := &RestingOrder{
+		ID:          fmt.Sprintf("%smk-%d", as.idPrefix(), as.makerSeq),
+		Status:      OrderResting,
+		Venue:       venue,
+		Symbol:      symbol,
+		Side:        side,
+		Price:       target.price,
+		Fee:         as.maker.Fees[venue],
+		Quantity:    tradeSize / target.price,
+		HedgeVenue:  target.hedgeVenue,
+		HedgePrice:  target.hedgePrice,
+		EdgePercent: target.edge,
+		Posted:      now,
+		Updated:     now,
+	}
+	hedgeSide := "SELL"
+	if side == "SELL" {
+		hedgeSide = "BUY"
+	}
+	hedge := risk.Order{Venue: target.hedgeVenue, Symbol: target.hedgeSymbol, Side: hedgeSide, Price: target.hedgePrice, Quantity: o.Quantity}
+	if err := as.riskEngine.CheckOrders(o.riskOrder(o.Quantity), hedge); err != nil {
+		makerOrders.WithLabelValues("risk_rejected").Inc()
+		logger.Debug("risk check rejected maker order", "venue", venue, "side", side, "err", err)
+		return
+	}
+	as.makerOrders = append(as.makerOrders, o)
+	makerOrders.WithLabelValues(EventPosted).Inc()
+	logger.Info("posted maker order",
+		"order_id", o.ID,
+		"venue", venue,
+		"side", side,
+		"price", o.Price,
+		"quantity", o.Quantity,
+		"hedge_venue", o.HedgeVenue,
+		"hedge_price", o.HedgePrice,
+		"edge_pct", o.EdgePercent)
+	as.events.Publish(TopicOpportunities, EventPosted, *o)
+}
+
+// reprice replaces a resting order at a new target; a replacement the risk
+// engine rejects cancels the order. The caller must hold the maker lock.
+func (as *ArbitrageStrategy) reprice(o *RestingOrder, target makerTarget, now time.Time) {
+	replacement := *o
+	replacement.Price = target.price
+	if err := as.riskEngine.CheckOrders(replacement.riskOrder(o.Quantity)); err != nil {
+		as.cancel(o, reasonRiskRejected, now)
+		return
+	}
+	o.Price = target.price
+	o.HedgeVenue = target.hedgeVenue
+	o.HedgePrice = target.hedgePrice
+	o.EdgePercent = target.edge
+	o.Updated = now
+	o.Reprices++
+	makerOrders.WithLabelValues(EventRepriced).Inc()
+	as.events.Publish(TopicOpportunities, EventRepriced, *o)
+}
+
+// cancel pulls a resting order; fills it already had are still hedged. The
+// caller must hold the maker lock.
+func (as *ArbitrageStrategy) cancel(o *RestingOrder, reason string, now time.Time) {
+	o.Status = OrderCancelled
+	o.CancelReason = reason
+	o.Updated = now
+	makerOrders.WithLabelValues(EventCancelled).Inc()
+	logger.Info("cancelled maker order", "order_id", o.ID, "venue", o.Venue, "side", o.Side, "reason", reason)
+	as.events.Publish(TopicOpportunities, EventCancelled, *o)
+}
+
+// pruneMakerOrders forgets orders that no longer rest and are fully hedged;
+// the caller must hold the maker lock
+func (as *ArbitrageStrategy) pruneMakerOrders() {
+	kept := as.makerOrders[:0]
+	for _, o := range as.makerOrders {
+		if o.Status == OrderResting || o.Unhedged > 0 {
+			kept = append(kept, o)
+		}
+	}
+	as.makerOrders = kept
+}
+
+// fillResting fills the resting orders a venue's quote trades through and
+// hedges them at once. A resting bid fills when the venue offers at or below
+// its price, for the size offered there; a resting ask likewise. Quotes
+// older than the order's last pricing are ignored.
+func (as *ArbitrageStrategy) fillResting(quote Quote) {
+	if !as.maker.Enabled {
+		return
+	}
+	as.makerLock.Lock()
+	defer as.makerLock.Unlock()
+
+	var filled []*RestingOrder
+	for _, o := range as.makerOrders {
+		if o.Status != OrderResting || o.Venue != quote.Exchange || !quote.Timestamp.After(o.Updated) {
+			continue
+		}
+		quantity := tradedThrough(o, quote)
+		if quantity <= 0 {
+			continue
+		}
+		o.Quantity -= quantity
+		o.Filled += quantity
+		o.Unhedged += quantity
+		if o.Quantity <= 0 {
+			o.Quantity = 0
+			o.Status = OrderFilled
+		}
+		as.riskEngine.OnFill(o.riskOrder(quantity))
+		makerOrders.WithLabelValues(OrderFilled).Inc()
+		filled = append(filled, o)
+	}
+	if len(filled) == 0 {
+		return
+	}
+
+	quotes := as.quoteSnapshot()
+	params := as.params.Load()
+	now := as.clock.Now()
+	for _, o := range filled {
+		as.hedge(o, quotes, params, now)
+	}
+	as.pruneMakerOrders()
+}
+
+// tradedThrough returns how much of a resting order a quote of its venue
+// fills. A size of zero means the venue does not report sizes and fills it
+// all.
+func tradedThrough(o *RestingOrder, quote Quote) float64 {
+	levels := []PriceLevel{{Price: quote.Ask, Size: quote.AskSize}}
+	through := func(price float64) bool { return price > 0 && price <= o.Price }
+	if quote.Book != nil && len(quote.Book.Asks) > 0 {
+		levels = quote.Book.Asks
+	}
+	if o.Side == "SELL" {
+		levels = []PriceLevel{{Price: quote.Bid, Size: quote.BidSize}}
+		through = func(price float64) bool { return price > 0 && price >= o.Price }
+		if quote.Book != nil && len(quote.Book.Bids) > 0 {
+			levels = quote.Book.Bids
+		}
+	}
+
+	size := 0.0
+	for _, level := range levels {
+		if !through(level.Price) {
+			break
+		}
+		if level.Size == 0 {
+			return o.Quantity
+		}
+		size += level.Size
+	}
+	return math.Min(size, o.Quantity)
+}
+
+// hedge takes the other side of an order's unhedged fills on its hedge venue,
+// or on the best usable venue when that one went stale, and books the pair.
+// What the hedge leaves unfilled is retried at the next evaluation. The
+// caller must hold the maker lock.
+func (as *ArbitrageStrategy) hedge(o *RestingOrder, quotes map[string]Quote, params *Params, now time.Time) {
+	venue := o.HedgeVenue
+	if quote, ok := quotes[venue]; !as.makerUsable(quote, ok, params, now) {
+		target, ok := as.makerTarget(o.Venue, o.Side, quotes, params, now)
+		if !ok {
+			logger.Warn("maker fill waiting for a hedge venue", "order_id", o.ID, "unhedged", o.Unhedged)
+			return
+		}
+		venue = target.hedgeVenue
+	}
+	quote := quotes[venue]
+
+	leg := OpportunityLeg{Venue: venue, Symbol: quote.Symbol, Side: "SELL", Price: quote.Bid,
+		Fee: params.Fee(venue), Slippage: exchangeSlippage[venue], Quantity: 1}
+	leg.From = risk.BaseAsset(quote.Symbol)
+	leg.To = strings.TrimPrefix(NormalizeSymbol(quote.Symbol), leg.From)
+	if o.Side == "SELL" {
+		leg.Side, leg.Price, leg.From, leg.To = "BUY", quote.Ask, leg.To, leg.From
+	}
+	opp := ArbitrageOpportunity{
+		ID:        fmt.Sprintf("%s-hedge", o.ID),
+		Type:      OpportunityMakerTaker,
+		Symbol:    o.Symbol,
+		Timestamp: now,
+		QuoteTime: quote.Timestamp,
+		Legs:      []OpportunityLeg{leg},
+	}
+
+	start := time.Now()
+	roundTrip, hedged, err := as.pnlManager.HedgeMakerFill(*o, opp)
+	if err != nil {
+		opportunitiesTotal.WithLabelValues(reasonExecutionFailed).Inc()
+		logger.Warn("maker hedge failed", "order_id", o.ID, "hedge_venue", venue, "unhedged", o.Unhedged, "err", err)
+		as.events.Publish(TopicOpportunities, EventRejected,
+			RejectedOpportunity{Opportunity: opp, Reason: reasonExecutionFailed, Error: err.Error()})
+		return
+	}
+	o.Unhedged = math.Max(0, o.Unhedged-roundTrip.Quantity)
+	opportunitiesTotal.WithLabelValues(reasonExecuted).Inc()
+	executionsTotal.WithLabelValues(roundTrip.BuyExchange, roundTrip.SellExchange).Inc()
+	executionDuration.Observe(time.Since(start).Seconds())
+
+	as.riskEngine.OnFill(risk.Order{Venue: venue, Symbol: quote.Symbol, Side: leg.Side, Price: hedged.Price, Quantity: roundTrip.Quantity})
+	as.riskEngine.OnArbitrageClosed(roundTrip.PnL)
+	as.events.Publish(TopicTrades, EventExecuted, roundTrip)
+	if as.events.Active() {
+		as.events.Publish(TopicPnL, EventPnL, as.pnlManager.GetCurrentPnL())
+	}
+}
+
+// HedgeMakerFill sends the taker leg of hedge for a resting order's unhedged
+// fills and books both as one round trip: the passive leg at the order's
+// price and maker rate, the hedge as it filled. It returns the round trip
+// and the hedge's fill, which may be for less than was unhedged.
+func (pm *PnLManager) HedgeMakerFill(order RestingOrder, hedge ArbitrageOpportunity) (RoundTrip, LegFill, error) {
+	pm.mutex.RLock()
+	execution, now := pm.execution, pm.clock.Now()
+	pm.mutex.RUnlock()
+
+	executed, err := execution.Execute(hedge, order.Unhedged, now)
+	if err != nil {
+		return RoundTrip{}, LegFill{}, err
+	}
+	hedged := executed.Legs[0]
+	quantity := hedged.Quantity
+	leg := hedge.Legs[0]
+
+	opp := ArbitrageOpportunity{
+		ID:           hedge.ID,
+		Type:         OpportunityMakerTaker,
+		BuyExchange:  order.Venue,
+		SellExchange: leg.Venue,
+		Symbol:       order.Symbol,
+		BuySymbol:    order.Symbol,
+		SellSymbol:   leg.Symbol,
+		BuyPrice:     order.Price,
+		SellPrice:    leg.Price,
+		BuyFee:       order.Fee,
+		SellFee:      leg.Fee,
+		SellSlippage: leg.Slippage,
+		Timestamp:    hedge.Timestamp,
+		QuoteTime:    hedge.QuoteTime,
+	}
+	fill := Execution{
+		Quantity:      quantity,
+		BuyPrice:      order.Price * (1 + order.Fee),
+		SellPrice:     hedged.Price * (1 - hedged.FeeRate),
+		Time:          executed.Time,
+		BuyFee:        quantity * order.Price * order.Fee,
+		SellFee:       quantity * hedged.Price * hedged.FeeRate,
+		BuyLiquidity:  LiquidityMaker,
+		SellLiquidity: hedged.Liquidity,
+	}
+	if order.Side == "SELL" {
+		opp.BuyExchange, opp.SellExchange = leg.Venue, order.Venue
+		opp.BuySymbol, opp.SellSymbol = leg.Symbol, order.Symbol
+		opp.BuyPrice, opp.SellPrice = leg.Price, order.Price
+		opp.BuyFee, opp.SellFee = leg.Fee, order.Fee
+		opp.BuySlippage, opp.SellSlippage = leg.Slippage, 0
+		fill.BuyPrice = hedged.Price * (1 + hedged.FeeRate)
+		fill.SellPrice = order.Price * (1 - order.Fee)
+		fill.BuyFee = quantity * hedged.Price * hedged.FeeRate
+		fill.SellFee = quantity * order.Price * order.Fee
+		fill.BuyLiquidity, fill.SellLiquidity = hedged.Liquidity, LiquidityMaker
+	}
+	opp.Spread = opp.SellPrice - opp.BuyPrice
+	opp.SpreadPercent = opp.Spread / opp.BuyPrice * 100
+	opp.EffBuyPrice = opp.BuyPrice * (1 + opp.BuyFee + opp.BuySlippage)
+	opp.EffSellPrice = opp.SellPrice * (1 - opp.SellFee - opp.SellSlippage)
+
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	return pm.book(opp, fill), hedged, nil
+}
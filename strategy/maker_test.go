@@ -0,0 +1,261 @@
+package strategy
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"hft-arbitrage-bot/clock"
+)
+
+func TestMakerConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(c MakerConfig) bool
+		wantErr bool
+	}{
+		{name: "defaults", check: func(c MakerConfig) bool { return !c.Enabled && len(c.Venues) == 5 && c.MaxQuoteAge == 2*time.Second }},
+		{
+			name: "venues and thresholds",
+			env:  map[string]string{"HFT_MAKER": "on", "HFT_MAKER_VENUES": " OKX, binance ,", "HFT_MAKER_EDGE": "0.1", "HFT_MAKER_STALE": "500ms"},
+			check: func(c MakerConfig) bool {
+				return c.Enabled && len(c.Venues) == 2 && c.Venues[0] == "okx" && c.EdgePercent == 0.1 && c.MaxQuoteAge == 500*time.Millisecond
+			},
+		},
+		{name: "unknown switch", env: map[string]string{"HFT_MAKER": "yes"}, wantErr: true},
+		{name: "venue without a maker fee", env: map[string]string{"HFT_MAKER": "on", "HFT_MAKER_VENUES": "bitstamp"}, wantErr: true},
+		{name: "negative edge", env: map[string]string{"HFT_MAKER": "on", "HFT_MAKER_EDGE": "-0.1"}, wantErr: true},
+		{name: "zero reprice", env: map[string]string{"HFT_MAKER": "on", "HFT_MAKER_REPRICE": "0"}, wantErr: true},
+		{name: "malformed stale age", env: map[string]string{"HFT_MAKER_STALE": "2"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"HFT_MAKER", "HFT_MAKER_VENUES", "HFT_MAKER_EDGE", "HFT_MAKER_REPRICE", "HFT_MAKER_STALE"} {
+				t.Setenv(name, tt.env[name])
+			}
+			config, err := MakerConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MakerConfigFromEnv() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && !tt.check(config) {
+				t.Errorf("MakerConfigFromEnv() = %+v", config)
+			}
+		})
+	}
+}
+
+func TestTradedThrough(t *testing.T) {
+	bid := &RestingOrder{Side: "BUY", Price: 0.1, Quantity: 1000}
+	ask := &RestingOrder{Side: "SELL", Price: 0.1, Quantity: 1000}
+	tests := []struct {
+		name  string
+		order *RestingOrder
+		quote Quote
+		want  float64
+	}{
+		{name: "offer above the bid", order: bid, quote: Quote{Bid: 0.1, Ask: 0.1001, AskSize: 500}},
+		{name: "offer at the bid", order: bid, quote: Quote{Bid: 0.0999, Ask: 0.1, AskSize: 300}, want: 300},
+		{name: "offer without a size fills it all", order: bid, quote: Quote{Bid: 0.0998, Ask: 0.0999}, want: 1000},
+		{
+			name:  "book levels through the bid",
+			order: bid,
+			quote: Quote{Bid: 0.0998, Ask: 0.0999, AskSize: 200, Book: &OrderBook{Asks: []PriceLevel{{0.0999, 200}, {0.1, 300}, {0.1001, 5000}}}},
+			want:  500,
+		},
+		{name: "more offered than resting", order: bid, quote: Quote{Bid: 0.0998, Ask: 0.0999, AskSize: 5000}, want: 1000},
+		{name: "bid below the ask", order: ask, quote: Quote{Bid: 0.0999, Ask: 0.1, BidSize: 500}},
+		{name: "bid through the ask", order: ask, quote: Quote{Bid: 0.1001, Ask: 0.1002, BidSize: 400}, want: 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tradedThrough(tt.order, tt.quote); got != tt.want {
+				t.Errorf("tradedThrough() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// makerQuotes are flat binance and okx DOGE books at a time: no cross-venue
+// spread to take, but room for a passive order on okx's lower maker fee
+func makerQuotes(at time.Time) []Quote {
+	return []Quote{
+		{Exchange: "binance", Symbol: "DOGEUSDT", Bid: 0.0999, Ask: 0.1000, BidSize: 5000, AskSize: 5000, Timestamp: at},
+		{Exchange: "okx", Symbol: "DOGE-USDT", Bid: 0.0999, Ask: 0.1000, BidSize: 5000, AskSize: 5000, Timestamp: at},
+	}
+}
+
+// newMakerStrategy returns a strategy with maker-taker arbitrage enabled on
+// the venues given and its simulated clock
+func newMakerStrategy(now time.Time, venues ...string) (*ArbitrageStrategy, *clock.Sim) {
+	sim := clock.NewSim(now)
+	as := NewArbitrageStrategy(0, 1000, 100)
+	as.SetClock(sim)
+	config := DefaultMakerConfig()
+	config.Enabled = true
+	config.Venues = venues
+	as.SetMaker(config)
+	return as, sim
+}
+
+func TestManageMakerPosts(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	cost := exchangeFees["binance"] + exchangeSlippage["binance"]
+	edge := 1 + DefaultMakerConfig().EdgePercent/100
+	tests := []struct {
+		name      string
+		venues    []string
+		quotes    []Quote
+		wantVenue string // of both orders; empty for none
+		wantBid   float64
+		wantAsk   float64
+	}{
+		{
+			name:      "rests on the lowest maker fee",
+			venues:    []string{"binance", "okx"},
+			quotes:    makerQuotes(now),
+			wantVenue: "okx",
+			wantBid:   0.0999 * (1 - cost) / (1 + makerFees["okx"]) / edge,
+			wantAsk:   0.1000 * (1 + cost) / (1 - makerFees["okx"]) * edge,
+		},
+		{
+			name:      "joins the venue's own bid when the hedge allows more",
+			venues:    []string{"okx"},
+			quotes:    append(makerQuotes(now)[:1], Quote{Exchange: "okx", Symbol: "DOGE-USDT", Bid: 0.0995, Ask: 0.1004, Timestamp: now}),
+			wantVenue: "okx",
+			wantBid:   0.0995,
+			wantAsk:   0.1004,
+		},
+		{name: "no venue may rest", venues: nil, quotes: makerQuotes(now)},
+		{name: "stale hedge venue", venues: []string{"okx"}, quotes: append(makerQuotes(now.Add(-3 * time.Second))[:1], makerQuotes(now)[1])},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as, _ := newMakerStrategy(now, tt.venues...)
+			for _, q := range tt.quotes {
+				as.UpdateQuote(q)
+			}
+			as.Evaluate()
+
+			orders := as.RestingOrders()
+			if tt.wantVenue == "" {
+				if len(orders) != 0 {
+					t.Fatalf("posted %+v, want nothing", orders)
+				}
+				return
+			}
+			if len(orders) != 2 {
+				t.Fatalf("posted %d orders, want a bid and an ask", len(orders))
+			}
+			for _, o := range orders {
+				want := tt.wantBid
+				if o.Side == "SELL" {
+					want = tt.wantAsk
+				}
+				if o.Venue != tt.wantVenue || o.HedgeVenue != "binance" || math.Abs(o.Price-want) > 1e-12 {
+					t.Errorf("%s on %s at %v hedged on %s, want %s at %v hedged on binance", o.Side, o.Venue, o.Price, o.HedgeVenue, tt.wantVenue, want)
+				}
+				if o.EdgePercent < DefaultMakerConfig().EdgePercent-1e-9 {
+					t.Errorf("%s priced for %.4f%% edge, below the configured %.4f%%", o.Side, o.EdgePercent, DefaultMakerConfig().EdgePercent)
+				}
+			}
+		})
+	}
+}
+
+func TestMakerFillIsHedged(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	as, sim := newMakerStrategy(now, "okx")
+	for _, q := range makerQuotes(now) {
+		as.UpdateQuote(q)
+	}
+	as.Evaluate()
+
+	// okx offers 300 DOGE through the resting bid
+	sim.Advance(100 * time.Millisecond)
+	as.UpdateQuote(Quote{Exchange: "okx", Symbol: "DOGE-USDT", Bid: 0.0995, Ask: 0.0996, BidSize: 5000, AskSize: 300, Timestamp: sim.Now()})
+
+	var bid RestingOrder
+	for _, o := range as.RestingOrders() {
+		if o.Side == "BUY" {
+			bid = o
+		}
+	}
+	if bid.Status != OrderResting || bid.Filled != 300 || bid.Unhedged != 0 {
+		t.Errorf("bid %s with %v filled and %v unhedged, want resting with 300 filled and hedged", bid.Status, bid.Filled, bid.Unhedged)
+	}
+	trades := as.GetPnLManager().GetTradeHistory(2)
+	if len(trades) != 2 {
+		t.Fatalf("booked %d trades, want the passive buy and its hedge", len(trades))
+	}
+	for _, trade := range trades {
+		wantExchange, wantLiquidity := "okx", LiquidityMaker
+		if trade.Type == "SELL" {
+			wantExchange, wantLiquidity = "binance", LiquidityTaker
+		}
+		if trade.Exchange != wantExchange || trade.Liquidity != wantLiquidity || trade.Quantity != 300 {
+			t.Errorf("%s of %v on %s as %s, want 300 on %s as %s", trade.Type, trade.Quantity, trade.Exchange, trade.Liquidity, wantExchange, wantLiquidity)
+		}
+	}
+	if pnl := as.GetPnLManager().GetCurrentPnL().TotalPnL; pnl <= 0 {
+		t.Errorf("hedged maker fill made %v, want a profit", pnl)
+	}
+}
+
+func TestManageMakerCancels(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		change func(as *ArbitrageStrategy, sim *clock.Sim)
+		want   string // cancel reason, empty for still resting
+	}{
+		{name: "fresh quotes", change: func(as *ArbitrageStrategy, sim *clock.Sim) {}},
+		{name: "stale quotes", change: func(as *ArbitrageStrategy, sim *clock.Sim) { sim.Advance(3 * time.Second) }, want: "stale"},
+		{name: "kill switch", change: func(as *ArbitrageStrategy, sim *clock.Sim) { as.GetRiskEngine().Halt("test") }, want: "halted"},
+		{name: "paused", change: func(as *ArbitrageStrategy, sim *clock.Sim) {
+			as.UpdateParams(func(p *Params) error { p.TradingPaused = true; return nil })
+		}, want: reasonPaused},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as, sim := newMakerStrategy(now, "okx")
+			for _, q := range makerQuotes(now) {
+				as.UpdateQuote(q)
+			}
+			as.Evaluate()
+			sub := as.GetEventBus().Subscribe(16, TopicOpportunities)
+			defer sub.Close()
+
+			tt.change(as, sim)
+			as.Evaluate()
+
+			orders := as.RestingOrders()
+			if tt.want == "" {
+				if len(orders) != 2 {
+					t.Errorf("%d orders resting, want 2", len(orders))
+				}
+				return
+			}
+			if len(orders) != 0 {
+				t.Errorf("%d orders still resting", len(orders))
+			}
+			cancelled := 0
+			for len(sub.Events()) > 0 {
+				event := <-sub.Events()
+				if o, ok := event.Data.(RestingOrder); ok && event.Type == EventCancelled {
+					cancelled++
+					if o.CancelReason != tt.want {
+						t.Errorf("%s cancelled for %q, want %q", o.ID, o.CancelReason, tt.want)
+					}
+				}
+			}
+			if cancelled != 2 {
+				t.Errorf("%d orders cancelled, want 2", cancelled)
+			}
+		})
+	}
+}
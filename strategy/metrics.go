@@ -0,0 +1,52 @@
+package strategy
+
+import "hft-arbitrage-bot/metrics"
+
+// Opportunity outcomes used as the reason label of hft_opportunities_total
+const (
+	reasonDetected        = "detected"
+	reasonBelowThreshold  = "below_threshold"
+	reasonRiskRejected    = "risk_rejected"
+	reasonPaused          = "paused"
+	reasonExecutionFailed = "execution_failed"
+	reasonExecuted        = "executed"
+	reasonNegativeEV      = "negative_ev"
+	reasonCooldown        = "cooldown"
+	reasonMaxOpen         = "max_open"
+	reasonSignal          = "signal"
+)
+
+var (
+	opportunitiesTotal = metrics.NewCounterVec("hft_opportunities_total",
+		"Arbitrage opportunities by outcome.", "reason")
+	executionsTotal = metrics.NewCounterVec("hft_executions_total",
+		"Executed arbitrages by directed venue pair.", "buy_venue", "sell_venue")
+	quoteAge = metrics.NewGaugeVec("hft_quote_age_seconds",
+		"Age of the latest quote per venue at the last evaluation.", "venue")
+	evaluationDuration = metrics.NewHistogram("hft_evaluation_duration_seconds",
+		"Time spent scanning the quote store for opportunities.", metrics.LatencyBuckets)
+	quoteToDecision = metrics.NewHistogram("hft_quote_to_decision_seconds",
+		"Age of the oldest quote behind a detected opportunity when it was acted on.", metrics.LatencyBuckets)
+	executionDuration = metrics.NewHistogram("hft_execution_duration_seconds",
+		"Time from starting risk checks to the arbitrage being booked.", metrics.LatencyBuckets)
+	quoteLifetime = metrics.NewGaugeVec("hft_quote_lifetime_seconds",
+		"Smoothed time between top of book changes per venue and symbol.", "book")
+	microstructure = metrics.NewGaugeVec("hft_microstructure",
+		"Microstructure signals per venue and symbol: imbalance, microprice_pct, drift_pct and trade_flow.", "book", "signal")
+	opportunityDuration = metrics.NewHistogram("hft_opportunity_duration_seconds",
+		"Time from an opportunity first being seen to the evaluation it was gone from.", metrics.DurationBuckets)
+	graphSearchDuration = metrics.NewHistogram("hft_graph_search_duration_seconds",
+		"Time spent building the market graph and searching it for cycles.", metrics.LatencyBuckets)
+	graphBudgetExceeded = metrics.NewCounter("hft_graph_budget_exceeded_total",
+		"Cycle searches cut short by the relaxation budget.")
+
+	makerOrders = metrics.NewCounterVec("hft_maker_orders_total",
+		"Maker order actions: posted, repriced, cancelled, filled or risk_rejected.", "action")
+	strategyFills = metrics.NewCounterVec("hft_strategy_fills_total",
+		"Executions delivered back to their strategy by the runtime.", "strategy")
+
+	balanceGauge = metrics.NewGaugeVec("hft_balance_usd", "Current account balance per strategy.", "strategy")
+	pnlGauge     = metrics.NewGaugeVec("hft_pnl_usd", "Total realized P&L per strategy.", "strategy")
+	tradesGauge  = metrics.NewGaugeVec("hft_trades", "Total executed trades per strategy (both legs counted).", "strategy")
+	winRateGauge = metrics.NewGaugeVec("hft_win_rate_percent", "Share of profitable arbitrages per strategy.", "strategy")
+)
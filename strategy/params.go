@@ -0,0 +1,120 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Params are the strategy settings that can be changed while the bot runs.
+// A Params value is never modified once published; updates build a new copy
+// and swap it in atomically.
+type Params struct {
+	MinSpreadPercent float64            `json:"min_spread_percent"` // minimum net edge, after fees and slippage, to trade
+	TradeSize        float64            `json:"trade_size"`         // quote currency spent per arbitrage
+	FeeOverrides     map[string]float64 `json:"fee_overrides"`      // venue -> taker fee rate, replaces the built-in fee
+	TradingPaused    bool               `json:"trading_paused"`     // detect but do not execute
+	PausedVenues     map[string]bool    `json:"paused_venues"`      // quotes are kept but the venue is not traded
+	DisabledVenues   map[string]bool    `json:"disabled_venues"`    // quotes from the venue are ignored entirely
+}
+
+// clone returns a deep copy that can be modified safely
+func (p Params) clone() Params {
+	c := p
+	c.FeeOverrides = copyMap(p.FeeOverrides)
+	c.PausedVenues = copyMap(p.PausedVenues)
+	c.DisabledVenues = copyMap(p.DisabledVenues)
+	return c
+}
+
+func copyMap[V any](m map[string]V) map[string]V {
+	c := make(map[string]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// validate rejects settings the strategy cannot trade with
+func (p Params) validate() error {
+	if p.MinSpreadPercent < 0 {
+		return fmt.Errorf("min spread must not be negative, got %.4f", p.MinSpreadPercent)
+	}
+	if p.TradeSize <= 0 {
+		return fmt.Errorf("trade size must be positive, got %.2f", p.TradeSize)
+	}
+	for venue, fee := range p.FeeOverrides {
+		if fee < 0 || fee >= 0.05 {
+			return fmt.Errorf("fee override for %s must be in [0, 0.05), got %.6f", venue, fee)
+		}
+	}
+	return nil
+}
+
+// Fee returns the taker fee rate used for a venue
+func (p *Params) Fee(venue string) float64 {
+	if fee, ok := p.FeeOverrides[venue]; ok {
+		return fee
+	}
+	return exchangeFees[venue]
+}
+
+// Tradable reports whether opportunities on a venue may be executed
+func (p *Params) Tradable(venue string) bool {
+	return !p.PausedVenues[venue] && !p.DisabledVenues[venue]
+}
+
+// effectivePrices applies fees and slippage to the buy and sell legs
+func (p *Params) effectivePrices(buyVenue string, ask float64, sellVenue string, bid float64) (effBuy, effSell float64) {
+	effBuy = ask * (1 + p.Fee(buyVenue) + exchangeSlippage[buyVenue])
+	effSell = bid * (1 - p.Fee(sellVenue) - exchangeSlippage[sellVenue])
+	return effBuy, effSell
+}
+
+// Venues returns the sorted keys of a venue set
+func Venues(set map[string]bool) []string {
+	venues := make([]string, 0, len(set))
+	for venue, on := range set {
+		if on {
+			venues = append(venues, venue)
+		}
+	}
+	sort.Strings(venues)
+	return venues
+}
+
+// Params returns the settings currently in effect
+func (as *ArbitrageStrategy) Params() Params {
+	return as.params.Load().clone()
+}
+
+// UpdateParams applies a change to the running strategy. The change is made
+// on a copy, validated and published in one step, so the strategy loop sees
+// either all of it or none of it. It returns the settings before and after.
+func (as *ArbitrageStrategy) UpdateParams(change func(p *Params) error) (before, after Params, err error) {
+	as.paramsLock.Lock()
+	defer as.paramsLock.Unlock()
+
+	current := as.params.Load()
+	next := current.clone()
+	if err := change(&next); err != nil {
+		return *current, *current, err
+	}
+	if err := next.validate(); err != nil {
+		return *current, *current, err
+	}
+
+	as.params.Store(&next)
+	as.pnlManager.SetTradeSize(next.TradeSize)
+
+	// Drop the last quote of disabled venues so it is not traded when they
+	// are enabled again
+	if len(next.DisabledVenues) > 0 {
+		as.quotesLock.Lock()
+		for venue := range next.DisabledVenues {
+			delete(as.quotes, venue)
+		}
+		as.quotesLock.Unlock()
+	}
+
+	return current.clone(), next.clone(), nil
+}
@@ -2,104 +2,188 @@ package strategy
 
 import (
 	"fmt"
-	"log"
 	"sync"
 	"time"
+
+	"hft-arbitrage-bot/clock"
 )
 
 // Trade represents an executed trade
 type Trade struct {
-	ID          string
-	Type        string // "BUY" or "SELL"
-	Exchange    string
-	Symbol      string
-	Price       float64
-	Quantity    float64
-	Timestamp   time.Time
-	OrderID     string
-	Status      string // "PENDING", "FILLED", "CANCELLED", "FAILED"
+	ID          string    `json:"id"`
+	Type        string    `json:"type"` // "BUY" or "SELL"
+	Exchange    string    `json:"exchange"`
+	Symbol      string    `json:"symbol"`
+	Price       float64   `json:"price"`
+	Quantity    float64   `json:"quantity"`
+	Timestamp   time.Time `json:"timestamp"`
+	OrderID     string    `json:"order_id"`
+	Status      string    `json:"status"`              // "PENDING", "FILLED", "CANCELLED", "FAILED"
+	RoundTripID string    `json:"round_trip_id"`       // shared by the buy and sell legs of one arbitrage
+	Fee         float64   `json:"fee"`                 // in quote currency, already included in Price
+	Liquidity   string    `json:"liquidity,omitempty"` // "taker" or "maker"
 }
 
 // Position represents a current position in a symbol
 type Position struct {
-	Symbol    string
-	Quantity  float64
-	AvgPrice  float64
-	PnL       float64
-	UnrealizedPnL float64
-	LastUpdate time.Time
+	Symbol        string    `json:"symbol"`
+	Quantity      float64   `json:"quantity"`
+	AvgPrice      float64   `json:"avg_price"`
+	PnL           float64   `json:"pnl"`
+	UnrealizedPnL float64   `json:"unrealized_pnl"`
+	LastUpdate    time.Time `json:"last_update"`
 }
 
 // PnLManager manages profit/loss tracking and trade execution
 type PnLManager struct {
-	trades     []Trade
-	positions  map[string]*Position
-	balance    float64
+	name           string // the strategy whose book this is, labels the gauges
+	trades         []Trade
+	roundTrips     []RoundTrip
+	positions      map[string]*Position
+	basis          map[string]*BasisPosition // open spot-perpetual positions by id
+	balance        float64
 	initialBalance float64
-	mutex      sync.RWMutex
-	
+	mutex          sync.RWMutex
+	clock          clock.Clock
+	execution      ExecutionModel
+	lastIDNanos    int64 // keeps ids unique when several arbitrages share a timestamp
+
 	// Configuration
-	baseBalance    float64
-	tradeSize      float64
-	maxPositions   int
-	
+	baseBalance  float64
+	tradeSize    float64
+	maxPositions int
+
 	// Statistics
-	totalTrades    int
-	winningTrades  int
-	losingTrades   int
-	totalPnL       float64
-	largestWin     float64
-	largestLoss    float64
+	totalTrades   int
+	winningTrades int
+	losingTrades  int
+	totalPnL      float64
+	largestWin    float64
+	largestLoss   float64
+	fundingPnL    float64
 }
 
 // NewPnLManager creates a new P&L manager
 func NewPnLManager(initialBalance, tradeSize float64) *PnLManager {
+	return newPnLManager(DefaultStrategyName, initialBalance, tradeSize)
+}
+
+func newPnLManager(name string, initialBalance, tradeSize float64) *PnLManager {
+	balanceGauge.WithLabelValues(name).Set(initialBalance)
 	return &PnLManager{
+		name:           name,
 		trades:         make([]Trade, 0),
+		roundTrips:     make([]RoundTrip, 0),
 		positions:      make(map[string]*Position),
+		basis:          make(map[string]*BasisPosition),
 		balance:        initialBalance,
 		initialBalance: initialBalance,
 		baseBalance:    initialBalance,
 		tradeSize:      tradeSize,
 		maxPositions:   5,
+		clock:          clock.Real,
+		execution:      InstantExecution{},
 	}
 }
 
-// ExecuteArbitrage executes an arbitrage opportunity
-func (pm *PnLManager) ExecuteArbitrage(opp ArbitrageOpportunity) error {
+// SetClock makes the ledger timestamp trades with c
+func (pm *PnLManager) SetClock(c clock.Clock) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.clock = c
+}
+
+// SetExecutionModel replaces the model that decides how arbitrages fill
+func (pm *PnLManager) SetExecutionModel(model ExecutionModel) {
 	pm.mutex.Lock()
 	defer pm.mutex.Unlock()
+	pm.execution = model
+}
+
+// nextIDNanos returns a unix nano timestamp for ids that is never reused
+func (pm *PnLManager) nextIDNanos(now time.Time) int64 {
+	nanos := now.UnixNano()
+	if nanos <= pm.lastIDNanos {
+		nanos = pm.lastIDNanos + 1
+	}
+	pm.lastIDNanos = nanos
+	return nanos
+}
+
+// SetTradeSize changes the quote currency spent per arbitrage
+func (pm *PnLManager) SetTradeSize(tradeSize float64) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.tradeSize = tradeSize
+}
+
+// TradeQuantity returns the quantity ExecuteArbitrage would trade for an opportunity
+func (pm *PnLManager) TradeQuantity(opp ArbitrageOpportunity) float64 {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+	return pm.tradeSize / opp.EffBuyPrice
+}
+
+// ExecuteArbitrage executes an arbitrage opportunity and returns the
+// resulting round trip
+func (pm *PnLManager) ExecuteArbitrage(opp ArbitrageOpportunity) (RoundTrip, error) {
+	pm.mutex.RLock()
+	balance, tradeSize, execution, now := pm.balance, pm.tradeSize, pm.execution, pm.clock.Now()
+	pm.mutex.RUnlock()
 
 	// Check if we have enough balance
-	if pm.balance < pm.tradeSize {
-		return fmt.Errorf("insufficient balance: %.2f < %.2f", pm.balance, pm.tradeSize)
+	if balance < tradeSize {
+		return RoundTrip{}, fmt.Errorf("insufficient balance: %.2f < %.2f", balance, tradeSize)
 	}
 
-	// Calculate quantity based on trade size
-	quantity := pm.tradeSize / opp.EffBuyPrice
+	// Calculate quantity based on trade size. The ledger is not locked while
+	// the legs fill, which may take a venue round trip.
+	quantity := tradeSize / opp.EffBuyPrice
+	fill, err := execution.Execute(opp, quantity, now)
+	if err != nil {
+		return RoundTrip{}, err
+	}
+
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	return pm.book(opp, fill), nil
+}
+
+// book records the legs of an executed opportunity and returns its round
+// trip; the caller must hold the lock
+func (pm *PnLManager) book(opp ArbitrageOpportunity, fill Execution) RoundTrip {
+	idNanos := pm.nextIDNanos(fill.Time)
+	roundTripID := fmt.Sprintf("arb_%d", idNanos)
+	buySymbol, sellSymbol := opp.LegSymbols()
 
 	// Execute buy trade
 	buyTrade := Trade{
-		ID:        fmt.Sprintf("buy_%d", time.Now().UnixNano()),
-		Type:      "BUY",
-		Exchange:  opp.BuyExchange,
-		Symbol:    opp.Symbol,
-		Price:     opp.EffBuyPrice,
-		Quantity:  quantity,
-		Timestamp: time.Now(),
-		Status:    "FILLED",
+		ID:          fmt.Sprintf("buy_%d", idNanos),
+		Type:        "BUY",
+		Exchange:    opp.BuyExchange,
+		Symbol:      buySymbol,
+		Price:       fill.BuyPrice,
+		Quantity:    fill.Quantity,
+		Timestamp:   fill.Time,
+		Status:      "FILLED",
+		RoundTripID: roundTripID,
+		Fee:         fill.BuyFee,
+		Liquidity:   fill.BuyLiquidity,
 	}
 
 	// Execute sell trade
 	sellTrade := Trade{
-		ID:        fmt.Sprintf("sell_%d", time.Now().UnixNano()),
-		Type:      "SELL",
-		Exchange:  opp.SellExchange,
-		Symbol:    opp.Symbol,
-		Price:     opp.EffSellPrice,
-		Quantity:  quantity,
-		Timestamp: time.Now(),
-		Status:    "FILLED",
+		ID:          fmt.Sprintf("sell_%d", idNanos),
+		Type:        "SELL",
+		Exchange:    opp.SellExchange,
+		Symbol:      sellSymbol,
+		Price:       fill.SellPrice,
+		Quantity:    fill.Quantity,
+		Timestamp:   fill.Time,
+		Status:      "FILLED",
+		RoundTripID: roundTripID,
+		Fee:         fill.SellFee,
+		Liquidity:   fill.SellLiquidity,
 	}
 
 	// Update balance and positions
@@ -107,7 +191,7 @@ func (pm *PnLManager) ExecuteArbitrage(opp ArbitrageOpportunity) error {
 	pm.balance += sellTrade.Price * sellTrade.Quantity
 
 	// Calculate P&L for this arbitrage
-	pnl := (sellTrade.Price - buyTrade.Price) * quantity
+	pnl := (sellTrade.Price - buyTrade.Price) * fill.Quantity
 	pm.totalPnL += pnl
 
 	// Update statistics
@@ -124,15 +208,51 @@ func (pm *PnLManager) ExecuteArbitrage(opp ArbitrageOpportunity) error {
 		}
 	}
 
-	// Add trades to history
-	pm.trades = append(pm.trades, buyTrade, sellTrade)
+	// Add trades to history; a triangle books its legs instead
+	if len(fill.Legs) > 0 {
+		legs := legTrades(opp, fill, idNanos, roundTripID)
+		pm.totalTrades += len(legs) - 2
+		pm.trades = append(pm.trades, legs...)
+	} else {
+		pm.trades = append(pm.trades, buyTrade, sellTrade)
+	}
+	roundTrip := newRoundTrip(roundTripID, opp, fill, pnl)
+	pm.roundTrips = append(pm.roundTrips, roundTrip)
+	pm.updateGauges()
 
 	// Log the execution with fee/slippage info
-	log.Printf("🔄 EXECUTED ARBITRAGE: Buy %.4f %s on %s at $%.2f (fee %.4f, slippage %.4f), Sell on %s at $%.2f (fee %.4f, slippage %.4f)",
-		quantity, opp.Symbol, opp.BuyExchange, opp.BuyPrice, opp.BuyFee, opp.BuySlippage, opp.SellExchange, opp.SellPrice, opp.SellFee, opp.SellSlippage)
-	log.Printf("   Effective Buy: $%.4f, Effective Sell: $%.4f", opp.EffBuyPrice, opp.EffSellPrice)
-	log.Printf("💰 P&L: $%.2f (%.2f%%)", pnl, (pnl/pm.tradeSize)*100)
+	logger.Info("executed arbitrage",
+		"opp_id", opp.ID,
+		"round_trip_id", roundTripID,
+		"symbol", opp.Symbol,
+		"quantity", fill.Quantity,
+		"buy_venue", opp.BuyExchange,
+		"buy_price", opp.BuyPrice,
+		"buy_fee", opp.BuyFee,
+		"buy_slippage", opp.BuySlippage,
+		"eff_buy_price", opp.EffBuyPrice,
+		"sell_venue", opp.SellExchange,
+		"sell_price", opp.SellPrice,
+		"sell_fee", opp.SellFee,
+		"sell_slippage", opp.SellSlippage,
+		"eff_sell_price", opp.EffSellPrice,
+		"pnl", pnl,
+		"pnl_pct", (pnl/pm.tradeSize)*100)
 
+	return roundTrip
+}
+
+// CheckWritable verifies that the ledger can take a write lock within the
+// timeout, i.e. that trade booking is not wedged behind a stuck holder
+func (pm *PnLManager) CheckWritable(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for !pm.mutex.TryLock() {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("ledger lock not acquired within %s", timeout)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	pm.mutex.Unlock()
 	return nil
 }
 
@@ -147,18 +267,31 @@ func (pm *PnLManager) GetCurrentPnL() PnLStatus {
 	}
 
 	return PnLStatus{
-		CurrentBalance:    pm.balance,
-		InitialBalance:    pm.initialBalance,
-		TotalPnL:          pm.totalPnL,
-		TotalPnLPercent:   ((pm.balance - pm.initialBalance) / pm.initialBalance) * 100,
-		TotalTrades:       pm.totalTrades,
-		WinningTrades:     pm.winningTrades,
-		LosingTrades:      pm.losingTrades,
-		WinRate:           winRate,
-		LargestWin:        pm.largestWin,
-		LargestLoss:       pm.largestLoss,
-		AveragePnL:        pm.getAveragePnL(),
-		LastUpdate:        time.Now(),
+		CurrentBalance:  pm.balance,
+		InitialBalance:  pm.initialBalance,
+		TotalPnL:        pm.totalPnL,
+		TotalPnLPercent: ((pm.balance - pm.initialBalance) / pm.initialBalance) * 100,
+		TotalTrades:     pm.totalTrades,
+		WinningTrades:   pm.winningTrades,
+		LosingTrades:    pm.losingTrades,
+		WinRate:         winRate,
+		LargestWin:      pm.largestWin,
+		LargestLoss:     pm.largestLoss,
+		AveragePnL:      pm.getAveragePnL(),
+		FundingPnL:      pm.fundingPnL,
+		CarryPnL:        pm.carry(),
+		BasisPositions:  len(pm.basis),
+		LastUpdate:      pm.clock.Now(),
+	}
+}
+
+// updateGauges publishes the P&L statistics as metrics; the caller must hold the lock
+func (pm *PnLManager) updateGauges() {
+	balanceGauge.WithLabelValues(pm.name).Set(pm.balance)
+	pnlGauge.WithLabelValues(pm.name).Set(pm.totalPnL)
+	tradesGauge.WithLabelValues(pm.name).Set(float64(pm.totalTrades))
+	if arbs := pm.winningTrades + pm.losingTrades; arbs > 0 {
+		winRateGauge.WithLabelValues(pm.name).Set(float64(pm.winningTrades) / float64(arbs) * 100)
 	}
 }
 
@@ -191,41 +324,61 @@ func (pm *PnLManager) GetTradeHistory(limit int) []Trade {
 
 // PnLStatus represents the current P&L status
 type PnLStatus struct {
-	CurrentBalance    float64
-	InitialBalance    float64
-	TotalPnL          float64
-	TotalPnLPercent   float64
-	TotalTrades       int
-	WinningTrades     int
-	LosingTrades      int
-	WinRate           float64
-	LargestWin        float64
-	LargestLoss       float64
-	AveragePnL        float64
-	LastUpdate        time.Time
-}
-
-// PrintPnLStatus prints the current P&L status in a formatted way
+	CurrentBalance  float64   `json:"current_balance"`
+	InitialBalance  float64   `json:"initial_balance"`
+	TotalPnL        float64   `json:"total_pnl"`
+	TotalPnLPercent float64   `json:"total_pnl_percent"`
+	TotalTrades     int       `json:"total_trades"`
+	WinningTrades   int       `json:"winning_trades"`
+	LosingTrades    int       `json:"losing_trades"`
+	WinRate         float64   `json:"win_rate"`
+	LargestWin      float64   `json:"largest_win"`
+	LargestLoss     float64   `json:"largest_loss"`
+	AveragePnL      float64   `json:"average_pnl"`
+	FundingPnL      float64   `json:"funding_pnl"`     // settled on basis positions, included in TotalPnL
+	CarryPnL        float64   `json:"carry_pnl"`       // unrealized on open basis positions, not in TotalPnL
+	BasisPositions  int       `json:"basis_positions"` // open basis positions
+	LastUpdate      time.Time `json:"last_update"`
+}
+
+// PrintPnLStatus prints the current P&L status in a formatted way to stdout
 func (pm *PnLManager) PrintPnLStatus() {
 	status := pm.GetCurrentPnL()
-	
-	log.Println("=== PROFIT/LOSS STATUS ===")
-	log.Printf("💰 Current Balance: $%.2f", status.CurrentBalance)
-	log.Printf("📈 Total P&L: $%.2f (%.2f%%)", status.TotalPnL, status.TotalPnLPercent)
-	log.Printf("📊 Total Trades: %d", status.TotalTrades)
-	log.Printf("✅ Winning Trades: %d", status.WinningTrades)
-	log.Printf("❌ Losing Trades: %d", status.LosingTrades)
-	log.Printf("🎯 Win Rate: %.1f%%", status.WinRate)
-	log.Printf("📈 Largest Win: $%.2f", status.LargestWin)
-	log.Printf("📉 Largest Loss: $%.2f", status.LargestLoss)
-	log.Printf("📊 Average P&L per Trade: $%.2f", status.AveragePnL)
-	log.Printf("🕐 Last Update: %s", status.LastUpdate.Format("15:04:05"))
-	log.Println("==========================")
+
+	fmt.Println("=== PROFIT/LOSS STATUS ===")
+	fmt.Printf("💰 Current Balance: $%.2f\n", status.CurrentBalance)
+	fmt.Printf("📈 Total P&L: $%.2f (%.2f%%)\n", status.TotalPnL, status.TotalPnLPercent)
+	fmt.Printf("📊 Total Trades: %d\n", status.TotalTrades)
+	fmt.Printf("✅ Winning Trades: %d\n", status.WinningTrades)
+	fmt.Printf("❌ Losing Trades: %d\n", status.LosingTrades)
+	fmt.Printf("🎯 Win Rate: %.1f%%\n", status.WinRate)
+	fmt.Printf("📈 Largest Win: $%.2f\n", status.LargestWin)
+	fmt.Printf("📉 Largest Loss: $%.2f\n", status.LargestLoss)
+	fmt.Printf("📊 Average P&L per Trade: $%.2f\n", status.AveragePnL)
+	fmt.Printf("🕐 Last Update: %s\n", status.LastUpdate.Format("15:04:05"))
+	fmt.Println("==========================")
+}
+
+// LogPnLStatus writes the current P&L status as one structured log record
+func (pm *PnLManager) LogPnLStatus() {
+	status := pm.GetCurrentPnL()
+	logger.Info("pnl status",
+		"strategy", pm.name,
+		"balance", status.CurrentBalance,
+		"pnl", status.TotalPnL,
+		"pnl_pct", status.TotalPnLPercent,
+		"trades", status.TotalTrades,
+		"wins", status.WinningTrades,
+		"losses", status.LosingTrades,
+		"win_rate", status.WinRate,
+		"largest_win", status.LargestWin,
+		"largest_loss", status.LargestLoss,
+		"avg_pnl", status.AveragePnL)
 }
 
 // GetPnLSummary returns a concise P&L summary
 func (pm *PnLManager) GetPnLSummary() string {
 	status := pm.GetCurrentPnL()
-	return fmt.Sprintf("P&L: $%.2f (%.2f%%) | Trades: %d | Win Rate: %.1f%%", 
+	return fmt.Sprintf("P&L: $%.2f (%.2f%%) | Trades: %d | Win Rate: %.1f%%",
 		status.TotalPnL, status.TotalPnLPercent, status.TotalTrades, status.WinRate)
-} 
\ No newline at end of file
+}
@@ -0,0 +1,152 @@
+package strategy
+
+import (
+	"sync"
+	"time"
+
+	"hft-arbitrage-bot/clock"
+)
+
+// Strategy is a trading strategy driven by a Runtime. The runtime calls it
+// from one goroutine, one callback at a time.
+type Strategy interface {
+	Name() string
+	OnQuote(quote Quote)   // a top of book update
+	OnBook(quote Quote)    // an update carrying depth in quote.Book
+	OnTrade(quote Quote)   // prints of a trade stream in quote.Trades, without prices
+	OnFill(fill Fill)      // one of the strategy's executions filled
+	OnTimer(now time.Time) // every EvaluationInterval
+}
+
+// Fill is an execution made through a Runtime executor, delivered to the
+// strategy that made it
+type Fill struct {
+	Strategy    string
+	Opportunity ArbitrageOpportunity
+	Requested   float64 // quantity asked for
+	Execution   Execution
+}
+
+// Runtime fans the quotes of one set of exchange connections out to several
+// strategies, each with its own P&L book and risk budget, and drives their
+// timers. Fills of executions made through Executor are handed back to the
+// strategy that made them once the callback that executed them returns.
+type Runtime struct {
+	strategies []Strategy
+	byName     map[string]Strategy
+	clock      clock.Clock
+
+	mu    sync.Mutex
+	fills []Fill // not yet delivered
+}
+
+// NewRuntime returns a runtime for strategies, which must have distinct names
+func NewRuntime(strategies ...Strategy) *Runtime {
+	r := &Runtime{byName: make(map[string]Strategy), clock: clock.Real}
+	for _, s := range strategies {
+		r.strategies = append(r.strategies, s)
+		r.byName[s.Name()] = s
+	}
+	return r
+}
+
+// SetClock makes the runtime's timers read the time from c. It must be
+// called before Run.
+func (r *Runtime) SetClock(c clock.Clock) {
+	r.clock = c
+}
+
+// Strategies returns the strategies in the order they are called
+func (r *Runtime) Strategies() []Strategy {
+	return append([]Strategy(nil), r.strategies...)
+}
+
+// Executor wraps the execution model a strategy fills with, so that its
+// fills are delivered to the strategy's OnFill
+func (r *Runtime) Executor(name string, model ExecutionModel) ExecutionModel {
+	return &routedExecution{runtime: r, strategy: name, model: model}
+}
+
+// Dispatch hands a quote to every strategy: OnTrade when it only carries
+// trades, OnBook when it carries depth, OnQuote otherwise
+func (r *Runtime) Dispatch(quote Quote) {
+	for _, s := range r.strategies {
+		switch {
+		case quote.TradesOnly():
+			s.OnTrade(quote)
+		case quote.Book != nil:
+			s.OnBook(quote)
+		default:
+			s.OnQuote(quote)
+		}
+		r.deliverFills()
+	}
+}
+
+// Tick calls every strategy's timer
+func (r *Runtime) Tick(now time.Time) {
+	for _, s := range r.strategies {
+		s.OnTimer(now)
+		r.deliverFills()
+	}
+}
+
+// Run dispatches quotes and ticks the strategies every EvaluationInterval
+// until quoteChan is closed
+func (r *Runtime) Run(quoteChan <-chan Quote) {
+	names := make([]string, len(r.strategies))
+	for i, s := range r.strategies {
+		names[i] = s.Name()
+	}
+	logger.Info("starting strategy runtime", "strategies", names)
+
+	ticker := r.clock.NewTicker(EvaluationInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case quote, ok := <-quoteChan:
+			if !ok {
+				logger.Info("quote channel closed, stopping strategy runtime")
+				return
+			}
+			r.Dispatch(quote)
+
+		case now := <-ticker.C():
+			r.Tick(now)
+		}
+	}
+}
+
+// deliverFills hands the fills queued so far to their strategies
+func (r *Runtime) deliverFills() {
+	r.mu.Lock()
+	fills := r.fills
+	r.fills = nil
+	r.mu.Unlock()
+
+	for _, fill := range fills {
+		strategyFills.WithLabelValues(fill.Strategy).Inc()
+		if s, ok := r.byName[fill.Strategy]; ok {
+			s.OnFill(fill)
+		}
+	}
+}
+
+// routedExecution queues the fills of a strategy's executions for delivery
+type routedExecution struct {
+	runtime  *Runtime
+	strategy string
+	model    ExecutionModel
+}
+
+// Execute implements ExecutionModel
+func (e *routedExecution) Execute(opp ArbitrageOpportunity, quantity float64, now time.Time) (Execution, error) {
+	execution, err := e.model.Execute(opp, quantity, now)
+	if err != nil {
+		return execution, err
+	}
+	e.runtime.mu.Lock()
+	e.runtime.fills = append(e.runtime.fills, Fill{Strategy: e.strategy, Opportunity: opp, Requested: quantity, Execution: execution})
+	e.runtime.mu.Unlock()
+	return execution, nil
+}
@@ -0,0 +1,396 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"hft-arbitrage-bot/logging"
+)
+
+// signalLogSampler keeps "filtered by signals" logging to one record per
+// venue pair and type every few seconds
+var signalLogSampler = logging.NewSampler(5*time.Second, 1)
+
+// MarketTrade is one print of a venue's trade stream
+type MarketTrade struct {
+	Price float64 `json:"price"`
+	Size  float64 `json:"size"` // base asset
+	Side  string  `json:"side"` // the aggressor: BUY lifted the ask, SELL hit the bid
+}
+
+// TradesOnly reports whether a quote is a trade stream message rather than
+// a book update
+func (q Quote) TradesOnly() bool {
+	return len(q.Trades) > 0 && q.Bid == 0 && q.Ask == 0
+}
+
+// SignalConfig configures how microstructure signals select opportunities.
+// A leg is hurt by a book about to move its way: a sell into a book about to
+// tick up, a buy from one about to tick down. Each signal is read as pressure
+// against the leg, and the filters reject an opportunity when any leg's
+// pressure exceeds their limit; the weights raise the edge it needs instead.
+type SignalConfig struct {
+	Horizon          time.Duration // time constant of the mid average and the trade flow
+	MaxImbalance     float64       // top of book imbalance against a leg, 0 to 1; 0 disables
+	MaxTradeFlow     float64       // trade flow against a leg, 0 to 1; 0 disables
+	MaxDriftPercent  float64       // mid drift against a leg in percent; 0 disables
+	MicropriceWeight float64       // share of the microprice's move against the legs added to the required edge
+	DriftWeight      float64       // share of the drift against the legs added to the required edge
+}
+
+// DefaultSignalConfig prices in the microprice and leaves the filters off
+func DefaultSignalConfig() SignalConfig {
+	return SignalConfig{
+		Horizon:          5 * time.Second,
+		MicropriceWeight: 1,
+	}
+}
+
+// SignalConfigFromEnv builds the config from environment variables on top of
+// DefaultSignalConfig:
+//
+//	HFT_SIGNAL_HORIZON            averaging horizon of drift and trade flow, e.g. 5s
+//	HFT_SIGNAL_MAX_IMBALANCE      imbalance against a leg that rejects an opportunity
+//	HFT_SIGNAL_MAX_FLOW           trade flow against a leg that rejects an opportunity
+//	HFT_SIGNAL_MAX_DRIFT          drift in percent against a leg that rejects an opportunity
+//	HFT_SIGNAL_MICROPRICE_WEIGHT  weight of the microprice in the required edge
+//	HFT_SIGNAL_DRIFT_WEIGHT       weight of the drift in the required edge
+func SignalConfigFromEnv() (SignalConfig, error) {
+	config := DefaultSignalConfig()
+
+	if value := os.Getenv("HFT_SIGNAL_HORIZON"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return config, fmt.Errorf("invalid HFT_SIGNAL_HORIZON %q", value)
+		}
+		config.Horizon = d
+	}
+	for name, field := range map[string]*float64{
+		"HFT_SIGNAL_MAX_IMBALANCE":     &config.MaxImbalance,
+		"HFT_SIGNAL_MAX_FLOW":          &config.MaxTradeFlow,
+		"HFT_SIGNAL_MAX_DRIFT":         &config.MaxDriftPercent,
+		"HFT_SIGNAL_MICROPRICE_WEIGHT": &config.MicropriceWeight,
+		"HFT_SIGNAL_DRIFT_WEIGHT":      &config.DriftWeight,
+	} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return config, fmt.Errorf("invalid %s %q", name, value)
+		}
+		*field = v
+	}
+	return config, config.Validate()
+}
+
+// Validate checks the config for values the signals cannot work with
+func (c SignalConfig) Validate() error {
+	if c.Horizon <= 0 {
+		return fmt.Errorf("signal horizon must be positive, got %s", c.Horizon)
+	}
+	if c.MaxImbalance < 0 || c.MaxImbalance > 1 {
+		return fmt.Errorf("max imbalance must be in [0, 1], got %.4f", c.MaxImbalance)
+	}
+	if c.MaxTradeFlow < 0 || c.MaxTradeFlow > 1 {
+		return fmt.Errorf("max trade flow must be in [0, 1], got %.4f", c.MaxTradeFlow)
+	}
+	if c.MaxDriftPercent < 0 {
+		return fmt.Errorf("max drift must not be negative, got %.4f", c.MaxDriftPercent)
+	}
+	if c.MicropriceWeight < 0 || c.DriftWeight < 0 {
+		return fmt.Errorf("signal weights must not be negative, got %.4f and %.4f", c.MicropriceWeight, c.DriftWeight)
+	}
+	return nil
+}
+
+// Enabled reports whether any filter or weight is set
+func (c SignalConfig) Enabled() bool {
+	return c.MaxImbalance > 0 || c.MaxTradeFlow > 0 || c.MaxDriftPercent > 0 || c.MicropriceWeight > 0 || c.DriftWeight > 0
+}
+
+// SetSignals sets how the microstructure signals select opportunities. It
+// must be called before the strategy receives quotes.
+func (as *ArbitrageStrategy) SetSignals(config SignalConfig) {
+	as.signal = config
+}
+
+// MicrostructureSignals are the short-horizon signals of one book
+type MicrostructureSignals struct {
+	Venue             string    `json:"venue"`
+	Symbol            string    `json:"symbol"`
+	Mid               float64   `json:"mid"`
+	Microprice        float64   `json:"microprice"`     // mid leaning toward the thinner side, where the price is likely to go
+	MicropricePercent float64   `json:"microprice_pct"` // microprice above (+) or below (-) the mid, in percent
+	Imbalance         float64   `json:"imbalance"`      // (bid size - ask size) / (bid size + ask size) at the top, -1 to 1
+	DriftPercent      float64   `json:"drift_pct"`      // mid above (+) or below (-) its average over the horizon, in percent
+	TradeFlow         float64   `json:"trade_flow"`     // (bought - sold) / (bought + sold) by aggressors over the horizon, -1 to 1
+	BuyVolume         float64   `json:"buy_volume"`     // aggressor volume decayed over the horizon
+	SellVolume        float64   `json:"sell_volume"`
+	Trades            int       `json:"trades"` // prints received
+	Updated           time.Time `json:"updated,omitzero"`
+	LastTrade         time.Time `json:"last_trade,omitzero"`
+}
+
+// bookSignals holds a book's signals, updated with each quote and trade
+type bookSignals struct {
+	mid        float64
+	midAverage float64 // mid averaged exponentially over the horizon
+	microprice float64
+	imbalance  float64
+	updated    time.Time // last book update
+	buyVolume  float64   // aggressor volume decayed over the horizon
+	sellVolume float64
+	traded     time.Time // last print
+	trades     int
+}
+
+// signalWeight is the weight an exponential average over horizon gives to
+// what happened over elapsed
+func signalWeight(elapsed, horizon time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return 1 - math.Exp(-elapsed.Seconds()/horizon.Seconds())
+}
+
+// bookSignalsFor returns a book's signals, creating them; the caller must
+// hold the quotes write lock
+func (as *ArbitrageStrategy) bookSignalsFor(key string) *bookSignals {
+	s, ok := as.signals[key]
+	if !ok {
+		s = &bookSignals{}
+		as.signals[key] = s
+	}
+	return s
+}
+
+// recordSignals updates a book's signals with a new quote; the caller must
+// hold the quotes write lock
+func (as *ArbitrageStrategy) recordSignals(quote Quote) {
+	if quote.Bid <= 0 || quote.Ask <= 0 {
+		return
+	}
+	key := bookKey(quote.Exchange, quote.Symbol)
+	s := as.bookSignalsFor(key)
+	mid := (quote.Bid + quote.Ask) / 2
+	if s.mid == 0 {
+		s.midAverage = mid
+	} else {
+		// The previous mid held since the last update
+		s.midAverage += signalWeight(quote.Timestamp.Sub(s.updated), as.signal.Horizon) * (s.mid - s.midAverage)
+	}
+	s.mid, s.updated = mid, quote.Timestamp
+	s.imbalance, s.microprice = 0, mid
+	if quote.BidSize > 0 && quote.AskSize > 0 {
+		total := quote.BidSize + quote.AskSize
+		s.imbalance = (quote.BidSize - quote.AskSize) / total
+		s.microprice = (quote.Ask*quote.BidSize + quote.Bid*quote.AskSize) / total
+	}
+	microstructure.WithLabelValues(key, "imbalance").Set(s.imbalance)
+	microstructure.WithLabelValues(key, "microprice_pct").Set((s.microprice - mid) / mid * 100)
+	microstructure.WithLabelValues(key, "drift_pct").Set((mid - s.midAverage) / s.midAverage * 100)
+}
+
+// recordTrades adds the prints of a trade stream message to its book's
+// trade flow
+func (as *ArbitrageStrategy) recordTrades(quote Quote) {
+	key := bookKey(quote.Exchange, quote.Symbol)
+	as.quotesLock.Lock()
+	defer as.quotesLock.Unlock()
+
+	s := as.bookSignalsFor(key)
+	if !s.traded.IsZero() {
+		keep := 1 - signalWeight(quote.Timestamp.Sub(s.traded), as.signal.Horizon)
+		s.buyVolume *= keep
+		s.sellVolume *= keep
+	}
+	for _, trade := range quote.Trades {
+		switch trade.Side {
+		case "BUY":
+			s.buyVolume += trade.Size
+		case "SELL":
+			s.sellVolume += trade.Size
+		}
+	}
+	s.trades += len(quote.Trades)
+	s.traded = quote.Timestamp
+	if total := s.buyVolume + s.sellVolume; total > 0 {
+		microstructure.WithLabelValues(key, "trade_flow").Set((s.buyVolume - s.sellVolume) / total)
+	}
+}
+
+// snapshot returns the signals as of now. Trade flow only counts while the
+// last print is within the horizon, since decay alone leaves the ratio of an
+// idle stream unchanged.
+func (s *bookSignals) snapshot(now time.Time, horizon time.Duration) MicrostructureSignals {
+	signals := MicrostructureSignals{
+		Mid:        s.mid,
+		Microprice: s.microprice,
+		Imbalance:  s.imbalance,
+		Trades:     s.trades,
+		Updated:    s.updated,
+		LastTrade:  s.traded,
+	}
+	if s.mid > 0 {
+		average := s.midAverage + signalWeight(now.Sub(s.updated), horizon)*(s.mid-s.midAverage)
+		signals.MicropricePercent = (s.microprice - s.mid) / s.mid * 100
+		signals.DriftPercent = (s.mid - average) / average * 100
+	}
+	if !s.traded.IsZero() {
+		keep := 1 - signalWeight(now.Sub(s.traded), horizon)
+		signals.BuyVolume, signals.SellVolume = s.buyVolume*keep, s.sellVolume*keep
+		if total := s.buyVolume + s.sellVolume; total > 0 && now.Sub(s.traded) <= horizon {
+			signals.TradeFlow = (s.buyVolume - s.sellVolume) / total
+		}
+	}
+	return signals
+}
+
+// Signals returns the microstructure signals of every book, sorted by venue
+// and symbol
+func (as *ArbitrageStrategy) Signals() []MicrostructureSignals {
+	now := as.clock.Now()
+	as.quotesLock.RLock()
+	signals := make([]MicrostructureSignals, 0, len(as.signals))
+	for key, s := range as.signals {
+		snapshot := s.snapshot(now, as.signal.Horizon)
+		snapshot.Venue, snapshot.Symbol, _ = strings.Cut(key, "/")
+		signals = append(signals, snapshot)
+	}
+	as.quotesLock.RUnlock()
+
+	sort.Slice(signals, func(i, j int) bool {
+		if signals[i].Venue != signals[j].Venue {
+			return signals[i].Venue < signals[j].Venue
+		}
+		return signals[i].Symbol < signals[j].Symbol
+	})
+	return signals
+}
+
+// SignalCheck is what the microstructure signals made of an opportunity.
+// Pressures are against the legs: positive means the book is expected to
+// move the leg's way, up for a sell and down for a buy.
+type SignalCheck struct {
+	Imbalance         float64 `json:"imbalance"`        // largest imbalance against a leg
+	TradeFlow         float64 `json:"trade_flow"`       // largest trade flow against a leg
+	DriftPercent      float64 `json:"drift_pct"`        // largest drift against a leg
+	MicropricePercent float64 `json:"microprice_pct"`   // microprice moves against the legs, summed
+	AdjustPercent     float64 `json:"adjust_pct"`       // added to the required edge
+	EdgePercent       float64 `json:"edge_pct"`         // expected value when the model selected it, otherwise the net edge
+	RequiredPercent   float64 `json:"required_pct"`     // minimum EV or spread plus the adjustment
+	Filter            string  `json:"filter,omitempty"` // the filter that rejected it: imbalance, trade_flow, drift or edge
+}
+
+// checkSignals reads an opportunity's legs against the signals of their
+// books at now; it reports false when a leg has no book. The caller must
+// hold the quotes read lock.
+func (as *ArbitrageStrategy) checkSignals(opp ArbitrageOpportunity, now time.Time) (SignalCheck, bool) {
+	legs, ok := as.evLegs(opp, 1)
+	if !ok || len(legs) == 0 {
+		return SignalCheck{}, false
+	}
+
+	var check SignalCheck
+	drift := 0.0
+	for i, leg := range legs {
+		var signals MicrostructureSignals
+		if s, ok := as.signals[bookKey(leg.venue, leg.quote.Symbol)]; ok {
+			signals = s.snapshot(now, as.signal.Horizon)
+		}
+		against := 1.0
+		if leg.side == "BUY" {
+			against = -1
+		}
+		imbalance, flow, move := against*signals.Imbalance, against*signals.TradeFlow, against*signals.DriftPercent
+		if i == 0 {
+			check.Imbalance, check.TradeFlow, check.DriftPercent = imbalance, flow, move
+		}
+		check.Imbalance = math.Max(check.Imbalance, imbalance)
+		check.TradeFlow = math.Max(check.TradeFlow, flow)
+		check.DriftPercent = math.Max(check.DriftPercent, move)
+		check.MicropricePercent += against * signals.MicropricePercent
+		drift += move
+	}
+
+	check.EdgePercent, check.RequiredPercent = netEdge(opp), as.params.Load().MinSpreadPercent
+	if opp.EV != nil {
+		check.EdgePercent, check.RequiredPercent = opp.EV.EVPercent, as.ev.MinEVPercent
+	}
+	check.AdjustPercent = math.Max(0, as.signal.MicropriceWeight*check.MicropricePercent+as.signal.DriftWeight*drift)
+	check.RequiredPercent += check.AdjustPercent
+
+	switch {
+	case as.signal.MaxImbalance > 0 && check.Imbalance > as.signal.MaxImbalance:
+		check.Filter = "imbalance"
+	case as.signal.MaxTradeFlow > 0 && check.TradeFlow > as.signal.MaxTradeFlow:
+		check.Filter = "trade_flow"
+	case as.signal.MaxDriftPercent > 0 && check.DriftPercent > as.signal.MaxDriftPercent:
+		check.Filter = "drift"
+	case check.AdjustPercent > 0 && check.EdgePercent <= check.RequiredPercent:
+		check.Filter = "edge"
+	}
+	return check, true
+}
+
+// selectBySignals attaches the signal check to every opportunity and keeps
+// those no filter rejects and whose edge covers the adjustment; it returns
+// the others separately. Basis trades are held, not crossed, and are left
+// alone.
+func (as *ArbitrageStrategy) selectBySignals(opportunities []ArbitrageOpportunity) (selected, filtered []ArbitrageOpportunity) {
+	if !as.signal.Enabled() || len(opportunities) == 0 {
+		return opportunities, nil
+	}
+	now := as.clock.Now()
+	selected = opportunities[:0]
+
+	as.quotesLock.RLock()
+	for _, opp := range opportunities {
+		if opp.Type == OpportunityBasis {
+			selected = append(selected, opp)
+			continue
+		}
+		check, ok := as.checkSignals(opp, now)
+		if !ok {
+			selected = append(selected, opp)
+			continue
+		}
+		opp.Signals = &check
+		if check.Filter == "" {
+			selected = append(selected, opp)
+			continue
+		}
+		opportunitiesTotal.WithLabelValues(reasonSignal).Inc()
+		filtered = append(filtered, opp)
+	}
+	as.quotesLock.RUnlock()
+
+	// Logged after the lock is released and sampled, like negative EV
+	for _, opp := range filtered {
+		ok, suppressed := signalLogSampler.Allow(opp.Type + ":" + opp.BuyExchange + "->" + opp.SellExchange)
+		if !ok {
+			continue
+		}
+		logger.Info("opportunity filtered by signals",
+			"type", opp.Type,
+			"buy_venue", opp.BuyExchange,
+			"sell_venue", opp.SellExchange,
+			"symbol", opp.Symbol,
+			"filter", opp.Signals.Filter,
+			"imbalance", opp.Signals.Imbalance,
+			"trade_flow", opp.Signals.TradeFlow,
+			"drift_pct", opp.Signals.DriftPercent,
+			"microprice_pct", opp.Signals.MicropricePercent,
+			"edge_pct", opp.Signals.EdgePercent,
+			"required_pct", opp.Signals.RequiredPercent,
+			"suppressed", suppressed)
+	}
+	return selected, filtered
+}
@@ -0,0 +1,223 @@
+package strategy
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSignalConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(c SignalConfig) bool
+		wantErr bool
+	}{
+		{name: "defaults", check: func(c SignalConfig) bool { return c.Enabled() && c.MicropriceWeight == 1 && c.MaxImbalance == 0 }},
+		{
+			name: "filters",
+			env:  map[string]string{"HFT_SIGNAL_HORIZON": "2s", "HFT_SIGNAL_MAX_IMBALANCE": "0.6", "HFT_SIGNAL_MAX_FLOW": "0.8", "HFT_SIGNAL_MICROPRICE_WEIGHT": "0"},
+			check: func(c SignalConfig) bool {
+				return c.Horizon == 2*time.Second && c.MaxImbalance == 0.6 && c.MaxTradeFlow == 0.8 && c.MicropriceWeight == 0
+			},
+		},
+		{name: "all off", env: map[string]string{"HFT_SIGNAL_MICROPRICE_WEIGHT": "0"}, check: func(c SignalConfig) bool { return !c.Enabled() }},
+		{name: "imbalance above one", env: map[string]string{"HFT_SIGNAL_MAX_IMBALANCE": "1.5"}, wantErr: true},
+		{name: "negative weight", env: map[string]string{"HFT_SIGNAL_DRIFT_WEIGHT": "-1"}, wantErr: true},
+		{name: "zero horizon", env: map[string]string{"HFT_SIGNAL_HORIZON": "0s"}, wantErr: true},
+		{name: "malformed flow", env: map[string]string{"HFT_SIGNAL_MAX_FLOW": "high"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"HFT_SIGNAL_HORIZON", "HFT_SIGNAL_MAX_IMBALANCE", "HFT_SIGNAL_MAX_FLOW", "HFT_SIGNAL_MAX_DRIFT", "HFT_SIGNAL_MICROPRICE_WEIGHT", "HFT_SIGNAL_DRIFT_WEIGHT"} {
+				t.Setenv(name, tt.env[name])
+			}
+			config, err := SignalConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SignalConfigFromEnv() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && !tt.check(config) {
+				t.Errorf("SignalConfigFromEnv() = %+v", config)
+			}
+		})
+	}
+}
+
+func TestSignals(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	trades := func(at time.Time, sides ...string) Quote {
+		q := Quote{Exchange: "binance", Symbol: "DOGEUSDT", Timestamp: at}
+		for _, side := range sides {
+			q.Trades = append(q.Trades, MarketTrade{Price: 0.1, Size: 100, Side: side})
+		}
+		return q
+	}
+	tests := []struct {
+		name          string
+		quotes        []Quote
+		at            time.Duration // after now
+		wantImbalance float64
+		wantMicro     float64 // sign of MicropricePercent
+		wantDrift     float64 // sign of DriftPercent
+		wantFlow      float64
+		wantTrades    int
+	}{
+		{
+			name:   "balanced book",
+			quotes: []Quote{{Exchange: "binance", Symbol: "DOGEUSDT", Bid: 0.0999, Ask: 0.1000, BidSize: 500, AskSize: 500, Timestamp: now}},
+		},
+		{
+			name:          "bid-heavy book leans up",
+			quotes:        []Quote{{Exchange: "binance", Symbol: "DOGEUSDT", Bid: 0.0999, Ask: 0.1000, BidSize: 900, AskSize: 100, Timestamp: now}},
+			wantImbalance: 0.8,
+			wantMicro:     1,
+		},
+		{
+			name: "mid above its average",
+			quotes: []Quote{
+				{Exchange: "binance", Symbol: "DOGEUSDT", Bid: 0.0999, Ask: 0.1000, Timestamp: now.Add(-time.Second)},
+				{Exchange: "binance", Symbol: "DOGEUSDT", Bid: 0.1001, Ask: 0.1002, Timestamp: now},
+			},
+			wantDrift: 1,
+		},
+		{
+			name:       "aggressive buying",
+			quotes:     []Quote{trades(now, "BUY", "BUY", "BUY", "SELL")},
+			wantFlow:   0.5,
+			wantTrades: 4,
+		},
+		{
+			name:       "idle trade stream",
+			quotes:     []Quote{trades(now, "BUY", "BUY", "BUY", "SELL")},
+			at:         10 * time.Second,
+			wantTrades: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as := newTestStrategy(now)
+			for _, q := range tt.quotes {
+				as.UpdateQuote(q)
+			}
+			as.clock.(interface{ Advance(time.Duration) }).Advance(tt.at)
+
+			signals := as.Signals()
+			if len(signals) != 1 || signals[0].Venue != "binance" || signals[0].Symbol != "DOGEUSDT" {
+				t.Fatalf("Signals() = %+v, want the binance DOGEUSDT book", signals)
+			}
+			s := signals[0]
+			if math.Abs(s.Imbalance-tt.wantImbalance) > 1e-9 || math.Abs(s.TradeFlow-tt.wantFlow) > 1e-9 || s.Trades != tt.wantTrades {
+				t.Errorf("imbalance %v, trade flow %v, %d trades; want %v, %v, %d", s.Imbalance, s.TradeFlow, s.Trades, tt.wantImbalance, tt.wantFlow, tt.wantTrades)
+			}
+			if sign(s.MicropricePercent) != tt.wantMicro || sign(s.DriftPercent) != tt.wantDrift {
+				t.Errorf("microprice %v%%, drift %v%%", s.MicropricePercent, s.DriftPercent)
+			}
+		})
+	}
+}
+
+// sign returns -1, 0 or 1, treating rounding noise as 0
+func sign(v float64) float64 {
+	switch {
+	case v > 1e-12:
+		return 1
+	case v < -1e-12:
+		return -1
+	}
+	return 0
+}
+
+func TestSelectBySignals(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	// evOpportunity buys on binance and sells on okx
+	balanced := []Quote{
+		{Exchange: "binance", Symbol: "DOGEUSDT", Bid: 0.0999, Ask: 0.1000, BidSize: 500, AskSize: 500, Timestamp: now},
+		{Exchange: "okx", Symbol: "DOGE-USDT", Bid: 0.1010, Ask: 0.1011, BidSize: 500, AskSize: 500, Timestamp: now},
+	}
+	tests := []struct {
+		name       string
+		config     SignalConfig
+		quotes     []Quote
+		ev         *ExpectedValue
+		minSpread  float64
+		wantFilter string // empty when selected
+		wantCheck  bool   // a SignalCheck is attached
+	}{
+		{name: "balanced books", config: DefaultSignalConfig(), quotes: balanced, wantCheck: true},
+		{
+			name:       "okx about to tick up into the sell",
+			config:     SignalConfig{Horizon: 5 * time.Second, MaxImbalance: 0.5},
+			quotes:     append(balanced[:1:1], Quote{Exchange: "okx", Symbol: "DOGE-USDT", Bid: 0.1010, Ask: 0.1011, BidSize: 900, AskSize: 100, Timestamp: now}),
+			wantFilter: "imbalance",
+			wantCheck:  true,
+		},
+		{
+			name:      "binance bid-heavy helps the buy",
+			config:    SignalConfig{Horizon: 5 * time.Second, MaxImbalance: 0.5},
+			quotes:    append([]Quote{{Exchange: "binance", Symbol: "DOGEUSDT", Bid: 0.0999, Ask: 0.1000, BidSize: 900, AskSize: 100, Timestamp: now}}, balanced[1]),
+			wantCheck: true,
+		},
+		{
+			name:   "binance sellers hitting the bid",
+			config: SignalConfig{Horizon: 5 * time.Second, MaxTradeFlow: 0.5},
+			quotes: append(balanced[:2:2], Quote{Exchange: "binance", Symbol: "DOGEUSDT", Timestamp: now,
+				Trades: []MarketTrade{{Price: 0.0999, Size: 1000, Side: "SELL"}}}),
+			wantFilter: "trade_flow",
+			wantCheck:  true,
+		},
+		{
+			name:       "EV does not cover the microprice",
+			config:     DefaultSignalConfig(),
+			quotes:     append(balanced[:1:1], Quote{Exchange: "okx", Symbol: "DOGE-USDT", Bid: 0.1010, Ask: 0.1011, BidSize: 900, AskSize: 100, Timestamp: now}),
+			ev:         &ExpectedValue{EVPercent: 0.01},
+			wantFilter: "edge",
+			wantCheck:  true,
+		},
+		{
+			name:      "net edge covers the microprice",
+			config:    DefaultSignalConfig(),
+			quotes:    append(balanced[:1:1], Quote{Exchange: "okx", Symbol: "DOGE-USDT", Bid: 0.1010, Ask: 0.1011, BidSize: 900, AskSize: 100, Timestamp: now}),
+			wantCheck: true,
+		},
+		// The 0.76% net edge clears the 0.73% minimum but not the 0.04%
+		// microprice adjustment on top of it
+		{
+			name:       "net edge does not cover the minimum spread and the microprice",
+			config:     DefaultSignalConfig(),
+			quotes:     append(balanced[:1:1], Quote{Exchange: "okx", Symbol: "DOGE-USDT", Bid: 0.1010, Ask: 0.1011, BidSize: 900, AskSize: 100, Timestamp: now}),
+			minSpread:  0.73,
+			wantFilter: "edge",
+			wantCheck:  true,
+		},
+		{name: "signals off", config: SignalConfig{Horizon: 5 * time.Second}, quotes: balanced},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as := newTestStrategy(now)
+			as.SetSignals(tt.config)
+			if _, _, err := as.UpdateParams(func(p *Params) error { p.MinSpreadPercent = tt.minSpread; return nil }); err != nil {
+				t.Fatal(err)
+			}
+			for _, q := range tt.quotes {
+				as.UpdateQuote(q)
+			}
+			opp := evOpportunity()
+			opp.EV = tt.ev
+
+			selected, filtered := as.selectBySignals([]ArbitrageOpportunity{opp})
+			if len(selected)+len(filtered) != 1 || (len(filtered) == 1) != (tt.wantFilter != "") {
+				t.Fatalf("selected %d and filtered %d, want filter %q", len(selected), len(filtered), tt.wantFilter)
+			}
+			got := append(selected, filtered...)[0]
+			if (got.Signals != nil) != tt.wantCheck {
+				t.Fatalf("signal check attached = %v, want %v", got.Signals != nil, tt.wantCheck)
+			}
+			if got.Signals != nil && got.Signals.Filter != tt.wantFilter {
+				t.Errorf("Filter = %q, want %q (check %+v)", got.Signals.Filter, tt.wantFilter, *got.Signals)
+			}
+		})
+	}
+}
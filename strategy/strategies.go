@@ -0,0 +1,207 @@
+package strategy
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"hft-arbitrage-bot/risk"
+)
+
+// DefaultStrategyName names the strategy when HFT_STRATEGIES is unset
+const DefaultStrategyName = "arbitrage"
+
+// PnLLogInterval is how often a strategy logs its P&L status
+const PnLLogInterval = 5 * time.Second
+
+// OpportunityTypes lists every opportunity type, in scan order
+var OpportunityTypes = []string{OpportunityCrossVenue, OpportunityTriangular, OpportunityCycle, OpportunityBasis, OpportunityMakerTaker}
+
+// StrategyConfig is one ArbitrageStrategy run by the Runtime. Each has its
+// own P&L book, sized by InitialBalance and TradeSize, and its own risk
+// engine, checking orders against Limits.
+type StrategyConfig struct {
+	Name           string
+	Types          []string // opportunity types it trades; empty for all
+	InitialBalance float64
+	TradeSize      float64
+	Limits         risk.Limits
+}
+
+// ParseStrategies parses "name:type+type[:balance[:trade size]][:limit=value...]"
+// entries separated by commas, e.g.
+// "arb:cross_venue+cycle:800,carry:basis:200:50:daily_loss=10". Limits are
+// named as in risk.LimitNames. Strategies without a balance or trade size get
+// the defaults given, and limits not set are risk.DefaultLimits.
+func ParseStrategies(s string, initialBalance, tradeSize float64) ([]StrategyConfig, error) {
+	var configs []StrategyConfig
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		fields := strings.Split(entry, ":")
+		amounts := 2 // fields up to the last balance or trade size
+		for amounts < len(fields) && !strings.Contains(fields[amounts], "=") {
+			amounts++
+		}
+		if len(fields) < 2 || amounts > 4 {
+			return nil, fmt.Errorf("want name:type+type[:balance[:trade size]][:limit=value...], got %q", entry)
+		}
+		config := StrategyConfig{
+			Name:           strings.ToLower(strings.TrimSpace(fields[0])),
+			InitialBalance: initialBalance,
+			TradeSize:      tradeSize,
+			Limits:         risk.DefaultLimits(),
+		}
+		if config.Name == "" || strings.ContainsAny(config.Name, " /") {
+			return nil, fmt.Errorf("invalid strategy name in %q", entry)
+		}
+		if seen[config.Name] {
+			return nil, fmt.Errorf("strategy %s is listed twice", config.Name)
+		}
+		seen[config.Name] = true
+
+		for _, kind := range strings.Split(fields[1], "+") {
+			kind = strings.ToLower(strings.TrimSpace(kind))
+			if !knownType(kind) {
+				return nil, fmt.Errorf("strategy %s: unknown opportunity type %q (want one of %s)",
+					config.Name, kind, strings.Join(OpportunityTypes, ", "))
+			}
+			config.Types = append(config.Types, kind)
+		}
+		for i, field := range []*float64{&config.InitialBalance, &config.TradeSize} {
+			if amounts <= i+2 {
+				break
+			}
+			v, err := strconv.ParseFloat(strings.TrimSpace(fields[i+2]), 64)
+			if err != nil || v <= 0 {
+				return nil, fmt.Errorf("strategy %s: invalid amount %q", config.Name, fields[i+2])
+			}
+			*field = v
+		}
+		for _, field := range fields[amounts:] {
+			name, value, ok := strings.Cut(field, "=")
+			if !ok {
+				return nil, fmt.Errorf("strategy %s: balance and trade size go before the limits, got %q", config.Name, field)
+			}
+			if err := config.Limits.Set(name, value); err != nil {
+				return nil, fmt.Errorf("strategy %s: %w", config.Name, err)
+			}
+		}
+		configs = append(configs, config)
+	}
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no strategies in %q", s)
+	}
+	return configs, nil
+}
+
+// StrategiesFromEnv returns the strategies in HFT_STRATEGIES, or a single
+// strategy trading every opportunity type when it is unset
+func StrategiesFromEnv(initialBalance, tradeSize float64) ([]StrategyConfig, error) {
+	value := os.Getenv("HFT_STRATEGIES")
+	if value == "" {
+		return []StrategyConfig{{
+			Name:           DefaultStrategyName,
+			InitialBalance: initialBalance,
+			TradeSize:      tradeSize,
+			Limits:         risk.DefaultLimits(),
+		}}, nil
+	}
+	configs, err := ParseStrategies(value, initialBalance, tradeSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid HFT_STRATEGIES: %w", err)
+	}
+	return configs, nil
+}
+
+func knownType(kind string) bool {
+	for _, t := range OpportunityTypes {
+		if t == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// NewStrategy creates the arbitrage strategy a config describes
+func NewStrategy(config StrategyConfig) *ArbitrageStrategy {
+	as := newArbitrageStrategy(config.Name, config.InitialBalance, config.TradeSize)
+	as.riskEngine.SetLimits(config.Limits)
+	if len(config.Types) > 0 {
+		as.types = make(map[string]bool)
+		for _, kind := range config.Types {
+			as.types[kind] = true
+		}
+	}
+	return as
+}
+
+// Name returns the strategy's name, which labels its metrics and logs
+func (as *ArbitrageStrategy) Name() string {
+	return as.name
+}
+
+// Types returns the opportunity types the strategy trades
+func (as *ArbitrageStrategy) Types() []string {
+	var types []string
+	for _, kind := range OpportunityTypes {
+		if as.trades(kind) {
+			types = append(types, kind)
+		}
+	}
+	return types
+}
+
+// idPrefix starts the ids the strategy gives opportunities and orders, so
+// that strategies sharing a runtime number them separately
+func (as *ArbitrageStrategy) idPrefix() string {
+	if as.name == DefaultStrategyName {
+		return ""
+	}
+	return as.name + "-"
+}
+
+// trades reports whether the strategy looks for opportunities of a type
+func (as *ArbitrageStrategy) trades(kind string) bool {
+	return as.types == nil || as.types[kind]
+}
+
+// OnQuote implements Strategy
+func (as *ArbitrageStrategy) OnQuote(quote Quote) {
+	as.UpdateQuote(quote)
+}
+
+// OnBook implements Strategy; books are stored like any other quote
+func (as *ArbitrageStrategy) OnBook(quote Quote) {
+	as.UpdateQuote(quote)
+}
+
+// OnTrade implements Strategy; trades feed the microstructure signals
+func (as *ArbitrageStrategy) OnTrade(quote Quote) {
+	as.UpdateQuote(quote)
+}
+
+// OnFill implements Strategy. The strategy books each execution into its
+// ledger and risk engine as it makes it; the fill handed back here only
+// reports those that came back short of the quantity asked for.
+func (as *ArbitrageStrategy) OnFill(fill Fill) {
+	if fill.Execution.Quantity < fill.Requested {
+		logger.Info("partial fill", "strategy", fill.Strategy, "opp_id", fill.Opportunity.ID,
+			"requested", fill.Requested, "filled", fill.Execution.Quantity)
+	}
+}
+
+// OnTimer implements Strategy: it evaluates the strategy and logs its P&L
+// every PnLLogInterval
+func (as *ArbitrageStrategy) OnTimer(now time.Time) {
+	as.Evaluate()
+	if now.Sub(as.lastPnLLog) >= PnLLogInterval {
+		as.lastPnLLog = now
+		as.pnlManager.LogPnLStatus()
+	}
+}
@@ -0,0 +1,182 @@
+package strategy
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"hft-arbitrage-bot/clock"
+	"hft-arbitrage-bot/risk"
+)
+
+func TestParseStrategies(t *testing.T) {
+	defaults := risk.DefaultLimits()
+	tests := []struct {
+		name    string
+		value   string
+		want    []StrategyConfig
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			value: "arb:cross_venue+cycle",
+			want:  []StrategyConfig{{Name: "arb", Types: []string{"cross_venue", "cycle"}, InitialBalance: 1000, TradeSize: 100, Limits: defaults}},
+		},
+		{
+			name:  "amounts and limits",
+			value: " Arb:cross_venue:800 , carry:basis:200:50:daily_loss=10:streak=0",
+			want: []StrategyConfig{
+				{Name: "arb", Types: []string{"cross_venue"}, InitialBalance: 800, TradeSize: 100, Limits: defaults},
+				{Name: "carry", Types: []string{"basis"}, InitialBalance: 200, TradeSize: 50, Limits: risk.Limits{
+					MaxOrderNotional: 250, MaxVenueExposure: 1000, MaxOrdersPerSecond: 10, MaxDailyLoss: 10,
+				}},
+			},
+		},
+		{
+			name:  "limits without amounts",
+			value: "arb:cycle:order=500:exposure=2000:rate=20",
+			want: []StrategyConfig{{Name: "arb", Types: []string{"cycle"}, InitialBalance: 1000, TradeSize: 100, Limits: risk.Limits{
+				MaxOrderNotional: 500, MaxVenueExposure: 2000, MaxOrdersPerSecond: 20, MaxDailyLoss: 50, MaxConsecutiveLosses: 5,
+			}}},
+		},
+		{name: "no types", value: "arb", wantErr: true},
+		{name: "unknown type", value: "arb:spot", wantErr: true},
+		{name: "listed twice", value: "arb:cycle,ARB:basis", wantErr: true},
+		{name: "too many amounts", value: "arb:cycle:800:100:5", wantErr: true},
+		{name: "amount after a limit", value: "arb:cycle:daily_loss=10:800", wantErr: true},
+		{name: "unknown limit", value: "arb:cycle:loss=10", wantErr: true},
+		{name: "negative limit", value: "arb:cycle:daily_loss=-10", wantErr: true},
+		{name: "empty", value: " , ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configs, err := ParseStrategies(tt.value, 1000, 100)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseStrategies(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			}
+			if !slices.EqualFunc(configs, tt.want, func(a, b StrategyConfig) bool {
+				return a.Name == b.Name && slices.Equal(a.Types, b.Types) && a.InitialBalance == b.InitialBalance &&
+					a.TradeSize == b.TradeSize && a.Limits == b.Limits
+			}) {
+				t.Errorf("ParseStrategies(%q) = %+v, want %+v", tt.value, configs, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrategiesFromEnv(t *testing.T) {
+	t.Setenv("HFT_STRATEGIES", "")
+	configs, err := StrategiesFromEnv(1000, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 1 || configs[0].Name != DefaultStrategyName || configs[0].Limits != risk.DefaultLimits() {
+		t.Errorf("StrategiesFromEnv() = %+v, want the default strategy with the default limits", configs)
+	}
+
+	t.Setenv("HFT_STRATEGIES", "arb:cycle:rate=x")
+	if _, err := StrategiesFromEnv(1000, 100); err == nil {
+		t.Error("StrategiesFromEnv() accepted an invalid limit")
+	}
+}
+
+// recorder is a Strategy that records its callbacks. With an executor it
+// executes every quote it sees.
+type recorder struct {
+	name     string
+	executor ExecutionModel
+	calls    []string
+}
+
+func (r *recorder) Name() string          { return r.name }
+func (r *recorder) OnBook(quote Quote)    { r.calls = append(r.calls, "book "+quote.Symbol) }
+func (r *recorder) OnTrade(quote Quote)   { r.calls = append(r.calls, "trade "+quote.Symbol) }
+func (r *recorder) OnFill(fill Fill)      { r.calls = append(r.calls, "fill "+fill.Strategy) }
+func (r *recorder) OnTimer(now time.Time) { r.calls = append(r.calls, "timer") }
+
+func (r *recorder) OnQuote(quote Quote) {
+	r.calls = append(r.calls, "quote "+quote.Symbol)
+	if r.executor != nil {
+		opp := ArbitrageOpportunity{Symbol: quote.Symbol, EffBuyPrice: quote.Ask, EffSellPrice: quote.Bid}
+		r.executor.Execute(opp, 1, quote.Timestamp)
+	}
+}
+
+func TestRuntimeDispatch(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	a, b := &recorder{name: "a"}, &recorder{name: "b"}
+	r := NewRuntime(a, b)
+	r.Dispatch(Quote{Symbol: "DOGEUSDT", Bid: 0.1, Ask: 0.1001})
+	r.Dispatch(Quote{Symbol: "DOGE-USDT", Bid: 0.1, Ask: 0.1001, Book: &OrderBook{}})
+	r.Dispatch(Quote{Symbol: "DOGEUSDT", Trades: []MarketTrade{{Size: 10}}})
+	r.Tick(now)
+
+	want := []string{"quote DOGEUSDT", "book DOGE-USDT", "trade DOGEUSDT", "timer"}
+	for _, s := range []*recorder{a, b} {
+		if !slices.Equal(s.calls, want) {
+			t.Errorf("strategy %s got %v, want %v", s.name, s.calls, want)
+		}
+	}
+}
+
+func TestRuntimeRoutesFills(t *testing.T) {
+	a, b := &recorder{name: "a"}, &recorder{name: "b"}
+	r := NewRuntime(a, b)
+	b.executor = r.Executor(b.name, InstantExecution{})
+	r.Dispatch(Quote{Symbol: "DOGEUSDT", Bid: 0.1, Ask: 0.1001})
+	r.Dispatch(Quote{Symbol: "DOGEUSDT", Trades: []MarketTrade{{Size: 10}}})
+
+	tests := []struct {
+		strategy *recorder
+		want     []string
+	}{
+		{strategy: a, want: []string{"quote DOGEUSDT", "trade DOGEUSDT"}},
+		{strategy: b, want: []string{"quote DOGEUSDT", "fill b", "trade DOGEUSDT"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy.name, func(t *testing.T) {
+			if !slices.Equal(tt.strategy.calls, tt.want) {
+				t.Errorf("got %v, want %v", tt.strategy.calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuntimeRiskBudgets(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	configs, err := ParseStrategies("wide:cross_venue,narrow:cross_venue:order=50", 1000, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := clock.NewSim(now)
+	var strategies []Strategy
+	for _, config := range configs {
+		s := NewStrategy(config)
+		s.SetClock(sim)
+		strategies = append(strategies, s)
+	}
+	r := NewRuntime(strategies...)
+	r.Dispatch(Quote{Exchange: "binance", Symbol: "DOGEUSDT", Bid: 0.0999, Ask: 0.1000, BidSize: 5000, AskSize: 5000, Timestamp: now})
+	r.Dispatch(Quote{Exchange: "okx", Symbol: "DOGE-USDT", Bid: 0.1010, Ask: 0.1011, BidSize: 5000, AskSize: 5000, Timestamp: now})
+	r.Tick(now)
+
+	tests := []struct {
+		strategy       int
+		wantTrades     int
+		wantRejections int
+	}{
+		{strategy: 0, wantTrades: 2},
+		{strategy: 1, wantRejections: 1},
+	}
+	for _, tt := range tests {
+		s := strategies[tt.strategy].(*ArbitrageStrategy)
+		t.Run(s.Name(), func(t *testing.T) {
+			trades := s.GetPnLManager().GetCurrentPnL().TotalTrades
+			rejections := s.GetRiskEngine().Status().Rejections
+			if trades != tt.wantTrades || rejections != tt.wantRejections {
+				t.Errorf("%d trades and %d risk rejections, want %d and %d", trades, rejections, tt.wantTrades, tt.wantRejections)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package strategy
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Trade sort keys accepted by QueryTrades; prefix with "-" for descending
+const (
+	TradeSortTimestamp = "timestamp"
+	TradeSortPrice     = "price"
+	TradeSortQuantity  = "quantity"
+)
+
+// TradeFilter selects trades; empty fields match everything
+type TradeFilter struct {
+	Venue       string    // exchange, case-insensitive
+	Symbol      string    // any separator: DOGE-USDT, DOGE/USDT and DOGEUSDT match
+	Side        string    // "BUY" or "SELL", case-insensitive
+	Status      string    // "FILLED", "CANCELLED", ..., case-insensitive
+	RoundTripID string    // both legs of one arbitrage
+	From        time.Time // inclusive
+	To          time.Time // exclusive
+}
+
+// TradeQuery is a filtered, sorted page request over the trade history
+type TradeQuery struct {
+	TradeFilter
+	Sort   string // sort key, "-timestamp" (newest first) when empty
+	Limit  int    // page size
+	Cursor string // NextCursor of the previous page; empty for the first page
+}
+
+// TradePage is one page of a trade query
+type TradePage struct {
+	Trades     []Trade `json:"trades"`
+	NextCursor string  `json:"next_cursor,omitempty"` // empty on the last page
+	Total      int     `json:"total"`                 // trades matching the filter
+}
+
+// matches reports whether a trade passes the filter
+func (f TradeFilter) matches(t Trade) bool {
+	switch {
+	case f.Venue != "" && !strings.EqualFold(t.Exchange, f.Venue):
+		return false
+	case f.Symbol != "" && NormalizeSymbol(t.Symbol) != NormalizeSymbol(f.Symbol):
+		return false
+	case f.Side != "" && !strings.EqualFold(t.Type, f.Side):
+		return false
+	case f.Status != "" && !strings.EqualFold(t.Status, f.Status):
+		return false
+	case f.RoundTripID != "" && t.RoundTripID != f.RoundTripID:
+		return false
+	case !f.From.IsZero() && t.Timestamp.Before(f.From):
+		return false
+	case !f.To.IsZero() && !t.Timestamp.Before(f.To):
+		return false
+	}
+	return true
+}
+
+// tradeLess returns the ordering for a sort key
+func tradeLess(key string) (func(a, b Trade) bool, error) {
+	desc := strings.HasPrefix(key, "-")
+	var less func(a, b Trade) bool
+	switch strings.TrimPrefix(key, "-") {
+	case TradeSortTimestamp:
+		less = func(a, b Trade) bool { return a.Timestamp.Before(b.Timestamp) }
+	case TradeSortPrice:
+		less = func(a, b Trade) bool { return a.Price < b.Price }
+	case TradeSortQuantity:
+		less = func(a, b Trade) bool { return a.Quantity < b.Quantity }
+	default:
+		return nil, fmt.Errorf("unknown sort %q (want timestamp, price or quantity, prefixed with - for descending)", key)
+	}
+	if desc {
+		return func(a, b Trade) bool { return less(b, a) }, nil
+	}
+	return less, nil
+}
+
+// tradeCursor is the position of the next page. The history only ever
+// grows, so pinning the page to the first n trades keeps offsets stable
+// while new trades are booked.
+type tradeCursor struct {
+	n      int // trades in the history when the first page was read
+	offset int // matching trades already returned
+}
+
+func (c tradeCursor) encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d.%d", c.n, c.offset)))
+}
+
+func decodeTradeCursor(s string) (tradeCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return tradeCursor{}, fmt.Errorf("invalid cursor")
+	}
+	nStr, offsetStr, ok := strings.Cut(string(raw), ".")
+	n, err1 := strconv.Atoi(nStr)
+	offset, err2 := strconv.Atoi(offsetStr)
+	if !ok || err1 != nil || err2 != nil || n < 0 || offset < 0 {
+		return tradeCursor{}, fmt.Errorf("invalid cursor")
+	}
+	return tradeCursor{n: n, offset: offset}, nil
+}
+
+// QueryTrades returns one page of the trades matching a query. Pass the
+// returned NextCursor with the same filter and sort to get the next page.
+func (pm *PnLManager) QueryTrades(q TradeQuery) (TradePage, error) {
+	if q.Sort == "" {
+		q.Sort = "-" + TradeSortTimestamp
+	}
+	less, err := tradeLess(q.Sort)
+	if err != nil {
+		return TradePage{}, err
+	}
+	if q.Limit <= 0 {
+		return TradePage{}, fmt.Errorf("limit must be positive, got %d", q.Limit)
+	}
+
+	pm.mutex.RLock()
+	cursor := tradeCursor{n: len(pm.trades)}
+	if q.Cursor != "" {
+		if cursor, err = decodeTradeCursor(q.Cursor); err != nil || cursor.n > len(pm.trades) {
+			pm.mutex.RUnlock()
+			return TradePage{}, fmt.Errorf("invalid cursor")
+		}
+	}
+	matched := make([]Trade, 0)
+	for _, t := range pm.trades[:cursor.n] {
+		if q.matches(t) {
+			matched = append(matched, t)
+		}
+	}
+	pm.mutex.RUnlock()
+
+	// The history is in booking order, so a stable sort keeps the buy leg
+	// ahead of its sell leg when keys tie
+	sort.SliceStable(matched, func(i, j int) bool { return less(matched[i], matched[j]) })
+
+	page := TradePage{Trades: []Trade{}, Total: len(matched)}
+	if cursor.offset < len(matched) {
+		end := min(cursor.offset+q.Limit, len(matched))
+		page.Trades = matched[cursor.offset:end]
+		if end < len(matched) {
+			page.NextCursor = tradeCursor{n: cursor.n, offset: end}.encode()
+		}
+	}
+	return page, nil
+}
@@ -0,0 +1,116 @@
+package strategy
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// newTradeLedger returns a P&L manager holding three round trips of two legs
+// each, booked a minute apart
+func newTradeLedger() *PnLManager {
+	start := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	pm := NewPnLManager(1000, 100)
+	legs := []struct {
+		buy, sell    string
+		symbol       string
+		price, qty   float64
+		status       string
+		roundTripID  string
+		minutesLater int
+	}{
+		{"okx", "binance", "DOGE-USDT", 0.1000, 1000, "FILLED", "rt-1", 0},
+		{"kraken", "okx", "DOGE/USD", 0.1010, 500, "FILLED", "rt-2", 1},
+		{"binance", "okx", "DOGEUSDT", 0.0990, 2000, "FAILED", "rt-3", 2},
+	}
+	for _, l := range legs {
+		at := start.Add(time.Duration(l.minutesLater) * time.Minute)
+		pm.trades = append(pm.trades,
+			Trade{ID: l.roundTripID + "-buy", Type: "BUY", Exchange: l.buy, Symbol: l.symbol, Price: l.price, Quantity: l.qty, Timestamp: at, Status: l.status, RoundTripID: l.roundTripID},
+			Trade{ID: l.roundTripID + "-sell", Type: "SELL", Exchange: l.sell, Symbol: l.symbol, Price: l.price + 0.0005, Quantity: l.qty, Timestamp: at, Status: l.status, RoundTripID: l.roundTripID},
+		)
+	}
+	return pm
+}
+
+func TestQueryTrades(t *testing.T) {
+	start := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		query   TradeQuery
+		wantIDs []string
+		wantErr bool
+	}{
+		{name: "newest first by default", query: TradeQuery{Limit: 3}, wantIDs: []string{"rt-3-buy", "rt-3-sell", "rt-2-buy"}},
+		{name: "venue is case insensitive", query: TradeQuery{TradeFilter: TradeFilter{Venue: "OKX"}, Sort: "timestamp", Limit: 10}, wantIDs: []string{"rt-1-buy", "rt-2-sell", "rt-3-sell"}},
+		{name: "symbol in any format", query: TradeQuery{TradeFilter: TradeFilter{Symbol: "doge-usd"}, Limit: 10}, wantIDs: []string{"rt-2-buy", "rt-2-sell"}},
+		{name: "side and status", query: TradeQuery{TradeFilter: TradeFilter{Side: "sell", Status: "filled"}, Sort: "timestamp", Limit: 10}, wantIDs: []string{"rt-1-sell", "rt-2-sell"}},
+		{name: "round trip", query: TradeQuery{TradeFilter: TradeFilter{RoundTripID: "rt-2"}, Limit: 10}, wantIDs: []string{"rt-2-buy", "rt-2-sell"}},
+		{name: "from inclusive, to exclusive", query: TradeQuery{TradeFilter: TradeFilter{From: start.Add(time.Minute), To: start.Add(2 * time.Minute)}, Limit: 10}, wantIDs: []string{"rt-2-buy", "rt-2-sell"}},
+		{name: "by quantity descending", query: TradeQuery{TradeFilter: TradeFilter{Side: "BUY"}, Sort: "-quantity", Limit: 10}, wantIDs: []string{"rt-3-buy", "rt-1-buy", "rt-2-buy"}},
+		{name: "by price", query: TradeQuery{TradeFilter: TradeFilter{Side: "BUY"}, Sort: "price", Limit: 10}, wantIDs: []string{"rt-3-buy", "rt-1-buy", "rt-2-buy"}},
+		{name: "no match", query: TradeQuery{TradeFilter: TradeFilter{Venue: "kucoin"}, Limit: 10}, wantIDs: []string{}},
+		{name: "unknown sort", query: TradeQuery{Sort: "fee", Limit: 10}, wantErr: true},
+		{name: "zero limit", query: TradeQuery{}, wantErr: true},
+		{name: "invalid cursor", query: TradeQuery{Limit: 10, Cursor: "not-a-cursor"}, wantErr: true},
+	}
+
+	pm := newTradeLedger()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := pm.QueryTrades(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("QueryTrades() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			ids := make([]string, len(page.Trades))
+			for i, trade := range page.Trades {
+				ids[i] = trade.ID
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("QueryTrades() = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestQueryTradesPagination(t *testing.T) {
+	pm := newTradeLedger()
+	query := TradeQuery{Sort: "timestamp", Limit: 4}
+
+	first, err := pm.QueryTrades(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Trades booked between pages must not shift the next page
+	pm.trades = append(pm.trades, Trade{ID: "rt-4-buy", Type: "BUY", Timestamp: time.Date(2026, 1, 5, 11, 0, 0, 0, time.UTC)})
+
+	query.Cursor = first.NextCursor
+	second, err := pm.QueryTrades(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		page       TradePage
+		wantFirst  string
+		wantLen    int
+		wantCursor bool
+	}{
+		{name: "first page", page: first, wantFirst: "rt-1-buy", wantLen: 4, wantCursor: true},
+		{name: "last page", page: second, wantFirst: "rt-3-buy", wantLen: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.page.Trades) != tt.wantLen || tt.page.Trades[0].ID != tt.wantFirst || tt.page.Total != 6 {
+				t.Errorf("got %d trades from %s of %d, want %d from %s of 6", len(tt.page.Trades), tt.page.Trades[0].ID, tt.page.Total, tt.wantLen, tt.wantFirst)
+			}
+			if (tt.page.NextCursor != "") != tt.wantCursor {
+				t.Errorf("next cursor = %q, want one %v", tt.page.NextCursor, tt.wantCursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,423 @@
+package strategy
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"hft-arbitrage-bot/risk"
+)
+
+// Opportunity types
+const (
+	OpportunityCrossVenue = "cross_venue" // buy on one venue, sell on another
+	OpportunityTriangular = "triangular"  // three conversions on one venue back to the start asset
+	OpportunityCycle      = "cycle"       // any cycle found by the graph search
+	OpportunityBasis      = "basis"       // spot against the venue's perpetual, held until the basis converges
+	OpportunityMakerTaker = "maker_taker" // a resting order on one venue, hedged on another when it fills
+)
+
+// LegTransfer is the side of a leg that moves an asset between venues
+// instead of trading it
+const LegTransfer = "TRANSFER"
+
+// maxBookAge keeps books that stopped updating out of triangles, graph
+// cycles and basis trades; cross-venue quotes are policed by the circuit
+// breakers instead
+const maxBookAge = 5 * time.Second
+
+// crossVenueSymbols are the markets compared across venues. Other symbols a
+// venue streams only feed that venue's triangles and basis trades.
+var crossVenueSymbols = map[string]bool{"DOGEUSDT": true, "DOGEUSD": true}
+
+// quotePriority orders assets by how commonly they quote others: the market
+// between two of them is quoted in the one listed first
+var quotePriority = []string{"USDT", "USDC", "USD", "EUR", "BTC", "ETH"}
+
+// Triangle is a cycle of three markets on one venue that converts the start
+// asset back into itself, e.g. USDT -> DOGE -> BTC -> USDT. Both directions
+// of the cycle are evaluated. The start asset must be the currency the trade
+// size is given in.
+type Triangle struct {
+	Venue  string    `json:"venue"`
+	Assets [3]string `json:"assets"` // start asset first
+}
+
+// DefaultTriangles are the cycles the bot trades unless HFT_TRIANGLES says otherwise
+var DefaultTriangles = []Triangle{{Venue: "binance", Assets: [3]string{"USDT", "DOGE", "BTC"}}}
+
+// String returns the triangle as venue:START/A/B
+func (t Triangle) String() string {
+	return t.Venue + ":" + strings.Join(t.Assets[:], "/")
+}
+
+// Symbols returns the three markets of the triangle, e.g. DOGEUSDT, DOGEBTC
+// and BTCUSDT, which a venue adapter has to stream
+func (t Triangle) Symbols() []string {
+	return []string{
+		marketSymbol(t.Assets[0], t.Assets[1]),
+		marketSymbol(t.Assets[1], t.Assets[2]),
+		marketSymbol(t.Assets[2], t.Assets[0]),
+	}
+}
+
+// marketSymbol returns the symbol of the market between two assets
+func marketSymbol(a, b string) string {
+	rank := func(asset string) int {
+		for i, q := range quotePriority {
+			if q == asset {
+				return i
+			}
+		}
+		return len(quotePriority)
+	}
+	if rank(a) < rank(b) {
+		return b + a
+	}
+	return a + b
+}
+
+// ParseTriangles parses comma separated venue:START/A/B triangles, e.g.
+// "binance:USDT/DOGE/BTC". "none" returns no triangles.
+func ParseTriangles(s string) ([]Triangle, error) {
+	if strings.EqualFold(strings.TrimSpace(s), "none") {
+		return nil, nil
+	}
+	var triangles []Triangle
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		venue, path, ok := strings.Cut(entry, ":")
+		assets := strings.Split(strings.ToUpper(path), "/")
+		if !ok || venue == "" || len(assets) != 3 {
+			return nil, fmt.Errorf("want venue:START/A/B, got %q", entry)
+		}
+		t := Triangle{Venue: strings.ToLower(strings.TrimSpace(venue))}
+		for i, asset := range assets {
+			t.Assets[i] = strings.TrimSpace(asset)
+		}
+		if t.Assets[0] == "" || t.Assets[1] == "" || t.Assets[2] == "" ||
+			t.Assets[0] == t.Assets[1] || t.Assets[1] == t.Assets[2] || t.Assets[0] == t.Assets[2] {
+			return nil, fmt.Errorf("triangle %q needs three different assets", entry)
+		}
+		triangles = append(triangles, t)
+	}
+	return triangles, nil
+}
+
+// TrianglesFromEnv returns the triangles in HFT_TRIANGLES, or
+// DefaultTriangles when it is unset
+func TrianglesFromEnv() ([]Triangle, error) {
+	value, ok := os.LookupEnv("HFT_TRIANGLES")
+	if !ok {
+		return append([]Triangle(nil), DefaultTriangles...), nil
+	}
+	triangles, err := ParseTriangles(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid HFT_TRIANGLES: %w", err)
+	}
+	return triangles, nil
+}
+
+// VenueSymbols returns the symbols the triangles need streamed from a venue
+func VenueSymbols(triangles []Triangle, venue string) []string {
+	seen := make(map[string]bool)
+	var symbols []string
+	for _, t := range triangles {
+		if t.Venue != venue {
+			continue
+		}
+		for _, symbol := range t.Symbols() {
+			if !seen[symbol] {
+				seen[symbol] = true
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	return symbols
+}
+
+// SetTriangles sets the cycles the strategy evaluates. It must be called
+// before the strategy receives quotes.
+func (as *ArbitrageStrategy) SetTriangles(triangles ...Triangle) {
+	as.quotesLock.Lock()
+	defer as.quotesLock.Unlock()
+	as.triangles = append([]Triangle(nil), triangles...)
+}
+
+// Triangles returns the cycles the strategy evaluates
+func (as *ArbitrageStrategy) Triangles() []Triangle {
+	as.quotesLock.RLock()
+	defer as.quotesLock.RUnlock()
+	return append([]Triangle(nil), as.triangles...)
+}
+
+// bookKey identifies one market of one venue in the book store
+func bookKey(venue, symbol string) string {
+	return venue + "/" + NormalizeSymbol(symbol)
+}
+
+// bookOnly reports whether a quote is kept out of the cross-venue comparison
+// and its circuit breakers. Only the crossVenueSymbols are compared across
+// venues; any other market, traded by a triangle, a cycle or a basis trade or
+// by nothing at all, must not replace the venue's cross-venue quote.
+func (as *ArbitrageStrategy) bookOnly(quote Quote) bool {
+	return !crossVenueSymbols[NormalizeSymbol(quote.Symbol)]
+}
+
+// updateBook stores a book-only quote after basic sanity checks
+func (as *ArbitrageStrategy) updateBook(quote Quote) {
+	if quote.Bid <= 0 || quote.Ask <= 0 || quote.Ask < quote.Bid {
+		return
+	}
+	as.quotesLock.Lock()
+	as.books[bookKey(quote.Exchange, quote.Symbol)] = quote
+	as.recordQuote(quote)
+	as.recordSignals(quote)
+	as.quotesLock.Unlock()
+}
+
+// OpportunityLeg is one order of a multi-leg opportunity, or a transfer of
+// inventory between venues
+type OpportunityLeg struct {
+	Venue    string  `json:"venue"`
+	ToVenue  string  `json:"to_venue,omitempty"` // destination of a transfer
+	Symbol   string  `json:"symbol,omitempty"`
+	Side     string  `json:"side"`  // BUY takes the ask, SELL takes the bid, or LegTransfer
+	From     string  `json:"from"`  // asset spent
+	To       string  `json:"to"`    // asset received
+	Price    float64 `json:"price"` // detected ask or bid, 1 for a transfer
+	Fee      float64 `json:"fee"`   // taker rate, or the cost of a transfer
+	Slippage float64 `json:"slippage"`
+	Quantity float64 `json:"quantity"` // base asset traded, or asset moved, per unit of the start asset
+}
+
+// Convert returns what amount of the From asset becomes after the leg at
+// price, before and after fees
+func (l OpportunityLeg) Convert(amount, price float64) (gross, net float64) {
+	switch l.Side {
+	case "BUY":
+		gross = amount / price
+	case LegTransfer:
+		gross = amount
+	default:
+		gross = amount * price
+	}
+	return gross, gross * (1 - l.Fee)
+}
+
+// scanTriangles evaluates both directions of every triangle under the
+// quotes read lock
+func (as *ArbitrageStrategy) scanTriangles() []ArbitrageOpportunity {
+	as.quotesLock.RLock()
+	defer as.quotesLock.RUnlock()
+
+	if len(as.triangles) == 0 {
+		return nil
+	}
+	now := as.clock.Now()
+	params := as.params.Load()
+
+	var opportunities []ArbitrageOpportunity
+	for _, t := range as.triangles {
+		if paused, _ := as.breakers.Paused(t.Venue, now); paused || !params.Tradable(t.Venue) {
+			continue
+		}
+		a := t.Assets
+		for _, path := range [][3]string{{a[0], a[1], a[2]}, {a[0], a[2], a[1]}} {
+			opp, ok := as.evaluateCycle(t.Venue, path, params, now)
+			if !ok {
+				continue
+			}
+			if opp.EffSellPrice > 1 && (opp.EffSellPrice-1)*100 >= params.MinSpreadPercent {
+				opportunities = append(opportunities, opp)
+				opportunitiesTotal.WithLabelValues(reasonDetected).Inc()
+			} else if opp.SellPrice > 1 {
+				opportunitiesTotal.WithLabelValues(reasonBelowThreshold).Inc()
+			}
+		}
+	}
+	return opportunities
+}
+
+// evaluateCycle prices the three conversions of path on a venue; it returns
+// false when a book is missing or stale
+func (as *ArbitrageStrategy) evaluateCycle(venue string, path [3]string, params *Params, now time.Time) (ArbitrageOpportunity, bool) {
+	legs := make([]OpportunityLeg, 0, len(path))
+	quoteTime := now
+	for i, from := range path {
+		to := path[(i+1)%len(path)]
+		leg, quote, ok := as.cycleLeg(venue, from, to, now)
+		if !ok {
+			return ArbitrageOpportunity{}, false
+		}
+		leg.Fee = params.Fee(venue)
+		leg.Slippage = exchangeSlippage[venue]
+		quoteTime = olderOf(quoteTime, quote.Timestamp)
+		legs = append(legs, leg)
+	}
+	return newCycleOpportunity(OpportunityTriangular, legs, quoteTime, now), true
+}
+
+// newCycleOpportunity prices one unit of the first leg's asset through the
+// legs and sizes each leg per unit. The opportunity buys the cycle at 1 and
+// sells it at what comes back, so the price fields read like a two-leg
+// arbitrage: SellPrice is the return before costs and EffSellPrice after
+// fees and slippage.
+func newCycleOpportunity(kind string, legs []OpportunityLeg, quoteTime, now time.Time) ArbitrageOpportunity {
+	gross, net := 1.0, 1.0
+	assets := []string{legs[0].From}
+	sellVenue := legs[0].Venue
+	for i := range legs {
+		leg := &legs[i]
+		leg.Quantity = net
+		if leg.Side == "BUY" {
+			leg.Quantity = net / leg.Price
+		}
+		g, _ := leg.Convert(1, leg.Price)
+		gross *= g
+		net *= g * (1 - leg.Fee - leg.Slippage)
+		if leg.Side != LegTransfer {
+			assets = append(assets, leg.To)
+			sellVenue = leg.Venue
+		}
+	}
+
+	return ArbitrageOpportunity{
+		Type:          kind,
+		BuyExchange:   legs[0].Venue,
+		SellExchange:  sellVenue,
+		Symbol:        strings.Join(assets, ">"),
+		BuyPrice:      1,
+		SellPrice:     gross,
+		Spread:        gross - 1,
+		SpreadPercent: (gross - 1) * 100,
+		Timestamp:     now,
+		SellFee:       1 - net/gross,
+		EffBuyPrice:   1,
+		EffSellPrice:  net,
+		QuoteTime:     quoteTime,
+		Legs:          legs,
+	}
+}
+
+// cycleLeg finds the book that converts from into to: buying to where it is
+// the base asset, selling from where from is
+func (as *ArbitrageStrategy) cycleLeg(venue, from, to string, now time.Time) (OpportunityLeg, Quote, bool) {
+	leg := OpportunityLeg{Venue: venue, From: from, To: to}
+	quote, ok := as.books[bookKey(venue, to+from)]
+	if ok {
+		leg.Side, leg.Price = "BUY", quote.Ask
+	} else if quote, ok = as.books[bookKey(venue, from+to)]; ok {
+		leg.Side, leg.Price = "SELL", quote.Bid
+	}
+	if !ok || leg.Price <= 0 || now.Sub(quote.Timestamp) > maxBookAge {
+		return OpportunityLeg{}, Quote{}, false
+	}
+	leg.Symbol = quote.Symbol
+	return leg, quote, true
+}
+
+// legOrders returns the risk orders of a multi-leg opportunity. Every leg is
+// valued in the start asset, so notional limits apply as for a two-leg trade.
+// A leg between two assets other than the start asset also moves the asset
+// it is quoted in; conversions carries that side so open exposure nets out
+// once the cycle closes.
+func legOrders(opp ArbitrageOpportunity, quantity float64) (orders, conversions []risk.Order) {
+	for _, leg := range opp.Legs {
+		if leg.Quantity <= 0 || leg.Side == LegTransfer {
+			continue
+		}
+		order := risk.Order{
+			Venue:    leg.Venue,
+			Symbol:   leg.Symbol,
+			Side:     leg.Side,
+			Price:    1 / leg.Quantity,
+			Quantity: quantity * leg.Quantity,
+		}
+		orders = append(orders, order)
+
+		start := opp.Legs[0].From
+		quoteAsset, side := leg.From, "SELL"
+		if leg.Side == "SELL" {
+			quoteAsset, side = leg.To, "BUY"
+		}
+		if quoteAsset == start {
+			continue
+		}
+		quoteQuantity := order.Quantity * leg.Price
+		conversions = append(conversions, risk.Order{
+			Venue:    leg.Venue,
+			Symbol:   quoteAsset + start,
+			Side:     side,
+			Price:    order.Notional() / quoteQuantity,
+			Quantity: quoteQuantity,
+		})
+	}
+	return orders, conversions
+}
+
+// LegFill is how one leg of a multi-leg execution filled
+type LegFill struct {
+	Price     float64 // average price before fees
+	Quantity  float64 // base asset
+	FeeRate   float64
+	Liquidity string // LiquidityTaker or LiquidityMaker
+}
+
+// CycleExecution builds the execution of a cycle that was sent with start
+// units of the start asset and filled as fills, one per leg. The cycle reads
+// as a buy at 1 and a sell at what came back after fees, with the fees, in
+// the start asset, on the sell side.
+func CycleExecution(opp ArbitrageOpportunity, start float64, fills []LegFill, t time.Time) Execution {
+	gross, net := start, start
+	for i, fill := range fills {
+		leg := opp.Legs[i]
+		leg.Fee = fill.FeeRate
+		gross, _ = leg.Convert(gross, fill.Price)
+		_, net = leg.Convert(net, fill.Price)
+	}
+	return Execution{
+		Quantity:      start,
+		BuyPrice:      1,
+		SellPrice:     net / start,
+		Time:          t,
+		SellFee:       gross - net,
+		BuyLiquidity:  LiquidityTaker,
+		SellLiquidity: LiquidityTaker,
+		Legs:          fills,
+	}
+}
+
+// legTrades books the legs of a cycle as one trade each; transfers move
+// inventory the bot already holds and are not trades
+func legTrades(opp ArbitrageOpportunity, fill Execution, idNanos int64, roundTripID string) []Trade {
+	trades := make([]Trade, 0, len(fill.Legs))
+	for i, legFill := range fill.Legs {
+		leg := opp.Legs[i]
+		if leg.Side == LegTransfer {
+			continue
+		}
+		price := legFill.Price * (1 + legFill.FeeRate)
+		if leg.Side == "SELL" {
+			price = legFill.Price * (1 - legFill.FeeRate)
+		}
+		trades = append(trades, Trade{
+			ID:          fmt.Sprintf("leg%d_%d", i+1, idNanos),
+			Type:        leg.Side,
+			Exchange:    leg.Venue,
+			Symbol:      leg.Symbol,
+			Price:       price,
+			Quantity:    legFill.Quantity,
+			Timestamp:   fill.Time,
+			Status:      "FILLED",
+			RoundTripID: roundTripID,
+			Fee:         legFill.Quantity * legFill.Price * legFill.FeeRate,
+			Liquidity:   legFill.Liquidity,
+		})
+	}
+	return trades
+}
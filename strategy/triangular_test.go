@@ -0,0 +1,157 @@
+package strategy
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseTriangles(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []string // Triangle.String of each
+		wantErr bool
+	}{
+		{name: "one", value: "binance:USDT/DOGE/BTC", want: []string{"binance:USDT/DOGE/BTC"}},
+		{name: "normalized and several", value: " Binance:usdt/doge/btc , okx:USDT/DOGE/ETH,", want: []string{"binance:USDT/DOGE/BTC", "okx:USDT/DOGE/ETH"}},
+		{name: "none", value: "None"},
+		{name: "no venue", value: "USDT/DOGE/BTC", wantErr: true},
+		{name: "two assets", value: "binance:USDT/DOGE", wantErr: true},
+		{name: "repeated asset", value: "binance:USDT/DOGE/USDT", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			triangles, err := ParseTriangles(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTriangles(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			}
+			var got []string
+			for _, triangle := range triangles {
+				got = append(got, triangle.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseTriangles(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTriangleSymbols(t *testing.T) {
+	tests := []struct {
+		triangle Triangle
+		want     []string
+	}{
+		{triangle: Triangle{Venue: "binance", Assets: [3]string{"USDT", "DOGE", "BTC"}}, want: []string{"DOGEUSDT", "DOGEBTC", "BTCUSDT"}},
+		{triangle: Triangle{Venue: "binance", Assets: [3]string{"USDT", "BTC", "ETH"}}, want: []string{"BTCUSDT", "ETHBTC", "ETHUSDT"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.triangle.String(), func(t *testing.T) {
+			if got := tt.triangle.Symbols(); !slices.Equal(got, tt.want) {
+				t.Errorf("Symbols() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanTriangles(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	books := func(dogeBTCBid float64, age time.Duration) []Quote {
+		at := now.Add(-age)
+		return []Quote{
+			{Exchange: "binance", Symbol: "DOGEUSDT", Bid: 0.0999, Ask: 0.1000, Timestamp: now},
+			{Exchange: "binance", Symbol: "DOGEBTC", Bid: dogeBTCBid, Ask: dogeBTCBid + 0.00000001, Timestamp: at},
+			{Exchange: "binance", Symbol: "BTCUSDT", Bid: 50000, Ask: 50010, Timestamp: now},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		quotes     []Quote
+		minSpread  float64
+		wantSymbol string // of the one opportunity expected, empty for none
+		wantGross  float64
+	}{
+		{name: "mispriced cross rate", quotes: books(0.00000203, 0), wantSymbol: "USDT>DOGE>BTC>USDT", wantGross: 1.015},
+		{name: "consistent rates", quotes: books(0.00000200, 0)},
+		{name: "below the minimum spread", quotes: books(0.00000203, 0), minSpread: 2},
+		{name: "stale book", quotes: books(0.00000203, 10*time.Second)},
+		{name: "missing book", quotes: books(0.00000203, 0)[:2]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as := newTestStrategy(now)
+			as.SetTriangles(DefaultTriangles...)
+			if _, _, err := as.UpdateParams(func(p *Params) error { p.MinSpreadPercent = tt.minSpread; return nil }); err != nil {
+				t.Fatal(err)
+			}
+			for _, q := range tt.quotes {
+				as.UpdateQuote(q)
+			}
+
+			opportunities := as.scanTriangles()
+			if tt.wantSymbol == "" {
+				if len(opportunities) != 0 {
+					t.Fatalf("found %+v, want nothing", opportunities)
+				}
+				return
+			}
+			if len(opportunities) != 1 {
+				t.Fatalf("found %d opportunities, want 1", len(opportunities))
+			}
+			opp := opportunities[0]
+			if opp.Type != OpportunityTriangular || opp.Symbol != tt.wantSymbol || len(opp.Legs) != 3 {
+				t.Errorf("opportunity = %s %s with %d legs", opp.Type, opp.Symbol, len(opp.Legs))
+			}
+			if math.Abs(opp.SellPrice-tt.wantGross) > 1e-9 {
+				t.Errorf("gross return = %v, want %v", opp.SellPrice, tt.wantGross)
+			}
+			fees := 3 * (as.params.Load().Fee("binance") + exchangeSlippage["binance"])
+			if opp.EffSellPrice >= opp.SellPrice || opp.EffSellPrice < opp.SellPrice*(1-fees)-1e-9 {
+				t.Errorf("net return %v is not the gross %v less about %v in costs", opp.EffSellPrice, opp.SellPrice, fees)
+			}
+			wantSides := []string{"BUY", "SELL", "SELL"}
+			for i, leg := range opp.Legs {
+				if leg.Side != wantSides[i] {
+					t.Errorf("leg %d %s %s, want %s", i, leg.Side, leg.Symbol, wantSides[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCycleExecution(t *testing.T) {
+	legs := []OpportunityLeg{
+		{Venue: "binance", Symbol: "DOGEUSDT", Side: "BUY", From: "USDT", To: "DOGE", Price: 0.1},
+		{Venue: "binance", Symbol: "DOGEBTC", Side: "SELL", From: "DOGE", To: "BTC", Price: 0.00000203},
+		{Venue: "binance", Symbol: "BTCUSDT", Side: "SELL", From: "BTC", To: "USDT", Price: 50000},
+	}
+	opp := newCycleOpportunity(OpportunityTriangular, legs, time.Time{}, time.Time{})
+
+	tests := []struct {
+		name     string
+		feeRate  float64
+		wantSell float64 // USDT back per USDT sent
+	}{
+		{name: "no fees", wantSell: 1.015},
+		{name: "taker fees on every leg", feeRate: 0.001, wantSell: 1.015 * 0.999 * 0.999 * 0.999},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fills := make([]LegFill, len(legs))
+			for i, leg := range opp.Legs {
+				fills[i] = LegFill{Price: leg.Price, Quantity: 100 * leg.Quantity, FeeRate: tt.feeRate}
+			}
+			fill := CycleExecution(opp, 100, fills, time.Time{})
+			if math.Abs(fill.SellPrice-tt.wantSell) > 1e-9 {
+				t.Errorf("SellPrice = %v, want %v", fill.SellPrice, tt.wantSell)
+			}
+			if want := 100 * (1.015 - tt.wantSell); math.Abs(fill.SellFee-want) > 1e-9 {
+				t.Errorf("SellFee = %v, want %v", fill.SellFee, want)
+			}
+		})
+	}
+}
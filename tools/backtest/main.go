@@ -0,0 +1,126 @@
+// Command backtest replays recorded quotes through the arbitrage strategy
+// and prints a P&L and performance report.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"hft-arbitrage-bot/backtest"
+	"hft-arbitrage-bot/logging"
+	"hft-arbitrage-bot/strategy"
+)
+
+func main() {
+	cfg := backtest.DefaultConfig()
+
+	data := flag.String("data", "", "quotes to replay: a capture directory or .hftcap file, JSON lines of quotes, or .csv (optionally .gz)")
+	flag.Float64Var(&cfg.MinSpreadPercent, "min-spread", cfg.MinSpreadPercent, "minimum net edge in percent to trade")
+	flag.Float64Var(&cfg.InitialBalance, "balance", cfg.InitialBalance, "initial balance in quote currency")
+	flag.Float64Var(&cfg.TradeSize, "trade-size", cfg.TradeSize, "quote currency spent per arbitrage")
+	flag.DurationVar(&cfg.Interval, "interval", cfg.Interval, "strategy evaluation interval")
+	fees := flag.String("fees", "", "taker fee overrides, e.g. kraken=0.0016,okx=0.0008")
+	flag.StringVar(&cfg.Execution.Backend, "execution", cfg.Execution.Backend, "paper to simulate fills against the data, instant to fill at detected prices")
+	flag.DurationVar(&cfg.Execution.Latency, "latency", cfg.Execution.Latency, "order submission to fill latency")
+	venueLatency := flag.String("venue-latency", "", "per venue latency, e.g. kraken=120ms,binance=20ms")
+	flag.Float64Var(&cfg.Execution.QueueAhead, "queue-ahead", cfg.Execution.QueueAhead, "share of displayed size filled by others before us, 0 to 1")
+	flag.Float64Var(&cfg.Execution.MinFillRatio, "min-fill", cfg.Execution.MinFillRatio, "smallest fill, as a share of the order, that is accepted")
+	flag.Float64Var(&cfg.Limits.MaxDailyLoss, "max-daily-loss", cfg.Limits.MaxDailyLoss, "risk limit: daily loss that engages the kill switch (0 disables)")
+	flag.IntVar(&cfg.Limits.MaxConsecutiveLosses, "max-losing-streak", cfg.Limits.MaxConsecutiveLosses, "risk limit: losing arbitrages in a row that engage the kill switch (0 disables)")
+	triangles := flag.String("triangles", "", "triangles to evaluate, e.g. binance:USDT/DOGE/BTC, or none (default binance:USDT/DOGE/BTC)")
+	flag.IntVar(&cfg.Graph.MaxLegs, "max-legs", cfg.Graph.MaxLegs, "longest cycle the graph search looks for, transfers included; 0 disables the search")
+	flag.BoolVar(&cfg.Basis.Enabled, "basis", cfg.Basis.Enabled, "trade spot against perpetuals where the data has both")
+	flag.Float64Var(&cfg.Basis.EntryPercent, "basis-entry", cfg.Basis.EntryPercent, "annualized percent, after costs and with funding, that opens a basis position")
+	flag.BoolVar(&cfg.Maker.Enabled, "maker", cfg.Maker.Enabled, "rest passive orders on the cheapest maker venue and hedge them on fill")
+	flag.Float64Var(&cfg.Maker.EdgePercent, "maker-edge", cfg.Maker.EdgePercent, "net edge in percent, after the maker fee and the hedge's costs, resting orders are priced for")
+	flag.BoolVar(&cfg.EV.Enabled, "ev", cfg.EV.Enabled, "select opportunities by expected value after quote decay, adverse moves and partial fills")
+	flag.Float64Var(&cfg.EV.MinEVPercent, "min-ev", cfg.EV.MinEVPercent, "expected value in percent of the trade an opportunity must exceed")
+	flag.Float64Var(&cfg.Signals.MicropriceWeight, "microprice-weight", cfg.Signals.MicropriceWeight, "share of the microprice's move against the legs added to the required edge")
+	flag.Float64Var(&cfg.Signals.MaxImbalance, "max-imbalance", cfg.Signals.MaxImbalance, "top of book imbalance against a leg that rejects an opportunity, 0 to 1 (0 disables)")
+	flag.Float64Var(&cfg.Signals.MaxTradeFlow, "max-flow", cfg.Signals.MaxTradeFlow, "trade flow against a leg that rejects an opportunity, 0 to 1 (0 disables)")
+	flag.Float64Var(&cfg.Signals.MaxDriftPercent, "max-drift", cfg.Signals.MaxDriftPercent, "mid drift in percent against a leg that rejects an opportunity (0 disables)")
+	flag.DurationVar(&cfg.Cooldown.Cooldown, "cooldown", cfg.Cooldown.Cooldown, "time after an execution before the same venue pair is traded again (0 disables)")
+	flag.IntVar(&cfg.Cooldown.MaxOpen, "max-open", cfg.Cooldown.MaxOpen, "executions on a venue pair while its spread lasts (0 for no limit)")
+	flag.Float64Var(&cfg.Cooldown.ReentryEdge, "reentry-edge", cfg.Cooldown.ReentryEdge, "edge improvement in percentage points that allows a spread to be entered again")
+	jsonOutput := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	// Per-trade logs, including every missed fill, would drown the report;
+	// setting HFT_LOG_LEVEL brings them back on stderr
+	logOptions, err := logging.OptionsFromEnv()
+	if err != nil {
+		fail(err)
+	}
+	if os.Getenv("HFT_LOG_LEVEL") == "" {
+		logOptions.Output = io.Discard
+	}
+	defer logging.Setup(logOptions)()
+
+	if *data == "" {
+		fmt.Fprintln(os.Stderr, "usage: backtest -data <file> [options]")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+	if cfg.FeeOverrides, err = parseVenueMap(*fees, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) }); err != nil {
+		fail(fmt.Errorf("-fees: %w", err))
+	}
+	if cfg.Execution.VenueLatency, err = parseVenueMap(*venueLatency, time.ParseDuration); err != nil {
+		fail(fmt.Errorf("-venue-latency: %w", err))
+	}
+
+	if *triangles != "" {
+		if cfg.Triangles, err = strategy.ParseTriangles(*triangles); err != nil {
+			fail(fmt.Errorf("-triangles: %w", err))
+		}
+	}
+	cfg.Graph.Enabled = cfg.Graph.MaxLegs > 0
+
+	quotes, err := backtest.LoadQuotes(*data)
+	if err != nil {
+		fail(err)
+	}
+	report, err := backtest.Run(quotes, cfg)
+	if err != nil {
+		fail(err)
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+		return
+	}
+	report.Print(os.Stdout)
+}
+
+// parseVenueMap parses "venue=value,venue=value"
+func parseVenueMap[V any](s string, parse func(string) (V, error)) (map[string]V, error) {
+	values := make(map[string]V)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		venue, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("want venue=value, got %q", entry)
+		}
+		v, err := parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", venue, err)
+		}
+		values[strings.ToLower(strings.TrimSpace(venue))] = v
+	}
+	return values, nil
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+	os.Exit(1)
+}
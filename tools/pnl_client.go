@@ -1,20 +1,54 @@
 package main
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
 	"os"
+	"strings"
 	"time"
 )
 
+// apiToken is sent as a bearer token when set (--token or HFT_API_TOKEN)
+var apiToken = os.Getenv("HFT_API_TOKEN")
+
+// apiGet fetches an API endpoint with the configured credentials
+func apiGet(endpoint string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if apiToken != "" {
+		req.Header.Set("Authorization", "Bearer "+apiToken)
+	}
+	return http.DefaultClient.Do(req)
+}
+
+// PnLResponse is the /api/v1 envelope; Error is set when Status is "error"
 type PnLResponse struct {
 	Status    string      `json:"status"`
 	Data      interface{} `json:"data"`
+	Error     *APIError   `json:"error"`
 	Timestamp int64       `json:"timestamp"`
 }
 
+// APIError mirrors api.APIError
+type APIError struct {
+	Code    int    `json:"code"`
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+func (e *APIError) String() string {
+	if e == nil {
+		return "unknown error"
+	}
+	return fmt.Sprintf("%s (%d): %s", e.Type, e.Code, e.Message)
+}
+
 func main() {
 	if len(os.Args) < 2 {
 		fmt.Println("Usage: pnl_client <command> [options]")
@@ -22,17 +56,36 @@ func main() {
 		fmt.Println("  pnl     - Get full P&L status")
 		fmt.Println("  summary - Get P&L summary")
 		fmt.Println("  trades  - Get recent trades")
+		fmt.Println("  attribution - Get P&L by venue pair, symbol and hour")
 		fmt.Println("  health  - Check API health")
+		fmt.Println("  quotes  - Latest quote per venue")
+		fmt.Println("  spreads - Gross and net edge for every venue pair")
+		fmt.Println("  stream  - Follow live events")
 		fmt.Println("")
 		fmt.Println("Options:")
 		fmt.Println("  --host <host> - API host (default: localhost:8080)")
+		fmt.Println("  --https       - Connect over TLS")
+		fmt.Println("  --token <t>   - Bearer token (default: $HFT_API_TOKEN)")
 		fmt.Println("  --limit <n>   - Number of trades to fetch (for trades command)")
+		fmt.Println("  --venue, --symbol, --side, --status, --round-trip <v>")
+		fmt.Println("                - Trade filters (for trades command)")
+		fmt.Println("  --from, --to <t> - Trade time range, RFC 3339 or Unix seconds")
+		fmt.Println("  --sort <key>  - timestamp, price or quantity; -key for descending")
+		fmt.Println("  --cursor <c>  - Continue from a previous page of trades")
+		fmt.Println("  --csv         - Print trades as CSV")
+		fmt.Println("  --by <dim>    - Attribution dimension: pair, symbol or hour (default: all)")
+		fmt.Println("  --topics <t>  - Stream topics: quotes,opportunities,trades,pnl (default: all)")
 		os.Exit(1)
 	}
 
 	command := os.Args[1]
 	host := "localhost:8080"
 	limit := "10"
+	tradeQuery := neturl.Values{}
+	csvOutput := false
+	by := ""
+	topics := ""
+	scheme := "http"
 
 	// Parse options
 	for i := 2; i < len(os.Args); i++ {
@@ -47,10 +100,38 @@ func main() {
 				limit = os.Args[i+1]
 				i++
 			}
+		case "--venue", "--symbol", "--side", "--status", "--round-trip", "--from", "--to", "--sort", "--cursor":
+			if i+1 < len(os.Args) {
+				name := strings.ReplaceAll(strings.TrimPrefix(os.Args[i], "--"), "-", "_")
+				if name == "round_trip" {
+					name = "round_trip_id"
+				}
+				tradeQuery.Set(name, os.Args[i+1])
+				i++
+			}
+		case "--csv":
+			csvOutput = true
+		case "--by":
+			if i+1 < len(os.Args) {
+				by = os.Args[i+1]
+				i++
+			}
+		case "--https":
+			scheme = "https"
+		case "--token":
+			if i+1 < len(os.Args) {
+				apiToken = os.Args[i+1]
+				i++
+			}
+		case "--topics":
+			if i+1 < len(os.Args) {
+				topics = os.Args[i+1]
+				i++
+			}
 		}
 	}
 
-	url := fmt.Sprintf("http://%s", host)
+	url := fmt.Sprintf("%s://%s/api/v1", scheme, host)
 
 	switch command {
 	case "pnl":
@@ -58,9 +139,18 @@ func main() {
 	case "summary":
 		getSummary(url)
 	case "trades":
-		getTrades(url, limit)
+		tradeQuery.Set("limit", limit)
+		getTrades(url, tradeQuery, csvOutput)
+	case "attribution":
+		getAttribution(url, by)
 	case "health":
 		getHealth(url)
+	case "quotes":
+		getQuotes(url)
+	case "spreads":
+		getSpreads(url)
+	case "stream":
+		followStream(url, topics)
 	default:
 		fmt.Printf("Unknown command: %s\n", command)
 		os.Exit(1)
@@ -68,7 +158,7 @@ func main() {
 }
 
 func getPnL(url string) {
-	resp, err := http.Get(url + "/pnl")
+	resp, err := apiGet(url + "/pnl")
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
@@ -88,7 +178,7 @@ func getPnL(url string) {
 	}
 
 	if response.Status != "success" {
-		fmt.Printf("API Error: %v\n", response.Data)
+		fmt.Printf("API Error: %s\n", response.Error)
 		os.Exit(1)
 	}
 
@@ -100,7 +190,7 @@ func getPnL(url string) {
 }
 
 func getSummary(url string) {
-	resp, err := http.Get(url + "/summary")
+	resp, err := apiGet(url + "/summary")
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
@@ -120,7 +210,7 @@ func getSummary(url string) {
 	}
 
 	if response.Status != "success" {
-		fmt.Printf("API Error: %v\n", response.Data)
+		fmt.Printf("API Error: %s\n", response.Error)
 		os.Exit(1)
 	}
 
@@ -131,8 +221,75 @@ func getSummary(url string) {
 	fmt.Printf("%s\n", string(data))
 }
 
-func getTrades(url string, limit string) {
-	resp, err := http.Get(fmt.Sprintf("%s/trades?limit=%s", url, limit))
+func getTrades(url string, query neturl.Values, csvOutput bool) {
+	endpoint := url + "/trades?" + query.Encode()
+	if csvOutput {
+		resp, err := apiGet(endpoint + "&format=csv")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			fmt.Printf("API Error: %s\n", string(body))
+			os.Exit(1)
+		}
+		io.Copy(os.Stdout, resp.Body)
+		if next := resp.Header.Get("X-Next-Cursor"); next != "" {
+			fmt.Fprintf(os.Stderr, "more trades: --cursor %s\n", next)
+		}
+		return
+	}
+
+	var page struct {
+		Trades []struct {
+			ID          string    `json:"id"`
+			Type        string    `json:"type"`
+			Exchange    string    `json:"exchange"`
+			Symbol      string    `json:"symbol"`
+			Price       float64   `json:"price"`
+			Quantity    float64   `json:"quantity"`
+			Timestamp   time.Time `json:"timestamp"`
+			Status      string    `json:"status"`
+			RoundTripID string    `json:"round_trip_id"`
+		} `json:"trades"`
+		NextCursor string `json:"next_cursor"`
+		Total      int    `json:"total"`
+	}
+	timestamp := fetchData(endpoint, &page)
+
+	fmt.Printf("=== TRADES ===\n")
+	fmt.Printf("Timestamp: %s\n", time.Unix(timestamp, 0).Format("2006-01-02 15:04:05"))
+	fmt.Printf("%-23s %-4s %-8s %-10s %14s %14s %-9s %s\n", "TIME", "SIDE", "VENUE", "SYMBOL", "PRICE", "QUANTITY", "STATUS", "ROUND TRIP")
+	for _, t := range page.Trades {
+		fmt.Printf("%-23s %-4s %-8s %-10s %14.6f %14.4f %-9s %s\n",
+			t.Timestamp.Local().Format("2006-01-02 15:04:05.000"), t.Type, t.Exchange, t.Symbol, t.Price, t.Quantity, t.Status, t.RoundTripID)
+	}
+	fmt.Printf("Showing %d of %d matching trades\n", len(page.Trades), page.Total)
+	if page.NextCursor != "" {
+		fmt.Printf("More: --cursor %s\n", page.NextCursor)
+	}
+}
+
+// AttributionBucket mirrors strategy.AttributionBucket
+type AttributionBucket struct {
+	Key              string  `json:"key"`
+	Trades           int     `json:"trades"`
+	PnL              float64 `json:"pnl"`
+	Fees             float64 `json:"fees"`
+	AverageGrossEdge float64 `json:"average_gross_edge"`
+	AverageNetEdge   float64 `json:"average_net_edge"`
+	WinRate          float64 `json:"win_rate"`
+}
+
+func getAttribution(url string, by string) {
+	endpoint := url + "/attribution"
+	if by != "" {
+		endpoint += "/" + by
+	}
+
+	resp, err := apiGet(endpoint)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
@@ -145,26 +302,62 @@ func getTrades(url string, limit string) {
 		os.Exit(1)
 	}
 
-	var response PnLResponse
+	var response struct {
+		Status    string          `json:"status"`
+		Data      json.RawMessage `json:"data"`
+		Error     *APIError       `json:"error"`
+		Timestamp int64           `json:"timestamp"`
+	}
 	if err := json.Unmarshal(body, &response); err != nil {
 		fmt.Printf("Error parsing JSON: %v\n", err)
 		os.Exit(1)
 	}
 
 	if response.Status != "success" {
-		fmt.Printf("API Error: %v\n", response.Data)
+		fmt.Printf("API Error: %s\n", response.Error)
 		os.Exit(1)
 	}
 
-	// Pretty print the trades data
-	data, _ := json.MarshalIndent(response.Data, "", "  ")
-	fmt.Printf("=== RECENT TRADES ===\n")
+	fmt.Printf("=== P&L ATTRIBUTION ===\n")
 	fmt.Printf("Timestamp: %s\n", time.Unix(response.Timestamp, 0).Format("2006-01-02 15:04:05"))
-	fmt.Printf("%s\n", string(data))
+
+	if by != "" {
+		var buckets []AttributionBucket
+		if err := json.Unmarshal(response.Data, &buckets); err != nil {
+			fmt.Printf("Error parsing JSON: %v\n", err)
+			os.Exit(1)
+		}
+		printAttribution(by, buckets)
+		return
+	}
+
+	var attribution struct {
+		ByVenuePair []AttributionBucket `json:"by_venue_pair"`
+		BySymbol    []AttributionBucket `json:"by_symbol"`
+		ByHour      []AttributionBucket `json:"by_hour"`
+		RoundTrips  int                 `json:"round_trips"`
+	}
+	if err := json.Unmarshal(response.Data, &attribution); err != nil {
+		fmt.Printf("Error parsing JSON: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Round trips: %d\n", attribution.RoundTrips)
+	printAttribution("pair", attribution.ByVenuePair)
+	printAttribution("symbol", attribution.BySymbol)
+	printAttribution("hour", attribution.ByHour)
+}
+
+func printAttribution(title string, buckets []AttributionBucket) {
+	fmt.Printf("\n--- by %s ---\n", title)
+	fmt.Printf("%-22s %7s %12s %10s %10s %10s %8s\n", "KEY", "TRADES", "P&L", "FEES", "GROSS %", "NET %", "WIN %")
+	for _, b := range buckets {
+		fmt.Printf("%-22s %7d %12.4f %10.4f %10.4f %10.4f %8.1f\n",
+			b.Key, b.Trades, b.PnL, b.Fees, b.AverageGrossEdge, b.AverageNetEdge, b.WinRate)
+	}
 }
 
 func getHealth(url string) {
-	resp, err := http.Get(url + "/health")
+	resp, err := apiGet(url + "/health/ready")
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
@@ -177,13 +370,177 @@ func getHealth(url string) {
 		os.Exit(1)
 	}
 
-	var response PnLResponse
+	var response struct {
+		Status     string `json:"status"`
+		Uptime     string `json:"uptime"`
+		Timestamp  int64  `json:"timestamp"`
+		Components map[string]struct {
+			Healthy bool   `json:"healthy"`
+			Detail  string `json:"detail"`
+		} `json:"components"`
+	}
 	if err := json.Unmarshal(body, &response); err != nil {
 		fmt.Printf("Error parsing JSON: %v\n", err)
 		os.Exit(1)
 	}
 
 	fmt.Printf("=== API HEALTH ===\n")
-	fmt.Printf("Status: %s\n", response.Data.(map[string]interface{})["status"])
+	fmt.Printf("Status: %s (HTTP %d)\n", response.Status, resp.StatusCode)
+	fmt.Printf("Uptime: %s\n", response.Uptime)
+	for _, name := range []string{"feeds", "strategy", "ledger"} {
+		c, ok := response.Components[name]
+		if !ok {
+			continue
+		}
+		mark := "✅"
+		if !c.Healthy {
+			mark = "❌"
+		}
+		fmt.Printf("%s %-8s %s\n", mark, name, c.Detail)
+	}
 	fmt.Printf("Timestamp: %s\n", time.Unix(response.Timestamp, 0).Format("2006-01-02 15:04:05"))
-} 
\ No newline at end of file
+}
+
+// fetchData gets an endpoint and decodes the data of a successful response
+func fetchData(endpoint string, v interface{}) int64 {
+	resp, err := apiGet(endpoint)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Error reading response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response struct {
+		Status    string          `json:"status"`
+		Data      json.RawMessage `json:"data"`
+		Error     *APIError       `json:"error"`
+		Timestamp int64           `json:"timestamp"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Error parsing JSON: %v\n", err)
+		os.Exit(1)
+	}
+	if response.Status != "success" {
+		fmt.Printf("API Error: %s\n", response.Error)
+		os.Exit(1)
+	}
+	if err := json.Unmarshal(response.Data, v); err != nil {
+		fmt.Printf("Error parsing JSON: %v\n", err)
+		os.Exit(1)
+	}
+	return response.Timestamp
+}
+
+func getQuotes(url string) {
+	var quotes []struct {
+		Venue         string  `json:"venue"`
+		Symbol        string  `json:"symbol"`
+		Bid           float64 `json:"bid"`
+		Ask           float64 `json:"ask"`
+		BidSize       float64 `json:"bid_size"`
+		AskSize       float64 `json:"ask_size"`
+		SpreadPercent float64 `json:"spread_percent"`
+		Depth         int     `json:"depth"`
+		AgeMs         float64 `json:"age_ms"`
+		Paused        bool    `json:"paused"`
+	}
+	timestamp := fetchData(url+"/quotes", &quotes)
+
+	fmt.Printf("=== LIVE QUOTES ===\n")
+	fmt.Printf("Timestamp: %s\n", time.Unix(timestamp, 0).Format("2006-01-02 15:04:05"))
+	fmt.Printf("%-10s %-10s %12s %12s %12s %12s %9s %6s %9s\n", "VENUE", "SYMBOL", "BID", "ASK", "BID SIZE", "ASK SIZE", "SPREAD %", "DEPTH", "AGE ms")
+	for _, q := range quotes {
+		venue := q.Venue
+		if q.Paused {
+			venue += "⛔"
+		}
+		fmt.Printf("%-10s %-10s %12.6f %12.6f %12.2f %12.2f %9.4f %6d %9.0f\n",
+			venue, q.Symbol, q.Bid, q.Ask, q.BidSize, q.AskSize, q.SpreadPercent, q.Depth, q.AgeMs)
+	}
+}
+
+func getSpreads(url string) {
+	var spreads []struct {
+		BuyVenue         string  `json:"buy_venue"`
+		SellVenue        string  `json:"sell_venue"`
+		BuyPrice         float64 `json:"buy_price"`
+		SellPrice        float64 `json:"sell_price"`
+		GrossEdgePercent float64 `json:"gross_edge_percent"`
+		NetEdgePercent   float64 `json:"net_edge_percent"`
+		Paused           bool    `json:"paused"`
+	}
+	timestamp := fetchData(url+"/spreads", &spreads)
+
+	fmt.Printf("=== VENUE SPREADS ===\n")
+	fmt.Printf("Timestamp: %s\n", time.Unix(timestamp, 0).Format("2006-01-02 15:04:05"))
+	fmt.Printf("%-22s %12s %12s %9s %9s\n", "BUY -> SELL", "BUY @", "SELL @", "GROSS %", "NET %")
+	for _, s := range spreads {
+		pair := s.BuyVenue + " -> " + s.SellVenue
+		if s.Paused {
+			pair += " ⛔"
+		}
+		fmt.Printf("%-22s %12.6f %12.6f %9.4f %9.4f\n", pair, s.BuyPrice, s.SellPrice, s.GrossEdgePercent, s.NetEdgePercent)
+	}
+}
+
+// followStream prints events from the Server-Sent Events stream until the
+// connection closes
+func followStream(url string, topics string) {
+	endpoint := url + "/stream"
+	if topics != "" {
+		endpoint += "?topics=" + topics
+	}
+
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if apiToken != "" {
+		req.Header.Set("Authorization", "Bearer "+apiToken)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("API Error: %s\n", string(body))
+		os.Exit(1)
+	}
+
+	fmt.Printf("=== LIVE EVENTS ===\n")
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+
+		var event struct {
+			Topic     string          `json:"topic"`
+			Type      string          `json:"type"`
+			Data      json.RawMessage `json:"data"`
+			Timestamp time.Time       `json:"timestamp"`
+		}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+			continue
+		}
+		fmt.Printf("%s [%s/%s] %s\n", event.Timestamp.Format("15:04:05.000"), event.Topic, event.Type, string(event.Data))
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Printf("Stream closed: %v\n", err)
+	}
+}
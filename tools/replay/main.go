@@ -0,0 +1,147 @@
+// Command replay reproduces a recorded session: raw venue messages from a
+// capture go through the adapters' parsers and the strategy, and every
+// decision is written to a log that can be diffed against a golden file.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"hft-arbitrage-bot/backtest"
+	"hft-arbitrage-bot/capture"
+	"hft-arbitrage-bot/execution"
+	"hft-arbitrage-bot/logging"
+	"hft-arbitrage-bot/replay"
+	"hft-arbitrage-bot/strategy"
+)
+
+func main() {
+	cfg := backtest.DefaultConfig()
+	cfg.FeeOverrides = make(map[string]float64)
+
+	capturePath := flag.String("capture", "", "capture directory or .hftcap file to replay")
+	logPath := flag.String("log", "", "write the decision log to this file instead of stdout")
+	golden := flag.String("golden", "", "compare the decision log with this golden file; exit 1 on a difference")
+	update := flag.Bool("update", false, "with -golden, write the decision log as the new golden file")
+	flag.Float64Var(&cfg.MinSpreadPercent, "min-spread", cfg.MinSpreadPercent, "minimum net edge in percent to trade")
+	flag.Float64Var(&cfg.InitialBalance, "balance", cfg.InitialBalance, "initial balance in quote currency")
+	flag.Float64Var(&cfg.TradeSize, "trade-size", cfg.TradeSize, "quote currency spent per arbitrage")
+	fees := flag.String("fees", "", "taker fee overrides, e.g. kraken=0.0016,okx=0.0008")
+	triangles := flag.String("triangles", "", "triangles to evaluate, e.g. binance:USDT/DOGE/BTC, or none (default binance:USDT/DOGE/BTC)")
+	simulate := flag.Bool("simulate", false, "simulate fills against the recorded book, as hft-backtest does, instead of filling at the detected prices")
+	flag.Parse()
+
+	logOptions, err := logging.OptionsFromEnv()
+	if err != nil {
+		fail(err)
+	}
+	if os.Getenv("HFT_LOG_LEVEL") == "" {
+		logOptions.Output = io.Discard
+	}
+	defer logging.Setup(logOptions)()
+
+	if *capturePath == "" {
+		fmt.Fprintln(os.Stderr, "usage: replay -capture <dir|file> [-golden file [-update]] [options]")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+	if err := parseFees(*fees, cfg.FeeOverrides); err != nil {
+		fail(fmt.Errorf("-fees: %w", err))
+	}
+	if *triangles != "" {
+		if cfg.Triangles, err = strategy.ParseTriangles(*triangles); err != nil {
+			fail(fmt.Errorf("-triangles: %w", err))
+		}
+	}
+	cfg.Execution.Backend = execution.BackendInstant
+	if *simulate {
+		cfg.Execution.Backend = execution.BackendPaper
+	}
+
+	paths := []string{*capturePath}
+	if info, err := os.Stat(*capturePath); err == nil && info.IsDir() {
+		if paths, err = capture.Files(*capturePath); err != nil {
+			fail(err)
+		}
+	}
+
+	var decisions bytes.Buffer
+	parsed, report, err := replay.Run(paths, cfg, &decisions)
+	if err != nil {
+		fail(err)
+	}
+
+	fmt.Fprintf(os.Stderr, "🎬 Replayed %d messages from %d files: %d quotes, %d without a quote, %d parser mismatches\n",
+		parsed.Records, len(paths), len(parsed.Quotes), parsed.Unparsed, parsed.Mismatches)
+	for _, venue := range sortedKeys(parsed.Skipped) {
+		fmt.Fprintf(os.Stderr, "⚠️  %d messages from %s skipped: no parser\n", parsed.Skipped[venue], venue)
+	}
+	fmt.Fprintf(os.Stderr, "🔎 Detected %d, executed %d, P&L $%.4f\n", report.Detected, report.Executed, report.PnL.TotalPnL)
+
+	switch {
+	case *golden != "" && *update:
+		if err := os.WriteFile(*golden, decisions.Bytes(), 0o644); err != nil {
+			fail(err)
+		}
+		fmt.Fprintf(os.Stderr, "✅ Updated %s\n", *golden)
+	case *golden != "":
+		diff, err := replay.Diff(*golden, decisions.Bytes())
+		if err != nil {
+			fail(err)
+		}
+		if diff != "" {
+			fmt.Fprintf(os.Stderr, "❌ Decision log differs from %s:\n%s", *golden, diff)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "✅ Decision log matches %s\n", *golden)
+	}
+
+	switch {
+	case *logPath != "":
+		if err := os.WriteFile(*logPath, decisions.Bytes(), 0o644); err != nil {
+			fail(err)
+		}
+	case *golden == "":
+		os.Stdout.Write(decisions.Bytes())
+	}
+}
+
+// parseFees parses "venue=rate,venue=rate" into overrides
+func parseFees(s string, overrides map[string]float64) error {
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		venue, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("want venue=rate, got %q", entry)
+		}
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%s: %w", venue, err)
+		}
+		overrides[strings.ToLower(strings.TrimSpace(venue))] = rate
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+	os.Exit(1)
+}